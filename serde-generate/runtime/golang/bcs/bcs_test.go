@@ -4,8 +4,14 @@
 package bcs_test
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
 	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
@@ -46,10 +52,51 @@ func TestSerializeDeserializeBytes(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeBytes()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
+func TestSerializeDeserializeFixedBytes(t *testing.T) {
+	s := bcs.NewSerializer()
+	require.NoError(t, s.SerializeFixedBytes([]byte{1, 2, 3}))
+	assert.Equal(t, []byte{1, 2, 3}, s.GetBytes())
+
+	d := bcs.NewDeserializer([]byte{1, 2, 3})
+	ret, err := d.DeserializeFixedBytes(2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, ret)
+	// A short read fails without consuming the remaining bytes.
+	_, err = d.DeserializeFixedBytes(2)
+	assert.EqualError(t, err, "input is too short")
+	ret, err = d.DeserializeFixedBytes(1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{3}, ret)
+	_, err = d.DeserializeFixedBytes(1)
+	assert.EqualError(t, err, "input is too short")
+
+	t.Run("deserialize error: declared length larger than the input", func(t *testing.T) {
+		d := bcs.NewDeserializer([]byte{0xff, 0xff, 0xff, 0xff, 0x07, 1, 2})
+		_, err := d.DeserializeBytes()
+		assert.EqualError(t, err, "input is too short")
+	})
+}
+
+func TestSerializeDeserializeVecBytes(t *testing.T) {
+	s := bcs.NewSerializer()
+	require.NoError(t, s.SerializeVecBytes([][]byte{{1, 2}, {}, {3}}))
+	expected := []byte{3, 2, 1, 2, 0, 1, 3}
+	assert.Equal(t, expected, s.GetBytes())
+
+	d := bcs.NewDeserializer(expected)
+	ret, err := d.DeserializeVecBytes()
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{1, 2}, {}, {3}}, ret)
+
+	d = bcs.NewDeserializer(expected[:5])
+	_, err = d.DeserializeVecBytes()
+	assert.EqualError(t, err, "unexpected EOF")
+}
+
 func TestSerializeDeserializeStr(t *testing.T) {
 	cases := []struct {
 		target   string
@@ -83,7 +130,21 @@ func TestSerializeDeserializeStr(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeStr()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
+	})
+	t.Run("deserialize error: invalid UTF8", func(t *testing.T) {
+		d := bcs.NewDeserializer([]byte{2, 0xc3, 0x28})
+		_, err := d.DeserializeStr()
+		assert.True(t, errors.Is(err, serde.ErrInvalidUTF8))
+		assert.EqualError(t, err, "invalid UTF8 string")
+	})
+	t.Run("AllowInvalidUTF8", func(t *testing.T) {
+		config := bcs.DefaultConfig()
+		config.AllowInvalidUTF8 = true
+		d := bcs.NewDeserializerWithConfig([]byte{2, 0xc3, 0x28}, config)
+		deserialized, err := d.DeserializeStr()
+		require.NoError(t, err)
+		assert.Equal(t, "\xc3\x28", deserialized)
 	})
 }
 
@@ -121,7 +182,7 @@ func TestSerializeDeserializeBool(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeBool()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 
 	t.Run("deserialize error: invalid byte", func(t *testing.T) {
@@ -193,7 +254,7 @@ func TestSerializeDeserializeU8(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeU8()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -234,7 +295,7 @@ func TestSerializeDeserializeU16(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeU16()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -279,7 +340,7 @@ func TestSerializeDeserializeU32(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeU32()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -328,7 +389,7 @@ func TestSerializeDeserializeU64(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeU64()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -339,8 +400,8 @@ func TestSerializeDeserializeU128(t *testing.T) {
 	}{
 		{
 			target: serde.Uint128{
-				^uint64(0),
-				^uint64(0),
+				High: ^uint64(0),
+				Low:  ^uint64(0),
 			},
 			expected: []byte{
 				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
@@ -350,7 +411,7 @@ func TestSerializeDeserializeU128(t *testing.T) {
 			},
 		},
 		{
-			target: serde.Uint128{0, 0},
+			target: serde.Uint128{High: 0, Low: 0},
 			expected: []byte{
 				0, 0, 0, 0, 0, 0, 0, 0,
 				0, 0, 0, 0, 0, 0, 0, 0,
@@ -390,7 +451,65 @@ func TestSerializeDeserializeU128(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeU128()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
+	})
+}
+
+func TestSerializeDeserializeU256(t *testing.T) {
+	cases := []struct {
+		target   serde.Uint256
+		expected []byte
+	}{
+		{
+			target: serde.Uint256{
+				High: serde.Uint128{High: ^uint64(0), Low: ^uint64(0)},
+				Low:  serde.Uint128{High: ^uint64(0), Low: ^uint64(0)},
+			},
+			expected: []byte{
+				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
+				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
+				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
+				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
+			},
+		},
+		{
+			target: serde.Uint256{},
+			expected: []byte{
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+			},
+		},
+		{
+			target: serde.Uint256{
+				High: serde.Uint128{High: 321243314, Low: 0},
+				Low:  serde.Uint128{High: 0, Low: 827},
+			},
+			expected: []byte{
+				59, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0, 0, 0, 0, 178, 200, 37, 19, 0, 0, 0, 0,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%#v", tc.target), func(t *testing.T) {
+			s := bcs.NewSerializer()
+			d := bcs.NewDeserializer(tc.expected)
+
+			err := s.SerializeU256(tc.target)
+			require.NoError(t, err)
+
+			deserialized, err := d.DeserializeU256()
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, s.GetBytes())
+			assert.Equal(t, tc.target, deserialized)
+		})
+	}
+	t.Run("deserialize error: EOF", func(t *testing.T) {
+		d := bcs.NewDeserializer(make([]byte, 31))
+		_, err := d.DeserializeU256()
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -427,7 +546,7 @@ func TestSerializeDeserializeI8(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeI8()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -468,7 +587,7 @@ func TestSerializeDeserializeI16(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeI16()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -509,7 +628,7 @@ func TestSerializeDeserializeI32(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeI32()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -553,7 +672,7 @@ func TestSerializeDeserializeI64(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeI64()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -563,7 +682,7 @@ func TestSerializeDeserializeI128(t *testing.T) {
 		expected []byte
 	}{
 		{
-			target: serde.Int128{^int64(0), ^uint64(0)},
+			target: serde.Int128{High: ^int64(0), Low: ^uint64(0)},
 			expected: []byte{
 				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
 				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
@@ -572,7 +691,7 @@ func TestSerializeDeserializeI128(t *testing.T) {
 			},
 		},
 		{
-			target: serde.Int128{0, 0},
+			target: serde.Int128{High: 0, Low: 0},
 			expected: []byte{
 				0, 0, 0, 0, 0, 0, 0, 0,
 				0, 0, 0, 0, 0, 0, 0, 0,
@@ -605,7 +724,65 @@ func TestSerializeDeserializeI128(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeI128()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
+	})
+}
+
+func TestSerializeDeserializeI256(t *testing.T) {
+	cases := []struct {
+		target   serde.Int256
+		expected []byte
+	}{
+		{
+			target: serde.Int256{
+				High: serde.Int128{High: ^int64(0), Low: ^uint64(0)},
+				Low:  serde.Uint128{High: ^uint64(0), Low: ^uint64(0)},
+			},
+			expected: []byte{
+				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
+				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
+				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
+				^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0), ^uint8(0),
+			},
+		},
+		{
+			target: serde.Int256{},
+			expected: []byte{
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+			},
+		},
+		{
+			target: serde.Int256{
+				High: serde.Int128{High: -232, Low: 0},
+				Low:  serde.Uint128{High: 0, Low: 321243314},
+			},
+			expected: []byte{
+				178, 200, 37, 19, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0, 0, 0, 0, 24, 255, 255, 255, 255, 255, 255, 255,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%#v", tc.target), func(t *testing.T) {
+			s := bcs.NewSerializer()
+			d := bcs.NewDeserializer(tc.expected)
+
+			err := s.SerializeI256(tc.target)
+			require.NoError(t, err)
+
+			deserialized, err := d.DeserializeI256()
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, s.GetBytes())
+			assert.Equal(t, tc.target, deserialized)
+		})
+	}
+	t.Run("deserialize error: EOF", func(t *testing.T) {
+		d := bcs.NewDeserializer(make([]byte, 31))
+		_, err := d.DeserializeI256()
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -637,7 +814,7 @@ func TestSerializeDeserializeVariantIndex(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeVariantIndex()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
 	})
 }
 
@@ -670,8 +847,134 @@ func TestSerializeDeserializeLenLimit(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeLen()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
+	})
+}
+
+func TestConfigMaxSequenceLength(t *testing.T) {
+	config := bcs.DefaultConfig()
+	config.MaxSequenceLength = 1 << 40
+	length := uint64(1) << 35
+	expected := []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x01}
+
+	t.Run("SerializeLen: 64-bit length", func(t *testing.T) {
+		s := bcs.NewSerializerWithConfig(config)
+		require.NoError(t, s.SerializeLen(length))
+		assert.Equal(t, expected, s.GetBytes())
+		assert.EqualError(t, s.SerializeLen(config.MaxSequenceLength+1), "length is too large")
 	})
+	t.Run("DeserializeLen: 64-bit length", func(t *testing.T) {
+		d := bcs.NewDeserializerWithConfig(expected, config)
+		ret, err := d.DeserializeLen()
+		require.NoError(t, err)
+		assert.Equal(t, length, ret)
+
+		_, err = bcs.NewDeserializer(expected).DeserializeLen()
+		assert.EqualError(t, err, "overflow while parsing uleb128-encoded uint32 value")
+	})
+	t.Run("DeserializeLen: length is too large", func(t *testing.T) {
+		d := bcs.NewDeserializerWithConfig([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}, config)
+		_, err := d.DeserializeLen()
+		assert.EqualError(t, err, "length is too large")
+	})
+	t.Run("lower limit", func(t *testing.T) {
+		config := bcs.Config{MaxSequenceLength: 2}
+		s := bcs.NewSerializerWithConfig(config)
+		assert.EqualError(t, s.SerializeBytes([]byte{1, 2, 3}), "length is too large")
+
+		d := bcs.NewDeserializerWithConfig([]byte{3, 1, 2, 3}, config)
+		_, err := d.DeserializeBytes()
+		assert.EqualError(t, err, "length is too large")
+	})
+}
+
+func TestConfigPerKindLimits(t *testing.T) {
+	config := bcs.DefaultConfig()
+	config.MaxStringLength = 1
+	config.MaxBytesLength = 2
+	config.MaxVectorLength = 3
+	config.MaxMapLength = 4
+	// Each length is accepted up to its own limit.
+	length, err := bcs.NewDeserializerWithConfig([]byte{3}, config).DeserializeLen()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), length)
+	_, err = bcs.NewDeserializerWithConfig([]byte{4}, config).DeserializeLen()
+	assert.EqualError(t, err, "length is too large")
+	_, err = bcs.NewDeserializerWithConfig([]byte{2, 104, 105}, config).DeserializeStr()
+	assert.EqualError(t, err, "length is too large")
+	ret, err := bcs.NewDeserializerWithConfig([]byte{2, 104, 105}, config).DeserializeBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{104, 105}, ret)
+	_, err = bcs.NewDeserializerWithConfig([]byte{3, 1, 2, 3}, config).DeserializeBytes()
+	assert.EqualError(t, err, "length is too large")
+	length, err = bcs.NewDeserializerWithConfig([]byte{4}, config).DeserializeMapLen()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), length)
+	_, err = bcs.NewDeserializerWithConfig([]byte{5}, config).DeserializeMapLen()
+	assert.EqualError(t, err, "length is too large")
+}
+
+func TestConfigMaxContainerDepth(t *testing.T) {
+	config := bcs.DefaultConfig()
+	config.MaxContainerDepth = 2
+
+	s := bcs.NewSerializerWithConfig(config)
+	require.NoError(t, s.IncreaseContainerDepth())
+	require.NoError(t, s.IncreaseContainerDepth())
+	assert.EqualError(t, s.IncreaseContainerDepth(), "exceeded maximum container depth")
+
+	d := bcs.NewDeserializerWithConfig([]byte{}, config)
+	require.NoError(t, d.IncreaseContainerDepth())
+	require.NoError(t, d.IncreaseContainerDepth())
+	assert.EqualError(t, d.IncreaseContainerDepth(), "exceeded maximum container depth")
+	assert.True(t, errors.Is(d.IncreaseContainerDepth(), serde.ErrMaxDepthExceeded))
+	d.DecreaseContainerDepth()
+	require.NoError(t, d.IncreaseContainerDepth())
+}
+
+func TestConfigAllowNonCanonicalUleb128(t *testing.T) {
+	input := []byte{0x82, 0x00}
+	_, err := bcs.NewDeserializer(input).DeserializeLen()
+	assert.EqualError(t, err, "invalid uleb128 number (unexpected zero digit)")
+	_, err = bcs.NewDeserializer(input).DeserializeVariantIndex()
+	assert.EqualError(t, err, "invalid uleb128 number (unexpected zero digit)")
+
+	config := bcs.DefaultConfig()
+	config.AllowNonCanonicalUleb128 = true
+	length, err := bcs.NewDeserializerWithConfig(input, config).DeserializeLen()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), length)
+	index, err := bcs.NewDeserializerWithConfig(input, config).DeserializeVariantIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), index)
+
+	config.MaxSequenceLength = 1 << 40
+	length, err = bcs.NewDeserializerWithConfig(input, config).DeserializeLen()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), length)
+}
+
+func TestConfigAllowMissingTrailingFields(t *testing.T) {
+	input := []byte{59, 3, 0, 0}
+	d := bcs.NewDeserializer(input)
+	assert.False(t, serde.IsMissingTrailingField(d))
+	_, err := d.DeserializeU32()
+	require.NoError(t, err)
+	assert.False(t, serde.IsMissingTrailingField(d))
+
+	config := bcs.DefaultConfig()
+	config.AllowMissingTrailingFields = true
+	d = bcs.NewDeserializerWithConfig(input, config)
+	assert.False(t, serde.IsMissingTrailingField(d))
+	_, err = d.DeserializeU32()
+	require.NoError(t, err)
+	assert.True(t, serde.IsMissingTrailingField(d))
+
+	child, err := bcs.NewDeserializerWithConfig(input, config).Slice(0, 2)
+	require.NoError(t, err)
+	_, err = child.DeserializeU16()
+	require.NoError(t, err)
+	assert.True(t, serde.IsMissingTrailingField(child))
 }
 
 func TestSerializeDeserializeOptionTag(t *testing.T) {
@@ -706,7 +1009,12 @@ func TestSerializeDeserializeOptionTag(t *testing.T) {
 	t.Run("deserialize error: EOF", func(t *testing.T) {
 		d := bcs.NewDeserializer([]byte{})
 		_, err := d.DeserializeOptionTag()
-		require.EqualError(t, err, "EOF")
+		require.EqualError(t, err, "unexpected EOF")
+	})
+	t.Run("deserialize error: invalid option tag", func(t *testing.T) {
+		d := bcs.NewDeserializer([]byte{2})
+		_, err := d.DeserializeOptionTag()
+		require.EqualError(t, err, "invalid option tag: expected 0 / 1, but got 2")
 	})
 }
 
@@ -721,13 +1029,155 @@ func TestGetBufferOffset(t *testing.T) {
 	assert.Equal(t, uint64(8), d.GetBufferOffset())
 }
 
+func TestEndOfInput(t *testing.T) {
+	d := bcs.NewDeserializer([]byte{1, 2})
+	_, err := d.DeserializeU8()
+	require.NoError(t, err)
+	assert.EqualError(t, d.EndOfInput(), "Some input bytes were not read")
+	_, err = d.DeserializeU8()
+	require.NoError(t, err)
+	assert.NoError(t, d.EndOfInput())
+}
+
+func TestSentinelErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  []byte
+		call   func(d serde.Deserializer) error
+		target error
+	}{
+		{"length", []byte{0x80, 0x80, 0x80, 0x80, 0x08}, func(d serde.Deserializer) error { _, err := d.DeserializeLen(); return err }, serde.ErrLengthExceeded},
+		{"uleb128 overflow", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, func(d serde.Deserializer) error { _, err := d.DeserializeLen(); return err }, serde.ErrUleb128Overflow},
+		{"non-canonical uleb128", []byte{0x80, 0x00}, func(d serde.Deserializer) error { _, err := d.DeserializeLen(); return err }, serde.ErrNonCanonicalUleb128},
+		{"bool", []byte{2}, func(d serde.Deserializer) error { _, err := d.DeserializeBool(); return err }, serde.ErrInvalidBool},
+		{"option tag", []byte{2}, func(d serde.Deserializer) error { _, err := d.DeserializeOptionTag(); return err }, serde.ErrInvalidOptionTag},
+		{"remaining bytes", []byte{0}, func(d serde.Deserializer) error { return d.EndOfInput() }, serde.ErrRemainingBytes},
+		{"truncated integer", []byte{1, 0}, func(d serde.Deserializer) error { _, err := d.DeserializeU32(); return err }, io.ErrUnexpectedEOF},
+		{"truncated bytes", []byte{3, 1, 2}, func(d serde.Deserializer) error { _, err := d.DeserializeBytes(); return err }, io.ErrUnexpectedEOF},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call(bcs.NewDeserializer(tc.input))
+			assert.True(t, errors.Is(err, tc.target), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	value := serde.UUID{1, 2, 3}
+	data, err := bcs.Marshal(value)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, data)
+
+	var result serde.UUID
+	require.NoError(t, bcs.Unmarshal(data, &result))
+	assert.Equal(t, value, result)
+	assert.EqualError(t, bcs.Unmarshal(append(data, 0), &result), "Some input bytes were not read")
+}
+
+func TestUnmarshalPrefix(t *testing.T) {
+	first, second := serde.UUID{1}, serde.UUID{2}
+	input := append(append([]byte{}, first[:]...), second[:]...)
+
+	var values []serde.UUID
+	for len(input) > 0 {
+		var value serde.UUID
+		consumed, err := bcs.UnmarshalPrefix(input, &value)
+		require.NoError(t, err)
+		assert.Equal(t, 16, consumed)
+		values = append(values, value)
+		input = input[consumed:]
+	}
+	assert.Equal(t, []serde.UUID{first, second}, values)
+
+	var value serde.UUID
+	_, err := bcs.UnmarshalPrefix(first[:8], &value)
+	assert.EqualError(t, err, "input is too short")
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	var uuid serde.UUID
+	input := make([]byte, 17)
+	input[0] = 1
+	require.NoError(t, bcs.UnmarshalStrict(input[:16], &uuid))
+	assert.Equal(t, serde.UUID{1}, uuid)
+	assert.EqualError(t, bcs.UnmarshalStrict(input, &uuid), "Some input bytes were not read")
+	assert.EqualError(t, bcs.UnmarshalStrict(input[:15], &uuid), "input is too short")
+}
+
+type measurement float64
+
+func (m *measurement) Serialize(serializer serde.Serializer) error {
+	return serializer.SerializeF64(float64(*m))
+}
+
+func TestHash(t *testing.T) {
+	uuid := serde.UUID{1, 2, 3}
+	data, err := bcs.Marshal(uuid)
+	require.NoError(t, err)
+	hash := fnv.New64a()
+	hash.Write(data)
+	assert.Equal(t, hash.Sum64(), bcs.Hash64(uuid))
+	assert.NotEqual(t, bcs.Hash64(uuid), bcs.Hash64(serde.UUID{1, 2}))
+
+	digest, err := bcs.Sum256(uuid)
+	require.NoError(t, err)
+	assert.Equal(t, sha256.Sum256(data), digest)
+
+	// Floats are not supported by BCS but they can be hashed.
+	m := measurement(1.5)
+	_, err = bcs.Sum256(&m)
+	assert.Equal(t, serde.ErrUnimplemented, err)
+	other := measurement(2.5)
+	assert.NotEqual(t, bcs.Hash64(&m), bcs.Hash64(&other))
+}
+
+func TestMapKey(t *testing.T) {
+	uuid := serde.UUID{1, 2, 3}
+	data, err := bcs.Marshal(uuid)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), bcs.MapKey(uuid))
+	m := measurement(1.5)
+	assert.Equal(t, "\x00\x00\x00\x00\x00\x00\xf8\x3f", bcs.MapKey(&m))
+}
+
+func TestSlice(t *testing.T) {
+	config := bcs.DefaultConfig()
+	config.MaxContainerDepth = 2
+	d := bcs.NewDeserializerWithConfig([]byte{1, 2, 0x81, 0x00, 3}, config)
+	require.NoError(t, d.IncreaseContainerDepth())
+
+	child, err := d.Slice(1, 4)
+	require.NoError(t, err)
+	value, err := child.DeserializeU8()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(2), value)
+	assert.Equal(t, uint64(1), child.GetBufferOffset())
+	// The configuration is inherited.
+	_, err = child.DeserializeLen()
+	assert.EqualError(t, err, "invalid uleb128 number (unexpected zero digit)")
+	// The container depth budget is shared.
+	require.NoError(t, child.IncreaseContainerDepth())
+	assert.EqualError(t, d.IncreaseContainerDepth(), "exceeded maximum container depth")
+	child.DecreaseContainerDepth()
+	require.NoError(t, d.IncreaseContainerDepth())
+
+	// The parent is unaffected.
+	assert.Equal(t, uint64(0), d.GetBufferOffset())
+
+	_, err = d.Slice(4, 6)
+	assert.EqualError(t, err, "invalid input range: 4..6")
+	_, err = d.Slice(3, 2)
+	assert.EqualError(t, err, "invalid input range: 3..2")
+}
+
 func TestCheckThatKeySlicesAreIncreasing(t *testing.T) {
 	d := bcs.NewDeserializer([]byte{0, 1, 2, 0, 2})
 	// Offsets are taken from the input bytes.
 	d.DeserializeU32()
-	require.NoError(t, d.CheckThatKeySlicesAreIncreasing(serde.Slice{0, 3}, serde.Slice{3, 5}))
-	require.Error(t, d.CheckThatKeySlicesAreIncreasing(serde.Slice{0, 3}, serde.Slice{0, 3}))
-	require.Error(t, d.CheckThatKeySlicesAreIncreasing(serde.Slice{1, 3}, serde.Slice{3, 5}))
+	require.NoError(t, d.CheckThatKeySlicesAreIncreasing(serde.Slice{Start: 0, End: 3}, serde.Slice{Start: 3, End: 5}))
+	require.Error(t, d.CheckThatKeySlicesAreIncreasing(serde.Slice{Start: 0, End: 3}, serde.Slice{Start: 0, End: 3}))
+	require.Error(t, d.CheckThatKeySlicesAreIncreasing(serde.Slice{Start: 1, End: 3}, serde.Slice{Start: 3, End: 5}))
 }
 
 func TestSortMapEntries(t *testing.T) {
@@ -742,3 +1192,144 @@ func TestSortMapEntries(t *testing.T) {
 	s.SortMapEntries(offsets)
 	assert.Equal(t, s.GetBytes(), []byte{255 /**/, 0 /**/, 0 /**/, 0, 0 /**/, 0, 1, 0 /**/, 1 /**/, 2, 0, 0, 0})
 }
+
+// A sequence of maps from strings to large byte arrays, serialized the way generated code does.
+type blobs []map[string][]byte
+
+func (b *blobs) Serialize(serializer serde.Serializer) error {
+	if err := serializer.IncreaseContainerDepth(); err != nil {
+		return err
+	}
+	if err := serializer.SerializeLen(uint64(len(*b))); err != nil {
+		return err
+	}
+	for _, m := range *b {
+		if err := serializer.SerializeLen(uint64(len(m))); err != nil {
+			return err
+		}
+		serde.BeginMap(serializer)
+		offsets := make([]uint64, len(m))
+		count := 0
+		for k, v := range m {
+			offsets[count] = serializer.GetBufferOffset()
+			count += 1
+			if err := serializer.SerializeStr(k); err != nil {
+				return err
+			}
+			if err := serializer.SerializeBytes(v); err != nil {
+				return err
+			}
+		}
+		serializer.SortMapEntries(offsets)
+	}
+	serializer.DecreaseContainerDepth()
+	return nil
+}
+
+func (b *blobs) Deserialize(deserializer serde.Deserializer) error {
+	if err := deserializer.IncreaseContainerDepth(); err != nil {
+		return err
+	}
+	value, err := serde.DeserializeVector(deserializer, func(deserializer serde.Deserializer) (map[string][]byte, error) {
+		return serde.DeserializeMap(deserializer, serde.Deserializer.DeserializeStr, serde.Deserializer.DeserializeBytes)
+	})
+	if err != nil {
+		return err
+	}
+	deserializer.DecreaseContainerDepth()
+	*b = value
+	return nil
+}
+
+type recordingWriter struct {
+	writes [][]byte
+	err    error
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.writes = append(w.writes, append([]byte{}, p...))
+	return len(p), nil
+}
+
+func TestAppend(t *testing.T) {
+	value := blobs{{"b": {2}, "a": {1}, "c": {}}}
+	expected, err := bcs.Marshal(&value)
+	require.NoError(t, err)
+
+	// Map entries are sorted after the existing content of the buffer.
+	buffer := make([]byte, 2, 64)
+	buffer[0], buffer[1] = 'z', 'z'
+	output, err := bcs.Append(buffer, &value)
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte("zz"), expected...), output)
+	assert.Equal(t, &buffer[0], &output[0])
+
+	output, err = bcs.Append(nil, &value)
+	require.NoError(t, err)
+	assert.Equal(t, expected, output)
+}
+
+func TestMarshalTo(t *testing.T) {
+	value := blobs{}
+	for i := 0; i < 4; i++ {
+		m := make(map[string][]byte)
+		for j := 0; j < 5; j++ {
+			m[fmt.Sprintf("key%d", (j*7)%5)] = make([]byte, 1000*j)
+		}
+		value = append(value, m)
+	}
+	expected, err := bcs.Marshal(&value)
+	require.NoError(t, err)
+
+	writer := &recordingWriter{}
+	require.NoError(t, bcs.MarshalTo(writer, &value))
+	assert.Greater(t, len(writer.writes), 1)
+	var output []byte
+	for _, data := range writer.writes {
+		output = append(output, data...)
+	}
+	assert.Equal(t, expected, output)
+
+	writer = &recordingWriter{err: io.ErrShortWrite}
+	assert.Equal(t, io.ErrShortWrite, bcs.MarshalTo(writer, &value))
+}
+
+func TestUnmarshalFrom(t *testing.T) {
+	value := blobs{}
+	for i := 0; i < 4; i++ {
+		m := make(map[string][]byte)
+		for j := 0; j < 5; j++ {
+			m[fmt.Sprintf("key%d", (j*7)%5)] = bytes.Repeat([]byte{byte(i)}, 1000*j)
+		}
+		value = append(value, m)
+	}
+	input, err := bcs.Marshal(&value)
+	require.NoError(t, err)
+
+	var decoded blobs
+	require.NoError(t, bcs.UnmarshalFrom(iotest.HalfReader(bytes.NewReader(input)), &decoded))
+	assert.Equal(t, value, decoded)
+
+	err = bcs.UnmarshalFrom(bytes.NewReader(append(input, 0)), &decoded)
+	assert.Equal(t, serde.ErrRemainingBytes, err)
+	err = bcs.UnmarshalFrom(bytes.NewReader(input[:len(input)-1]), &decoded)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	// Keys are compared although the input is read one byte at a time.
+	unordered := []byte{1, 2, 1, 'b', 1, 0, 1, 'a', 0}
+	err = bcs.UnmarshalFrom(iotest.OneByteReader(bytes.NewReader(unordered)), &decoded)
+	assert.True(t, errors.Is(err, serde.ErrMapKeysNotOrdered))
+	err = bcs.UnmarshalFrom(iotest.ErrReader(io.ErrClosedPipe), &decoded)
+	assert.True(t, errors.Is(err, io.ErrClosedPipe))
+}
+
+func TestGrow(t *testing.T) {
+	serializer := bcs.NewSerializer()
+	serde.Grow(serializer, 64)
+	require.NoError(t, serializer.SerializeU64(1))
+	output := serializer.GetBytes()
+	assert.Equal(t, []byte{1, 0, 0, 0, 0, 0, 0, 0}, output)
+	assert.GreaterOrEqual(t, cap(output), 64)
+}
@@ -0,0 +1,261 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bcs
+
+import (
+	"fmt"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// Canonicalize parses `input` as the BCS encoding of a value of the container `typeName`
+// and re-serializes it in canonical form: ULEB128 numbers are minimal and map entries
+// are sorted by keys. Input that cannot be repaired (e.g. duplicate map keys, invalid
+// bool values or option tags, invalid UTF-8, trailing bytes) is rejected. So are sequences
+// of values serialized as zero bytes (e.g. `Vec<()>`) with more elements than the number
+// of remaining input bytes.
+func Canonicalize(input []byte, registry *serdetypes.Registry, typeName string) ([]byte, error) {
+	config := DefaultConfig()
+	config.AllowNonCanonicalUleb128 = true
+	c := canonicalizer{
+		registry:     registry,
+		input:        input,
+		deserializer: NewDeserializerWithConfig(input, config),
+		serializer:   NewSerializer(),
+	}
+	if err := c.container(typeName); err != nil {
+		return nil, err
+	}
+	if err := c.deserializer.EndOfInput(); err != nil {
+		return nil, err
+	}
+	return c.serializer.GetBytes(), nil
+}
+
+type canonicalizer struct {
+	registry     *serdetypes.Registry
+	input        []byte
+	deserializer serde.Deserializer
+	serializer   serde.Serializer
+}
+
+// Read a value with `deserialize` and write it back with `serialize`.
+func transfer[T any](deserialize func() (T, error), serialize func(T) error) error {
+	value, err := deserialize()
+	if err != nil {
+		return err
+	}
+	return serialize(value)
+}
+
+func (c *canonicalizer) format(format *serdetypes.Format) error {
+	d, s := c.deserializer, c.serializer
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return c.container(format.Name)
+	case serdetypes.UnitKind:
+		return transfer(d.DeserializeUnit, s.SerializeUnit)
+	case serdetypes.BoolKind:
+		return transfer(d.DeserializeBool, s.SerializeBool)
+	case serdetypes.I8Kind:
+		return transfer(d.DeserializeI8, s.SerializeI8)
+	case serdetypes.I16Kind:
+		return transfer(d.DeserializeI16, s.SerializeI16)
+	case serdetypes.I32Kind:
+		return transfer(d.DeserializeI32, s.SerializeI32)
+	case serdetypes.I64Kind:
+		return transfer(d.DeserializeI64, s.SerializeI64)
+	case serdetypes.I128Kind:
+		return transfer(d.DeserializeI128, s.SerializeI128)
+	case serdetypes.U8Kind:
+		return transfer(d.DeserializeU8, s.SerializeU8)
+	case serdetypes.U16Kind:
+		return transfer(d.DeserializeU16, s.SerializeU16)
+	case serdetypes.U32Kind:
+		return transfer(d.DeserializeU32, s.SerializeU32)
+	case serdetypes.U64Kind:
+		return transfer(d.DeserializeU64, s.SerializeU64)
+	case serdetypes.U128Kind:
+		return transfer(d.DeserializeU128, s.SerializeU128)
+	case serdetypes.F32Kind:
+		return transfer(d.DeserializeF32, s.SerializeF32)
+	case serdetypes.F64Kind:
+		return transfer(d.DeserializeF64, s.SerializeF64)
+	case serdetypes.CharKind:
+		return transfer(d.DeserializeChar, s.SerializeChar)
+	case serdetypes.StrKind:
+		return transfer(d.DeserializeStr, s.SerializeStr)
+	case serdetypes.BytesKind:
+		return transfer(d.DeserializeBytes, s.SerializeBytes)
+
+	case serdetypes.OptionKind:
+		tag, err := d.DeserializeOptionTag()
+		if err != nil {
+			return err
+		}
+		if err := s.SerializeOptionTag(tag); err != nil {
+			return err
+		}
+		if tag {
+			return c.format(format.Content)
+		}
+		return nil
+
+	case serdetypes.SeqKind:
+		length, err := d.DeserializeLen()
+		if err != nil {
+			return err
+		}
+		if err := c.checkLength(length, format.Content); err != nil {
+			return err
+		}
+		if err := s.SerializeLen(length); err != nil {
+			return err
+		}
+		for i := uint64(0); i < length; i++ {
+			if err := c.format(format.Content); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case serdetypes.MapKind:
+		length, err := d.DeserializeMapLen()
+		if err != nil {
+			return err
+		}
+		if err := c.checkLength(length, format.Key, format.Value); err != nil {
+			return err
+		}
+		if err := s.SerializeLen(length); err != nil {
+			return err
+		}
+		var offsets []uint64
+		keys := make(map[string]bool)
+		for i := uint64(0); i < length; i++ {
+			start := s.GetBufferOffset()
+			offsets = append(offsets, start)
+			if err := c.format(format.Key); err != nil {
+				return err
+			}
+			key := string(s.GetBytes()[start:s.GetBufferOffset()])
+			if keys[key] {
+				return fmt.Errorf("Error while decoding map: %w", serde.ErrDuplicateMapKeys)
+			}
+			keys[key] = true
+			if err := c.format(format.Value); err != nil {
+				return err
+			}
+		}
+		s.SortMapEntries(offsets)
+		return nil
+
+	case serdetypes.TupleKind:
+		return c.formats(format.Elements)
+
+	case serdetypes.TupleArrayKind:
+		for i := uint64(0); i < format.Size; i++ {
+			if err := c.format(format.Content); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unexpected format kind: %d", format.Kind)
+	}
+}
+
+// Reading a sequence of values with an empty encoding (see `serdetypes.HasEmptyEncoding`)
+// does not consume the input: reject lengths larger than the remaining input, as if each
+// element took at least one byte. This rejects some valid inputs (e.g. `vec![(); 2]` at the
+// end of the input) but such sequences hardly occur in practice.
+func (c *canonicalizer) checkLength(length uint64, formats ...*serdetypes.Format) error {
+	for _, format := range formats {
+		if !c.registry.HasEmptyEncoding(format) {
+			return nil
+		}
+	}
+	if length > uint64(len(c.input))-c.deserializer.GetBufferOffset() {
+		return fmt.Errorf("%w: %d elements of empty values exceed the remaining input", serde.ErrInputTooShort, length)
+	}
+	return nil
+}
+
+func (c *canonicalizer) formats(formats []serdetypes.Format) error {
+	for i := range formats {
+		if err := c.format(&formats[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *canonicalizer) fields(fields []serdetypes.Named[serdetypes.Format]) error {
+	for i := range fields {
+		if err := c.format(&fields[i].Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *canonicalizer) container(name string) error {
+	format, err := c.registry.Lookup(name)
+	if err != nil {
+		return err
+	}
+	if err := c.deserializer.IncreaseContainerDepth(); err != nil {
+		return err
+	}
+	if err := c.serializer.IncreaseContainerDepth(); err != nil {
+		return err
+	}
+	if err := c.containerContent(name, &format); err != nil {
+		return err
+	}
+	c.serializer.DecreaseContainerDepth()
+	c.deserializer.DecreaseContainerDepth()
+	return nil
+}
+
+func (c *canonicalizer) containerContent(name string, format *serdetypes.ContainerFormat) error {
+	switch format.Kind {
+	case serdetypes.UnitStructKind:
+		return nil
+	case serdetypes.NewTypeStructKind:
+		return c.format(format.Content)
+	case serdetypes.TupleStructKind:
+		return c.formats(format.Elements)
+	case serdetypes.StructKind:
+		return c.fields(format.Fields)
+	case serdetypes.EnumKind:
+		index, err := c.deserializer.DeserializeVariantIndex()
+		if err != nil {
+			return err
+		}
+		variant, ok := format.Variants[index]
+		if !ok {
+			return fmt.Errorf("Unknown variant index for %s: %d", name, index)
+		}
+		if err := c.serializer.SerializeVariantIndex(index); err != nil {
+			return err
+		}
+		switch variant.Value.Kind {
+		case serdetypes.UnitVariantKind:
+			return nil
+		case serdetypes.NewTypeVariantKind:
+			return c.format(variant.Value.Content)
+		case serdetypes.TupleVariantKind:
+			return c.formats(variant.Value.Elements)
+		case serdetypes.StructVariantKind:
+			return c.fields(variant.Value.Fields)
+		default:
+			return fmt.Errorf("unexpected variant kind: %d", variant.Value.Kind)
+		}
+	default:
+		return fmt.Errorf("unexpected container kind: %d", format.Kind)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bcs_test
+
+import (
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	st "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// struct Test { a: BTreeMap<u8, String>, b: Option<Choice> }
+// enum Choice { A, B(u16), C { x: Vec<u8> } }
+var testRegistry = st.Registry{
+	Containers: map[string]st.ContainerFormat{
+		"Test": {
+			Kind: st.StructKind,
+			Fields: []st.Named[st.Format]{
+				{Name: "a", Value: st.Map(st.Primitive(st.U8Kind), st.Primitive(st.StrKind))},
+				{Name: "b", Value: st.Option(st.TypeName("Choice"))},
+			},
+		},
+		"Choice": {
+			Kind: st.EnumKind,
+			Variants: map[uint32]st.Named[st.VariantFormat]{
+				0: {Name: "A", Value: st.VariantFormat{Kind: st.UnitVariantKind}},
+				1: {Name: "B", Value: st.VariantFormat{Kind: st.TupleVariantKind, Elements: []st.Format{st.Primitive(st.U16Kind)}}},
+				2: {Name: "C", Value: st.VariantFormat{Kind: st.StructVariantKind, Fields: []st.Named[st.Format]{
+					{Name: "x", Value: st.Seq(st.Primitive(st.U8Kind))},
+				}}},
+			},
+		},
+	},
+}
+
+func TestCanonicalize(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    []byte
+		expected []byte
+	}{
+		{
+			name:     "canonical",
+			input:    []byte{2, 1, 1, 'a', 2, 0, 1, 1, 3, 0},
+			expected: []byte{2, 1, 1, 'a', 2, 0, 1, 1, 3, 0},
+		},
+		{
+			name:     "unsorted map",
+			input:    []byte{2, 2, 0, 1, 1, 'a', 0},
+			expected: []byte{2, 1, 1, 'a', 2, 0, 0},
+		},
+		{
+			name:     "non-minimal uleb128",
+			input:    []byte{0x81, 0x00, 1, 0x80, 0x00, 1, 0x82, 0x00, 0x82, 0x00, 4, 5},
+			expected: []byte{1, 1, 0, 1, 2, 2, 4, 5},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := bcs.Canonicalize(tc.input, &testRegistry, "Test")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		input []byte
+		err   string
+	}{
+		{name: "duplicate keys", input: []byte{2, 1, 0, 1, 0, 0}, err: "Error while decoding map: duplicate keys"},
+		{name: "invalid option tag", input: []byte{0, 2}, err: "invalid option tag: expected 0 / 1, but got 2"},
+		{name: "invalid UTF8", input: []byte{1, 1, 1, 0xff, 0}, err: "invalid UTF8 string"},
+		{name: "unknown variant", input: []byte{0, 1, 3}, err: "Unknown variant index for Choice: 3"},
+		{name: "trailing bytes", input: []byte{0, 0, 0}, err: "Some input bytes were not read"},
+		{name: "EOF", input: []byte{0, 1, 1, 0}, err: "unexpected EOF"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := bcs.Canonicalize(tc.input, &testRegistry, "Test")
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+	_, err := bcs.Canonicalize([]byte{}, &testRegistry, "Unknown")
+	assert.EqualError(t, err, `unknown container "Unknown"`)
+}
+
+func TestCanonicalizeEmptyElements(t *testing.T) {
+	// struct Units(Vec<()>, u16)
+	registry := st.Registry{Containers: map[string]st.ContainerFormat{
+		"Units": {Kind: st.TupleStructKind, Elements: []st.Format{st.Seq(st.Primitive(st.UnitKind)), st.Primitive(st.U16Kind)}},
+	}}
+	output, err := bcs.Canonicalize([]byte{0x82, 0x00, 7, 0}, &registry, "Units")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 7, 0}, output)
+
+	// The length 2^31 - 1 is rejected without reading as many units.
+	_, err = bcs.Canonicalize([]byte{0xff, 0xff, 0xff, 0xff, 0x07}, &registry, "Units")
+	assert.EqualError(t, err, "input is too short: 2147483647 elements of empty values exceed the remaining input")
+}
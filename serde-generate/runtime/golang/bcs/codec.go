@@ -0,0 +1,25 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bcs
+
+import (
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+)
+
+// Codec implements `serde.Codec` for BCS. It is registered under the name "bcs".
+var Codec serde.Codec = codec{}
+
+type codec struct{}
+
+func init() {
+	serde.RegisterCodec("bcs", Codec)
+}
+
+func (codec) Marshal(value serde.Serializable) ([]byte, error) {
+	return Marshal(value)
+}
+
+func (codec) Unmarshal(input []byte, value serde.Deserializable) error {
+	return Unmarshal(input, value)
+}
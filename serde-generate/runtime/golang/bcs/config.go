@@ -0,0 +1,43 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bcs
+
+// Config controls the limits enforced by BCS serializers and deserializers.
+// Custom configurations should be obtained by modifying `DefaultConfig()`.
+type Config struct {
+	// Maximum length allowed for sequences (vectors, bytes, strings) and maps.
+	// Values above 2^32 - 1 allow lengths to be encoded as 64-bit ULEB128 numbers
+	// instead of 32-bit ones, which is an extension of the BCS specification.
+	MaxSequenceLength uint64
+	// Optional tighter limits for specific kinds of sequences, in bytes for strings
+	// and byte arrays, and in number of elements (resp. entries) for vectors (resp. maps).
+	// The value 0 means that only `MaxSequenceLength` applies.
+	MaxStringLength uint64
+	MaxBytesLength  uint64
+	MaxVectorLength uint64
+	MaxMapLength    uint64
+	// Maximum number of nested structs and enum variants.
+	MaxContainerDepth uint64
+	// Return deserialized strings without checking that they are valid UTF-8.
+	// By default, invalid strings are rejected with `serde.ErrInvalidUTF8`.
+	AllowInvalidUTF8 bool
+	// Accept non-minimal ULEB128 encodings (e.g. produced by older encoders) for lengths
+	// and variant indices. By default, such encodings are rejected as non-canonical.
+	AllowNonCanonicalUleb128 bool
+	// Accept inputs that end before the trailing fields of a struct for which the generated
+	// code declares default values (see `serde.IsMissingTrailingField`), e.g. records
+	// persisted with an older version of the schema. Such inputs are not canonical.
+	AllowMissingTrailingFields bool
+}
+
+// DefaultConfig returns the configuration used by `NewSerializer` and `NewDeserializer`.
+func DefaultConfig() Config {
+	return Config{
+		MaxSequenceLength:          MaxSequenceLength,
+		MaxContainerDepth:          MaxContainerDepth,
+		AllowInvalidUTF8:           false,
+		AllowNonCanonicalUleb128:   false,
+		AllowMissingTrailingFields: false,
+	}
+}
@@ -5,7 +5,8 @@ package bcs
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
+	"io"
 
 	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
 )
@@ -21,53 +22,141 @@ const maxUint32 = uint64(^uint32(0))
 // `deserializer` extends `serde.BinaryDeserializer` to implement `serde.Deserializer`.
 type deserializer struct {
 	serde.BinaryDeserializer
+	config Config
+	// When set (see `Validate`), the canonicality violations found so far. Shared
+	// with the child deserializers created by `Slice`.
+	violations *[]Violation
 }
 
 func NewDeserializer(input []byte) serde.Deserializer {
-	return &deserializer{*serde.NewBinaryDeserializer(input, MaxContainerDepth)}
+	return NewDeserializerWithConfig(input, DefaultConfig())
+}
+
+func NewDeserializerWithConfig(input []byte, config Config) serde.Deserializer {
+	return &deserializer{BinaryDeserializer: *serde.NewBinaryDeserializer(input, config.MaxContainerDepth), config: config}
+}
+
+// NewStreamDeserializer creates a BCS deserializer that reads from `reader` as the
+// deserialization progresses. `EndOfInput` reads the rest of `reader`.
+func NewStreamDeserializer(reader io.Reader) serde.Deserializer {
+	return NewStreamDeserializerWithConfig(reader, DefaultConfig())
+}
+
+func NewStreamDeserializerWithConfig(reader io.Reader, config Config) serde.Deserializer {
+	return &deserializer{BinaryDeserializer: *serde.NewBinaryStreamDeserializer(reader, config.MaxContainerDepth), config: config}
 }
 
 // DeserializeF32 is unimplemented.
 func (d *deserializer) DeserializeF32() (float32, error) {
-	return 0, errors.New("unimplemented")
+	return 0, serde.ErrUnimplemented
 }
 
 // DeserializeF64 is unimplemented.
 func (d *deserializer) DeserializeF64() (float64, error) {
-	return 0, errors.New("unimplemented")
+	return 0, serde.ErrUnimplemented
 }
 
 func (d *deserializer) DeserializeBytes() ([]byte, error) {
-	return d.BinaryDeserializer.DeserializeBytes(d.DeserializeLen)
+	return d.BinaryDeserializer.DeserializeBytes(d.deserializeBytesLen)
+}
+
+func (d *deserializer) DeserializeVecBytes() ([][]byte, error) {
+	return d.BinaryDeserializer.DeserializeVecBytes(d.DeserializeLen, d.deserializeBytesLen)
 }
 
 func (d *deserializer) DeserializeStr() (string, error) {
-	return d.BinaryDeserializer.DeserializeStr(d.DeserializeLen)
+	if d.config.AllowInvalidUTF8 {
+		bytes, err := d.BinaryDeserializer.DeserializeBytes(d.deserializeStrLen)
+		return string(bytes), err
+	}
+	return d.BinaryDeserializer.DeserializeStr(d.deserializeStrLen)
 }
 
 func (d *deserializer) DeserializeLen() (uint64, error) {
-	ret, err := d.deserializeUleb128AsU32()
-	if ret > MaxSequenceLength {
-		return 0, errors.New("length is too large")
+	return d.deserializeLenWithLimit(d.config.MaxVectorLength)
+}
+
+func (d *deserializer) DeserializeMapLen() (uint64, error) {
+	return d.deserializeLenWithLimit(d.config.MaxMapLength)
+}
+
+func (d *deserializer) deserializeBytesLen() (uint64, error) {
+	return d.deserializeLenWithLimit(d.config.MaxBytesLength)
+}
+
+func (d *deserializer) deserializeStrLen() (uint64, error) {
+	return d.deserializeLenWithLimit(d.config.MaxStringLength)
+}
+
+// Read a length and check it against `MaxSequenceLength` as well as `limit` (if non-zero).
+func (d *deserializer) deserializeLenWithLimit(limit uint64) (uint64, error) {
+	var ret uint64
+	var err error
+	if d.config.MaxSequenceLength > maxUint32 {
+		ret, err = d.DeserializeUleb128(!d.config.AllowNonCanonicalUleb128)
+	} else {
+		var value uint32
+		value, err = d.deserializeUleb128AsU32()
+		ret = uint64(value)
 	}
-	return uint64(ret), err
+	if ret > d.config.MaxSequenceLength || (limit != 0 && ret > limit) {
+		return 0, serde.ErrLengthExceeded
+	}
+	return ret, err
 }
 
 func (d *deserializer) DeserializeVariantIndex() (uint32, error) {
 	return d.deserializeUleb128AsU32()
 }
 
+func (d *deserializer) AllowsMissingTrailingFields() bool {
+	return d.config.AllowMissingTrailingFields
+}
+
+func (d *deserializer) Slice(start, end uint64) (serde.Deserializer, error) {
+	child, err := d.BinaryDeserializer.Slice(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &deserializer{*child, d.config, d.violations}, nil
+}
+
 func (d *deserializer) CheckThatKeySlicesAreIncreasing(key1, key2 serde.Slice) error {
-	if bytes.Compare(d.Input[key1.Start:key1.End], d.Input[key2.Start:key2.End]) >= 0 {
-		return errors.New("Error while decoding map: keys are not serialized in the expected order")
+	bytes1, bytes2, err := d.KeyBytes(key1, key2)
+	if err != nil {
+		return err
+	}
+	if bytes.Compare(bytes1, bytes2) >= 0 {
+		if d.violations == nil {
+			return serde.ErrMapKeysNotOrdered
+		}
+		d.addViolation(key2.Start, serde.ErrMapKeysNotOrdered)
 	}
 	return nil
 }
 
+// DeserializeBool is the same as `BinaryDeserializer.DeserializeBool` except that
+// invalid bytes are read as `true` when collecting violations.
+func (d *deserializer) DeserializeBool() (bool, error) {
+	if d.violations == nil {
+		return d.BinaryDeserializer.DeserializeBool()
+	}
+	offset := d.GetBufferOffset()
+	ret, err := d.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	if ret > 1 {
+		d.addViolation(offset, fmt.Errorf("%w: expected 0 / 1, but got %d", serde.ErrInvalidBool, ret))
+	}
+	return ret != 0, nil
+}
+
 func (d *deserializer) deserializeUleb128AsU32() (uint32, error) {
+	offset := d.GetBufferOffset()
 	var value uint64
 	for shift := 0; shift < 32; shift += 7 {
-		byte, err := d.Buffer.ReadByte()
+		byte, err := d.ReadByte()
 		if err != nil {
 			return 0, err
 		}
@@ -75,14 +164,17 @@ func (d *deserializer) deserializeUleb128AsU32() (uint32, error) {
 		value = value | (uint64(digit) << shift)
 
 		if value > maxUint32 {
-			return 0, errors.New("overflow while parsing uleb128-encoded uint32 value")
+			return 0, serde.ErrUleb128OverflowU32
 		}
 		if digit == byte {
-			if shift > 0 && digit == 0 {
-				return 0, errors.New("invalid uleb128 number (unexpected zero digit)")
+			if !d.config.AllowNonCanonicalUleb128 && shift > 0 && digit == 0 {
+				if d.violations == nil {
+					return 0, serde.ErrNonCanonicalUleb128
+				}
+				d.addViolation(offset, serde.ErrNonCanonicalUleb128)
 			}
 			return uint32(value), nil
 		}
 	}
-	return 0, errors.New("overflow while parsing uleb128-encoded uint32 value")
+	return 0, serde.ErrUleb128OverflowU32
 }
@@ -0,0 +1,20 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+// Package bcs implements the Binary Canonical Serialization (BCS) format.
+//
+// With the default configuration, deserializers only accept canonical inputs:
+// bool values and option tags must be 0 or 1, ULEB128-encoded lengths and variant
+// indices must be minimal, map keys must be serialized in strictly increasing order,
+// strings must be valid UTF-8, and `Unmarshal` rejects trailing bytes. As a result,
+// any value successfully decoded from some bytes re-serializes to the identical bytes.
+//
+// The options `Config.AllowNonCanonicalUleb128` and `Config.AllowInvalidUTF8` lift
+// some of these checks, in which case this guarantee no longer holds. To normalize
+// non-canonical inputs, use `Canonicalize` instead.
+//
+// This package and the packages `serde` and `bincode` can be built with TinyGo (e.g. for
+// WebAssembly smart contracts): their support of `encoding/json` and `database/sql` is then
+// left out by the `tinygo` build tag. TinyGo itself is not tested: tests only build the
+// packages with `go build -tags tinygo` and the standard Go toolchain.
+package bcs
@@ -0,0 +1,62 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bcs
+
+import (
+	"crypto/sha256"
+	"hash/fnv"
+	"math"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+)
+
+// `unboundedSerializer` is a BCS serializer without limits that also accepts floats
+// (written as their IEEE 754 bits), so that serializing a value never fails.
+type unboundedSerializer struct {
+	serializer
+}
+
+func newUnboundedSerializer() *unboundedSerializer {
+	config := Config{MaxSequenceLength: math.MaxUint64, MaxContainerDepth: math.MaxUint64}
+	return &unboundedSerializer{serializer{*serde.NewBinarySerializer(config.MaxContainerDepth), config}}
+}
+
+func (s *unboundedSerializer) SerializeF32(value float32) error {
+	return s.SerializeU32(math.Float32bits(value))
+}
+
+func (s *unboundedSerializer) SerializeF64(value float64) error {
+	return s.SerializeU64(math.Float64bits(value))
+}
+
+// Hash64 returns the 64-bit FNV-1a hash of the BCS serialization of `value`. Since
+// BCS is canonical, the result is stable across processes and platforms. Contrary
+// to `Marshal`, no limits are enforced and floats are supported.
+func Hash64[T serde.Serializable](value T) uint64 {
+	serializer := newUnboundedSerializer()
+	// The serializer never fails.
+	_ = value.Serialize(serializer)
+	hash := fnv.New64a()
+	_, _ = hash.Write(serializer.GetBytes())
+	return hash.Sum64()
+}
+
+// MapKey returns the BCS serialization of `value` as a string, which generated code
+// uses to index maps by keys that are not comparable in Go (e.g. structs with slices).
+// Like `Hash64`, this never fails.
+func MapKey[T serde.Serializable](value T) string {
+	serializer := newUnboundedSerializer()
+	_ = value.Serialize(serializer)
+	return string(serializer.GetBytes())
+}
+
+// Sum256 returns the SHA-256 digest of the BCS serialization of `value`, e.g. to
+// address values by content.
+func Sum256[T serde.Serializable](value T) ([32]byte, error) {
+	data, err := Marshal(value)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
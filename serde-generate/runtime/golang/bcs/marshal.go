@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bcs
+
+import (
+	"io"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+)
+
+// Marshal serializes `value` into a new byte array.
+func Marshal[T serde.Serializable](value T) ([]byte, error) {
+	serializer := NewSerializer()
+	if err := value.Serialize(serializer); err != nil {
+		return nil, err
+	}
+	return serializer.GetBytes(), nil
+}
+
+// Append serializes `value` at the end of `buffer` and returns the extended buffer, which
+// reuses the capacity of `buffer` when possible (as `encoding.BinaryAppender` does).
+func Append[T serde.Serializable](buffer []byte, value T) ([]byte, error) {
+	config := DefaultConfig()
+	serializer := &serializer{*serde.NewBinarySerializerAppendingTo(buffer, config.MaxContainerDepth), config}
+	if err := value.Serialize(serializer); err != nil {
+		return nil, err
+	}
+	return serializer.GetBytes(), nil
+}
+
+// MarshalTo serializes `value` into `writer` without keeping the entire output in memory.
+func MarshalTo[T serde.Serializable](writer io.Writer, value T) error {
+	serializer := NewStreamSerializer(writer)
+	if err := value.Serialize(serializer); err != nil {
+		return err
+	}
+	return serializer.Flush()
+}
+
+// Unmarshal deserializes `value` from `input` and fails if `input` contains
+// trailing bytes.
+func Unmarshal[T serde.Deserializable](input []byte, value T) error {
+	deserializer := NewDeserializer(input)
+	if err := value.Deserialize(deserializer); err != nil {
+		return err
+	}
+	return deserializer.EndOfInput()
+}
+
+// UnmarshalFrom deserializes `value` from `reader` without keeping the entire input in
+// memory, and fails if `reader` contains trailing bytes.
+func UnmarshalFrom[T serde.Deserializable](reader io.Reader, value T) error {
+	deserializer := NewStreamDeserializer(reader)
+	if err := value.Deserialize(deserializer); err != nil {
+		return err
+	}
+	return deserializer.EndOfInput()
+}
+
+// UnmarshalPrefix deserializes `value` from the beginning of `input` and returns
+// the number of bytes consumed, e.g. to read a sequence of concatenated values.
+func UnmarshalPrefix[T serde.Deserializable](input []byte, value T) (int, error) {
+	deserializer := NewDeserializer(input)
+	if err := value.Deserialize(deserializer); err != nil {
+		return 0, err
+	}
+	return int(deserializer.GetBufferOffset()), nil
+}
+
+// UnmarshalStrict is the non-generic version of `Unmarshal`.
+func UnmarshalStrict(input []byte, value serde.Deserializable) error {
+	return Unmarshal(input, value)
+}
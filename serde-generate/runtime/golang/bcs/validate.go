@@ -0,0 +1,46 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bcs
+
+import (
+	"fmt"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+)
+
+// Violation is a non-canonical encoding found by `Validate` at the given input offset.
+type Violation struct {
+	Offset uint64
+	Err    error
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("offset %d: %v", v.Offset, v.Err)
+}
+
+// Validate deserializes `value` from `input` like `Unmarshal`, except that
+// non-canonical encodings (non-minimal ULEB128 numbers, map keys that are not
+// strictly increasing, bool bytes other than 0 and 1) do not stop the
+// deserialization. All of them are returned instead, in the order of the input.
+// This is meant for auditing data produced by other implementations.
+//
+// The returned error is set if `input` cannot be deserialized at all despite
+// these relaxations. The returned value should not be trusted whenever some
+// violations are reported.
+func Validate[T serde.Deserializable](input []byte, value T) ([]Violation, error) {
+	violations := make([]Violation, 0)
+	deserializer := &deserializer{
+		BinaryDeserializer: *serde.NewBinaryDeserializer(input, MaxContainerDepth),
+		config:             DefaultConfig(),
+		violations:         &violations,
+	}
+	if err := value.Deserialize(deserializer); err != nil {
+		return violations, err
+	}
+	return violations, deserializer.EndOfInput()
+}
+
+func (d *deserializer) addViolation(offset uint64, err error) {
+	*d.violations = append(*d.violations, Violation{Offset: offset, Err: err})
+}
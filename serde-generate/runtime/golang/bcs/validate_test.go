@@ -0,0 +1,59 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bcs_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	Flag    bool
+	Entries map[string]uint8
+}
+
+func (obj *record) Deserialize(deserializer serde.Deserializer) (err error) {
+	if obj.Flag, err = deserializer.DeserializeBool(); err != nil {
+		return err
+	}
+	obj.Entries, err = serde.DeserializeMap(deserializer,
+		func(d serde.Deserializer) (string, error) { return d.DeserializeStr() },
+		func(d serde.Deserializer) (uint8, error) { return d.DeserializeU8() },
+	)
+	return err
+}
+
+func TestValidate(t *testing.T) {
+	canonical := []byte{1, 2, 1, 'a', 1, 1, 'b', 2}
+	var value record
+	violations, err := bcs.Validate(canonical, &value)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.Equal(t, record{Flag: true, Entries: map[string]uint8{"a": 1, "b": 2}}, value)
+
+	// Invalid bool, non-minimal map length, unsorted keys.
+	input := []byte{2, 0x82, 0x00, 1, 'b', 2, 1, 'a', 1}
+	violations, err = bcs.Validate(input, &value)
+	require.NoError(t, err)
+	require.Len(t, violations, 3)
+	assert.Equal(t, uint64(0), violations[0].Offset)
+	assert.True(t, errors.Is(violations[0].Err, serde.ErrInvalidBool))
+	assert.Equal(t, "offset 1: invalid uleb128 number (unexpected zero digit)", violations[1].String())
+	assert.Equal(t, uint64(6), violations[2].Offset)
+	assert.Equal(t, serde.ErrMapKeysNotOrdered, violations[2].Err)
+	assert.Equal(t, record{Flag: true, Entries: map[string]uint8{"a": 1, "b": 2}}, value)
+
+	// The same input is rejected at the first violation otherwise.
+	assert.True(t, errors.Is(bcs.Unmarshal(input, &value), serde.ErrInvalidBool))
+
+	// Truncated input still fails.
+	_, err = bcs.Validate(input[:8], &value)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
@@ -0,0 +1,195 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bincode_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigMaxSequenceLength(t *testing.T) {
+	input := []byte{0, 0, 0, 0, 1, 0, 0, 0}
+
+	_, err := bincode.NewDeserializer(input).DeserializeLen()
+	assert.EqualError(t, err, "length is too large")
+
+	config := bincode.DefaultConfig()
+	config.MaxSequenceLength = 1 << 32
+	ret, err := bincode.NewDeserializerWithConfig(input, config).DeserializeLen()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1<<32), ret)
+
+	config.MaxSequenceLength = 2
+	_, err = bincode.NewDeserializerWithConfig([]byte{3, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3}, config).DeserializeBytes()
+	assert.EqualError(t, err, "length is too large")
+}
+
+func TestConfigMaxContainerDepth(t *testing.T) {
+	config := bincode.DefaultConfig()
+	config.MaxContainerDepth = 1
+
+	d := bincode.NewDeserializerWithConfig([]byte{}, config)
+	require.NoError(t, d.IncreaseContainerDepth())
+	assert.EqualError(t, d.IncreaseContainerDepth(), "exceeded maximum container depth")
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	uuid := serde.UUID{1, 2, 3}
+	data, err := bincode.Marshal(uuid)
+	require.NoError(t, err)
+	assert.Equal(t, uuid[:], data)
+
+	var result serde.UUID
+	require.NoError(t, bincode.Unmarshal(data, &result))
+	assert.Equal(t, uuid, result)
+	assert.EqualError(t, bincode.Unmarshal(append(data, 0), &result), "Some input bytes were not read")
+}
+
+func TestMarshalTo(t *testing.T) {
+	uuid := serde.UUID{1, 2, 3}
+	var buffer bytes.Buffer
+	require.NoError(t, bincode.MarshalTo(&buffer, uuid))
+	assert.Equal(t, uuid[:], buffer.Bytes())
+}
+
+func TestUnmarshalFrom(t *testing.T) {
+	uuid := serde.UUID{1, 2, 3}
+	var result serde.UUID
+	require.NoError(t, bincode.UnmarshalFrom(bytes.NewReader(uuid[:]), &result))
+	assert.Equal(t, uuid, result)
+	assert.EqualError(t, bincode.UnmarshalFrom(bytes.NewReader(append(uuid[:], 0)), &result), "Some input bytes were not read")
+}
+
+func TestAppend(t *testing.T) {
+	uuid := serde.UUID{1, 2, 3}
+	output, err := bincode.Append([]byte{9}, uuid)
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{9}, uuid[:]...), output)
+}
+
+func TestConfigSizeLimit(t *testing.T) {
+	config := bincode.DefaultConfig().WithSizeLimit(10)
+	assert.Equal(t, uint64(10), config.SizeLimit)
+
+	t.Run("serializer", func(t *testing.T) {
+		s := bincode.NewSerializerWithConfig(config)
+		require.NoError(t, s.SerializeBytes([]byte{1, 2}))
+		assert.Equal(t, bincode.ErrSizeLimitExceeded, s.SerializeBytes([]byte{1}))
+
+		s = bincode.NewSerializerWithConfig(config)
+		require.NoError(t, s.SerializeU64(1))
+		require.NoError(t, s.SerializeU64(2))
+		assert.Equal(t, bincode.ErrSizeLimitExceeded, s.IncreaseContainerDepth())
+	})
+	t.Run("deserializer", func(t *testing.T) {
+		input := []byte{4, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4}
+		_, err := bincode.NewDeserializerWithConfig(input, config).DeserializeBytes()
+		assert.Equal(t, bincode.ErrSizeLimitExceeded, err)
+
+		d := bincode.NewDeserializerWithConfig(input, config)
+		_, err = d.DeserializeU64()
+		require.NoError(t, err)
+		_, err = d.DeserializeU16()
+		require.NoError(t, err)
+		_, err = d.DeserializeU8()
+		assert.EqualError(t, err, "unexpected EOF")
+		assert.EqualError(t, d.EndOfInput(), "Some input bytes were not read")
+
+		ret, err := bincode.NewDeserializerWithConfig(input, config.WithSizeLimit(12)).DeserializeBytes()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3, 4}, ret)
+	})
+	t.Run("Append", func(t *testing.T) {
+		// The limit only applies to the appended bytes.
+		uuid := serde.UUID{1, 2, 3}
+		output, err := bincode.AppendWithConfig(make([]byte, 20), uuid, config.WithSizeLimit(16))
+		require.NoError(t, err)
+		assert.Len(t, output, 36)
+		_, err = bincode.AppendWithConfig(nil, uuid, config)
+		assert.Equal(t, bincode.ErrSizeLimitExceeded, err)
+	})
+	t.Run("Marshal", func(t *testing.T) {
+		uuid := serde.UUID{1, 2, 3}
+		_, err := bincode.MarshalWithConfig(uuid, config)
+		assert.Equal(t, bincode.ErrSizeLimitExceeded, err)
+		data, err := bincode.MarshalWithConfig(uuid, config.WithSizeLimit(16))
+		require.NoError(t, err)
+
+		var result serde.UUID
+		assert.EqualError(t, bincode.UnmarshalWithConfig(data, &result, config), "input is too short")
+		require.NoError(t, bincode.UnmarshalWithConfig(data, &result, config.WithSizeLimit(16)))
+		assert.Equal(t, uuid, result)
+	})
+}
+
+func TestConfigAllowMissingTrailingFields(t *testing.T) {
+	input := []byte{1, 0, 0, 0, 2, 0}
+	config := bincode.DefaultConfig()
+	config.AllowMissingTrailingFields = true
+	d := bincode.NewDeserializerWithConfig(input, config)
+	_, err := d.DeserializeU32()
+	require.NoError(t, err)
+	assert.False(t, serde.IsMissingTrailingField(d))
+	_, err = d.DeserializeU16()
+	require.NoError(t, err)
+	assert.True(t, serde.IsMissingTrailingField(d))
+
+	// Inputs truncated to the size limit are not missing fields.
+	d = bincode.NewDeserializerWithConfig(input, config.WithSizeLimit(4))
+	_, err = d.DeserializeU32()
+	require.NoError(t, err)
+	assert.False(t, serde.IsMissingTrailingField(d))
+
+	d = bincode.NewDeserializer(input)
+	_, err = d.DeserializeU32()
+	require.NoError(t, err)
+	_, err = d.DeserializeU16()
+	require.NoError(t, err)
+	assert.False(t, serde.IsMissingTrailingField(d))
+}
+
+func TestDeserializeOptionTag(t *testing.T) {
+	_, err := bincode.NewDeserializer([]byte{2}).DeserializeOptionTag()
+	assert.EqualError(t, err, "invalid option tag: expected 0 / 1, but got 2")
+	_, err = bincode.NewDeserializer([]byte{2}).DeserializeBool()
+	assert.EqualError(t, err, "invalid bool byte: expected 0 / 1, but got 2")
+}
+
+func TestConfigRejectDuplicateMapKeys(t *testing.T) {
+	// A map with the keys 2, 1, 2 and unit values.
+	input := []byte{3, 0, 0, 0, 0, 0, 0, 0, 2, 1, 2}
+	deserializeKey := func(d serde.Deserializer) (uint8, error) { return d.DeserializeU8() }
+	deserializeValue := func(d serde.Deserializer) (struct{}, error) { return d.DeserializeUnit() }
+
+	value, err := serde.DeserializeMap(bincode.NewDeserializer(input), deserializeKey, deserializeValue)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint8]struct{}{1: {}, 2: {}}, value)
+
+	config := bincode.DefaultConfig()
+	config.RejectDuplicateMapKeys = true
+	_, err = serde.DeserializeMap(bincode.NewDeserializerWithConfig(input, config), deserializeKey, deserializeValue)
+	assert.EqualError(t, err, "Error while decoding map: duplicate keys")
+
+	input[0] = 2
+	value, err = serde.DeserializeMap(bincode.NewDeserializerWithConfig(input[:10], config), deserializeKey, deserializeValue)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint8]struct{}{1: {}, 2: {}}, value)
+}
+
+func TestSerializeDeserializeVecBytes(t *testing.T) {
+	s := bincode.NewSerializer()
+	require.NoError(t, s.SerializeVecBytes([][]byte{{1, 2}, {}}))
+	expected := []byte{2, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1, 2, 0, 0, 0, 0, 0, 0, 0, 0}
+	assert.Equal(t, expected, s.GetBytes())
+
+	d := bincode.NewDeserializer(expected)
+	ret, err := d.DeserializeVecBytes()
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{1, 2}, {}}, ret)
+}
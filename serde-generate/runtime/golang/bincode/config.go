@@ -0,0 +1,53 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bincode
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSizeLimitExceeded is returned when `Config.SizeLimit` is exceeded.
+var ErrSizeLimitExceeded = errors.New("size limit exceeded")
+
+// Config controls the limits enforced by Bincode serializers and deserializers.
+// Custom configurations should be obtained by modifying `DefaultConfig()`.
+type Config struct {
+	// Maximum length allowed for sequences (vectors, bytes, strings) and maps.
+	MaxSequenceLength uint64
+	// Maximum number of nested structs and enum variants. There is no limit by default.
+	MaxContainerDepth uint64
+	// Maximum number of bytes produced by a serializer or read by a deserializer
+	// (similar to `with_limit` in Rust). The value 0 means that there is no limit.
+	//
+	// Deserializers fail as if the input had ended when reading past the limit.
+	// Serializers check the limit each time a container, a length, a variant index,
+	// or an option tag is serialized. `MarshalWithConfig` also checks the final output.
+	SizeLimit uint64
+	// Reject maps containing several keys with the same serialization. Contrary to
+	// BCS, Bincode does not require keys to be ordered so duplicate keys are
+	// otherwise silently accepted (the last value wins).
+	RejectDuplicateMapKeys bool
+	// Accept inputs that end before the trailing fields of a struct for which the generated
+	// code declares default values (see `serde.IsMissingTrailingField`), e.g. records
+	// persisted with an older version of the schema.
+	AllowMissingTrailingFields bool
+}
+
+// DefaultConfig returns the configuration used by `NewSerializer` and `NewDeserializer`.
+func DefaultConfig() Config {
+	return Config{
+		MaxSequenceLength:          MaxSequenceLength,
+		MaxContainerDepth:          math.MaxUint64,
+		SizeLimit:                  0,
+		RejectDuplicateMapKeys:     false,
+		AllowMissingTrailingFields: false,
+	}
+}
+
+// WithSizeLimit returns a copy of the configuration with the given `SizeLimit`.
+func (c Config) WithSizeLimit(limit uint64) Config {
+	c.SizeLimit = limit
+	return c
+}
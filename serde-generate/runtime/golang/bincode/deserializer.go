@@ -4,7 +4,8 @@
 package bincode
 
 import (
-	"errors"
+	"fmt"
+	"io"
 	"math"
 
 	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
@@ -16,10 +17,45 @@ const MaxSequenceLength = (1 << 31) - 1
 // `deserializer` extends `serde.BinaryDeserializer` to implement `serde.Deserializer`.
 type deserializer struct {
 	serde.BinaryDeserializer
+	config Config
+	// Whether the input was truncated to `config.SizeLimit` bytes.
+	truncated bool
+	// When `config.RejectDuplicateMapKeys` is set, the serialized keys seen so far in
+	// each map being deserialized, indexed by the offset of the last key.
+	mapKeys map[uint64]map[string]bool
 }
 
 func NewDeserializer(input []byte) serde.Deserializer {
-	return &deserializer{*serde.NewBinaryDeserializer(input, math.MaxUint64)}
+	return NewDeserializerWithConfig(input, DefaultConfig())
+}
+
+func NewDeserializerWithConfig(input []byte, config Config) serde.Deserializer {
+	return newDeserializer(input, config)
+}
+
+// NewStreamDeserializer creates a Bincode deserializer that reads from `reader` as the
+// deserialization progresses. `EndOfInput` reads the rest of `reader`.
+func NewStreamDeserializer(reader io.Reader) serde.Deserializer {
+	config := DefaultConfig()
+	return &deserializer{
+		BinaryDeserializer: *serde.NewBinaryStreamDeserializer(reader, config.MaxContainerDepth),
+		config:             config,
+		mapKeys:            make(map[uint64]map[string]bool),
+	}
+}
+
+func newDeserializer(input []byte, config Config) *deserializer {
+	truncated := false
+	if config.SizeLimit != 0 && uint64(len(input)) > config.SizeLimit {
+		input = input[:config.SizeLimit]
+		truncated = true
+	}
+	return &deserializer{
+		BinaryDeserializer: *serde.NewBinaryDeserializer(input, config.MaxContainerDepth),
+		config:             config,
+		truncated:          truncated,
+		mapKeys:            make(map[uint64]map[string]bool),
+	}
 }
 
 func (d *deserializer) DeserializeF32() (float32, error) {
@@ -33,26 +69,88 @@ func (d *deserializer) DeserializeF64() (float64, error) {
 }
 
 func (d *deserializer) DeserializeBytes() ([]byte, error) {
-	return d.BinaryDeserializer.DeserializeBytes(d.DeserializeLen)
+	return d.BinaryDeserializer.DeserializeBytes(d.deserializeBytesLen)
+}
+
+func (d *deserializer) DeserializeVecBytes() ([][]byte, error) {
+	return d.BinaryDeserializer.DeserializeVecBytes(d.DeserializeLen, d.deserializeBytesLen)
 }
 
 func (d *deserializer) DeserializeStr() (string, error) {
-	return d.BinaryDeserializer.DeserializeStr(d.DeserializeLen)
+	return d.BinaryDeserializer.DeserializeStr(d.deserializeBytesLen)
+}
+
+// Same as `DeserializeLen` but fails early when the bytes cannot fit in the size limit.
+func (d *deserializer) deserializeBytesLen() (uint64, error) {
+	ret, err := d.DeserializeLen()
+	if err == nil && d.truncated && ret > uint64(d.Buffer.Len()) {
+		return 0, ErrSizeLimitExceeded
+	}
+	return ret, err
 }
 
 func (d *deserializer) DeserializeLen() (uint64, error) {
 	ret, err := d.DeserializeU64()
-	if ret > MaxSequenceLength {
-		return 0, errors.New("length is too large")
+	if ret > d.config.MaxSequenceLength {
+		return 0, serde.ErrLengthExceeded
 	}
 	return uint64(ret), err
 }
 
+func (d *deserializer) DeserializeMapLen() (uint64, error) {
+	return d.DeserializeLen()
+}
+
 func (d *deserializer) DeserializeVariantIndex() (uint32, error) {
 	return d.DeserializeU32()
 }
 
+func (d *deserializer) EndOfInput() error {
+	if d.truncated {
+		return serde.ErrRemainingBytes
+	}
+	return d.BinaryDeserializer.EndOfInput()
+}
+
+func (d *deserializer) AllowsMissingTrailingFields() bool {
+	return d.config.AllowMissingTrailingFields
+}
+
+func (d *deserializer) Slice(start, end uint64) (serde.Deserializer, error) {
+	child, err := d.BinaryDeserializer.Slice(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &deserializer{
+		BinaryDeserializer: *child,
+		config:             d.config,
+		truncated:          false,
+		mapKeys:            make(map[uint64]map[string]bool),
+	}, nil
+}
+
 func (d *deserializer) CheckThatKeySlicesAreIncreasing(key1, key2 serde.Slice) error {
+	bytes1, bytes2, err := d.KeyBytes(key1, key2)
 	// No need to check key ordering in Bincode.
+	if !d.config.RejectDuplicateMapKeys {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// This is called for each pair of consecutive keys in a map, so the keys seen
+	// so far are tracked along the last key of the map.
+	keys, ok := d.mapKeys[key1.Start]
+	if ok {
+		delete(d.mapKeys, key1.Start)
+	} else {
+		keys = map[string]bool{string(bytes1): true}
+	}
+	key := string(bytes2)
+	if keys[key] {
+		return fmt.Errorf("Error while decoding map: %w", serde.ErrDuplicateMapKeys)
+	}
+	keys[key] = true
+	d.mapKeys[key2.Start] = keys
 	return nil
 }
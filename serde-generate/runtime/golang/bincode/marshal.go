@@ -0,0 +1,81 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package bincode
+
+import (
+	"io"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+)
+
+// Marshal serializes `value` into a new byte array.
+func Marshal[T serde.Serializable](value T) ([]byte, error) {
+	return MarshalWithConfig(value, DefaultConfig())
+}
+
+// MarshalWithConfig serializes `value` into a new byte array using the given configuration.
+func MarshalWithConfig[T serde.Serializable](value T, config Config) ([]byte, error) {
+	serializer := newSerializer(config)
+	if err := value.Serialize(serializer); err != nil {
+		return nil, err
+	}
+	if err := serializer.checkSizeLimit(); err != nil {
+		return nil, err
+	}
+	return serializer.GetBytes(), nil
+}
+
+// Append serializes `value` at the end of `buffer` and returns the extended buffer, which
+// reuses the capacity of `buffer` when possible (as `encoding.BinaryAppender` does).
+func Append[T serde.Serializable](buffer []byte, value T) ([]byte, error) {
+	return AppendWithConfig(buffer, value, DefaultConfig())
+}
+
+// AppendWithConfig serializes `value` at the end of `buffer` using the given configuration.
+// The size limit of the configuration only applies to the appended bytes.
+func AppendWithConfig[T serde.Serializable](buffer []byte, value T, config Config) ([]byte, error) {
+	serializer := newSerializerAppendingTo(buffer, config)
+	if err := value.Serialize(serializer); err != nil {
+		return nil, err
+	}
+	if err := serializer.checkSizeLimit(); err != nil {
+		return nil, err
+	}
+	return serializer.GetBytes(), nil
+}
+
+// MarshalTo serializes `value` into `writer` without keeping the entire output in memory.
+func MarshalTo[T serde.Serializable](writer io.Writer, value T) error {
+	serializer := NewStreamSerializer(writer)
+	if err := value.Serialize(serializer); err != nil {
+		return err
+	}
+	return serializer.Flush()
+}
+
+// Unmarshal deserializes `value` from `input` and fails if `input` contains
+// trailing bytes.
+func Unmarshal[T serde.Deserializable](input []byte, value T) error {
+	return UnmarshalWithConfig(input, value, DefaultConfig())
+}
+
+// UnmarshalFrom deserializes `value` from `reader` without keeping the entire input in
+// memory, and fails if `reader` contains trailing bytes.
+func UnmarshalFrom[T serde.Deserializable](reader io.Reader, value T) error {
+	deserializer := NewStreamDeserializer(reader)
+	if err := value.Deserialize(deserializer); err != nil {
+		return err
+	}
+	return deserializer.EndOfInput()
+}
+
+// UnmarshalWithConfig deserializes `value` from `input` using the given configuration
+// and fails if `input` contains trailing bytes.
+func UnmarshalWithConfig[T serde.Deserializable](input []byte, value T, config Config) error {
+	deserializer := newDeserializer(input, config)
+	if err := value.Deserialize(deserializer); err != nil {
+		return err
+	}
+	return deserializer.EndOfInput()
+}
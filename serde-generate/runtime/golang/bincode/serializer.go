@@ -4,6 +4,7 @@
 package bincode
 
 import (
+	"io"
 	"math"
 
 	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
@@ -12,10 +13,35 @@ import (
 // `serializer` extends `serde.BinarySerializer` to implement `serde.Serializer`.
 type serializer struct {
 	serde.BinarySerializer
+	config Config
+	// Number of bytes in the buffer before the serialized value (see `Append`).
+	start uint64
 }
 
 func NewSerializer() serde.Serializer {
-	return &serializer{*serde.NewBinarySerializer(math.MaxUint64)}
+	return NewSerializerWithConfig(DefaultConfig())
+}
+
+func NewSerializerWithConfig(config Config) serde.Serializer {
+	return newSerializer(config)
+}
+
+// NewStreamSerializer creates a Bincode serializer that writes to `writer` as the
+// serialization progresses. Call `Flush` once the value is serialized.
+func NewStreamSerializer(writer io.Writer) *serde.StreamSerializer {
+	return serde.NewStreamSerializer(NewSerializer(), writer)
+}
+
+func newSerializer(config Config) *serializer {
+	return &serializer{BinarySerializer: *serde.NewBinarySerializer(config.MaxContainerDepth), config: config}
+}
+
+func newSerializerAppendingTo(buffer []byte, config Config) *serializer {
+	return &serializer{
+		BinarySerializer: *serde.NewBinarySerializerAppendingTo(buffer, config.MaxContainerDepth),
+		config:           config,
+		start:            uint64(len(buffer)),
+	}
 }
 
 func (s *serializer) SerializeF32(value float32) error {
@@ -27,21 +53,61 @@ func (s *serializer) SerializeF64(value float64) error {
 }
 
 func (s *serializer) SerializeStr(value string) error {
-	return s.BinarySerializer.SerializeStr(value, s.SerializeLen)
+	if err := s.BinarySerializer.SerializeStr(value, s.SerializeLen); err != nil {
+		return err
+	}
+	return s.checkSizeLimit()
 }
 
 func (s *serializer) SerializeBytes(value []byte) error {
-	return s.BinarySerializer.SerializeBytes(value, s.SerializeLen)
+	if err := s.BinarySerializer.SerializeBytes(value, s.SerializeLen); err != nil {
+		return err
+	}
+	return s.checkSizeLimit()
+}
+
+func (s *serializer) SerializeVecBytes(value [][]byte) error {
+	if err := s.BinarySerializer.SerializeVecBytes(value, s.SerializeLen); err != nil {
+		return err
+	}
+	return s.checkSizeLimit()
 }
 
 func (s *serializer) SerializeLen(value uint64) error {
+	if err := s.checkSizeLimit(); err != nil {
+		return err
+	}
 	return s.SerializeU64(value)
 }
 
 func (s *serializer) SerializeVariantIndex(value uint32) error {
+	if err := s.checkSizeLimit(); err != nil {
+		return err
+	}
 	return s.SerializeU32(value)
 }
 
+func (s *serializer) SerializeOptionTag(value bool) error {
+	if err := s.checkSizeLimit(); err != nil {
+		return err
+	}
+	return s.BinarySerializer.SerializeOptionTag(value)
+}
+
+func (s *serializer) IncreaseContainerDepth() error {
+	if err := s.checkSizeLimit(); err != nil {
+		return err
+	}
+	return s.BinarySerializer.IncreaseContainerDepth()
+}
+
 func (s *serializer) SortMapEntries(offsets []uint64) {
 	// No need to sort map entries in Bincode.
 }
+
+func (s *serializer) checkSizeLimit() error {
+	if s.config.SizeLimit != 0 && uint64(s.Buffer.Len())-s.start > s.config.SizeLimit {
+		return ErrSizeLimitExceeded
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+)
+
+// Decode `text`, given in hexadecimal (with an optional `0x` prefix) or base64 (standard or
+// URL-safe, with or without padding). Whitespace is ignored.
+func parseInput(inputFormat string, text string) ([]byte, error) {
+	text = strings.Join(strings.Fields(text), "")
+	switch inputFormat {
+	case "hex":
+		text = strings.TrimPrefix(strings.TrimPrefix(text, "0x"), "0X")
+		return hex.DecodeString(text)
+	case "base64":
+		text = strings.TrimRight(text, "=")
+		if strings.ContainsAny(text, "-_") {
+			return base64.RawURLEncoding.DecodeString(text)
+		}
+		return base64.RawStdEncoding.DecodeString(text)
+	default:
+		return nil, fmt.Errorf("unknown input format %q", inputFormat)
+	}
+}
+
+// Print `value` as a tree, one line per value, with the offset and the length of its
+// serialized bytes, e.g.
+//
+//	OFFSET LENGTH
+//	     0     12  Transfer
+//	     0      8    amount: 10
+//	     8      4    memo: Some
+//	     9      3      "ab"
+func printValue(w io.Writer, value *dynamic.Value) {
+	fmt.Fprintln(w, "OFFSET LENGTH")
+	printTree(w, "", value, 0)
+}
+
+func printTree(w io.Writer, label string, value *dynamic.Value, depth int) {
+	if label != "" {
+		label += ": "
+	}
+	fmt.Fprintf(w, "%6d %6d  %s%s%s\n", value.Start, value.End-value.Start, strings.Repeat("  ", depth), label, summary(value))
+	for i := range value.Elements {
+		elementLabel := fmt.Sprintf("[%d]", i)
+		if value.Kind == dynamic.OptionValue {
+			elementLabel = ""
+		}
+		printTree(w, elementLabel, &value.Elements[i], depth+1)
+	}
+	for i := range value.Entries {
+		printTree(w, fmt.Sprintf("[%d] key", i), &value.Entries[i].Key, depth+1)
+		printTree(w, fmt.Sprintf("[%d] value", i), &value.Entries[i].Value, depth+1)
+	}
+	for i := range value.Fields {
+		printTree(w, value.Fields[i].Name, &value.Fields[i].Value, depth+1)
+	}
+}
+
+// The description of `value` itself, without its content.
+func summary(value *dynamic.Value) string {
+	switch value.Kind {
+	case dynamic.PrimitiveValue:
+		return value.String()
+	case dynamic.OptionValue:
+		if len(value.Elements) == 0 {
+			return "None"
+		}
+		return "Some"
+	case dynamic.SeqValue:
+		return plural(len(value.Elements), "element", "elements")
+	case dynamic.MapValue:
+		return plural(len(value.Entries), "entry", "entries")
+	case dynamic.TupleValue:
+		return "tuple"
+	case dynamic.StructValue:
+		return value.TypeName
+	case dynamic.VariantValue:
+		return fmt.Sprintf("%s::%s (variant %d)", value.TypeName, value.VariantName, value.VariantIndex)
+	default:
+		return fmt.Sprintf("<unexpected value kind: %d>", value.Kind)
+	}
+}
+
+func plural(count int, singular string, plural string) string {
+	if count == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRegistry = `
+Transfer:
+  STRUCT:
+    - amount: U64
+    - memo:
+        OPTION: STR
+    - tags:
+        MAP:
+          KEY: U8
+          VALUE: BOOL
+    - status:
+        TYPENAME: Status
+Status:
+  ENUM:
+    0:
+      Pending: UNIT
+    1:
+      Failed:
+        NEWTYPE: U16
+`
+
+func TestParseInput(t *testing.T) {
+	for _, tc := range []struct {
+		format   string
+		text     string
+		expected []byte
+	}{
+		{"hex", "0x01ff", []byte{1, 0xff}},
+		{"hex", " 01 FF\n", []byte{1, 0xff}},
+		{"base64", "Af8=", []byte{1, 0xff}},
+		{"base64", "Af8", []byte{1, 0xff}},
+		{"base64", "Af+/", []byte{1, 0xff, 0xbf}},
+		{"base64", "Af-_", []byte{1, 0xff, 0xbf}},
+	} {
+		input, err := parseInput(tc.format, tc.text)
+		require.NoError(t, err, tc.text)
+		assert.Equal(t, tc.expected, input, tc.text)
+	}
+	_, err := parseInput("hex", "0x1")
+	assert.Error(t, err)
+	_, err = parseInput("octal", "1")
+	assert.EqualError(t, err, `unknown input format "octal"`)
+}
+
+func TestPrintValue(t *testing.T) {
+	registry, err := serdetypes.ParseRegistry([]byte(testRegistry))
+	require.NoError(t, err)
+	input := []byte{10, 0, 0, 0, 0, 0, 0, 0, 1, 2, 'a', 'b', 2, 1, 1, 3, 0, 1, 7, 0}
+	value, err := dynamic.DecodeBcs(input, registry, "Transfer")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	printValue(&out, &value)
+	assert.Equal(t, `OFFSET LENGTH
+     0     20  Transfer
+     0      8    amount: 10
+     8      4    memo: Some
+     9      3      "ab"
+    12      5    tags: 2 entries
+    13      1      [0] key: 1
+    14      1      [0] value: true
+    15      1      [1] key: 3
+    16      1      [1] value: false
+    17      3    status: Status::Failed (variant 1)
+    18      2      [0]: 7
+`, out.String())
+}
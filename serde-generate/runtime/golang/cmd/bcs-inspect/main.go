@@ -0,0 +1,95 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+// Command bcs-inspect decodes a BCS (or Bincode) payload according to the Serde formats
+// recorded by serde-reflection (in YAML or JSON), and prints the decoded values with their
+// offsets in the input:
+//
+//	go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/bcs-inspect \
+//		ledger.yaml Transaction 0x0102...
+//
+// The input is given in hexadecimal (the default), in base64 (`-input base64`), or as a file
+// of raw bytes (`-input file`). Without an argument, it is read from the standard input.
+// When the input cannot be decoded, the error gives the path of the value and the offset
+// where decoding failed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+func main() {
+	inputFormat := flag.String("input", "hex", "Representation of the input: hex, base64, or file (raw bytes)")
+	encoding := flag.String("encoding", "bcs", "Serialization format of the input: bcs or bincode")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <registry.yaml> <type name> [input]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 || flag.NArg() > 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	registry, err := serdetypes.ReadRegistry(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	input, err := readInput(*inputFormat, flag.Arg(2))
+	if err != nil {
+		fail(err)
+	}
+	var deserializer serde.Deserializer
+	switch *encoding {
+	case "bcs":
+		deserializer = bcs.NewDeserializer(input)
+	case "bincode":
+		deserializer = bincode.NewDeserializer(input)
+	default:
+		fail(fmt.Errorf("unknown encoding %q", *encoding))
+	}
+
+	value, err := dynamic.Decode(deserializer, registry, flag.Arg(1))
+	if err != nil {
+		fail(err)
+	}
+	// Print the value before reporting trailing bytes, if any.
+	printValue(os.Stdout, &value)
+	if err := deserializer.EndOfInput(); err != nil {
+		fail(fmt.Errorf("%w: %d of %d bytes were read", err, value.End, len(input)))
+	}
+}
+
+// Read the input given by `arg` (or the standard input, if empty) in the given representation.
+func readInput(inputFormat string, arg string) ([]byte, error) {
+	if inputFormat == "file" {
+		if arg == "" || arg == "-" {
+			return io.ReadAll(os.Stdin)
+		}
+		return os.ReadFile(arg)
+	}
+	text := []byte(arg)
+	if arg == "" {
+		var err error
+		if text, err = io.ReadAll(os.Stdin); err != nil {
+			return nil, err
+		}
+	}
+	return parseInput(inputFormat, string(text))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "bcs-inspect:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+// Command registry-compat compares two versions of a registry recorded by serde-reflection
+// (in YAML or JSON), prints the changes between them, and fails if a change is less
+// compatible than allowed for values serialized in BCS or Bincode:
+//
+//	go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/registry-compat \
+//		-allow backward old/ledger.yaml ledger.yaml
+//
+// By default, backward compatible changes are allowed (`-allow backward`), e.g. adding
+// variants to enums. Use `-allow compatible` to only allow changes to names, or
+// `-allow incompatible` to only print the changes. Use `-allow-missing-trailing-fields` if
+// the fields appended to structs have default values in the generated code and values are
+// decoded with the option `AllowMissingTrailingFields`. See the package `compat` for details.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/compat"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+var allowedValues = map[string]compat.Compatibility{
+	"compatible":   compat.Compatible,
+	"backward":     compat.BackwardCompatible,
+	"incompatible": compat.Incompatible,
+}
+
+func main() {
+	allow := flag.String("allow", "backward", "Least compatible changes to allow: compatible, backward, or incompatible")
+	allowMissingTrailingFields := flag.Bool("allow-missing-trailing-fields", false, "Report the fields appended to structs as backward compatible (see `compat.CheckOptions`)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <old registry.yaml> <new registry.yaml>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	allowed, ok := allowedValues[*allow]
+	if !ok {
+		fail(fmt.Errorf("unknown value for -allow: %q", *allow))
+	}
+
+	before, err := serdetypes.ReadRegistry(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	after, err := serdetypes.ReadRegistry(flag.Arg(1))
+	if err != nil {
+		fail(err)
+	}
+	report, err := compat.CheckWithOptions(before, after, compat.CheckOptions{AllowMissingTrailingFields: *allowMissingTrailingFields})
+	if err != nil {
+		fail(err)
+	}
+	for _, change := range report.Changes {
+		fmt.Println(change)
+	}
+	if compatibility := report.Compatibility(); compatibility > allowed {
+		fail(fmt.Errorf("changes are %s", compatibility))
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "registry-compat:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,1146 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+const defaultSerdeModulePath = "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang"
+
+// Encodings supported by the runtimes, in the order of the Rust generator.
+var knownEncodings = []string{"bincode", "bcs"}
+
+// config mirrors the options of the Rust generator (`serde_generate::golang::CodeGenerator`)
+// that are available on the command line.
+type config struct {
+	// Name of the generated package.
+	packageName string
+	// Module path where to find the packages `serde`, `bcs` and `bincode`.
+	serdeModulePath string
+	// Whether to include serialization methods.
+	serialization bool
+	// Encodings for which to generate specialized methods, e.g. "bcs".
+	encodings []string
+	// Whether to generate maps from container names to deserializers.
+	typeRegistry bool
+}
+
+func (c *config) hasEncoding(name string) bool {
+	for _, encoding := range c.encodings {
+		if encoding == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Encoding used to implement `encoding.BinaryMarshaler` and `encoding.BinaryUnmarshaler`:
+// BCS if available, otherwise the first encoding.
+func (c *config) binaryEncoding() (string, bool) {
+	if c.hasEncoding("bcs") {
+		return "bcs", true
+	}
+	if len(c.encodings) > 0 {
+		return c.encodings[0], true
+	}
+	return "", false
+}
+
+// emitter produces the same source code as the Rust generator for the options in `config`,
+// so that Go bindings can be regenerated with either tool.
+type emitter struct {
+	out    *indentedWriter
+	config *config
+	// Names of the enums defined in the registry.
+	enumNames map[string]bool
+	// Names of the types used as map keys that are not comparable in Go (e.g. structs
+	// with slices). Such maps are indexed by the BCS serialization of keys (see `MapKey`).
+	mapKeyNames map[string]bool
+	// Names of the variants in the registry, indexed by enum and variant index.
+	variantNames map[string]map[uint32]string
+}
+
+// generate returns the Go definitions of the containers in `registry`.
+func generate(c *config, registry *serdetypes.Registry) []byte {
+	e := &emitter{
+		out:          newIndentedWriter(),
+		config:       c,
+		enumNames:    make(map[string]bool),
+		mapKeyNames:  make(map[string]bool),
+		variantNames: make(map[string]map[uint32]string),
+	}
+	for name, format := range registry.Containers {
+		if format.Kind == serdetypes.EnumKind {
+			e.enumNames[name] = true
+			e.variantNames[name] = make(map[uint32]string)
+			for index, variant := range format.Variants {
+				e.variantNames[name][index] = variant.Name
+			}
+		}
+	}
+	registry = renameRegistry(registry)
+	if c.serialization {
+		e.mapKeyNames = e.getMapKeyNames(registry)
+	}
+	names := sortedNames(registry)
+
+	e.outputPreamble(registry)
+	for _, name := range names {
+		e.outputContainer(name, registry.Containers[name])
+	}
+	e.outputHelpers(registry)
+	e.outputTypeRegistry(names)
+	return e.out.out.Bytes()
+}
+
+// Convert the names of fields and variants to CamelCase, as the Rust generator does when no
+// renamings are given.
+func renameRegistry(registry *serdetypes.Registry) *serdetypes.Registry {
+	renameFields := func(fields []serdetypes.Named[serdetypes.Format]) []serdetypes.Named[serdetypes.Format] {
+		result := make([]serdetypes.Named[serdetypes.Format], len(fields))
+		for i, field := range fields {
+			result[i] = serdetypes.Named[serdetypes.Format]{Name: toCamelCase(field.Name), Value: field.Value}
+		}
+		return result
+	}
+	result := &serdetypes.Registry{Containers: make(map[string]serdetypes.ContainerFormat)}
+	for name, format := range registry.Containers {
+		switch format.Kind {
+		case serdetypes.StructKind:
+			format.Fields = renameFields(format.Fields)
+		case serdetypes.EnumKind:
+			variants := make(map[uint32]serdetypes.Named[serdetypes.VariantFormat])
+			for index, variant := range format.Variants {
+				if variant.Value.Kind == serdetypes.StructVariantKind {
+					variant.Value.Fields = renameFields(variant.Value.Fields)
+				}
+				variants[index] = serdetypes.Named[serdetypes.VariantFormat]{Name: toCamelCase(variant.Name), Value: variant.Value}
+			}
+			format.Variants = variants
+		}
+		result.Containers[name] = format
+	}
+	return result
+}
+
+// Same conversion as `heck::CamelCase`: words are delimited by non-alphanumeric characters
+// and changes of case (e.g. "HTTPServer" or "f_u8"), then capitalized.
+func toCamelCase(name string) string {
+	var out strings.Builder
+	capitalize := func(word []rune) {
+		for i, c := range word {
+			if i == 0 {
+				out.WriteString(strings.ToUpper(string(c)))
+			} else {
+				out.WriteString(strings.ToLower(string(c)))
+			}
+		}
+	}
+	const (
+		boundary = iota
+		lowercase
+		uppercase
+	)
+	words := strings.FieldsFunc(name, func(c rune) bool { return !unicode.IsLetter(c) && !unicode.IsDigit(c) })
+	for _, word := range words {
+		chars := []rune(word)
+		init := 0
+		mode := boundary
+		for i, c := range chars {
+			if i+1 == len(chars) {
+				capitalize(chars[init:])
+				break
+			}
+			next := chars[i+1]
+			nextMode := mode
+			if unicode.IsLower(c) {
+				nextMode = lowercase
+			} else if unicode.IsUpper(c) {
+				nextMode = uppercase
+			}
+			if nextMode == lowercase && unicode.IsUpper(next) {
+				capitalize(chars[init : i+1])
+				init = i + 1
+				mode = boundary
+			} else if mode == uppercase && unicode.IsUpper(c) && unicode.IsLower(next) {
+				capitalize(chars[init:i])
+				init = i
+				mode = boundary
+			} else {
+				mode = nextMode
+			}
+		}
+	}
+	return out.String()
+}
+
+func sortedNames(registry *serdetypes.Registry) []string {
+	names := make([]string, 0, len(registry.Containers))
+	for name := range registry.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func encodingPrefix(encoding string) string {
+	return strings.ToUpper(encoding[:1]) + encoding[1:]
+}
+
+// Visit all the formats in `format` in a depth-first way.
+func visitFormat(format *serdetypes.Format, f func(*serdetypes.Format)) {
+	switch format.Kind {
+	case serdetypes.OptionKind, serdetypes.SeqKind, serdetypes.TupleArrayKind:
+		visitFormat(format.Content, f)
+	case serdetypes.MapKind:
+		visitFormat(format.Key, f)
+		visitFormat(format.Value, f)
+	case serdetypes.TupleKind:
+		for i := range format.Elements {
+			visitFormat(&format.Elements[i], f)
+		}
+	}
+	f(format)
+}
+
+func visitFields(fields []serdetypes.Named[serdetypes.Format], f func(*serdetypes.Format)) {
+	for i := range fields {
+		visitFormat(&fields[i].Value, f)
+	}
+}
+
+func visitFormats(formats []serdetypes.Format, f func(*serdetypes.Format)) {
+	for i := range formats {
+		visitFormat(&formats[i], f)
+	}
+}
+
+// Visit all the formats in the container `format`.
+func visitContainer(format serdetypes.ContainerFormat, f func(*serdetypes.Format)) {
+	switch format.Kind {
+	case serdetypes.NewTypeStructKind:
+		visitFormat(format.Content, f)
+	case serdetypes.TupleStructKind:
+		visitFormats(format.Elements, f)
+	case serdetypes.StructKind:
+		visitFields(format.Fields, f)
+	case serdetypes.EnumKind:
+		for _, variant := range format.Variants {
+			switch variant.Value.Kind {
+			case serdetypes.NewTypeVariantKind:
+				visitFormat(variant.Value.Content, f)
+			case serdetypes.TupleVariantKind:
+				visitFormats(variant.Value.Elements, f)
+			case serdetypes.StructVariantKind:
+				visitFields(variant.Value.Fields, f)
+			}
+		}
+	}
+}
+
+func registryHas(registry *serdetypes.Registry, predicate func(*serdetypes.Format) bool) bool {
+	result := false
+	for _, format := range registry.Containers {
+		visitContainer(format, func(f *serdetypes.Format) {
+			result = result || predicate(f)
+		})
+	}
+	return result
+}
+
+func isKind(format *serdetypes.Format, kind serdetypes.FormatKind) bool {
+	return format != nil && format.Kind == kind
+}
+
+// Whether a struct or a variant has an optional field, formatted with `serde.OptionFromPtr`.
+func hasOptionField(registry *serdetypes.Registry) bool {
+	isOption := func(formats []serdetypes.Format) bool {
+		for i := range formats {
+			if isKind(&formats[i], serdetypes.OptionKind) {
+				return true
+			}
+		}
+		return false
+	}
+	isOptionField := func(fields []serdetypes.Named[serdetypes.Format]) bool {
+		for i := range fields {
+			if isKind(&fields[i].Value, serdetypes.OptionKind) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, format := range registry.Containers {
+		switch format.Kind {
+		case serdetypes.NewTypeStructKind:
+			if isKind(format.Content, serdetypes.OptionKind) {
+				return true
+			}
+		case serdetypes.TupleStructKind:
+			if isOption(format.Elements) {
+				return true
+			}
+		case serdetypes.StructKind:
+			if isOptionField(format.Fields) {
+				return true
+			}
+		case serdetypes.EnumKind:
+			for _, variant := range format.Variants {
+				if isKind(variant.Value.Content, serdetypes.OptionKind) ||
+					isOption(variant.Value.Elements) ||
+					isOptionField(variant.Value.Fields) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (e *emitter) outputPreamble(registry *serdetypes.Registry) {
+	e.out.printf("package %s\n\n\n", e.config.packageName)
+	// Go does not support disabling warnings on unused imports.
+	if len(registry.Containers) == 0 {
+		return
+	}
+	e.outputImports(e.getImports(registry))
+}
+
+func (e *emitter) getImports(registry *serdetypes.Registry) []string {
+	var imports []string
+	if registryHas(registry, func(f *serdetypes.Format) bool { return f.Kind == serdetypes.BytesKind }) {
+		imports = append(imports, "bytes")
+	}
+	imports = append(imports, "fmt")
+	hasInt128 := registryHas(registry, func(f *serdetypes.Format) bool {
+		return f.Kind == serdetypes.I128Kind || f.Kind == serdetypes.U128Kind
+	})
+	if e.config.serialization || hasInt128 || hasOptionField(registry) {
+		imports = append(imports, e.config.serdeModulePath+"/serde")
+	}
+	if e.config.serialization {
+		for _, encoding := range e.config.encodings {
+			imports = append(imports, e.config.serdeModulePath+"/"+encoding)
+		}
+		if len(e.mapKeyNames) > 0 && !e.config.hasEncoding("bcs") {
+			imports = append(imports, e.config.serdeModulePath+"/bcs")
+		}
+	}
+	return imports
+}
+
+func (e *emitter) outputImports(imports []string) {
+	if len(imports) == 0 {
+		return
+	}
+	e.out.println("import (")
+	e.out.indent()
+	for _, path := range imports {
+		e.out.printf("%q\n", path)
+	}
+	e.out.unindent()
+	e.out.println(")\n")
+}
+
+func (e *emitter) outputHelpers(registry *serdetypes.Registry) {
+	subtypes := helperSubtypes(registry)
+	names := make([]string, 0, len(subtypes))
+	for name := range subtypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if e.config.serialization {
+		for _, name := range names {
+			e.outputSerializationHelper(name, subtypes[name])
+			e.outputDeserializationHelper(name, subtypes[name])
+		}
+	}
+	for _, name := range names {
+		e.outputEqualHelper(name, subtypes[name])
+	}
+}
+
+func (e *emitter) getMapKeyNames(registry *serdetypes.Registry) map[string]bool {
+	// Compute the names of all non-comparable types as a fixed point.
+	names := make(map[string]bool)
+	for name := range e.enumNames {
+		names[name] = true
+	}
+	for {
+		size := len(names)
+		for name, format := range registry.Containers {
+			if names[name] {
+				continue
+			}
+			visitContainer(format, func(f *serdetypes.Format) {
+				if !isComparable(f, names) {
+					names[name] = true
+				}
+			})
+		}
+		if len(names) == size {
+			break
+		}
+	}
+	result := make(map[string]bool)
+	registryHas(registry, func(f *serdetypes.Format) bool {
+		if f.Kind == serdetypes.MapKind && f.Key.Kind == serdetypes.TypeNameKind && names[f.Key.Name] {
+			result[f.Key.Name] = true
+		}
+		return false
+	})
+	return result
+}
+
+// Whether values of type `format` can be compared with `==` in Go, assuming that the
+// registry types `incomparableNames` cannot (tuples and arrays are checked elsewhere by
+// visiting their content). Interfaces are compared by address.
+func isComparable(format *serdetypes.Format, incomparableNames map[string]bool) bool {
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return !incomparableNames[format.Name]
+	case serdetypes.OptionKind, serdetypes.BytesKind, serdetypes.SeqKind, serdetypes.MapKind:
+		return false
+	}
+	return true
+}
+
+func (e *emitter) isMapKeyName(format *serdetypes.Format) (string, bool) {
+	if format.Kind == serdetypes.TypeNameKind && e.mapKeyNames[format.Name] {
+		return format.Name, true
+	}
+	return "", false
+}
+
+func (e *emitter) isEnum(format *serdetypes.Format) bool {
+	return format.Kind == serdetypes.TypeNameKind && e.enumNames[format.Name]
+}
+
+var primitiveNames = map[serdetypes.FormatKind]struct{ goType, mangled, method string }{
+	serdetypes.UnitKind:  {"struct {}", "unit", "Unit"},
+	serdetypes.BoolKind:  {"bool", "bool", "Bool"},
+	serdetypes.I8Kind:    {"int8", "i8", "I8"},
+	serdetypes.I16Kind:   {"int16", "i16", "I16"},
+	serdetypes.I32Kind:   {"int32", "i32", "I32"},
+	serdetypes.I64Kind:   {"int64", "i64", "I64"},
+	serdetypes.I128Kind:  {"serde.Int128", "i128", "I128"},
+	serdetypes.U8Kind:    {"uint8", "u8", "U8"},
+	serdetypes.U16Kind:   {"uint16", "u16", "U16"},
+	serdetypes.U32Kind:   {"uint32", "u32", "U32"},
+	serdetypes.U64Kind:   {"uint64", "u64", "U64"},
+	serdetypes.U128Kind:  {"serde.Uint128", "u128", "U128"},
+	serdetypes.F32Kind:   {"float32", "f32", "F32"},
+	serdetypes.F64Kind:   {"float64", "f64", "F64"},
+	serdetypes.CharKind:  {"rune", "char", "Char"},
+	serdetypes.StrKind:   {"string", "str", "Str"},
+	serdetypes.BytesKind: {"[]byte", "bytes", "Bytes"},
+}
+
+// Same naming scheme as `serde_generate::common::mangle_type`.
+func mangleType(format *serdetypes.Format) string {
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return format.Name
+	case serdetypes.OptionKind:
+		return "option_" + mangleType(format.Content)
+	case serdetypes.SeqKind:
+		return "vector_" + mangleType(format.Content)
+	case serdetypes.MapKind:
+		return fmt.Sprintf("map_%s_to_%s", mangleType(format.Key), mangleType(format.Value))
+	case serdetypes.TupleKind:
+		elements := make([]string, len(format.Elements))
+		for i := range format.Elements {
+			elements[i] = mangleType(&format.Elements[i])
+		}
+		return fmt.Sprintf("tuple%d_%s", len(format.Elements), strings.Join(elements, "_"))
+	case serdetypes.TupleArrayKind:
+		return fmt.Sprintf("array%d_%s_array", format.Size, mangleType(format.Content))
+	}
+	return primitiveNames[format.Kind].mangled
+}
+
+func (e *emitter) quoteType(format *serdetypes.Format) string {
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return format.Name
+	case serdetypes.OptionKind:
+		return "*" + e.quoteType(format.Content)
+	case serdetypes.SeqKind:
+		return "[]" + e.quoteType(format.Content)
+	case serdetypes.MapKind:
+		if _, ok := e.isMapKeyName(format.Key); ok {
+			return "map[string]" + e.quoteType(format.Value)
+		}
+		return fmt.Sprintf("map[%s]%s", e.quoteType(format.Key), e.quoteType(format.Value))
+	case serdetypes.TupleKind:
+		elements := make([]string, len(format.Elements))
+		for i := range format.Elements {
+			elements[i] = fmt.Sprintf("Field%d %s", i, e.quoteType(&format.Elements[i]))
+		}
+		return fmt.Sprintf("struct {%s}", strings.Join(elements, "; "))
+	case serdetypes.TupleArrayKind:
+		return fmt.Sprintf("[%d]%s", format.Size, e.quoteType(format.Content))
+	}
+	return primitiveNames[format.Kind].goType
+}
+
+func needsHelper(format *serdetypes.Format) bool {
+	switch format.Kind {
+	case serdetypes.OptionKind, serdetypes.SeqKind, serdetypes.MapKind, serdetypes.TupleKind, serdetypes.TupleArrayKind:
+		return true
+	}
+	return false
+}
+
+func helperSubtypes(registry *serdetypes.Registry) map[string]*serdetypes.Format {
+	subtypes := make(map[string]*serdetypes.Format)
+	registryHas(registry, func(f *serdetypes.Format) bool {
+		if needsHelper(f) {
+			subtypes[mangleType(f)] = f
+		}
+		return false
+	})
+	return subtypes
+}
+
+// Name of the constructor argument for the field `name`.
+func quoteArgumentName(name string) string {
+	// Go keywords as well as identifiers used in the body of constructors.
+	const reserved = "break case chan const continue default defer else fallthrough " +
+		"for func go goto if import interface map package range return select struct switch " +
+		"type var copy fmt len nil obj"
+	if name != "" {
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	for _, word := range strings.Fields(reserved) {
+		if word == name {
+			return name + "_"
+		}
+	}
+	return name
+}
+
+// Fixed-size byte arrays are passed as slices and checked for length. Enums are checked for nil.
+func (e *emitter) outputStructConstructor(name string, fields []serdetypes.Named[serdetypes.Format]) {
+	arguments := make([]string, len(fields))
+	for i, field := range fields {
+		tpe := e.quoteType(&field.Value)
+		if field.Value.Kind == serdetypes.TupleArrayKind && field.Value.Content.Kind == serdetypes.U8Kind {
+			tpe = "[]byte"
+		}
+		arguments[i] = fmt.Sprintf("%s %s", quoteArgumentName(field.Name), tpe)
+	}
+	e.out.printf("\n// New%[1]s creates a value of type %[1]s after checking the given fields.\nfunc New%[1]s(%[2]s) (*%[1]s, error) {\n", name, strings.Join(arguments, ", "))
+	e.out.indent()
+	e.out.printf("var obj %s\n", name)
+	for _, field := range fields {
+		argument := quoteArgumentName(field.Name)
+		if field.Value.Kind == serdetypes.TupleArrayKind && field.Value.Content.Kind == serdetypes.U8Kind {
+			e.out.printf("if len(%[1]s) != %[2]d { return nil, fmt.Errorf(\"Invalid length for %[3]s.%[4]s: expected %[2]d bytes but got %%d\", len(%[1]s)) }\ncopy(obj.%[4]s[:], %[1]s)\n", argument, field.Value.Size, name, field.Name)
+			continue
+		}
+		if e.isEnum(&field.Value) {
+			e.out.printf("if %s == nil { return nil, fmt.Errorf(\"Missing value for %s.%s\") }\n", argument, name, field.Name)
+		}
+		e.out.printf("obj.%s = %s\n", field.Name, argument)
+	}
+	e.out.println("return &obj, nil")
+	e.out.unindent()
+	e.out.println("}")
+}
+
+func quoteDisplayArg(value string, format *serdetypes.Format) (string, string) {
+	switch format.Kind {
+	case serdetypes.StrKind, serdetypes.CharKind:
+		return "%q", value
+	case serdetypes.OptionKind:
+		return "%v", fmt.Sprintf("serde.OptionFromPtr(%s)", value)
+	}
+	return "%v", value
+}
+
+// `String()` mimics the `Debug` output of Rust (e.g. `Enum::Variant{Field: 1}`) while
+// `GoString()` avoids printing enums as pointers.
+func (e *emitter) outputStructStringMethods(variantBase string, name string, fields []serdetypes.Named[serdetypes.Format], tuple bool) {
+	fullName, displayName, reference := name, name, ""
+	if variantBase != "" {
+		fullName = variantBase + "__" + name
+		displayName = variantBase + "::" + name
+		reference = "&"
+	}
+	var verbs, values, goVerbs, goValues []string
+	for _, field := range fields {
+		verb, value := quoteDisplayArg("obj."+field.Name, &field.Value)
+		if tuple {
+			verbs = append(verbs, verb)
+		} else {
+			verbs = append(verbs, fmt.Sprintf("%s: %s", field.Name, verb))
+		}
+		values = append(values, ", "+value)
+		goVerbs = append(goVerbs, field.Name+":%#v")
+		goValues = append(goValues, ", obj."+field.Name)
+	}
+	display := displayName
+	if len(fields) > 0 {
+		if tuple {
+			display = fmt.Sprintf("%s(%s)", displayName, strings.Join(verbs, ", "))
+		} else {
+			display = fmt.Sprintf("%s{%s}", displayName, strings.Join(verbs, ", "))
+		}
+	}
+	e.out.printf("\nfunc (obj %s) String() string {\n\treturn fmt.Sprintf(\"%s\"%s)\n}\n", fullName, display, strings.Join(values, ""))
+	e.out.printf("\nfunc (obj %[1]s) GoString() string {\n\treturn fmt.Sprintf(\"%[2]s%[3]s.%[1]s{%[4]s}\"%[5]s)\n}\n", fullName, reference, e.config.packageName, strings.Join(goVerbs, ", "), strings.Join(goValues, ""))
+}
+
+func (e *emitter) quoteEqualExpr(a, b string, format *serdetypes.Format) string {
+	switch {
+	case format.Kind == serdetypes.TypeNameKind:
+		return fmt.Sprintf("%s.Equal(%s)", a, b)
+	case format.Kind == serdetypes.BytesKind:
+		return fmt.Sprintf("bytes.Equal(%s, %s)", a, b)
+	case needsHelper(format):
+		return fmt.Sprintf("equal_%s(%s, %s)", mangleType(format), a, b)
+	}
+	return fmt.Sprintf("%s == %s", a, b)
+}
+
+func (e *emitter) quoteEqualFields(a, b string, fields []serdetypes.Named[serdetypes.Format]) string {
+	if len(fields) == 0 {
+		return "true"
+	}
+	exprs := make([]string, len(fields))
+	for i, field := range fields {
+		exprs[i] = e.quoteEqualExpr(a+"."+field.Name, b+"."+field.Name, &field.Value)
+	}
+	return strings.Join(exprs, " && ")
+}
+
+func tupleFields(formats []serdetypes.Format) []serdetypes.Named[serdetypes.Format] {
+	fields := make([]serdetypes.Named[serdetypes.Format], len(formats))
+	for i, format := range formats {
+		fields[i] = serdetypes.Named[serdetypes.Format]{Name: fmt.Sprintf("Field%d", i), Value: format}
+	}
+	return fields
+}
+
+func (e *emitter) outputEqualHelper(name string, format *serdetypes.Format) {
+	e.out.printf("func equal_%[1]s(a %[2]s, b %[2]s) bool {", name, e.quoteType(format))
+	e.out.indent()
+	switch format.Kind {
+	case serdetypes.OptionKind:
+		e.out.printf("\nif a == nil || b == nil { return a == b }\nreturn %s\n", e.quoteEqualExpr("(*a)", "(*b)", format.Content))
+	case serdetypes.SeqKind:
+		e.out.printf("\nif len(a) != len(b) { return false }\nfor i := range a {\n\tif !(%s) { return false }\n}\nreturn true\n", e.quoteEqualExpr("a[i]", "b[i]", format.Content))
+	case serdetypes.MapKind:
+		e.out.printf("\nif len(a) != len(b) { return false }\nfor key, va := range a {\n\tif vb, ok := b[key]; !ok || !(%s) { return false }\n}\nreturn true\n", e.quoteEqualExpr("va", "vb", format.Value))
+	case serdetypes.TupleKind:
+		e.out.printf("\nreturn %s\n", e.quoteEqualFields("a", "b", tupleFields(format.Elements)))
+	case serdetypes.TupleArrayKind:
+		e.out.printf("\nfor i := range a {\n\tif !(%s) { return false }\n}\nreturn true\n", e.quoteEqualExpr("a[i]", "b[i]", format.Content))
+	}
+	e.out.unindent()
+	e.out.println("}\n")
+}
+
+func (e *emitter) quoteSerializeValue(value string, format *serdetypes.Format) string {
+	return fmt.Sprintf("if err := %s; err != nil { return err }", e.quoteSerializeExpr(value, format))
+}
+
+func (e *emitter) quoteSerializeExpr(value string, format *serdetypes.Format) string {
+	switch {
+	case format.Kind == serdetypes.TypeNameKind:
+		return fmt.Sprintf("%s.Serialize(serializer)", value)
+	case needsHelper(format):
+		return fmt.Sprintf("serialize_%s(%s, serializer)", mangleType(format), value)
+	}
+	return fmt.Sprintf("serializer.Serialize%s(%s)", primitiveNames[format.Kind].method, value)
+}
+
+// Compute a function value of type `func(T, serde.Serializer) error` to serialize `format`.
+func (e *emitter) quoteSerializeFunction(format *serdetypes.Format) string {
+	if needsHelper(format) {
+		return "serialize_" + mangleType(format)
+	}
+	return fmt.Sprintf("func(item %s, serializer serde.Serializer) error { return %s }", e.quoteType(format), e.quoteSerializeExpr("item", format))
+}
+
+func (e *emitter) quoteDeserialize(format *serdetypes.Format, dest, fail string) string {
+	return fmt.Sprintf("if val, err := %s; err == nil { %s = val } else { return %s, err }", e.quoteDeserializeExpr(format), dest, fail)
+}
+
+// Same as `quoteDeserialize` but errors are wrapped with the name of the field (or index) being decoded.
+func (e *emitter) quoteDeserializeField(format *serdetypes.Format, dest, fail, segment string) string {
+	return fmt.Sprintf("if val, err := %s; err == nil { %s = val } else { return %s, serde.WrapDecodeError(err, deserializer, %s) }", e.quoteDeserializeExpr(format), dest, fail, segment)
+}
+
+func (e *emitter) quoteDeserializeExpr(format *serdetypes.Format) string {
+	switch {
+	case format.Kind == serdetypes.TypeNameKind:
+		return fmt.Sprintf("Deserialize%s(deserializer)", format.Name)
+	case needsHelper(format):
+		return fmt.Sprintf("deserialize_%s(deserializer)", mangleType(format))
+	}
+	return fmt.Sprintf("deserializer.Deserialize%s()", primitiveNames[format.Kind].method)
+}
+
+// Compute a function value of type `func(serde.Deserializer) (T, error)` to deserialize `format`.
+func (e *emitter) quoteDeserializeFunction(format *serdetypes.Format) string {
+	if needsHelper(format) {
+		return "deserialize_" + mangleType(format)
+	}
+	return fmt.Sprintf("func(deserializer serde.Deserializer) (%s, error) { return %s }", e.quoteType(format), e.quoteDeserializeExpr(format))
+}
+
+func (e *emitter) outputSerializationHelper(name string, format *serdetypes.Format) {
+	e.out.printf("func serialize_%s(value %s, serializer serde.Serializer) error {", name, e.quoteType(format))
+	e.out.indent()
+	switch format.Kind {
+	case serdetypes.OptionKind:
+		e.out.printf("\nreturn serde.SerializeOption(value, serializer, %s)\n", e.quoteSerializeFunction(format.Content))
+	case serdetypes.SeqKind:
+		if format.Content.Kind == serdetypes.BytesKind {
+			e.out.println("\nreturn serializer.SerializeVecBytes(value)")
+		} else {
+			e.out.printf("\nreturn serde.SerializeVector(value, serializer, %s)\n", e.quoteSerializeFunction(format.Content))
+		}
+	case serdetypes.MapKind:
+		serializeKey := e.quoteSerializeFunction(format.Key)
+		if name, ok := e.isMapKeyName(format.Key); ok {
+			serializeKey = fmt.Sprintf("func(key string, serializer serde.Serializer) error { obj, err := %sFromMapKey(key); if err != nil { return err }; return obj.Serialize(serializer) }", name)
+		}
+		e.out.printf("\nreturn serde.SerializeMap(value, serializer, %s, %s)\n", serializeKey, e.quoteSerializeFunction(format.Value))
+	case serdetypes.TupleKind:
+		e.out.write("\n")
+		for i := range format.Elements {
+			e.out.println(e.quoteSerializeValue(fmt.Sprintf("value.Field%d", i), &format.Elements[i]))
+		}
+		e.out.println("return nil")
+	case serdetypes.TupleArrayKind:
+		if format.Content.Kind == serdetypes.U8Kind {
+			e.out.println("\nreturn serializer.SerializeFixedBytes(value[:])")
+		} else {
+			e.out.printf("\nfor _, item := range(value) {\n\t%s\n}\n", e.quoteSerializeValue("item", format.Content))
+			e.out.println("return nil")
+		}
+	}
+	e.out.unindent()
+	e.out.println("}\n")
+}
+
+func (e *emitter) outputDeserializationHelper(name string, format *serdetypes.Format) {
+	e.out.printf("func deserialize_%s(deserializer serde.Deserializer) (%s, error) {", name, e.quoteType(format))
+	e.out.indent()
+	switch format.Kind {
+	case serdetypes.OptionKind:
+		e.out.printf("\nreturn serde.DeserializeOption(deserializer, %s)\n", e.quoteDeserializeFunction(format.Content))
+	case serdetypes.SeqKind:
+		if format.Content.Kind == serdetypes.BytesKind {
+			e.out.println("\nreturn deserializer.DeserializeVecBytes()")
+		} else {
+			e.out.printf("\nreturn serde.DeserializeVector(deserializer, %s)\n", e.quoteDeserializeFunction(format.Content))
+		}
+	case serdetypes.MapKind:
+		deserializeKey := e.quoteDeserializeFunction(format.Key)
+		if _, ok := e.isMapKeyName(format.Key); ok {
+			deserializeKey = fmt.Sprintf("func(deserializer serde.Deserializer) (string, error) { obj, err := %s; if err != nil { return \"\", err }; return obj.MapKey(), nil }", e.quoteDeserializeExpr(format.Key))
+		}
+		e.out.printf("\nreturn serde.DeserializeMap(deserializer, %s, %s)\n", deserializeKey, e.quoteDeserializeFunction(format.Value))
+	case serdetypes.TupleKind:
+		lines := make([]string, len(format.Elements))
+		for i := range format.Elements {
+			lines[i] = e.quoteDeserializeField(&format.Elements[i], fmt.Sprintf("obj.Field%d", i), "obj", fmt.Sprintf("\"Field%d\"", i))
+		}
+		e.out.printf("\nvar obj %s\n%s\nreturn obj, nil\n", e.quoteType(format), strings.Join(lines, "\n"))
+	case serdetypes.TupleArrayKind:
+		if format.Content.Kind == serdetypes.U8Kind {
+			e.out.printf("\nvar obj [%[1]d]uint8\nbytes, err := deserializer.DeserializeFixedBytes(%[1]d)\nif err != nil { return obj, err }\ncopy(obj[:], bytes)\nreturn obj, nil\n", format.Size)
+		} else {
+			e.out.printf("\nvar obj [%[2]d]%[1]s\nfor i := range(obj) {\n\tif val, err := %[3]s; err == nil { obj[i] = val } else { return obj, serde.WrapDecodeErrorIndex(err, deserializer, i) }\n}\nreturn obj, nil\n", e.quoteType(format.Content), format.Size, e.quoteDeserializeExpr(format.Content))
+		}
+	}
+	e.out.unindent()
+	e.out.println("}\n")
+}
+
+func (e *emitter) outputVariant(base string, index uint32, name string, variant serdetypes.VariantFormat) {
+	var fields []serdetypes.Named[serdetypes.Format]
+	switch variant.Kind {
+	case serdetypes.NewTypeVariantKind:
+		// We cannot define a "new type" (e.g. `type Foo Bar`) out of a typename `Bar` because `Bar`
+		// could point to a Go interface. Similarly, option types are compiled as pointers but
+		// `type Foo *Bar` would prevent `Foo` from being a valid pointer receiver.
+		switch variant.Content.Kind {
+		case serdetypes.TypeNameKind, serdetypes.OptionKind:
+			fields = []serdetypes.Named[serdetypes.Format]{{Name: "Value", Value: *variant.Content}}
+		default:
+			e.outputStructOrVariantNewTypeContainer(base, index, name, variant.Content)
+			return
+		}
+	case serdetypes.TupleVariantKind:
+		fields = tupleFields(variant.Elements)
+	case serdetypes.StructVariantKind:
+		fields = variant.Fields
+	}
+	e.outputStructOrVariantContainer(base, index, name, fields, variant.Kind != serdetypes.StructVariantKind)
+}
+
+// An empty `variantBase` designates a struct, otherwise the variant of the enum `variantBase`.
+func (e *emitter) outputStructOrVariantContainer(variantBase string, variantIndex uint32, name string, fields []serdetypes.Named[serdetypes.Format], tuple bool) {
+	fullName := name
+	if variantBase != "" {
+		fullName = variantBase + "__" + name
+	}
+	// Struct
+	e.out.write("\n")
+	e.out.printf("type %s struct {\n", fullName)
+	e.out.indent()
+	for _, field := range fields {
+		e.out.printf("%s %s\n", field.Name, e.quoteType(&field.Value))
+	}
+	e.out.unindent()
+	e.out.println("}")
+
+	// Link to base interface.
+	if variantBase != "" {
+		e.out.printf("\nfunc (*%s) is%s() {}\n", fullName, variantBase)
+		e.out.printf("\nfunc (*%s) VariantName() string {\n\treturn %q\n}\n", fullName, e.variantNames[variantBase][variantIndex])
+	}
+
+	// Constructor
+	if variantBase == "" && len(fields) > 0 {
+		e.outputStructConstructor(fullName, fields)
+	}
+
+	// Equal
+	if variantBase == "" {
+		e.out.printf("\nfunc (obj %[1]s) Equal(other %[1]s) bool {\n\treturn %[2]s\n}\n", fullName, e.quoteEqualFields("obj", "other", fields))
+	} else {
+		e.out.printf("\nfunc (obj %[1]s) Equal(other %[2]s) bool {\n\tvalue, ok := other.(*%[1]s)\n\tif !ok || value == nil { return false }\n\treturn %[3]s\n}\n", fullName, variantBase, e.quoteEqualFields("obj", "value", fields))
+	}
+
+	// String
+	e.outputStructStringMethods(variantBase, name, fields, tuple)
+
+	if !e.config.serialization {
+		return
+	}
+	// Serialize
+	e.out.printf("\nfunc (obj *%s) Serialize(serializer serde.Serializer) error {\n", fullName)
+	e.out.indent()
+	e.out.println("if err := serializer.IncreaseContainerDepth(); err != nil { return err }")
+	if variantBase != "" {
+		e.out.printf("serializer.SerializeVariantIndex(%d)\n", variantIndex)
+	}
+	for _, field := range fields {
+		e.out.println(e.quoteSerializeValue("obj."+field.Name, &field.Value))
+	}
+	e.out.println("serializer.DecreaseContainerDepth()")
+	e.out.println("return nil")
+	e.out.unindent()
+	e.out.println("}")
+	for _, encoding := range e.config.encodings {
+		e.outputStructSerializeForEncoding(fullName, encoding)
+	}
+	e.outputStructBinaryMarshaler(fullName)
+	e.outputStructMapKeyMethods(variantBase, fullName)
+
+	// Deserialize (struct) or Load (variant)
+	prefix := "Deserialize"
+	if variantBase != "" {
+		prefix = "load_"
+	}
+	e.out.printf("\nfunc %[1]s%[2]s(deserializer serde.Deserializer) (%[2]s, error) {\n", prefix, fullName)
+	e.out.indent()
+	e.out.printf("var obj %s\n", fullName)
+	e.out.println("if err := deserializer.IncreaseContainerDepth(); err != nil { return obj, err }")
+	for _, field := range fields {
+		e.out.println(e.quoteDeserializeField(&field.Value, "obj."+field.Name, "obj", fmt.Sprintf("%q", field.Name)))
+	}
+	e.out.println("deserializer.DecreaseContainerDepth()")
+	e.out.println("return obj, nil")
+	e.out.unindent()
+	e.out.println("}")
+	if variantBase == "" {
+		e.outputStructDeserializeMethods(fullName)
+	}
+}
+
+// Same as outputStructOrVariantContainer but we map the container with a single anonymous field
+// to a new type in Go.
+func (e *emitter) outputStructOrVariantNewTypeContainer(variantBase string, variantIndex uint32, name string, format *serdetypes.Format) {
+	fullName, displayName := name, name
+	if variantBase != "" {
+		fullName = variantBase + "__" + name
+		displayName = variantBase + "::" + name
+	}
+	tpe := e.quoteType(format)
+	// Struct
+	e.out.write("\n")
+	e.out.printf("type %s %s\n", fullName, tpe)
+
+	// Link to base interface.
+	if variantBase != "" {
+		e.out.printf("\nfunc (*%s) is%s() {}\n", fullName, variantBase)
+		e.out.printf("\nfunc (*%s) VariantName() string {\n\treturn %q\n}\n", fullName, e.variantNames[variantBase][variantIndex])
+	}
+
+	// Equal
+	if variantBase == "" {
+		e.out.printf("\nfunc (obj %[1]s) Equal(other %[1]s) bool {\n\treturn %[2]s\n}\n", fullName, e.quoteEqualExpr(fmt.Sprintf("((%s)(obj))", tpe), fmt.Sprintf("((%s)(other))", tpe), format))
+	} else {
+		e.out.printf("\nfunc (obj %[1]s) Equal(other %[2]s) bool {\n\tvalue, ok := other.(*%[1]s)\n\tif !ok || value == nil { return false }\n\treturn %[3]s\n}\n", fullName, variantBase, e.quoteEqualExpr(fmt.Sprintf("((%s)(obj))", tpe), fmt.Sprintf("((%s)(*value))", tpe), format))
+	}
+
+	// String
+	verb, value := quoteDisplayArg(fmt.Sprintf("((%s)(obj))", tpe), format)
+	e.out.printf("\nfunc (obj %s) String() string {\n\treturn fmt.Sprintf(\"%s(%s)\", %s)\n}\n", fullName, displayName, verb, value)
+	e.out.printf("\nfunc (obj %[1]s) GoString() string {\n\treturn fmt.Sprintf(\"%[2]s.%[1]s(%%#v)\", ((%[3]s)(obj)))\n}\n", fullName, e.config.packageName, tpe)
+
+	if !e.config.serialization {
+		return
+	}
+	// Serialize
+	e.out.printf("\nfunc (obj *%s) Serialize(serializer serde.Serializer) error {\n", fullName)
+	e.out.indent()
+	e.out.println("if err := serializer.IncreaseContainerDepth(); err != nil { return err }")
+	if variantBase != "" {
+		e.out.printf("serializer.SerializeVariantIndex(%d)\n", variantIndex)
+	}
+	e.out.println(e.quoteSerializeValue(fmt.Sprintf("((%s)(*obj))", tpe), format))
+	e.out.println("serializer.DecreaseContainerDepth()")
+	e.out.println("return nil")
+	e.out.unindent()
+	e.out.println("}")
+	for _, encoding := range e.config.encodings {
+		e.outputStructSerializeForEncoding(fullName, encoding)
+	}
+	e.outputStructBinaryMarshaler(fullName)
+	e.outputStructMapKeyMethods(variantBase, fullName)
+
+	// Deserialize (struct) or Load (variant)
+	prefix := "Deserialize"
+	if variantBase != "" {
+		prefix = "load_"
+	}
+	e.out.printf("\nfunc %[1]s%[2]s(deserializer serde.Deserializer) (%[2]s, error) {\n", prefix, fullName)
+	e.out.indent()
+	e.out.printf("var obj %s\n", tpe)
+	e.out.printf("if err := deserializer.IncreaseContainerDepth(); err != nil { return (%s)(obj), err }\n", fullName)
+	e.out.println(e.quoteDeserialize(format, "obj", fmt.Sprintf("((%s)(obj))", fullName)))
+	e.out.println("deserializer.DecreaseContainerDepth()")
+	e.out.printf("return (%s)(obj), nil\n", fullName)
+	e.out.unindent()
+	e.out.println("}")
+	if variantBase == "" {
+		e.outputStructDeserializeMethods(fullName)
+	}
+}
+
+// Implement `serde.Deserializable` on top of the function `Deserialize<name>`, followed by the
+// functions and methods decoding each encoding.
+func (e *emitter) outputStructDeserializeMethods(name string) {
+	e.out.printf("\nfunc (obj *%[1]s) Deserialize(deserializer serde.Deserializer) error {\n\tvalue, err := Deserialize%[1]s(deserializer)\n\tif err == nil { *obj = value }\n\treturn err\n}\n", name)
+	for _, encoding := range e.config.encodings {
+		e.outputStructDeserializeForEncoding(name, encoding)
+	}
+	if encoding, ok := e.config.binaryEncoding(); ok {
+		e.out.printf("\nfunc (obj *%[1]s) UnmarshalBinary(data []byte) error {\n\tvalue, err := %[2]sDeserialize%[1]s(data)\n\tif err == nil { *obj = value }\n\treturn err\n}\n", name, encodingPrefix(encoding))
+	}
+}
+
+func (e *emitter) outputStructSerializeForEncoding(name, encoding string) {
+	e.out.printf("\nfunc (obj *%[1]s) %[3]sSerialize() ([]byte, error) {\n\tif obj == nil {\n\t\treturn nil, &serde.TypeError{Type: \"%[1]s\", Err: serde.ErrNilValue}\n\t}\n\tserializer := %[2]s.NewSerializer();\n\tif err := obj.Serialize(serializer); err != nil { return nil, err }\n\t// Empty values (e.g. unit structs) are not returned as nil, which deserializers reject.\n\tif output := serializer.GetBytes(); output != nil { return output, nil }\n\treturn []byte{}, nil\n}\n", name, encoding, encodingPrefix(encoding))
+}
+
+func (e *emitter) outputStructBinaryMarshaler(name string) {
+	if encoding, ok := e.config.binaryEncoding(); ok {
+		e.out.printf("\nfunc (obj *%s) MarshalBinary() ([]byte, error) {\n\treturn obj.%sSerialize()\n}\n", name, encodingPrefix(encoding))
+		e.out.printf("\nfunc (obj *%[1]s) AppendBinary(b []byte) ([]byte, error) {\n\tif obj == nil {\n\t\treturn nil, &serde.TypeError{Type: \"%[1]s\", Err: serde.ErrNilValue}\n\t}\n\treturn %[2]s.Append(b, obj)\n}\n", name, encoding)
+	}
+}
+
+func (e *emitter) outputStructMapKeyMethods(variantBase, name string) {
+	base := variantBase
+	if base == "" {
+		base = name
+	}
+	if !e.mapKeyNames[base] {
+		return
+	}
+	e.out.printf("\n// MapKey returns the BCS serialization of the value, used to index maps.\nfunc (obj %s) MapKey() string {\n\treturn bcs.MapKey(&obj)\n}\n", name)
+	if variantBase == "" {
+		e.outputFromMapKeyFunction(name)
+	}
+}
+
+func (e *emitter) outputFromMapKeyFunction(name string) {
+	e.out.printf("\nfunc %[1]sFromMapKey(key string) (%[1]s, error) {\n\tdeserializer := bcs.NewDeserializer([]byte(key))\n\tobj, err := Deserialize%[1]s(deserializer)\n\tif err != nil { return obj, err }\n\treturn obj, deserializer.EndOfInput()\n}\n", name)
+}
+
+func (e *emitter) outputStructDeserializeForEncoding(name, encoding string) {
+	e.out.printf("\nfunc %[3]sDeserialize%[1]s(input []byte) (%[1]s, error) {\n\tif input == nil {\n\t\tvar obj %[1]s\n\t\treturn obj, &serde.TypeError{Type: \"%[1]s\", Err: serde.ErrNilValue}\n\t}\n\tdeserializer := %[2]s.NewDeserializer(input);\n\tobj, err := Deserialize%[1]s(deserializer)\n\tif err != nil { return obj, serde.WrapDecodeError(err, deserializer, \"%[1]s\") }\n\treturn obj, deserializer.EndOfInput()\n}\n", name, encoding, encodingPrefix(encoding))
+}
+
+func (e *emitter) outputTypeRegistry(names []string) {
+	if !e.config.typeRegistry || !e.config.serialization {
+		return
+	}
+	for _, encoding := range e.config.encodings {
+		prefix := encodingPrefix(encoding)
+		description := map[string]string{"bcs": "BCS", "bincode": "Bincode"}[encoding]
+		e.out.printf("\n// %[1]sDeserializers maps the name of each container to a function decoding its %[2]s serialization.\nvar %[1]sDeserializers = map[string]func([]byte) (interface{}, error){\n", prefix, description)
+		e.out.indent()
+		for _, name := range names {
+			e.out.printf("%q: func(input []byte) (interface{}, error) { return %sDeserialize%s(input) },\n", name, prefix, name)
+		}
+		e.out.unindent()
+		e.out.println("}")
+	}
+}
+
+func sortedVariantIndices(variants map[uint32]serdetypes.Named[serdetypes.VariantFormat]) []uint32 {
+	indices := make([]uint32, 0, len(variants))
+	for index := range variants {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	return indices
+}
+
+func (e *emitter) outputEnumContainer(name string, variants map[uint32]serdetypes.Named[serdetypes.VariantFormat]) {
+	indices := sortedVariantIndices(variants)
+	e.out.write("\n")
+	e.out.printf("type %s interface {\n", name)
+	e.out.indent()
+	e.out.printf("is%s()\n", name)
+	e.out.printf("Equal(other %s) bool\n", name)
+	e.out.println("VariantName() string")
+	if e.config.serialization {
+		e.out.println("Serialize(serializer serde.Serializer) error")
+		for _, encoding := range e.config.encodings {
+			e.out.printf("%sSerialize() ([]byte, error)\n", encodingPrefix(encoding))
+		}
+		if _, ok := e.config.binaryEncoding(); ok {
+			e.out.println("MarshalBinary() ([]byte, error)")
+			e.out.println("AppendBinary(b []byte) ([]byte, error)")
+		}
+		if e.mapKeyNames[name] {
+			e.out.println("MapKey() string")
+		}
+	}
+	e.out.unindent()
+	e.out.println("}")
+
+	if e.config.serialization {
+		e.out.printf("\nfunc Deserialize%[1]s(deserializer serde.Deserializer) (%[1]s, error) {", name)
+		e.out.indent()
+		e.out.println("\nindex, err := deserializer.DeserializeVariantIndex()\nif err != nil { return nil, err }\n\nswitch index {")
+		for _, index := range indices {
+			e.out.printf("case %[1]d:\n\tif val, err := load_%[2]s__%[3]s(deserializer); err == nil {\n\t\treturn &val, nil\n\t} else {\n\t\treturn nil, serde.WrapDecodeError(err, deserializer, \"%[3]s\")\n\t}\n\n", index, name, variants[index].Name)
+		}
+		e.out.printf("default:\n\treturn nil, serde.UnknownVariantIndex(\"%s\", index)\n", name)
+		e.out.println("}")
+		e.out.unindent()
+		e.out.println("}")
+
+		for _, encoding := range e.config.encodings {
+			e.outputStructDeserializeForEncoding(name, encoding)
+		}
+		if e.mapKeyNames[name] {
+			e.outputFromMapKeyFunction(name)
+		}
+	}
+
+	for _, index := range indices {
+		e.outputVariant(name, index, variants[index].Name, variants[index].Value)
+	}
+	e.outputEnumVisitor(name, indices, variants)
+	e.outputEnumVariantIndices(name, indices, variants)
+	e.outputEnumFromVariantName(name, indices, variants)
+}
+
+// Since `{name}Visitor` has one method per variant, adding a variant makes existing
+// visitors fail to compile.
+func (e *emitter) outputEnumVisitor(name string, indices []uint32, variants map[uint32]serdetypes.Named[serdetypes.VariantFormat]) {
+	e.out.printf("\n// %[1]sVisitor handles every variant of the enum %[1]s (see `Match%[1]s`).\ntype %[1]sVisitor[R any] interface {\n", name)
+	e.out.indent()
+	for _, index := range indices {
+		e.out.printf("Visit%[2]s(value *%[1]s__%[2]s) R\n", name, variants[index].Name)
+	}
+	e.out.unindent()
+	e.out.println("}")
+	e.out.printf("\n// Match%[1]s calls the method of `visitor` corresponding to the variant of `value`.\nfunc Match%[1]s[R any](value %[1]s, visitor %[1]sVisitor[R]) R {\n", name)
+	e.out.indent()
+	e.out.println("switch value := value.(type) {")
+	for _, index := range indices {
+		e.out.printf("case *%[1]s__%[2]s:\n\treturn visitor.Visit%[2]s(value)\n", name, variants[index].Name)
+	}
+	e.out.println("}")
+	e.out.printf("panic(\"Cannot match null object for %s\")\n", name)
+	e.out.unindent()
+	e.out.println("}")
+}
+
+func (e *emitter) outputEnumFromVariantName(name string, indices []uint32, variants map[uint32]serdetypes.Named[serdetypes.VariantFormat]) {
+	e.out.printf("\n// %[1]sFromVariantName returns a value of the variant of %[1]s with the given name, if any. The\n// content of the variant (if any) is the zero value.\nfunc %[1]sFromVariantName(name string) (%[1]s, bool) {\n", name)
+	e.out.indent()
+	e.out.println("switch name {")
+	for _, index := range indices {
+		e.out.printf("case %q:\n\treturn new(%s__%s), true\n", e.variantNames[name][index], name, variants[index].Name)
+	}
+	e.out.println("default:\n\treturn nil, false\n}")
+	e.out.unindent()
+	e.out.println("}")
+}
+
+// Values of C-style enums are already variant indices.
+func (e *emitter) outputEnumVariantIndices(name string, indices []uint32, variants map[uint32]serdetypes.Named[serdetypes.VariantFormat]) {
+	e.out.printf("\n// Variant indices of the enum %s, as serialized in binary encodings.\nconst (\n", name)
+	e.out.indent()
+	for _, index := range indices {
+		e.out.printf("%sVariant%sIndex uint32 = %d\n", name, variants[index].Name, index)
+	}
+	e.out.unindent()
+	e.out.println(")")
+	e.out.printf("\n// %[1]sVariantIndexName returns the name of the variant of %[1]s with the given index, if any.\nfunc %[1]sVariantIndexName(index uint32) (string, bool) {\n", name)
+	e.out.indent()
+	e.out.println("switch index {")
+	for _, index := range indices {
+		e.out.printf("case %sVariant%sIndex:\n\treturn %q, true\n", name, variants[index].Name, e.variantNames[name][index])
+	}
+	e.out.println("default:\n\treturn \"\", false\n}")
+	e.out.unindent()
+	e.out.println("}")
+}
+
+func (e *emitter) outputContainer(name string, format serdetypes.ContainerFormat) {
+	var fields []serdetypes.Named[serdetypes.Format]
+	switch format.Kind {
+	case serdetypes.NewTypeStructKind:
+		// See comment in `outputVariant`.
+		switch format.Content.Kind {
+		case serdetypes.TypeNameKind, serdetypes.OptionKind:
+			fields = []serdetypes.Named[serdetypes.Format]{{Name: "Value", Value: *format.Content}}
+		default:
+			e.outputStructOrVariantNewTypeContainer("", 0, name, format.Content)
+			return
+		}
+	case serdetypes.TupleStructKind:
+		fields = tupleFields(format.Elements)
+	case serdetypes.StructKind:
+		fields = format.Fields
+	case serdetypes.EnumKind:
+		e.outputEnumContainer(name, format.Variants)
+		return
+	}
+	e.outputStructOrVariantContainer("", 0, name, fields, format.Kind != serdetypes.StructKind)
+}
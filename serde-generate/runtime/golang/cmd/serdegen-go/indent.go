@@ -0,0 +1,56 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// indentedWriter prefixes every non-empty line with the current indentation (one tab per level,
+// as `go fmt` does).
+type indentedWriter struct {
+	out               bytes.Buffer
+	indentation       string
+	atBeginningOfLine bool
+}
+
+func newIndentedWriter() *indentedWriter {
+	return &indentedWriter{atBeginningOfLine: true}
+}
+
+func (w *indentedWriter) indent() {
+	w.indentation += "\t"
+}
+
+func (w *indentedWriter) unindent() {
+	w.indentation = strings.TrimSuffix(w.indentation, "\t")
+}
+
+func (w *indentedWriter) write(text string) {
+	for text != "" {
+		line, rest, hasNewline := strings.Cut(text, "\n")
+		if w.atBeginningOfLine && line != "" {
+			w.out.WriteString(w.indentation)
+			w.atBeginningOfLine = false
+		}
+		w.out.WriteString(line)
+		if hasNewline {
+			w.out.WriteByte('\n')
+			w.atBeginningOfLine = true
+		}
+		text = rest
+	}
+}
+
+func (w *indentedWriter) printf(format string, args ...interface{}) {
+	w.write(fmt.Sprintf(format, args...))
+}
+
+func (w *indentedWriter) println(text string) {
+	w.write(text + "\n")
+}
@@ -0,0 +1,143 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+// Command serdegen-go generates Go definitions from the Serde formats recorded by
+// serde-reflection (in YAML or JSON), without requiring the Rust toolchain.
+//
+// The generated code is the same as the output of `serdegen --language go` with the
+// corresponding options:
+//
+//	go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/serdegen-go \
+//		-with-runtimes bcs -module-name types/ledger -o ledger/lib.go ledger.yaml
+//
+// Without `-o`, the code is printed on the standard output. The runtimes are imported from
+// the module given by `-serde-module-path` rather than installed. Other options of `serdegen`
+// (e.g. `--go-renamings`) are not supported natively: with `-serdegen`, the command runs the
+// given `serdegen` executable instead, and forwards the arguments that follow the registry.
+//
+// The command is meant to be invoked by `go generate`, from a comment in the package that
+// holds the generated code:
+//
+//	//go:generate go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/serdegen-go -with-runtimes bcs -o lib.go ledger.yaml
+//	//go:generate go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/serdegen-go -serdegen serdegen -o lib.go ledger.yaml --go-renamings renamings.yaml
+//
+// Paths are relative to the directory of the package. The package name defaults to the name
+// of this package (given by `go generate` in `$GOPACKAGE`), and the output file is left
+// untouched when its content is up to date.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+func main() {
+	moduleName := flag.String("module-name", "", "Module name of the generated code, e.g. \"types/ledger\" (by default, the name of the input file)")
+	packageName := flag.String("package-name", "", "Name of the generated package (by default, the last component of the module name)")
+	serdeModulePath := flag.String("serde-module-path", defaultSerdeModulePath, "Module path where to find the Serde runtimes")
+	runtimes := flag.String("with-runtimes", "", "Comma-separated runtimes for which to generate specialized methods (serde, bincode, bcs)")
+	typeRegistry := flag.Bool("type-registry", false, "Generate maps from container names to deserializers, e.g. `BcsDeserializers`")
+	output := flag.String("o", "", "Path of the generated source file (otherwise print code on stdout)")
+	serdegen := flag.String("serdegen", "", "Path of a `serdegen` executable to run instead of the Go generator, with the arguments after the registry")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <registry.yaml> [serdegen options]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || (flag.NArg() > 1 && *serdegen == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+	input := flag.Arg(0)
+
+	encodings, err := parseRuntimes(*runtimes)
+	if err != nil {
+		fail(err)
+	}
+	if *moduleName == "" {
+		*moduleName = strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	}
+	if *packageName == "" {
+		// Set by `go generate`.
+		*packageName = os.Getenv("GOPACKAGE")
+	}
+	if *packageName == "" {
+		*packageName = (*moduleName)[strings.LastIndex(*moduleName, "/")+1:]
+	}
+	c := &config{
+		packageName:     *packageName,
+		serdeModulePath: *serdeModulePath,
+		serialization:   true,
+		encodings:       encodings,
+		typeRegistry:    *typeRegistry,
+	}
+	var code []byte
+	if *serdegen != "" {
+		code, err = runSerdegen(*serdegen, serdegenArgs(input, *moduleName, c, flag.Args()[1:]))
+	} else {
+		code, err = generateFile(input, c)
+	}
+	if err != nil {
+		fail(err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = writeIfChanged(*output, code)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func generateFile(input string, c *config) ([]byte, error) {
+	registry, err := serdetypes.ReadRegistry(input)
+	if err != nil {
+		return nil, err
+	}
+	return generate(c, registry), nil
+}
+
+// Leave the file at `path` untouched if it already contains `data`, so that up-to-date
+// files keep their modification time when `go generate` runs again.
+func writeIfChanged(path string, data []byte) error {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Return the encodings designated by the runtimes in `value`, sorted as in `knownEncodings`.
+func parseRuntimes(value string) ([]string, error) {
+	selected := make(map[string]bool)
+	for _, runtime := range strings.Split(value, ",") {
+		runtime = strings.ToLower(strings.TrimSpace(runtime))
+		switch runtime {
+		case "", "serde":
+		case "bincode", "bcs":
+			selected[runtime] = true
+		default:
+			return nil, fmt.Errorf("unknown runtime %q", runtime)
+		}
+	}
+	var encodings []string
+	for _, encoding := range knownEncodings {
+		if selected[encoding] {
+			encodings = append(encodings, encoding)
+		}
+	}
+	return encodings, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "serdegen-go:", err)
+	os.Exit(1)
+}
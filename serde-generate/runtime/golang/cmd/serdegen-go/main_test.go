@@ -0,0 +1,106 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "Update the expected output in testdata")
+
+// The expected output is the code generated by `serdegen --language go --with-runtimes bcs`
+// for the same registry: the Rust test `test_that_golang_code_matches_the_go_generator_golden_file`
+// (serde-generate/tests/golang_generation.rs) checks it against the same file. After `-update`,
+// run both tests.
+func TestGenerateMatchesRustGenerator(t *testing.T) {
+	registry, err := serdetypes.ReadRegistry("testdata/registry.yaml")
+	require.NoError(t, err)
+	c := &config{
+		packageName:     "main",
+		serdeModulePath: defaultSerdeModulePath,
+		serialization:   true,
+		encodings:       []string{"bcs"},
+	}
+	code := generate(c, registry)
+
+	const golden = "testdata/registry_bcs.go.golden"
+	if *update {
+		require.NoError(t, os.WriteFile(golden, code, 0o644))
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(code))
+}
+
+func TestToCamelCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"f_u128":     "FU128",
+		"type":       "Type",
+		"UnitStruct": "UnitStruct",
+		"CStyleEnum": "CStyleEnum",
+		"HTTPServer": "HttpServer",
+		"my-field 2": "MyField2",
+		"ID":         "Id",
+	} {
+		assert.Equal(t, expected, toCamelCase(name), name)
+	}
+}
+
+func TestParseRuntimes(t *testing.T) {
+	encodings, err := parseRuntimes("serde,BCS,bincode")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bincode", "bcs"}, encodings)
+	_, err = parseRuntimes("lcs")
+	assert.EqualError(t, err, `unknown runtime "lcs"`)
+}
+
+func TestSerdegenArgs(t *testing.T) {
+	c := &config{
+		packageName:     "ledger",
+		serdeModulePath: defaultSerdeModulePath,
+		serialization:   true,
+		encodings:       []string{"bincode", "bcs"},
+		typeRegistry:    true,
+	}
+	args := serdegenArgs("ledger.yaml", "types/ledger", c, []string{"--go-renamings", "renamings.yaml"})
+	assert.Equal(t, []string{
+		"ledger.yaml",
+		"--language", "go",
+		"--module-name", "types/ledger",
+		"--go-package-name", "ledger",
+		"--serde-package-name", defaultSerdeModulePath,
+		"--go-type-registry",
+		"--go-renamings", "renamings.yaml",
+		"--with-runtimes", "bincode",
+		"--with-runtimes", "bcs",
+	}, args)
+}
+
+func TestWriteIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lib.go")
+	require.NoError(t, writeIfChanged(path, []byte("package a\n")))
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(path, past, past))
+
+	// Identical content is not written again.
+	require.NoError(t, writeIfChanged(path, []byte("package a\n")))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(past))
+
+	require.NoError(t, writeIfChanged(path, []byte("package b\n")))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package b\n", string(data))
+}
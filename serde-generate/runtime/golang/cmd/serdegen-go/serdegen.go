@@ -0,0 +1,45 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Compute the arguments of `serdegen --language go` equivalent to the options of `c`,
+// followed by `extraArgs` (e.g. `--go-renamings renamings.yaml`).
+func serdegenArgs(input, moduleName string, c *config, extraArgs []string) []string {
+	args := []string{
+		input,
+		"--language", "go",
+		"--module-name", moduleName,
+		"--go-package-name", c.packageName,
+		"--serde-package-name", c.serdeModulePath,
+	}
+	if c.typeRegistry {
+		args = append(args, "--go-type-registry")
+	}
+	args = append(args, extraArgs...)
+	// `--with-runtimes` accepts several values: pass them last.
+	for _, encoding := range c.encodings {
+		args = append(args, "--with-runtimes", encoding)
+	}
+	return args
+}
+
+// Run the `serdegen` executable at `command` and return the code printed on its standard
+// output. Error messages of the executable are printed on the standard error.
+func runSerdegen(command string, args []string) ([]byte, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = os.Stderr
+	code, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", command, err)
+	}
+	return code, nil
+}
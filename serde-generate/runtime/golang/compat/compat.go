@@ -0,0 +1,442 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+// Package compat compares two versions of a registry, as recorded by serde-reflection, and
+// reports the changes between them together with their impact on the values serialized in BCS
+// and Bincode, e.g. to enforce a policy of schema evolution in release tooling:
+//
+//	report, err := compat.Check(before, after)
+//	...
+//	if report.Compatibility() == compat.Incompatible {
+//		...
+//	}
+//
+// Since BCS and Bincode do not serialize the names of containers, fields, and variants,
+// renaming them (or changing between equivalent kinds of structs, e.g. from `struct A(u8, u8)`
+// to `struct A { x: u8, y: u8 }`) is compatible. Adding a variant to an enum is backward
+// compatible: the new registry still decodes the values serialized with the old one, but not
+// conversely. Note that removing a field from a struct is incompatible, and so is appending a
+// field by default, since the values serialized with the old registry miss the field. If the
+// generated code declares default values for the appended fields and the values are decoded
+// with the option `AllowMissingTrailingFields` (see `serde.IsMissingTrailingField`), use
+// `CheckWithOptions` to report such fields as backward compatible.
+package compat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// Compatibility is the impact of a change on the serialized values.
+type Compatibility int
+
+const (
+	// Values are serialized in the same way.
+	Compatible Compatibility = iota
+	// Values serialized with the old registry are decoded by the new registry, but not
+	// conversely.
+	BackwardCompatible
+	// Values serialized with the old registry are not decoded (correctly) by the new registry.
+	Incompatible
+)
+
+var compatibilityNames = [...]string{"compatible", "backward compatible", "incompatible"}
+
+func (c Compatibility) String() string {
+	if c < 0 || int(c) >= len(compatibilityNames) {
+		return fmt.Sprintf("Compatibility(%d)", int(c))
+	}
+	return compatibilityNames[c]
+}
+
+// ChangeKind is the kind of a `Change`.
+type ChangeKind int
+
+const (
+	// A container was added.
+	ContainerAdded ChangeKind = iota
+	// A container was removed.
+	ContainerRemoved
+	// The kind of a container changed, e.g. from a tuple struct to a struct.
+	ContainerKindChanged
+	// A field (or an element of a tuple struct) was added.
+	FieldAdded
+	// A field (or an element of a tuple struct) was removed.
+	FieldRemoved
+	// A field was renamed.
+	FieldRenamed
+	// A variant was added to an enum.
+	VariantAdded
+	// A variant was removed from an enum.
+	VariantRemoved
+	// A variant was renamed.
+	VariantRenamed
+	// The format of a value changed, e.g. from `u32` to `u64`.
+	FormatChanged
+)
+
+var changeKindNames = [...]string{
+	"container added",
+	"container removed",
+	"container kind changed",
+	"field added",
+	"field removed",
+	"field renamed",
+	"variant added",
+	"variant removed",
+	"variant renamed",
+	"format changed",
+}
+
+func (k ChangeKind) String() string {
+	if k < 0 || int(k) >= len(changeKindNames) {
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+	return changeKindNames[k]
+}
+
+// Change is a difference between two registries.
+type Change struct {
+	Kind ChangeKind
+	// The path of the value that changed, e.g. `Transaction.payload[0]`.
+	Path          string
+	Compatibility Compatibility
+	// A description of the change, e.g. `u32 to u64`.
+	Detail string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", c.Path, c.Kind, c.Detail, c.Compatibility)
+}
+
+// Report is the list of changes between two registries.
+type Report struct {
+	Changes []Change
+}
+
+// Compatibility returns the least compatible of the changes, or `Compatible` if there is none.
+func (r *Report) Compatibility() Compatibility {
+	result := Compatible
+	for _, change := range r.Changes {
+		if change.Compatibility > result {
+			result = change.Compatibility
+		}
+	}
+	return result
+}
+
+// CheckOptions configures `CheckWithOptions`.
+type CheckOptions struct {
+	// Report the named fields appended to structs (and struct variants) as backward
+	// compatible, assuming that the generated code declares default values for them and
+	// that the values are decoded with the option `AllowMissingTrailingFields`. Since missing
+	// fields are only detected at the end of the input, this only holds for the structs that
+	// end the serialized values, such as top-level structs.
+	AllowMissingTrailingFields bool
+}
+
+// Check compares the containers of the registry `before` with the containers of the same
+// names in the registry `after`. If a container refers to another container under a new
+// name, they are compared as well. An error is returned if a container refers to a container
+// missing from its registry.
+func Check(before, after *serdetypes.Registry) (*Report, error) {
+	return CheckWithOptions(before, after, CheckOptions{})
+}
+
+// CheckWithOptions is the same as `Check` using the given options.
+func CheckWithOptions(before, after *serdetypes.Registry, options CheckOptions) (*Report, error) {
+	c := checker{before: before, after: after, options: options, visited: make(map[[2]string]bool)}
+	var removed, added []string
+	for name := range before.Containers {
+		if _, ok := after.Containers[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	for name := range after.Containers {
+		if _, ok := before.Containers[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	for _, name := range removed {
+		c.report(ContainerRemoved, name, Incompatible, name)
+	}
+	for _, name := range added {
+		c.report(ContainerAdded, name, Compatible, name)
+	}
+	for _, name := range sortedNames(before) {
+		if _, ok := after.Containers[name]; ok {
+			if err := c.containers(name, name, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Report{Changes: c.changes}, nil
+}
+
+func sortedNames(registry *serdetypes.Registry) []string {
+	names := make([]string, 0, len(registry.Containers))
+	for name := range registry.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type checker struct {
+	before, after *serdetypes.Registry
+	options       CheckOptions
+	// The pairs of containers already compared.
+	visited map[[2]string]bool
+	changes []Change
+}
+
+func (c *checker) report(kind ChangeKind, path string, compatibility Compatibility, detail string) {
+	c.changes = append(c.changes, Change{Kind: kind, Path: path, Compatibility: compatibility, Detail: detail})
+}
+
+func (c *checker) containers(path, beforeName, afterName string) error {
+	if c.visited[[2]string{beforeName, afterName}] {
+		return nil
+	}
+	c.visited[[2]string{beforeName, afterName}] = true
+	before, err := c.before.Lookup(beforeName)
+	if err != nil {
+		return err
+	}
+	after, err := c.after.Lookup(afterName)
+	if err != nil {
+		return err
+	}
+	if (before.Kind == serdetypes.EnumKind) != (after.Kind == serdetypes.EnumKind) {
+		c.report(ContainerKindChanged, path, Incompatible, fmt.Sprintf("%s to %s", containerKindNames[before.Kind], containerKindNames[after.Kind]))
+		return nil
+	}
+	if before.Kind != serdetypes.EnumKind {
+		if before.Kind != after.Kind {
+			c.report(ContainerKindChanged, path, Compatible, fmt.Sprintf("%s to %s", containerKindNames[before.Kind], containerKindNames[after.Kind]))
+		}
+		return c.members(path, containerMembers(&before), containerMembers(&after))
+	}
+	for _, index := range variantIndices(before.Variants, after.Variants) {
+		b, inBefore := before.Variants[index]
+		a, inAfter := after.Variants[index]
+		switch {
+		case !inAfter:
+			c.report(VariantRemoved, path, Incompatible, fmt.Sprintf("%s (variant %d)", b.Name, index))
+		case !inBefore:
+			c.report(VariantAdded, path, BackwardCompatible, fmt.Sprintf("%s (variant %d)", a.Name, index))
+		default:
+			if b.Name != a.Name {
+				c.report(VariantRenamed, path, Compatible, fmt.Sprintf("%s to %s (variant %d)", b.Name, a.Name, index))
+			}
+			if err := c.members(path+"."+a.Name, variantMembers(&b.Value), variantMembers(&a.Value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var containerKindNames = map[serdetypes.ContainerKind]string{
+	serdetypes.UnitStructKind:    "unit struct",
+	serdetypes.NewTypeStructKind: "newtype struct",
+	serdetypes.TupleStructKind:   "tuple struct",
+	serdetypes.StructKind:        "struct",
+	serdetypes.EnumKind:          "enum",
+}
+
+// The indices of the variants of both enums, in increasing order.
+func variantIndices(before, after map[uint32]serdetypes.Named[serdetypes.VariantFormat]) []uint32 {
+	var indices []uint32
+	for index := range before {
+		indices = append(indices, index)
+	}
+	for index := range after {
+		if _, ok := before[index]; !ok {
+			indices = append(indices, index)
+		}
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	return indices
+}
+
+// Structs (and variants) of all kinds are serialized as the sequence of their members, which
+// are unnamed in the case of newtype and tuple structs.
+func containerMembers(format *serdetypes.ContainerFormat) []serdetypes.Named[serdetypes.Format] {
+	switch format.Kind {
+	case serdetypes.NewTypeStructKind:
+		return []serdetypes.Named[serdetypes.Format]{{Value: *format.Content}}
+	case serdetypes.TupleStructKind:
+		return unnamed(format.Elements)
+	case serdetypes.StructKind:
+		return format.Fields
+	default:
+		return nil
+	}
+}
+
+func variantMembers(format *serdetypes.VariantFormat) []serdetypes.Named[serdetypes.Format] {
+	switch format.Kind {
+	case serdetypes.NewTypeVariantKind:
+		return []serdetypes.Named[serdetypes.Format]{{Value: *format.Content}}
+	case serdetypes.TupleVariantKind:
+		return unnamed(format.Elements)
+	case serdetypes.StructVariantKind:
+		return format.Fields
+	default:
+		return nil
+	}
+}
+
+func unnamed(formats []serdetypes.Format) []serdetypes.Named[serdetypes.Format] {
+	members := make([]serdetypes.Named[serdetypes.Format], 0, len(formats))
+	for _, format := range formats {
+		members = append(members, serdetypes.Named[serdetypes.Format]{Value: format})
+	}
+	return members
+}
+
+// The name of a member, or its index if it is unnamed.
+func memberName(member *serdetypes.Named[serdetypes.Format], index int) string {
+	if member.Name == "" {
+		return fmt.Sprintf("[%d]", index)
+	}
+	return member.Name
+}
+
+// Append a segment to a path, in the same way as `serde.WrapDecodeError`.
+func join(path, segment string) string {
+	if strings.HasPrefix(segment, "[") {
+		return path + segment
+	}
+	return path + "." + segment
+}
+
+func (c *checker) members(path string, before, after []serdetypes.Named[serdetypes.Format]) error {
+	for i := range after {
+		name := memberName(&after[i], i)
+		if i >= len(before) {
+			if c.options.AllowMissingTrailingFields && after[i].Name != "" {
+				c.report(FieldAdded, path, BackwardCompatible, name)
+			} else {
+				c.report(FieldAdded, path, Incompatible, name)
+			}
+			continue
+		}
+		if before[i].Name != "" && after[i].Name != "" && before[i].Name != after[i].Name {
+			c.report(FieldRenamed, path, Compatible, fmt.Sprintf("%s to %s", before[i].Name, after[i].Name))
+		}
+		if err := c.formats(join(path, name), &before[i].Value, &after[i].Value); err != nil {
+			return err
+		}
+	}
+	for i := len(after); i < len(before); i++ {
+		c.report(FieldRemoved, path, Incompatible, memberName(&before[i], i))
+	}
+	return nil
+}
+
+func isBytes(format *serdetypes.Format) bool {
+	return format.Kind == serdetypes.BytesKind || (format.Kind == serdetypes.SeqKind && format.Content.Kind == serdetypes.U8Kind)
+}
+
+func (c *checker) formats(path string, before, after *serdetypes.Format) error {
+	if isBytes(before) && isBytes(after) {
+		// Bytes are serialized as sequences of `u8`.
+		if before.Kind != after.Kind {
+			c.report(FormatChanged, path, Compatible, fmt.Sprintf("%s to %s", formatString(before), formatString(after)))
+		}
+		return nil
+	}
+	if before.Kind != after.Kind {
+		c.report(FormatChanged, path, Incompatible, fmt.Sprintf("%s to %s", formatString(before), formatString(after)))
+		return nil
+	}
+	switch before.Kind {
+	case serdetypes.TypeNameKind:
+		if before.Name == after.Name {
+			// Containers of the same names are compared separately.
+			return nil
+		}
+		c.report(FormatChanged, path, Compatible, fmt.Sprintf("%s to %s", before.Name, after.Name))
+		if err := c.containers(after.Name, before.Name, after.Name); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case serdetypes.OptionKind, serdetypes.SeqKind:
+		return c.formats(path, before.Content, after.Content)
+	case serdetypes.MapKind:
+		if err := c.formats(path+"[key]", before.Key, after.Key); err != nil {
+			return err
+		}
+		return c.formats(path+"[value]", before.Value, after.Value)
+	case serdetypes.TupleKind:
+		if len(before.Elements) != len(after.Elements) {
+			c.report(FormatChanged, path, Incompatible, fmt.Sprintf("%s to %s", formatString(before), formatString(after)))
+			return nil
+		}
+		for i := range before.Elements {
+			if err := c.formats(fmt.Sprintf("%s[%d]", path, i), &before.Elements[i], &after.Elements[i]); err != nil {
+				return err
+			}
+		}
+	case serdetypes.TupleArrayKind:
+		if before.Size != after.Size {
+			c.report(FormatChanged, path, Incompatible, fmt.Sprintf("%s to %s", formatString(before), formatString(after)))
+			return nil
+		}
+		return c.formats(path, before.Content, after.Content)
+	}
+	return nil
+}
+
+var primitiveNames = map[serdetypes.FormatKind]string{
+	serdetypes.UnitKind:  "()",
+	serdetypes.BoolKind:  "bool",
+	serdetypes.I8Kind:    "i8",
+	serdetypes.I16Kind:   "i16",
+	serdetypes.I32Kind:   "i32",
+	serdetypes.I64Kind:   "i64",
+	serdetypes.I128Kind:  "i128",
+	serdetypes.U8Kind:    "u8",
+	serdetypes.U16Kind:   "u16",
+	serdetypes.U32Kind:   "u32",
+	serdetypes.U64Kind:   "u64",
+	serdetypes.U128Kind:  "u128",
+	serdetypes.F32Kind:   "f32",
+	serdetypes.F64Kind:   "f64",
+	serdetypes.CharKind:  "char",
+	serdetypes.StrKind:   "String",
+	serdetypes.BytesKind: "Bytes",
+}
+
+// Render a format in Rust syntax, e.g. `Option<Vec<u8>>`.
+func formatString(format *serdetypes.Format) string {
+	if name, ok := primitiveNames[format.Kind]; ok {
+		return name
+	}
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return format.Name
+	case serdetypes.OptionKind:
+		return fmt.Sprintf("Option<%s>", formatString(format.Content))
+	case serdetypes.SeqKind:
+		return fmt.Sprintf("Vec<%s>", formatString(format.Content))
+	case serdetypes.MapKind:
+		return fmt.Sprintf("Map<%s, %s>", formatString(format.Key), formatString(format.Value))
+	case serdetypes.TupleKind:
+		elements := make([]string, 0, len(format.Elements))
+		for i := range format.Elements {
+			elements = append(elements, formatString(&format.Elements[i]))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(elements, ", "))
+	case serdetypes.TupleArrayKind:
+		return fmt.Sprintf("[%s; %d]", formatString(format.Content), format.Size)
+	default:
+		return fmt.Sprintf("FormatKind(%d)", int(format.Kind))
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package compat_test
+
+import (
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/compat"
+	st "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// struct Test { a: Vec<u8>, b: Option<Choice>, c: (bool, u32), d: Point }
+// enum Choice { A, B(u16), C { x: Vec<u8> } }
+// struct Point(i32, i32)
+// struct Unused
+func beforeRegistry() *st.Registry {
+	u16 := st.Primitive(st.U16Kind)
+	return &st.Registry{Containers: map[string]st.ContainerFormat{
+		"Test": {Kind: st.StructKind, Fields: []st.Named[st.Format]{
+			{Name: "a", Value: st.Seq(st.Primitive(st.U8Kind))},
+			{Name: "b", Value: st.Option(st.TypeName("Choice"))},
+			{Name: "c", Value: st.Tuple(st.Primitive(st.BoolKind), st.Primitive(st.U32Kind))},
+			{Name: "d", Value: st.TypeName("Point")},
+		}},
+		"Choice": {Kind: st.EnumKind, Variants: map[uint32]st.Named[st.VariantFormat]{
+			0: {Name: "A", Value: st.VariantFormat{Kind: st.UnitVariantKind}},
+			1: {Name: "B", Value: st.VariantFormat{Kind: st.NewTypeVariantKind, Content: &u16}},
+			2: {Name: "C", Value: st.VariantFormat{Kind: st.StructVariantKind, Fields: []st.Named[st.Format]{
+				{Name: "x", Value: st.Seq(st.Primitive(st.U8Kind))},
+			}}},
+		}},
+		"Point":  {Kind: st.TupleStructKind, Elements: []st.Format{st.Primitive(st.I32Kind), st.Primitive(st.I32Kind)}},
+		"Unused": {Kind: st.UnitStructKind},
+	}}
+}
+
+func TestCheckIdentical(t *testing.T) {
+	report, err := compat.Check(beforeRegistry(), beforeRegistry())
+	require.NoError(t, err)
+	assert.Empty(t, report.Changes)
+	assert.Equal(t, compat.Compatible, report.Compatibility())
+}
+
+func TestCheckCompatible(t *testing.T) {
+	after := beforeRegistry()
+	test := after.Containers["Test"]
+	// Bytes are serialized as `Vec<u8>`.
+	test.Fields[0] = st.Named[st.Format]{Name: "data", Value: st.Primitive(st.BytesKind)}
+	test.Fields[3].Value = st.TypeName("Position")
+	after.Containers["Test"] = test
+	after.Containers["Position"] = st.ContainerFormat{Kind: st.StructKind, Fields: []st.Named[st.Format]{
+		{Name: "x", Value: st.Primitive(st.I32Kind)},
+		{Name: "y", Value: st.Primitive(st.I32Kind)},
+	}}
+	choice := after.Containers["Choice"]
+	choice.Variants[3] = st.Named[st.VariantFormat]{Name: "D", Value: st.VariantFormat{Kind: st.UnitVariantKind}}
+	after.Containers["Choice"] = choice
+
+	report, err := compat.Check(beforeRegistry(), after)
+	require.NoError(t, err)
+	var changes []string
+	for _, change := range report.Changes {
+		changes = append(changes, change.String())
+	}
+	assert.Equal(t, []string{
+		"Position: container added: Position (compatible)",
+		"Choice: variant added: D (variant 3) (backward compatible)",
+		"Test: field renamed: a to data (compatible)",
+		"Test.data: format changed: Vec<u8> to Bytes (compatible)",
+		"Test.d: format changed: Point to Position (compatible)",
+		"Position: container kind changed: tuple struct to struct (compatible)",
+	}, changes)
+	assert.Equal(t, compat.BackwardCompatible, report.Compatibility())
+}
+
+func TestCheckIncompatible(t *testing.T) {
+	after := beforeRegistry()
+	delete(after.Containers, "Unused")
+	test := after.Containers["Test"]
+	test.Fields[2].Value = st.Tuple(st.Primitive(st.BoolKind), st.Primitive(st.U64Kind))
+	test.Fields = append(test.Fields, st.Named[st.Format]{Name: "e", Value: st.Option(st.Primitive(st.StrKind))})
+	after.Containers["Test"] = test
+	choice := after.Containers["Choice"]
+	delete(choice.Variants, 1)
+	choice.Variants[2] = st.Named[st.VariantFormat]{Name: "C", Value: st.VariantFormat{Kind: st.UnitVariantKind}}
+	after.Containers["Choice"] = choice
+	after.Containers["Point"] = st.ContainerFormat{Kind: st.EnumKind, Variants: map[uint32]st.Named[st.VariantFormat]{}}
+
+	report, err := compat.Check(beforeRegistry(), after)
+	require.NoError(t, err)
+	assert.Equal(t, []compat.Change{
+		{Kind: compat.ContainerRemoved, Path: "Unused", Compatibility: compat.Incompatible, Detail: "Unused"},
+		{Kind: compat.VariantRemoved, Path: "Choice", Compatibility: compat.Incompatible, Detail: "B (variant 1)"},
+		{Kind: compat.FieldRemoved, Path: "Choice.C", Compatibility: compat.Incompatible, Detail: "x"},
+		{Kind: compat.ContainerKindChanged, Path: "Point", Compatibility: compat.Incompatible, Detail: "tuple struct to enum"},
+		{Kind: compat.FormatChanged, Path: "Test.c[1]", Compatibility: compat.Incompatible, Detail: "u32 to u64"},
+		{Kind: compat.FieldAdded, Path: "Test", Compatibility: compat.Incompatible, Detail: "e"},
+	}, report.Changes)
+	assert.Equal(t, compat.Incompatible, report.Compatibility())
+}
+
+func TestCheckAllowMissingTrailingFields(t *testing.T) {
+	after := beforeRegistry()
+	test := after.Containers["Test"]
+	test.Fields = append(test.Fields, st.Named[st.Format]{Name: "e", Value: st.Option(st.Primitive(st.StrKind))})
+	after.Containers["Test"] = test
+	after.Containers["Point"] = st.ContainerFormat{Kind: st.TupleStructKind, Elements: []st.Format{
+		st.Primitive(st.I32Kind), st.Primitive(st.I32Kind), st.Primitive(st.I32Kind),
+	}}
+	choice := after.Containers["Choice"]
+	c := choice.Variants[2]
+	c.Value.Fields = append(c.Value.Fields, st.Named[st.Format]{Name: "y", Value: st.Primitive(st.BoolKind)})
+	choice.Variants[2] = c
+	after.Containers["Choice"] = choice
+
+	report, err := compat.CheckWithOptions(beforeRegistry(), after, compat.CheckOptions{AllowMissingTrailingFields: true})
+	require.NoError(t, err)
+	assert.Equal(t, []compat.Change{
+		{Kind: compat.FieldAdded, Path: "Choice.C", Compatibility: compat.BackwardCompatible, Detail: "y"},
+		// Elements of tuple structs have no default values.
+		{Kind: compat.FieldAdded, Path: "Point", Compatibility: compat.Incompatible, Detail: "[2]"},
+		{Kind: compat.FieldAdded, Path: "Test", Compatibility: compat.BackwardCompatible, Detail: "e"},
+	}, report.Changes)
+	assert.Equal(t, compat.Incompatible, report.Compatibility())
+
+	// Without the option, appended fields are incompatible.
+	report, err = compat.Check(beforeRegistry(), after)
+	require.NoError(t, err)
+	for _, change := range report.Changes {
+		assert.Equal(t, compat.Incompatible, change.Compatibility)
+	}
+
+	// Removed fields remain incompatible.
+	report, err = compat.CheckWithOptions(after, beforeRegistry(), compat.CheckOptions{AllowMissingTrailingFields: true})
+	require.NoError(t, err)
+	assert.Equal(t, compat.Incompatible, report.Compatibility())
+	for _, change := range report.Changes {
+		assert.Equal(t, compat.FieldRemoved, change.Kind)
+	}
+}
+
+func TestCheckUnknownContainer(t *testing.T) {
+	after := beforeRegistry()
+	test := after.Containers["Test"]
+	test.Fields[3].Value = st.TypeName("Missing")
+	after.Containers["Test"] = test
+	_, err := compat.Check(beforeRegistry(), after)
+	assert.EqualError(t, err, `Test.d: unknown container "Missing"`)
+}
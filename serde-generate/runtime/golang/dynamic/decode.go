@@ -0,0 +1,333 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package dynamic
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// DecodeBcs decodes `input` as the BCS encoding of a value of the container `typeName`.
+// As with the generated `BcsDeserialize<Name>` functions, all of the input must be read.
+func DecodeBcs(input []byte, registry *serdetypes.Registry, typeName string) (Value, error) {
+	return decodeAll(bcs.NewDeserializer(input), registry, typeName)
+}
+
+// DecodeBincode decodes `input` as the Bincode encoding of a value of the container `typeName`.
+// As with the generated `BincodeDeserialize<Name>` functions, all of the input must be read.
+func DecodeBincode(input []byte, registry *serdetypes.Registry, typeName string) (Value, error) {
+	return decodeAll(bincode.NewDeserializer(input), registry, typeName)
+}
+
+func decodeAll(deserializer serde.Deserializer, registry *serdetypes.Registry, typeName string) (Value, error) {
+	value, err := Decode(deserializer, registry, typeName)
+	if err != nil {
+		return value, err
+	}
+	return value, deserializer.EndOfInput()
+}
+
+// Decode reads a value of the container `typeName` with `deserializer`. Errors are
+// `serde.DecodeError`s whose path starts with `typeName` and uses the names of the registry,
+// e.g. `Transaction.payload.args[3]`. To bound the memory used for untrusted inputs, sequences
+// and maps of values serialized as zero bytes (e.g. `Vec<()>`) may not have more elements
+// than the number of remaining input bytes.
+func Decode(deserializer serde.Deserializer, registry *serdetypes.Registry, typeName string) (Value, error) {
+	d := decoder{registry: registry, deserializer: deserializer}
+	start := deserializer.GetBufferOffset()
+	value, err := d.container(typeName)
+	if err != nil {
+		return value, serde.WrapDecodeError(err, deserializer, typeName)
+	}
+	value.Start, value.End = start, deserializer.GetBufferOffset()
+	return value, nil
+}
+
+// DecodeFormat reads a value of the given format with `deserializer`. Containers are looked
+// up in `registry`.
+func DecodeFormat(deserializer serde.Deserializer, registry *serdetypes.Registry, format *serdetypes.Format) (Value, error) {
+	d := decoder{registry: registry, deserializer: deserializer}
+	return d.format(format)
+}
+
+type decoder struct {
+	registry     *serdetypes.Registry
+	deserializer serde.Deserializer
+}
+
+// Read a primitive value with `deserialize`.
+func primitive[T any](kind serdetypes.FormatKind, deserialize func() (T, error)) (Value, error) {
+	value, err := deserialize()
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{Kind: PrimitiveValue, Format: kind, Primitive: value}, nil
+}
+
+func (d *decoder) format(format *serdetypes.Format) (Value, error) {
+	start := d.deserializer.GetBufferOffset()
+	value, err := d.formatContent(format)
+	if err != nil {
+		return value, err
+	}
+	value.Start, value.End = start, d.deserializer.GetBufferOffset()
+	return value, nil
+}
+
+func (d *decoder) formatContent(format *serdetypes.Format) (Value, error) {
+	de := d.deserializer
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return d.container(format.Name)
+	case serdetypes.UnitKind:
+		if _, err := de.DeserializeUnit(); err != nil {
+			return Value{}, err
+		}
+		return Value{Kind: PrimitiveValue, Format: serdetypes.UnitKind}, nil
+	case serdetypes.BoolKind:
+		return primitive(format.Kind, de.DeserializeBool)
+	case serdetypes.I8Kind:
+		return primitive(format.Kind, de.DeserializeI8)
+	case serdetypes.I16Kind:
+		return primitive(format.Kind, de.DeserializeI16)
+	case serdetypes.I32Kind:
+		return primitive(format.Kind, de.DeserializeI32)
+	case serdetypes.I64Kind:
+		return primitive(format.Kind, de.DeserializeI64)
+	case serdetypes.I128Kind:
+		return primitive(format.Kind, de.DeserializeI128)
+	case serdetypes.U8Kind:
+		return primitive(format.Kind, de.DeserializeU8)
+	case serdetypes.U16Kind:
+		return primitive(format.Kind, de.DeserializeU16)
+	case serdetypes.U32Kind:
+		return primitive(format.Kind, de.DeserializeU32)
+	case serdetypes.U64Kind:
+		return primitive(format.Kind, de.DeserializeU64)
+	case serdetypes.U128Kind:
+		return primitive(format.Kind, de.DeserializeU128)
+	case serdetypes.F32Kind:
+		return primitive(format.Kind, de.DeserializeF32)
+	case serdetypes.F64Kind:
+		return primitive(format.Kind, de.DeserializeF64)
+	case serdetypes.CharKind:
+		return primitive(format.Kind, de.DeserializeChar)
+	case serdetypes.StrKind:
+		return primitive(format.Kind, de.DeserializeStr)
+	case serdetypes.BytesKind:
+		return primitive(format.Kind, de.DeserializeBytes)
+
+	case serdetypes.OptionKind:
+		content, err := serde.DeserializeOption(de, func(serde.Deserializer) (Value, error) {
+			return d.format(format.Content)
+		})
+		if err != nil {
+			return Value{}, err
+		}
+		value := Value{Kind: OptionValue}
+		if content != nil {
+			value.Elements = []Value{*content}
+		}
+		return value, nil
+
+	case serdetypes.SeqKind:
+		length, err := de.DeserializeLen()
+		if err != nil {
+			return Value{}, err
+		}
+		if err := d.checkLength(length, format.Content); err != nil {
+			return Value{}, err
+		}
+		// As in `serde.DeserializeVector`, elements are appended as they are read, so that
+		// the allocated memory is proportional to the input.
+		var elements []Value
+		for i := 0; uint64(i) < length; i++ {
+			element, err := d.format(format.Content)
+			if err != nil {
+				return Value{}, serde.WrapDecodeErrorIndex(err, de, i)
+			}
+			elements = append(elements, element)
+		}
+		return Value{Kind: SeqValue, Elements: elements}, nil
+
+	case serdetypes.MapKind:
+		return d.mapValue(format)
+
+	case serdetypes.TupleKind:
+		elements, err := d.formats(format.Elements)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Kind: TupleValue, Elements: elements}, nil
+
+	case serdetypes.TupleArrayKind:
+		var elements []Value
+		for i := 0; uint64(i) < format.Size; i++ {
+			element, err := d.format(format.Content)
+			if err != nil {
+				return Value{}, serde.WrapDecodeErrorIndex(err, de, i)
+			}
+			elements = append(elements, element)
+		}
+		return Value{Kind: SeqValue, Elements: elements}, nil
+
+	default:
+		return Value{}, fmt.Errorf("unexpected format kind: %d", format.Kind)
+	}
+}
+
+// Same as `serde.DeserializeMap`, keeping the entries in a slice since values are not comparable.
+func (d *decoder) mapValue(format *serdetypes.Format) (Value, error) {
+	de := d.deserializer
+	length, err := de.DeserializeMapLen()
+	if err != nil {
+		return Value{}, err
+	}
+	if err := d.checkLength(length, format.Key, format.Value); err != nil {
+		return Value{}, err
+	}
+	var entries []Entry
+	serde.BeginReadingMap(de)
+	var previousSlice serde.Slice
+	for i := 0; uint64(i) < length; i++ {
+		var slice serde.Slice
+		slice.Start = de.GetBufferOffset()
+		key, err := d.format(format.Key)
+		if err != nil {
+			return Value{}, serde.WrapDecodeErrorIndex(err, de, i)
+		}
+		slice.End = de.GetBufferOffset()
+		if i > 0 {
+			if err := de.CheckThatKeySlicesAreIncreasing(previousSlice, slice); err != nil {
+				return Value{}, err
+			}
+		}
+		previousSlice = slice
+		value, err := d.format(format.Value)
+		if err != nil {
+			return Value{}, serde.WrapDecodeErrorIndex(err, de, i)
+		}
+		entries = append(entries, Entry{Key: key, Value: value})
+	}
+	serde.EndReadingMap(de)
+	return Value{Kind: MapValue, Entries: entries}, nil
+}
+
+// Reading a sequence of values with an empty encoding (see `serdetypes.HasEmptyEncoding`)
+// does not consume the input: reject lengths larger than the remaining input, as if each
+// element took at least one byte. This rejects some valid inputs (e.g. `vec![(); 2]` at the
+// end of the input) but such sequences hardly occur in practice.
+func (d *decoder) checkLength(length uint64, formats ...*serdetypes.Format) error {
+	for _, format := range formats {
+		if !d.registry.HasEmptyEncoding(format) {
+			return nil
+		}
+	}
+	start := d.deserializer.GetBufferOffset()
+	// `Slice` fails if the input ends before `start + length`.
+	if length <= math.MaxUint64-start {
+		if _, err := d.deserializer.Slice(start, start+length); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d elements of empty values exceed the remaining input", serde.ErrInputTooShort, length)
+}
+
+func (d *decoder) formats(formats []serdetypes.Format) ([]Value, error) {
+	values := make([]Value, 0, len(formats))
+	for i := range formats {
+		value, err := d.format(&formats[i])
+		if err != nil {
+			return nil, serde.WrapDecodeErrorIndex(err, d.deserializer, i)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (d *decoder) fields(fields []serdetypes.Named[serdetypes.Format]) ([]serdetypes.Named[Value], error) {
+	values := make([]serdetypes.Named[Value], 0, len(fields))
+	for i := range fields {
+		value, err := d.format(&fields[i].Value)
+		if err != nil {
+			return nil, serde.WrapDecodeError(err, d.deserializer, fields[i].Name)
+		}
+		values = append(values, serdetypes.Named[Value]{Name: fields[i].Name, Value: value})
+	}
+	return values, nil
+}
+
+func (d *decoder) container(name string) (Value, error) {
+	format, err := d.registry.Lookup(name)
+	if err != nil {
+		return Value{}, err
+	}
+	if err := d.deserializer.IncreaseContainerDepth(); err != nil {
+		return Value{}, err
+	}
+	value, err := d.containerContent(name, &format)
+	if err != nil {
+		return Value{}, err
+	}
+	d.deserializer.DecreaseContainerDepth()
+	return value, nil
+}
+
+func (d *decoder) containerContent(name string, format *serdetypes.ContainerFormat) (Value, error) {
+	value := Value{Kind: StructValue, TypeName: name}
+	var err error
+	switch format.Kind {
+	case serdetypes.UnitStructKind:
+	case serdetypes.NewTypeStructKind:
+		var content Value
+		content, err = d.format(format.Content)
+		value.Elements = []Value{content}
+	case serdetypes.TupleStructKind:
+		value.Elements, err = d.formats(format.Elements)
+	case serdetypes.StructKind:
+		value.Fields, err = d.fields(format.Fields)
+	case serdetypes.EnumKind:
+		return d.variant(name, format)
+	default:
+		return Value{}, fmt.Errorf("unexpected container kind: %d", format.Kind)
+	}
+	if err != nil {
+		return Value{}, err
+	}
+	return value, nil
+}
+
+func (d *decoder) variant(name string, format *serdetypes.ContainerFormat) (Value, error) {
+	index, err := d.deserializer.DeserializeVariantIndex()
+	if err != nil {
+		return Value{}, err
+	}
+	variant, ok := format.Variants[index]
+	if !ok {
+		return Value{}, serde.UnknownVariantIndex(name, index)
+	}
+	value := Value{Kind: VariantValue, TypeName: name, VariantIndex: index, VariantName: variant.Name}
+	switch variant.Value.Kind {
+	case serdetypes.UnitVariantKind:
+	case serdetypes.NewTypeVariantKind:
+		var content Value
+		content, err = d.format(variant.Value.Content)
+		value.Elements = []Value{content}
+	case serdetypes.TupleVariantKind:
+		value.Elements, err = d.formats(variant.Value.Elements)
+	case serdetypes.StructVariantKind:
+		value.Fields, err = d.fields(variant.Value.Fields)
+	default:
+		return Value{}, fmt.Errorf("unexpected variant kind: %d", variant.Value.Kind)
+	}
+	if err != nil {
+		return Value{}, serde.WrapDecodeError(err, d.deserializer, variant.Name)
+	}
+	return value, nil
+}
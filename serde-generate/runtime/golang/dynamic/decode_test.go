@@ -0,0 +1,213 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package dynamic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	st "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// struct Test { a: BTreeMap<u8, String>, b: Option<Choice>, c: (bool, i64), d: [u16; 2], e: Wrapper }
+// enum Choice { A, B(u16), C { x: Vec<u8> } }
+// struct Wrapper(Vec<u8>) (with `serde_bytes`)
+var testRegistry = st.Registry{
+	Containers: map[string]st.ContainerFormat{
+		"Test": {
+			Kind: st.StructKind,
+			Fields: []st.Named[st.Format]{
+				{Name: "a", Value: st.Map(st.Primitive(st.U8Kind), st.Primitive(st.StrKind))},
+				{Name: "b", Value: st.Option(st.TypeName("Choice"))},
+				{Name: "c", Value: st.Tuple(st.Primitive(st.BoolKind), st.Primitive(st.I64Kind))},
+				{Name: "d", Value: st.TupleArray(st.Primitive(st.U16Kind), 2)},
+				{Name: "e", Value: st.TypeName("Wrapper")},
+			},
+		},
+		"Choice": {
+			Kind: st.EnumKind,
+			Variants: map[uint32]st.Named[st.VariantFormat]{
+				0: {Name: "A", Value: st.VariantFormat{Kind: st.UnitVariantKind}},
+				1: {Name: "B", Value: st.VariantFormat{Kind: st.NewTypeVariantKind, Content: &st.Format{Kind: st.U16Kind}}},
+				2: {Name: "C", Value: st.VariantFormat{Kind: st.StructVariantKind, Fields: []st.Named[st.Format]{
+					{Name: "x", Value: st.Seq(st.Primitive(st.U8Kind))},
+				}}},
+			},
+		},
+		"Wrapper": {
+			Kind:    st.NewTypeStructKind,
+			Content: &st.Format{Kind: st.BytesKind},
+		},
+	},
+}
+
+var testInput = []byte{
+	1, 1, 1, 'a', // a
+	1, 2, 2, 3, 4, // b
+	1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // c
+	1, 0, 2, 0, // d
+	2, 1, 0xff, // e
+}
+
+func TestDecodeBcs(t *testing.T) {
+	value, err := dynamic.DecodeBcs(testInput, &testRegistry, "Test")
+	require.NoError(t, err)
+	assert.Equal(t, dynamic.StructValue, value.Kind)
+	assert.Equal(t, "Test", value.TypeName)
+	assert.Equal(t, `Test { a: {1: "a"}, b: Some(Choice::C { x: [3, 4] }), c: (true, -1), d: [1, 2], e: Wrapper(0x01ff) }`, value.String())
+
+	a, ok := value.Field("a")
+	require.True(t, ok)
+	require.Len(t, a.Entries, 1)
+	assert.Equal(t, uint8(1), a.Entries[0].Key.Primitive)
+	assert.Equal(t, "a", a.Entries[0].Value.Primitive)
+
+	b, ok := value.Field("b")
+	require.True(t, ok)
+	require.Equal(t, dynamic.OptionValue, b.Kind)
+	choice := b.Elements[0]
+	assert.Equal(t, dynamic.VariantValue, choice.Kind)
+	assert.Equal(t, uint32(2), choice.VariantIndex)
+	assert.Equal(t, "C", choice.VariantName)
+
+	c, ok := value.Field("c")
+	require.True(t, ok)
+	assert.Equal(t, st.I64Kind, c.Elements[1].Format)
+	assert.Equal(t, int64(-1), c.Elements[1].Primitive)
+
+	_, ok = value.Field("z")
+	assert.False(t, ok)
+
+	assert.Equal(t, uint64(0), value.Start)
+	assert.Equal(t, uint64(len(testInput)), value.End)
+	assert.Equal(t, uint64(4), b.Start)
+	assert.Equal(t, uint64(9), b.End)
+	assert.Equal(t, uint64(5), choice.Start)
+}
+
+func TestDecodeBincode(t *testing.T) {
+	value, err := dynamic.DecodeBincode([]byte{1, 0, 0, 0, 7, 0}, &testRegistry, "Choice")
+	require.NoError(t, err)
+	assert.Equal(t, "Choice::B(7)", value.String())
+	assert.Equal(t, uint16(7), value.Elements[0].Primitive)
+}
+
+func TestDecodeFormat(t *testing.T) {
+	format := st.Seq(st.Option(st.TypeName("Choice")))
+	value, err := dynamic.DecodeFormat(bcs.NewDeserializer([]byte{2, 0, 1, 0}), &testRegistry, &format)
+	require.NoError(t, err)
+	assert.Equal(t, "[None, Some(Choice::A)]", value.String())
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    []byte
+		typeName string
+		err      string
+	}{
+		{
+			name:     "unsorted map",
+			input:    []byte{2, 2, 1, 'b', 1, 1, 'a'},
+			typeName: "Test",
+			err:      "Error while decoding Test.a: Error while decoding map: keys are not serialized in the expected order (at offset 5)",
+		},
+		{
+			name:     "invalid UTF8",
+			input:    []byte{1, 1, 1, 0xff},
+			typeName: "Test",
+			err:      "Error while decoding Test.a[0]: invalid UTF8 string (at offset 4)",
+		},
+		{
+			name:     "unknown variant",
+			input:    []byte{0, 1, 3},
+			typeName: "Test",
+			err:      "Error while decoding Test.b: Choice: unknown variant index 3 (at offset 3)",
+		},
+		{
+			name:     "truncated variant",
+			input:    []byte{2, 2},
+			typeName: "Choice",
+			err:      "Error while decoding Choice.C.x[0]: unexpected EOF (at offset 2)",
+		},
+		{
+			name:     "unknown container",
+			input:    []byte{0},
+			typeName: "Unknown",
+			err:      `Error while decoding Unknown: unknown container "Unknown" (at offset 0)`,
+		},
+		{
+			name:     "trailing bytes",
+			input:    []byte{0, 0},
+			typeName: "Choice",
+			err:      "Some input bytes were not read",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := dynamic.DecodeBcs(tc.input, &testRegistry, tc.typeName)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestDecodeContainerDepth(t *testing.T) {
+	// struct List(Option<List>)
+	registry := st.Registry{Containers: map[string]st.ContainerFormat{
+		"List": {Kind: st.NewTypeStructKind, Content: &st.Format{Kind: st.OptionKind, Content: &st.Format{Kind: st.TypeNameKind, Name: "List"}}},
+	}}
+	input := make([]byte, bcs.MaxContainerDepth+1)
+	for i := range input {
+		input[i] = 1
+	}
+	input[len(input)-1] = 0
+	_, err := dynamic.DecodeBcs(input, &registry, "List")
+	var decodeErr *serde.DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.True(t, errors.Is(err, serde.ErrMaxDepthExceeded))
+}
+
+func TestDecodeEmptyElements(t *testing.T) {
+	// struct Units(Vec<()>, u16)
+	// struct Entries(BTreeMap<Empty, [u8; 0]>)
+	// struct Empty
+	registry := st.Registry{Containers: map[string]st.ContainerFormat{
+		"Units":   {Kind: st.TupleStructKind, Elements: []st.Format{st.Seq(st.Primitive(st.UnitKind)), st.Primitive(st.U16Kind)}},
+		"Entries": {Kind: st.NewTypeStructKind, Content: &st.Format{Kind: st.MapKind, Key: &st.Format{Kind: st.TypeNameKind, Name: "Empty"}, Value: &st.Format{Kind: st.TupleArrayKind, Content: &st.Format{Kind: st.U8Kind}}}},
+		"Empty":   {Kind: st.UnitStructKind},
+	}}
+
+	// Lengths are bounded by the remaining input.
+	value, err := dynamic.DecodeBcs([]byte{2, 7, 0}, &registry, "Units")
+	require.NoError(t, err)
+	assert.Equal(t, "Units([(), ()], 7)", value.String())
+	_, err = dynamic.DecodeBcs([]byte{3, 7, 0}, &registry, "Units")
+	assert.EqualError(t, err, "Error while decoding Units[0]: input is too short: 3 elements of empty values exceed the remaining input (at offset 1)")
+	assert.True(t, errors.Is(err, serde.ErrInputTooShort))
+
+	// Without the bound, these inputs would allocate gigabytes.
+	for _, input := range [][]byte{{0xff, 0xff, 0x3f}, {0xff, 0xff, 0xff, 0x0f}, {0xff, 0xff, 0xff, 0xff, 0x07}} {
+		_, err := dynamic.DecodeBcs(input, &registry, "Units")
+		assert.True(t, errors.Is(err, serde.ErrInputTooShort))
+	}
+	// Bincode does not check the order of map keys, so equal (empty) keys are accepted.
+	_, err = dynamic.DecodeBincode([]byte{0xff, 0xff, 0xff, 0x0f, 0, 0, 0, 0}, &registry, "Entries")
+	assert.True(t, errors.Is(err, serde.ErrInputTooShort))
+}
+
+func TestValueString(t *testing.T) {
+	value := dynamic.Value{Kind: dynamic.TupleValue, Elements: []dynamic.Value{
+		{Kind: dynamic.PrimitiveValue, Format: st.I32Kind, Primitive: int32(120)},
+		{Kind: dynamic.PrimitiveValue, Format: st.CharKind, Primitive: 'x'},
+		{Kind: dynamic.PrimitiveValue, Format: st.U128Kind, Primitive: serde.Uint128{High: 1}},
+		{Kind: dynamic.PrimitiveValue, Format: st.UnitKind},
+		{Kind: dynamic.StructValue, TypeName: "Empty"},
+	}}
+	assert.Equal(t, `(120, 'x', 18446744073709551616, (), Empty)`, value.String())
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// ErrNoHandler is returned (wrapped in a `serde.TypeError`) by `Dispatcher` for variants
+// without a handler.
+var ErrNoHandler = errors.New("no handler for the variant")
+
+// Handler processes a decoded value of a variant: `value.VariantIndex` and
+// `value.VariantName` identify the variant, and `value.Elements` or `value.Fields` hold its
+// content.
+type Handler func(value *Value) error
+
+// Dispatcher calls the handlers registered for the variants of an enum of a registry, e.g. to
+// route the messages of a protocol to their handlers:
+//
+//	dispatcher, err := dynamic.NewDispatcher(registry, "Message")
+//	...
+//	err = dispatcher.Handle("Transfer", func(value *dynamic.Value) error {
+//		amount, _ := value.Field("amount")
+//		...
+//	})
+//	...
+//	err = dispatcher.DispatchBcs(input)
+//
+// Handlers are registered before dispatching values. A `Dispatcher` may then be used
+// concurrently.
+type Dispatcher struct {
+	registry *serdetypes.Registry
+	typeName string
+	variants map[uint32]serdetypes.Named[serdetypes.VariantFormat]
+	handlers map[uint32]Handler
+	fallback Handler
+}
+
+// NewDispatcher returns a dispatcher for the values of the enum `typeName` of `registry`,
+// without handlers.
+func NewDispatcher(registry *serdetypes.Registry, typeName string) (*Dispatcher, error) {
+	format, err := registry.Lookup(typeName)
+	if err != nil {
+		return nil, err
+	}
+	if format.Kind != serdetypes.EnumKind {
+		return nil, fmt.Errorf("container %q is not an enum", typeName)
+	}
+	return &Dispatcher{
+		registry: registry,
+		typeName: typeName,
+		variants: format.Variants,
+		handlers: make(map[uint32]Handler),
+	}, nil
+}
+
+// Handle registers `handler` for the variant named `name`.
+func (d *Dispatcher) Handle(name string, handler Handler) error {
+	for index, variant := range d.variants {
+		if variant.Name == name {
+			return d.HandleIndex(index, handler)
+		}
+	}
+	return serde.UnknownVariantName(d.typeName, name)
+}
+
+// HandleIndex registers `handler` for the variant of index `index`.
+func (d *Dispatcher) HandleIndex(index uint32, handler Handler) error {
+	variant, ok := d.variants[index]
+	if !ok {
+		return serde.UnknownVariantIndex(d.typeName, index)
+	}
+	if _, ok := d.handlers[index]; ok {
+		return &serde.TypeError{Type: d.typeName, Field: variant.Name, Err: errors.New("a handler is already registered")}
+	}
+	d.handlers[index] = handler
+	return nil
+}
+
+// HandleDefault registers `handler` for the variants without a handler of their own.
+func (d *Dispatcher) HandleDefault(handler Handler) {
+	d.fallback = handler
+}
+
+// Dispatch calls the handler of the variant of `value`, a value of the enum of the
+// dispatcher (e.g. as returned by `Decode`), and returns its error. If the variant has no
+// handler (and no default handler is registered), the error wraps `ErrNoHandler`.
+func (d *Dispatcher) Dispatch(value *Value) error {
+	if value.Kind != VariantValue || (value.TypeName != "" && value.TypeName != d.typeName) {
+		return serde.InvalidValue(d.typeName, "", "expected a value of this enum")
+	}
+	variant, ok := d.variants[value.VariantIndex]
+	if !ok {
+		return serde.UnknownVariantIndex(d.typeName, value.VariantIndex)
+	}
+	handler, ok := d.handlers[value.VariantIndex]
+	if !ok {
+		handler = d.fallback
+	}
+	if handler == nil {
+		return &serde.TypeError{Type: d.typeName, Field: variant.Name, Err: ErrNoHandler}
+	}
+	return handler(value)
+}
+
+// DispatchBcs decodes `input` as the BCS encoding of a value of the enum of the dispatcher,
+// then dispatches it.
+func (d *Dispatcher) DispatchBcs(input []byte) error {
+	return d.dispatchAll(bcs.NewDeserializer(input))
+}
+
+// DispatchBincode decodes `input` as the Bincode encoding of a value of the enum of the
+// dispatcher, then dispatches it.
+func (d *Dispatcher) DispatchBincode(input []byte) error {
+	return d.dispatchAll(bincode.NewDeserializer(input))
+}
+
+func (d *Dispatcher) dispatchAll(deserializer serde.Deserializer) error {
+	value, err := decodeAll(deserializer, d.registry, d.typeName)
+	if err != nil {
+		return err
+	}
+	return d.Dispatch(&value)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package dynamic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher(t *testing.T) {
+	dispatcher, err := dynamic.NewDispatcher(&testRegistry, "Choice")
+	require.NoError(t, err)
+	var handled []string
+	require.NoError(t, dispatcher.Handle("B", func(value *dynamic.Value) error {
+		handled = append(handled, "B: "+value.Elements[0].String())
+		return nil
+	}))
+	require.NoError(t, dispatcher.HandleIndex(2, func(value *dynamic.Value) error {
+		x, _ := value.Field("x")
+		handled = append(handled, "C: "+x.String())
+		return errors.New("failed")
+	}))
+
+	require.NoError(t, dispatcher.DispatchBcs([]byte{1, 7, 0}))
+	assert.EqualError(t, dispatcher.DispatchBincode([]byte{2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 3}), "failed")
+	assert.Equal(t, []string{"B: 7", "C: [3]"}, handled)
+
+	err = dispatcher.DispatchBcs([]byte{0})
+	assert.True(t, errors.Is(err, dynamic.ErrNoHandler))
+	assert.EqualError(t, err, "Choice.A: no handler for the variant")
+
+	dispatcher.HandleDefault(func(value *dynamic.Value) error {
+		handled = append(handled, "default: "+value.String())
+		return nil
+	})
+	require.NoError(t, dispatcher.DispatchBcs([]byte{0}))
+	assert.Equal(t, "default: Choice::A", handled[2])
+
+	// Inputs are decoded before being dispatched.
+	err = dispatcher.DispatchBcs([]byte{1, 7, 0, 0})
+	assert.True(t, errors.Is(err, serde.ErrRemainingBytes))
+	assert.Len(t, handled, 3)
+}
+
+func TestDispatcherErrors(t *testing.T) {
+	_, err := dynamic.NewDispatcher(&testRegistry, "Test")
+	assert.EqualError(t, err, `container "Test" is not an enum`)
+
+	dispatcher, err := dynamic.NewDispatcher(&testRegistry, "Choice")
+	require.NoError(t, err)
+	handler := func(value *dynamic.Value) error { return nil }
+	assert.EqualError(t, dispatcher.Handle("D", handler), `Choice: unknown variant "D"`)
+	assert.EqualError(t, dispatcher.HandleIndex(3, handler), "Choice: unknown variant index 3")
+	require.NoError(t, dispatcher.Handle("A", handler))
+	assert.EqualError(t, dispatcher.HandleIndex(0, handler), "Choice.A: a handler is already registered")
+
+	value, err := dynamic.DecodeBcs([]byte{2, 0, 0}, &testRegistry, "Wrapper")
+	require.NoError(t, err)
+	err = dispatcher.Dispatch(&value)
+	assert.True(t, errors.Is(err, serde.ErrInvalidValue))
+}
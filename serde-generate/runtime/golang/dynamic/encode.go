@@ -0,0 +1,370 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package dynamic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// EncodeError adds the path of the value being encoded to an encoding error,
+// e.g. `Transaction.payload.args[3]`.
+type EncodeError struct {
+	Path string
+	Err  error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("Error while encoding %s: %v", e.Path, e.Err)
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// Same as `serde.WrapDecodeError`.
+func wrapEncodeError(err error, segment string) error {
+	if e, ok := err.(*EncodeError); ok {
+		if !strings.HasPrefix(e.Path, "[") {
+			segment += "."
+		}
+		e.Path = segment + e.Path
+		return e
+	}
+	return &EncodeError{Path: segment, Err: err}
+}
+
+func wrapEncodeErrorIndex(err error, index int) error {
+	return wrapEncodeError(err, fmt.Sprintf("[%d]", index))
+}
+
+// EncodeBcs serializes `value`, a value of the container `typeName`, in BCS. Map entries are
+// sorted, so that the output is canonical.
+func EncodeBcs(value *Value, registry *serdetypes.Registry, typeName string) ([]byte, error) {
+	serializer := bcs.NewSerializer()
+	if err := Encode(serializer, registry, typeName, value); err != nil {
+		return nil, err
+	}
+	return serializer.GetBytes(), nil
+}
+
+// EncodeBincode serializes `value`, a value of the container `typeName`, in Bincode.
+func EncodeBincode(value *Value, registry *serdetypes.Registry, typeName string) ([]byte, error) {
+	serializer := bincode.NewSerializer()
+	if err := Encode(serializer, registry, typeName, value); err != nil {
+		return nil, err
+	}
+	return serializer.GetBytes(), nil
+}
+
+// Encode writes `value`, a value of the container `typeName`, with `serializer`.
+//
+// The value must have the shape given by the registry (e.g. as returned by `Decode`), with
+// the Go types listed in `Value.Primitive` for primitive values. Fields of structs are looked
+// up by name, and variants by `VariantIndex` (`VariantName`, if not empty, must be the name of
+// this variant). Otherwise, the error wraps `serde.ErrInvalidValue` in an `EncodeError`.
+func Encode(serializer serde.Serializer, registry *serdetypes.Registry, typeName string, value *Value) error {
+	e := encoder{registry: registry, serializer: serializer}
+	if err := e.container(typeName, value); err != nil {
+		return wrapEncodeError(err, typeName)
+	}
+	return nil
+}
+
+// EncodeFormat writes `value`, a value of the given format, with `serializer`. Containers are
+// looked up in `registry`.
+func EncodeFormat(serializer serde.Serializer, registry *serdetypes.Registry, format *serdetypes.Format, value *Value) error {
+	e := encoder{registry: registry, serializer: serializer}
+	return e.format(format, value)
+}
+
+type encoder struct {
+	registry   *serdetypes.Registry
+	serializer serde.Serializer
+}
+
+func invalidValue(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", serde.ErrInvalidValue, fmt.Sprintf(format, args...))
+}
+
+var kindNames = map[ValueKind]string{
+	PrimitiveValue: "primitive value",
+	OptionValue:    "option",
+	SeqValue:       "sequence",
+	MapValue:       "map",
+	TupleValue:     "tuple",
+	StructValue:    "struct",
+	VariantValue:   "variant",
+}
+
+func checkKind(value *Value, kind ValueKind) error {
+	if value.Kind != kind {
+		return invalidValue("expected a %s, got a %s", kindNames[kind], kindNames[value.Kind])
+	}
+	return nil
+}
+
+func checkLen(values []Value, length int) error {
+	if len(values) != length {
+		return invalidValue("expected %d elements, got %d", length, len(values))
+	}
+	return nil
+}
+
+// Write the primitive value of type `T` with `serialize`.
+func primitiveTo[T any](value *Value, serialize func(T) error) error {
+	if err := checkKind(value, PrimitiveValue); err != nil {
+		return err
+	}
+	v, ok := value.Primitive.(T)
+	if !ok {
+		return invalidValue("expected a value of type %T, got %T", v, value.Primitive)
+	}
+	return serialize(v)
+}
+
+func (e *encoder) format(format *serdetypes.Format, value *Value) error {
+	s := e.serializer
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return e.container(format.Name, value)
+	case serdetypes.UnitKind:
+		if err := checkKind(value, PrimitiveValue); err != nil {
+			return err
+		}
+		if value.Primitive != nil {
+			return invalidValue("expected unit, got %T", value.Primitive)
+		}
+		return s.SerializeUnit(struct{}{})
+	case serdetypes.BoolKind:
+		return primitiveTo(value, s.SerializeBool)
+	case serdetypes.I8Kind:
+		return primitiveTo(value, s.SerializeI8)
+	case serdetypes.I16Kind:
+		return primitiveTo(value, s.SerializeI16)
+	case serdetypes.I32Kind:
+		return primitiveTo(value, s.SerializeI32)
+	case serdetypes.I64Kind:
+		return primitiveTo(value, s.SerializeI64)
+	case serdetypes.I128Kind:
+		return primitiveTo(value, s.SerializeI128)
+	case serdetypes.U8Kind:
+		return primitiveTo(value, s.SerializeU8)
+	case serdetypes.U16Kind:
+		return primitiveTo(value, s.SerializeU16)
+	case serdetypes.U32Kind:
+		return primitiveTo(value, s.SerializeU32)
+	case serdetypes.U64Kind:
+		return primitiveTo(value, s.SerializeU64)
+	case serdetypes.U128Kind:
+		return primitiveTo(value, s.SerializeU128)
+	case serdetypes.F32Kind:
+		return primitiveTo(value, s.SerializeF32)
+	case serdetypes.F64Kind:
+		return primitiveTo(value, s.SerializeF64)
+	case serdetypes.CharKind:
+		return primitiveTo(value, s.SerializeChar)
+	case serdetypes.StrKind:
+		return primitiveTo(value, s.SerializeStr)
+	case serdetypes.BytesKind:
+		return primitiveTo(value, s.SerializeBytes)
+
+	case serdetypes.OptionKind:
+		if err := checkKind(value, OptionValue); err != nil {
+			return err
+		}
+		if len(value.Elements) > 1 {
+			return invalidValue("expected at most 1 element, got %d", len(value.Elements))
+		}
+		if err := s.SerializeOptionTag(len(value.Elements) == 1); err != nil {
+			return err
+		}
+		if len(value.Elements) == 1 {
+			return e.format(format.Content, &value.Elements[0])
+		}
+		return nil
+
+	case serdetypes.SeqKind:
+		if err := checkKind(value, SeqValue); err != nil {
+			return err
+		}
+		if err := s.SerializeLen(uint64(len(value.Elements))); err != nil {
+			return err
+		}
+		for i := range value.Elements {
+			if err := e.format(format.Content, &value.Elements[i]); err != nil {
+				return wrapEncodeErrorIndex(err, i)
+			}
+		}
+		return nil
+
+	case serdetypes.MapKind:
+		return e.mapValue(format, value)
+
+	case serdetypes.TupleKind:
+		if err := checkKind(value, TupleValue); err != nil {
+			return err
+		}
+		return e.formats(format.Elements, value.Elements)
+
+	case serdetypes.TupleArrayKind:
+		if err := checkKind(value, SeqValue); err != nil {
+			return err
+		}
+		if err := checkLen(value.Elements, int(format.Size)); err != nil {
+			return err
+		}
+		for i := range value.Elements {
+			if err := e.format(format.Content, &value.Elements[i]); err != nil {
+				return wrapEncodeErrorIndex(err, i)
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unexpected format kind: %d", format.Kind)
+	}
+}
+
+// Same as the map case of `bcs.Canonicalize`: entries are sorted by serialized keys (in BCS)
+// and duplicate keys are rejected.
+func (e *encoder) mapValue(format *serdetypes.Format, value *Value) error {
+	s := e.serializer
+	if err := checkKind(value, MapValue); err != nil {
+		return err
+	}
+	if err := s.SerializeLen(uint64(len(value.Entries))); err != nil {
+		return err
+	}
+	serde.BeginMap(s)
+	var offsets []uint64
+	keys := make(map[string]bool)
+	for i := range value.Entries {
+		start := s.GetBufferOffset()
+		offsets = append(offsets, start)
+		if err := e.format(format.Key, &value.Entries[i].Key); err != nil {
+			return wrapEncodeErrorIndex(err, i)
+		}
+		key := string(s.GetBytes()[start:s.GetBufferOffset()])
+		if keys[key] {
+			return wrapEncodeErrorIndex(serde.ErrDuplicateMapKeys, i)
+		}
+		keys[key] = true
+		if err := e.format(format.Value, &value.Entries[i].Value); err != nil {
+			return wrapEncodeErrorIndex(err, i)
+		}
+	}
+	s.SortMapEntries(offsets)
+	return nil
+}
+
+func (e *encoder) formats(formats []serdetypes.Format, values []Value) error {
+	if err := checkLen(values, len(formats)); err != nil {
+		return err
+	}
+	for i := range formats {
+		if err := e.format(&formats[i], &values[i]); err != nil {
+			return wrapEncodeErrorIndex(err, i)
+		}
+	}
+	return nil
+}
+
+func (e *encoder) fields(fields []serdetypes.Named[serdetypes.Format], value *Value) error {
+	if len(value.Fields) != len(fields) {
+		return invalidValue("expected %d fields, got %d", len(fields), len(value.Fields))
+	}
+	for i := range fields {
+		field, ok := value.Field(fields[i].Name)
+		if !ok {
+			return invalidValue("missing field %q", fields[i].Name)
+		}
+		if err := e.format(&fields[i].Value, field); err != nil {
+			return wrapEncodeError(err, fields[i].Name)
+		}
+	}
+	return nil
+}
+
+func (e *encoder) container(name string, value *Value) error {
+	format, err := e.registry.Lookup(name)
+	if err != nil {
+		return err
+	}
+	if value.TypeName != "" && value.TypeName != name {
+		return invalidValue("expected a value of %s, got %s", name, value.TypeName)
+	}
+	if err := e.serializer.IncreaseContainerDepth(); err != nil {
+		return err
+	}
+	if err := e.containerContent(name, &format, value); err != nil {
+		return err
+	}
+	e.serializer.DecreaseContainerDepth()
+	return nil
+}
+
+func (e *encoder) containerContent(name string, format *serdetypes.ContainerFormat, value *Value) error {
+	if format.Kind == serdetypes.EnumKind {
+		return e.variant(name, format, value)
+	}
+	if err := checkKind(value, StructValue); err != nil {
+		return err
+	}
+	switch format.Kind {
+	case serdetypes.UnitStructKind:
+		return e.formats(nil, value.Elements)
+	case serdetypes.NewTypeStructKind:
+		if err := checkLen(value.Elements, 1); err != nil {
+			return err
+		}
+		return e.format(format.Content, &value.Elements[0])
+	case serdetypes.TupleStructKind:
+		return e.formats(format.Elements, value.Elements)
+	case serdetypes.StructKind:
+		return e.fields(format.Fields, value)
+	default:
+		return fmt.Errorf("unexpected container kind: %d", format.Kind)
+	}
+}
+
+func (e *encoder) variant(name string, format *serdetypes.ContainerFormat, value *Value) error {
+	if err := checkKind(value, VariantValue); err != nil {
+		return err
+	}
+	variant, ok := format.Variants[value.VariantIndex]
+	if !ok {
+		return serde.UnknownVariantIndex(name, value.VariantIndex)
+	}
+	if value.VariantName != "" && value.VariantName != variant.Name {
+		return invalidValue("variant index %d is %s, not %s", value.VariantIndex, variant.Name, value.VariantName)
+	}
+	if err := e.serializer.SerializeVariantIndex(value.VariantIndex); err != nil {
+		return err
+	}
+	var err error
+	switch variant.Value.Kind {
+	case serdetypes.UnitVariantKind:
+		err = e.formats(nil, value.Elements)
+	case serdetypes.NewTypeVariantKind:
+		if err = checkLen(value.Elements, 1); err == nil {
+			err = e.format(variant.Value.Content, &value.Elements[0])
+		}
+	case serdetypes.TupleVariantKind:
+		err = e.formats(variant.Value.Elements, value.Elements)
+	case serdetypes.StructVariantKind:
+		err = e.fields(variant.Value.Fields, value)
+	default:
+		return fmt.Errorf("unexpected variant kind: %d", variant.Value.Kind)
+	}
+	if err != nil {
+		return wrapEncodeError(err, variant.Name)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package dynamic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	st "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeBcsRoundTrip(t *testing.T) {
+	value, err := dynamic.DecodeBcs(testInput, &testRegistry, "Test")
+	require.NoError(t, err)
+	output, err := dynamic.EncodeBcs(&value, &testRegistry, "Test")
+	require.NoError(t, err)
+	assert.Equal(t, testInput, output)
+}
+
+func TestEncodeBincodeRoundTrip(t *testing.T) {
+	input := []byte{1, 0, 0, 0, 7, 0}
+	value, err := dynamic.DecodeBincode(input, &testRegistry, "Choice")
+	require.NoError(t, err)
+	output, err := dynamic.EncodeBincode(&value, &testRegistry, "Choice")
+	require.NoError(t, err)
+	assert.Equal(t, input, output)
+}
+
+func TestEncodeModifiedValue(t *testing.T) {
+	value, err := dynamic.DecodeBcs(testInput, &testRegistry, "Test")
+	require.NoError(t, err)
+
+	// Add a smaller key to the map and replace the option by `Some(Choice::A)`.
+	a, _ := value.Field("a")
+	a.Entries = append(a.Entries, dynamic.Entry{
+		Key:   dynamic.Value{Kind: dynamic.PrimitiveValue, Primitive: uint8(0)},
+		Value: dynamic.Value{Kind: dynamic.PrimitiveValue, Primitive: "b"},
+	})
+	b, _ := value.Field("b")
+	b.Elements[0] = dynamic.Value{Kind: dynamic.VariantValue, VariantIndex: 0}
+
+	output, err := dynamic.EncodeBcs(&value, &testRegistry, "Test")
+	require.NoError(t, err)
+	expected := append([]byte{2, 0, 1, 'b', 1, 1, 'a', 1, 0}, testInput[9:]...)
+	assert.Equal(t, expected, output)
+
+	decoded, err := dynamic.DecodeBcs(output, &testRegistry, "Test")
+	require.NoError(t, err)
+	assert.Equal(t, `Test { a: {0: "b", 1: "a"}, b: Some(Choice::A), c: (true, -1), d: [1, 2], e: Wrapper(0x01ff) }`, decoded.String())
+}
+
+func TestEncodeErrors(t *testing.T) {
+	str := func(s string) dynamic.Value { return dynamic.Value{Kind: dynamic.PrimitiveValue, Primitive: s} }
+	u8 := func(n uint8) dynamic.Value { return dynamic.Value{Kind: dynamic.PrimitiveValue, Primitive: n} }
+	cases := []struct {
+		name  string
+		value dynamic.Value
+		err   string
+	}{
+		{
+			name:  "wrong primitive type",
+			value: dynamic.Value{Kind: dynamic.VariantValue, VariantIndex: 1, Elements: []dynamic.Value{u8(7)}},
+			err:   "Error while encoding Choice.B: invalid value: expected a value of type uint16, got uint8",
+		},
+		{
+			name:  "wrong kind",
+			value: dynamic.Value{Kind: dynamic.VariantValue, VariantIndex: 2, Fields: []st.Named[dynamic.Value]{{Name: "x", Value: str("a")}}},
+			err:   "Error while encoding Choice.C.x: invalid value: expected a sequence, got a primitive value",
+		},
+		{
+			name:  "missing field",
+			value: dynamic.Value{Kind: dynamic.VariantValue, VariantIndex: 2, Fields: []st.Named[dynamic.Value]{{Name: "y", Value: str("a")}}},
+			err:   `Error while encoding Choice.C: invalid value: missing field "x"`,
+		},
+		{
+			name:  "unknown variant",
+			value: dynamic.Value{Kind: dynamic.VariantValue, VariantIndex: 3},
+			err:   "Error while encoding Choice: Choice: unknown variant index 3",
+		},
+		{
+			name:  "inconsistent variant name",
+			value: dynamic.Value{Kind: dynamic.VariantValue, VariantIndex: 0, VariantName: "B"},
+			err:   "Error while encoding Choice: invalid value: variant index 0 is A, not B",
+		},
+		{
+			name:  "wrong type name",
+			value: dynamic.Value{Kind: dynamic.VariantValue, TypeName: "Test"},
+			err:   "Error while encoding Choice: invalid value: expected a value of Choice, got Test",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := dynamic.EncodeBcs(&tc.value, &testRegistry, "Choice")
+			assert.EqualError(t, err, tc.err)
+			assert.True(t, errors.Is(err, serde.ErrInvalidValue) || errors.Is(err, serde.ErrUnknownVariant))
+		})
+	}
+}
+
+func TestEncodeDuplicateMapKeys(t *testing.T) {
+	value, err := dynamic.DecodeBcs(testInput, &testRegistry, "Test")
+	require.NoError(t, err)
+	a, _ := value.Field("a")
+	a.Entries = append(a.Entries, a.Entries[0])
+	_, err = dynamic.EncodeBcs(&value, &testRegistry, "Test")
+	assert.EqualError(t, err, "Error while encoding Test.a[1]: duplicate keys")
+	assert.True(t, errors.Is(err, serde.ErrDuplicateMapKeys))
+}
@@ -0,0 +1,178 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+// Package dynamic decodes values serialized in BCS or Bincode into generic `Value` trees,
+// given the registry of their formats (see `serdetypes.ReadRegistry`), and encodes them back.
+// This allows writing tools (e.g. explorers or ETL pipelines) that handle any type without
+// generated code. A `Dispatcher` calls the handlers registered for the variants of an enum,
+// e.g. to route messages.
+package dynamic
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// ValueKind is the kind of a `Value`.
+type ValueKind int
+
+const (
+	// A value of a primitive format (see `Value.Primitive`).
+	PrimitiveValue ValueKind = iota
+	// An optional value: `Elements` contains the value, if any.
+	OptionValue
+	// A sequence or a fixed-size array: the values are in `Elements`.
+	SeqValue
+	// A map: the entries are in `Entries`, in the order of the input.
+	MapValue
+	// A tuple: the values are in `Elements`.
+	TupleValue
+	// A value of a struct: the values of named fields are in `Fields`, the values of
+	// newtype and tuple structs are in `Elements`.
+	StructValue
+	// A value of an enum: the variant is `VariantIndex` (named `VariantName`), and its content
+	// is in `Fields` or `Elements`, as for structs.
+	VariantValue
+)
+
+// Value is a value decoded according to a Serde format.
+type Value struct {
+	Kind ValueKind
+	// The format of the primitive value, for `PrimitiveValue`.
+	Format serdetypes.FormatKind
+	// The primitive value, for `PrimitiveValue`: nil (unit), bool, int8, int16, int32, int64,
+	// serde.Int128, uint8, uint16, uint32, uint64, serde.Uint128, float32, float64, rune,
+	// string, or []byte.
+	Primitive interface{}
+	// The name of the container, for `StructValue` and `VariantValue`.
+	TypeName string
+	// The variant, for `VariantValue`.
+	VariantIndex uint32
+	VariantName  string
+	// The unnamed values, for `OptionValue`, `SeqValue`, `TupleValue`, and the newtype or tuple
+	// structs (or variants).
+	Elements []Value
+	// The entries of maps, for `MapValue`.
+	Entries []Entry
+	// The named values of structs (or variants).
+	Fields []serdetypes.Named[Value]
+	// The position of the serialized value in the input (see `serde.Deserializer.GetBufferOffset`),
+	// for values returned by `Decode`. Ignored by `Encode`.
+	Start uint64
+	End   uint64
+}
+
+// Entry is an entry of a map.
+type Entry struct {
+	Key   Value
+	Value Value
+}
+
+// Field returns the value of the field `name` of a struct (or struct variant).
+func (v *Value) Field(name string) (*Value, bool) {
+	for i := range v.Fields {
+		if v.Fields[i].Name == name {
+			return &v.Fields[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// String formats the value like the `Debug` trait of Rust, e.g.
+// `Transfer { amount: 10, memo: Some("rent") }` or `Status::Failed(3)`.
+// Byte arrays are written in hexadecimal, e.g. `0x01ff`.
+func (v Value) String() string {
+	var b strings.Builder
+	v.write(&b)
+	return b.String()
+}
+
+func (v *Value) write(b *strings.Builder) {
+	switch v.Kind {
+	case PrimitiveValue:
+		writePrimitive(b, v.Format, v.Primitive)
+	case OptionValue:
+		if len(v.Elements) == 0 {
+			b.WriteString("None")
+			return
+		}
+		b.WriteString("Some")
+		writeValues(b, "(", v.Elements, ")")
+	case SeqValue:
+		writeValues(b, "[", v.Elements, "]")
+	case MapValue:
+		b.WriteString("{")
+		for i := range v.Entries {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			v.Entries[i].Key.write(b)
+			b.WriteString(": ")
+			v.Entries[i].Value.write(b)
+		}
+		b.WriteString("}")
+	case TupleValue:
+		writeValues(b, "(", v.Elements, ")")
+	case StructValue, VariantValue:
+		b.WriteString(v.TypeName)
+		if v.Kind == VariantValue {
+			b.WriteString("::")
+			b.WriteString(v.VariantName)
+		}
+		if len(v.Elements) > 0 {
+			writeValues(b, "(", v.Elements, ")")
+		}
+		if len(v.Fields) > 0 {
+			b.WriteString(" { ")
+			for i := range v.Fields {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString(v.Fields[i].Name)
+				b.WriteString(": ")
+				v.Fields[i].Value.write(b)
+			}
+			b.WriteString(" }")
+		}
+	default:
+		fmt.Fprintf(b, "<unexpected value kind: %d>", v.Kind)
+	}
+}
+
+func writeValues(b *strings.Builder, open string, values []Value, close string) {
+	b.WriteString(open)
+	for i := range values {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		values[i].write(b)
+	}
+	b.WriteString(close)
+}
+
+func writePrimitive(b *strings.Builder, format serdetypes.FormatKind, value interface{}) {
+	// `rune` is an alias for `int32`.
+	if r, ok := value.(rune); ok && format == serdetypes.CharKind {
+		fmt.Fprintf(b, "%q", r)
+		return
+	}
+	switch value := value.(type) {
+	case nil:
+		b.WriteString("()")
+	case serde.Int128:
+		b.WriteString(value.BigInt().String())
+	case serde.Uint128:
+		b.WriteString(value.BigInt().String())
+	case string:
+		fmt.Fprintf(b, "%q", value)
+	case []byte:
+		b.WriteString("0x")
+		b.WriteString(hex.EncodeToString(value))
+	default:
+		fmt.Fprint(b, value)
+	}
+}
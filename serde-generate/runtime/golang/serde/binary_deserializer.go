@@ -7,35 +7,180 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"unicode/utf8"
 )
 
+// Minimum number of bytes read at once by deserializers reading from an `io.Reader`.
+const streamReadSize = 4096
+
 // `BinaryDeserializer` is a partial implementation of the `Deserializer` interface.
 // It is used as an embedded struct by the Bincode and BCS deserializers.
 type BinaryDeserializer struct {
-	Buffer               *bytes.Buffer
-	Input                []byte
-	containerDepthBudget uint64
+	Buffer *bytes.Buffer
+	// The input bytes. When reading from an `io.Reader` (see `NewBinaryStreamDeserializer`),
+	// only the bytes from some offset on are retained: use `KeyBytes` rather than indexing
+	// `Input` with offsets.
+	Input []byte
+	// Shared with the child deserializers created by `Slice`.
+	containerDepthBudget *uint64
+	// Set when reading from an `io.Reader`.
+	stream *inputStream
+}
+
+// Input of a deserializer read from an `io.Reader`.
+type inputStream struct {
+	reader io.Reader
+	// Offset of `Input[0]` in the input.
+	base uint64
+	// For each map being deserialized (see `BeginMap`), from the outermost one, the offset
+	// of the last key read, which may still be compared with the next key.
+	keys []uint64
+	// First error returned by `reader`, including `io.EOF`.
+	err error
 }
 
 func NewBinaryDeserializer(input []byte, max_container_depth uint64) *BinaryDeserializer {
+	budget := max_container_depth
 	return &BinaryDeserializer{
 		Buffer:               bytes.NewBuffer(input),
 		Input:                input,
-		containerDepthBudget: max_container_depth,
+		containerDepthBudget: &budget,
+	}
+}
+
+// NewBinaryStreamDeserializer reads the input from `reader` as the deserialization
+// progresses, rather than requiring the entire input in memory. Input bytes are only
+// retained while they may still be needed, that is, the last key of each map being
+// deserialized (see `BeginMap`) and the bytes after it.
+func NewBinaryStreamDeserializer(reader io.Reader, max_container_depth uint64) *BinaryDeserializer {
+	d := NewBinaryDeserializer(nil, max_container_depth)
+	d.stream = &inputStream{reader: reader}
+	return d
+}
+
+// ErrInputDiscarded is returned when comparing map keys whose bytes are no longer retained
+// by a deserializer reading from an `io.Reader`, i.e. when the map was not announced with
+// `BeginMap`.
+var ErrInputDiscarded = errors.New("input bytes were discarded")
+
+// Offset of `Input[0]` in the input.
+func (d *BinaryDeserializer) base() uint64 {
+	if d.stream == nil {
+		return 0
+	}
+	return d.stream.base
+}
+
+// Make sure that at least `n` bytes are buffered after the current position, reading
+// more input if needed. Returns false if the input ends before.
+func (d *BinaryDeserializer) fill(n uint64) bool {
+	s := d.stream
+	if uint64(d.Buffer.Len()) >= n || s == nil || s.err != nil {
+		return uint64(d.Buffer.Len()) >= n
+	}
+	offset := d.GetBufferOffset()
+	keep := offset
+	if len(s.keys) > 0 {
+		keep = s.keys[0]
+	}
+	input := d.Input
+	if keep > s.base {
+		input = append([]byte(nil), d.Input[keep-s.base:]...)
+		s.base = keep
+	}
+	position := offset - s.base
+	var chunk [streamReadSize]byte
+	for uint64(len(input))-position < n && s.err == nil {
+		var m int
+		m, s.err = s.reader.Read(chunk[:])
+		input = append(input, chunk[:m]...)
+	}
+	d.Input = input
+	d.Buffer = bytes.NewBuffer(input[position:])
+	return uint64(d.Buffer.Len()) >= n
+}
+
+// The error returned by the reader of the input, if any, other than `io.EOF`.
+func (d *BinaryDeserializer) readError() error {
+	if d.stream == nil || d.stream.err == io.EOF {
+		return nil
+	}
+	return d.stream.err
+}
+
+// BeginMap tells `d` that the entries of a map follow, until `EndMap`. When reading from
+// an `io.Reader`, the bytes of the keys are then retained until they are compared with
+// the next key (see `KeyBytes`).
+func (d *BinaryDeserializer) BeginMap() {
+	if d.stream != nil {
+		d.stream.keys = append(d.stream.keys, d.GetBufferOffset())
 	}
 }
 
+// EndMap tells `d` that the entries of the map announced by `BeginMap` were read.
+func (d *BinaryDeserializer) EndMap() {
+	if d.stream != nil && len(d.stream.keys) > 0 {
+		d.stream.keys = d.stream.keys[:len(d.stream.keys)-1]
+	}
+}
+
+// KeyBytes returns the input bytes of `key1` and `key2`, two consecutive keys of a map
+// (see `CheckThatKeySlicesAreIncreasing`). The bytes of `key1` are no longer retained
+// afterwards.
+func (d *BinaryDeserializer) KeyBytes(key1, key2 Slice) ([]byte, []byte, error) {
+	base := d.base()
+	if key1.Start < base {
+		return nil, nil, ErrInputDiscarded
+	}
+	if d.stream != nil && len(d.stream.keys) > 0 {
+		d.stream.keys[len(d.stream.keys)-1] = key2.Start
+	}
+	return d.Input[key1.Start-base : key1.End-base], d.Input[key2.Start-base : key2.End-base], nil
+}
+
+// Slice returns a deserializer reading the input bytes between the offsets `start`
+// and `end` (see `GetBufferOffset`). The new deserializer shares the container depth
+// budget of `d`. The position of `d` is not modified, although the input is read up to
+// `end` when reading from an `io.Reader`.
+func (d *BinaryDeserializer) Slice(start, end uint64) (*BinaryDeserializer, error) {
+	if offset := d.GetBufferOffset(); end > offset {
+		d.fill(end - offset)
+	}
+	base := d.base()
+	if start > end || start < base || end > base+uint64(len(d.Input)) {
+		return nil, fmt.Errorf("invalid input range: %d..%d", start, end)
+	}
+	input := d.Input[start-base : end-base]
+	return &BinaryDeserializer{
+		Buffer:               bytes.NewBuffer(input),
+		Input:                input,
+		containerDepthBudget: d.containerDepthBudget,
+	}, nil
+}
+
 func (d *BinaryDeserializer) IncreaseContainerDepth() error {
-	if d.containerDepthBudget == 0 {
-		return errors.New("exceeded maximum container depth")
+	if *d.containerDepthBudget == 0 {
+		return ErrMaxDepthExceeded
 	}
-	d.containerDepthBudget -= 1
+	*d.containerDepthBudget -= 1
 	return nil
 }
 
 func (d *BinaryDeserializer) DecreaseContainerDepth() {
-	d.containerDepthBudget += 1
+	*d.containerDepthBudget += 1
+}
+
+// ReadByte reads the next input byte. Unlike `Buffer.ReadByte`, the end of the
+// input is reported as `io.ErrUnexpectedEOF` since a value was expected.
+func (d *BinaryDeserializer) ReadByte() (byte, error) {
+	if !d.fill(1) {
+		if err := d.readError(); err != nil {
+			return 0, err
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+	return d.Buffer.ReadByte()
 }
 
 // `deserializeLen` to be provided by the extending struct.
@@ -44,12 +189,43 @@ func (d *BinaryDeserializer) DeserializeBytes(deserializeLen func() (uint64, err
 	if err != nil {
 		return nil, err
 	}
+	return d.DeserializeFixedBytes(len)
+}
+
+// DeserializeFixedBytes reads exactly `len` bytes, or fails with `ErrInputTooShort`
+// (and without allocating) if fewer bytes are available.
+func (d *BinaryDeserializer) DeserializeFixedBytes(len uint64) ([]byte, error) {
+	if !d.fill(len) {
+		if err := d.readError(); err != nil {
+			return nil, err
+		}
+		return nil, ErrInputTooShort
+	}
 	ret := make([]byte, len)
-	n, err := d.Buffer.Read(ret)
-	if err == nil && uint64(n) < len {
-		return nil, errors.New("input is too short")
+	if _, err := io.ReadFull(d.Buffer, ret); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInputTooShort
+		}
+		return nil, err
 	}
-	return ret, err
+	return ret, nil
+}
+
+// `deserializeLen` and `deserializeBytesLen` to be provided by the extending struct.
+func (d *BinaryDeserializer) DeserializeVecBytes(deserializeLen func() (uint64, error), deserializeBytesLen func() (uint64, error)) ([][]byte, error) {
+	len, err := deserializeLen()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([][]byte, 0)
+	for i := uint64(0); i < len; i++ {
+		item, err := d.DeserializeBytes(deserializeBytesLen)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
 }
 
 // `deserializeLen` to be provided by the extending struct.
@@ -59,13 +235,13 @@ func (d *BinaryDeserializer) DeserializeStr(deserializeLen func() (uint64, error
 		return "", err
 	}
 	if !utf8.Valid(bytes) {
-		return "", errors.New("invalid UTF8 string")
+		return "", ErrInvalidUTF8
 	}
 	return string(bytes), nil
 }
 
 func (d *BinaryDeserializer) DeserializeBool() (bool, error) {
-	ret, err := d.Buffer.ReadByte()
+	ret, err := d.ReadByte()
 	if err != nil {
 		return false, err
 	}
@@ -75,7 +251,7 @@ func (d *BinaryDeserializer) DeserializeBool() (bool, error) {
 	case 1:
 		return true, nil
 	default:
-		return false, fmt.Errorf("invalid bool byte: expected 0 / 1, but got %d", ret)
+		return false, fmt.Errorf("%w: expected 0 / 1, but got %d", ErrInvalidBool, ret)
 	}
 }
 
@@ -85,18 +261,18 @@ func (d *BinaryDeserializer) DeserializeUnit() (struct{}, error) {
 
 // DeserializeChar is unimplemented.
 func (d *BinaryDeserializer) DeserializeChar() (rune, error) {
-	return 0, errors.New("unimplemented")
+	return 0, ErrUnimplemented
 }
 
 func (d *BinaryDeserializer) DeserializeU8() (uint8, error) {
-	ret, err := d.Buffer.ReadByte()
+	ret, err := d.ReadByte()
 	return uint8(ret), err
 }
 
 func (d *BinaryDeserializer) DeserializeU16() (uint16, error) {
 	var ret uint16
 	for i := 0; i < 8*2; i += 8 {
-		b, err := d.Buffer.ReadByte()
+		b, err := d.ReadByte()
 		if err != nil {
 			return 0, err
 		}
@@ -108,7 +284,7 @@ func (d *BinaryDeserializer) DeserializeU16() (uint16, error) {
 func (d *BinaryDeserializer) DeserializeU32() (uint32, error) {
 	var ret uint32
 	for i := 0; i < 8*4; i += 8 {
-		b, err := d.Buffer.ReadByte()
+		b, err := d.ReadByte()
 		if err != nil {
 			return 0, err
 		}
@@ -120,7 +296,7 @@ func (d *BinaryDeserializer) DeserializeU32() (uint32, error) {
 func (d *BinaryDeserializer) DeserializeU64() (uint64, error) {
 	var ret uint64
 	for i := 0; i < 8*8; i += 8 {
-		b, err := d.Buffer.ReadByte()
+		b, err := d.ReadByte()
 		if err != nil {
 			return 0, err
 		}
@@ -141,6 +317,18 @@ func (d *BinaryDeserializer) DeserializeU128() (Uint128, error) {
 	return Uint128{High: high, Low: low}, nil
 }
 
+func (d *BinaryDeserializer) DeserializeU256() (Uint256, error) {
+	low, err := d.DeserializeU128()
+	if err != nil {
+		return Uint256{}, err
+	}
+	high, err := d.DeserializeU128()
+	if err != nil {
+		return Uint256{}, err
+	}
+	return Uint256{High: high, Low: low}, nil
+}
+
 func (d *BinaryDeserializer) DeserializeI8() (int8, error) {
 	ret, err := d.DeserializeU8()
 	return int8(ret), err
@@ -173,10 +361,41 @@ func (d *BinaryDeserializer) DeserializeI128() (Int128, error) {
 	return Int128{High: high, Low: low}, nil
 }
 
+func (d *BinaryDeserializer) DeserializeI256() (Int256, error) {
+	low, err := d.DeserializeU128()
+	if err != nil {
+		return Int256{}, err
+	}
+	high, err := d.DeserializeI128()
+	if err != nil {
+		return Int256{}, err
+	}
+	return Int256{High: high, Low: low}, nil
+}
+
 func (d *BinaryDeserializer) DeserializeOptionTag() (bool, error) {
-	return d.DeserializeBool()
+	ret, err := d.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	switch ret {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: expected 0 / 1, but got %d", ErrInvalidOptionTag, ret)
+	}
 }
 
 func (d *BinaryDeserializer) GetBufferOffset() uint64 {
-	return uint64(len(d.Input)) - uint64(d.Buffer.Len())
+	return d.base() + uint64(len(d.Input)) - uint64(d.Buffer.Len())
+}
+
+// EndOfInput reads the rest of the input when reading from an `io.Reader`.
+func (d *BinaryDeserializer) EndOfInput() error {
+	if d.fill(1) {
+		return ErrRemainingBytes
+	}
+	return d.readError()
 }
@@ -0,0 +1,87 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryStreamDeserializer(t *testing.T) {
+	input := make([]byte, 100000)
+	for i := range input {
+		input[i] = byte(i % 251)
+	}
+	d := serde.NewBinaryStreamDeserializer(bytes.NewReader(input), 10)
+	for i := 0; i < 50000; i++ {
+		b, err := d.ReadByte()
+		require.NoError(t, err)
+		require.Equal(t, input[i], b)
+	}
+	assert.Equal(t, uint64(50000), d.GetBufferOffset())
+	// Bytes read before are no longer retained.
+	assert.LessOrEqual(t, len(d.Input), 2*4096)
+
+	// The last key of a map is retained until it is compared with the next key.
+	readKey := func() serde.Slice {
+		start := d.GetBufferOffset()
+		_, err := d.DeserializeFixedBytes(10)
+		require.NoError(t, err)
+		return serde.Slice{Start: start, End: d.GetBufferOffset()}
+	}
+	d.BeginMap()
+	key1 := readKey()
+	_, err := d.DeserializeFixedBytes(20000)
+	require.NoError(t, err)
+	key2 := readKey()
+	bytes1, bytes2, err := d.KeyBytes(key1, key2)
+	require.NoError(t, err)
+	assert.Equal(t, input[50000:50010], bytes1)
+	assert.Equal(t, input[70010:70020], bytes2)
+	d.EndMap()
+
+	// Keys of maps not announced with `BeginMap` may be discarded.
+	key3 := readKey()
+	_, err = d.DeserializeFixedBytes(10000)
+	require.NoError(t, err)
+	key4 := readKey()
+	_, _, err = d.KeyBytes(key3, key4)
+	assert.Equal(t, serde.ErrInputDiscarded, err)
+
+	assert.Equal(t, serde.ErrRemainingBytes, d.EndOfInput())
+	rest, err := d.DeserializeFixedBytes(uint64(len(input)) - d.GetBufferOffset())
+	require.NoError(t, err)
+	assert.Equal(t, input[80040:], rest)
+	require.NoError(t, d.EndOfInput())
+	_, err = d.ReadByte()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = d.DeserializeFixedBytes(1)
+	assert.Equal(t, serde.ErrInputTooShort, err)
+}
+
+func TestBinaryStreamDeserializerErrors(t *testing.T) {
+	d := serde.NewBinaryStreamDeserializer(iotest.OneByteReader(bytes.NewReader([]byte{1, 2, 3})), 10)
+	// Lengths beyond the input fail without allocating them.
+	_, err := d.DeserializeFixedBytes(1 << 40)
+	assert.Equal(t, serde.ErrInputTooShort, err)
+	rest, err := d.DeserializeFixedBytes(3)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, rest)
+
+	failure := errors.New("failure")
+	d = serde.NewBinaryStreamDeserializer(io.MultiReader(bytes.NewReader([]byte{1}), iotest.ErrReader(failure)), 10)
+	b, err := d.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, byte(1), b)
+	_, err = d.ReadByte()
+	assert.Equal(t, failure, err)
+	assert.Equal(t, failure, d.EndOfInput())
+}
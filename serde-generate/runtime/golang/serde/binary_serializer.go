@@ -5,7 +5,6 @@ package serde
 
 import (
 	"bytes"
-	"errors"
 )
 
 // `BinarySerializer` is a partial implementation of the `Serializer` interface.
@@ -21,9 +20,18 @@ func NewBinarySerializer(max_container_depth uint64) *BinarySerializer {
 	return s
 }
 
+// NewBinarySerializerAppendingTo creates a `BinarySerializer` whose output is appended to
+// `buffer`, reusing the capacity of `buffer` when possible. Offsets (see `GetBufferOffset`)
+// and the result of `GetBytes` include the initial content of `buffer`.
+func NewBinarySerializerAppendingTo(buffer []byte, max_container_depth uint64) *BinarySerializer {
+	s := NewBinarySerializer(max_container_depth)
+	s.Buffer = *bytes.NewBuffer(buffer)
+	return s
+}
+
 func (d *BinarySerializer) IncreaseContainerDepth() error {
 	if d.containerDepthBudget == 0 {
-		return errors.New("exceeded maximum container depth")
+		return ErrMaxDepthExceeded
 	}
 	d.containerDepthBudget -= 1
 	return nil
@@ -35,11 +43,31 @@ func (d *BinarySerializer) DecreaseContainerDepth() {
 
 // `serializeLen` to be provided by the extending struct.
 func (s *BinarySerializer) SerializeBytes(value []byte, serializeLen func(uint64) error) error {
-	serializeLen(uint64(len(value)))
+	if err := serializeLen(uint64(len(value))); err != nil {
+		return err
+	}
+	s.Buffer.Write(value)
+	return nil
+}
+
+func (s *BinarySerializer) SerializeFixedBytes(value []byte) error {
 	s.Buffer.Write(value)
 	return nil
 }
 
+// `serializeLen` to be provided by the extending struct.
+func (s *BinarySerializer) SerializeVecBytes(value [][]byte, serializeLen func(uint64) error) error {
+	if err := serializeLen(uint64(len(value))); err != nil {
+		return err
+	}
+	for _, item := range value {
+		if err := s.SerializeBytes(item, serializeLen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // `serializeLen` to be provided by the extending struct.
 func (s *BinarySerializer) SerializeStr(value string, serializeLen func(uint64) error) error {
 	return s.SerializeBytes([]byte(value), serializeLen)
@@ -58,7 +86,7 @@ func (s *BinarySerializer) SerializeUnit(value struct{}) error {
 
 // SerializeChar is unimplemented.
 func (s *BinarySerializer) SerializeChar(value rune) error {
-	return errors.New("unimplemented")
+	return ErrUnimplemented
 }
 
 func (s *BinarySerializer) SerializeU8(value uint8) error {
@@ -98,6 +126,12 @@ func (s *BinarySerializer) SerializeU128(value Uint128) error {
 	return nil
 }
 
+func (s *BinarySerializer) SerializeU256(value Uint256) error {
+	s.SerializeU128(value.Low)
+	s.SerializeU128(value.High)
+	return nil
+}
+
 func (s *BinarySerializer) SerializeI8(value int8) error {
 	s.SerializeU8(uint8(value))
 	return nil
@@ -124,6 +158,12 @@ func (s *BinarySerializer) SerializeI128(value Int128) error {
 	return nil
 }
 
+func (s *BinarySerializer) SerializeI256(value Int256) error {
+	s.SerializeU128(value.Low)
+	s.SerializeI128(value.High)
+	return nil
+}
+
 func (s *BinarySerializer) SerializeOptionTag(value bool) error {
 	return s.SerializeBool(value)
 }
@@ -135,3 +175,17 @@ func (s *BinarySerializer) GetBufferOffset() uint64 {
 func (s *BinarySerializer) GetBytes() []byte {
 	return s.Buffer.Bytes()
 }
+
+// Used by `StreamSerializer` to flush serialized bytes.
+func (s *BinarySerializer) binaryBuffer() *bytes.Buffer {
+	return &s.Buffer
+}
+
+// Grow reserves space for `n` more bytes in the output of `serializer`, e.g. the size
+// returned by the method `SerializedSize` of generated types, so that serializing a value of
+// known size allocates once. Serializers that do not embed `BinarySerializer` are ignored.
+func Grow(serializer Serializer, n int) {
+	if s, ok := serializer.(bufferedSerializer); ok {
+		s.binaryBuffer().Grow(n)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// `Codec` abstracts over an encoding format, so that applications can select the
+// format at runtime (see `LookupCodec`).
+type Codec interface {
+	// Serialize `value` into a new byte array.
+	Marshal(value Serializable) ([]byte, error)
+	// Deserialize `value` from `input`. All the input bytes must be consumed.
+	Unmarshal(input []byte, value Deserializable) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[string]Codec)
+)
+
+// RegisterCodec makes a codec available under the given name. Runtime packages
+// register themselves when imported, e.g. `import _ ".../runtime/golang/bcs"` for "bcs".
+// RegisterCodec panics if a codec with the same name was already registered.
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if codec == nil {
+		panic("serde: RegisterCodec codec is nil")
+	}
+	if _, ok := codecs[name]; ok {
+		panic("serde: RegisterCodec called twice for codec " + name)
+	}
+	codecs[name] = codec
+}
+
+// LookupCodec returns the codec registered under the given name.
+func LookupCodec(name string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q (forgotten import?)", name)
+	}
+	return codec, nil
+}
+
+// Codecs returns the sorted list of the names of registered codecs.
+func Codecs() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
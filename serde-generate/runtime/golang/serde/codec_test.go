@@ -0,0 +1,41 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"testing"
+
+	_ "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	_ "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecRegistry(t *testing.T) {
+	assert.Equal(t, []string{"bcs", "bincode"}, serde.Codecs())
+
+	for _, name := range serde.Codecs() {
+		t.Run(name, func(t *testing.T) {
+			codec, err := serde.LookupCodec(name)
+			require.NoError(t, err)
+
+			data, err := codec.Marshal(testUUID)
+			require.NoError(t, err)
+			assert.Equal(t, testUUID[:], data)
+
+			var uuid serde.UUID
+			require.NoError(t, codec.Unmarshal(data, &uuid))
+			assert.Equal(t, testUUID, uuid)
+			assert.EqualError(t, codec.Unmarshal(append(data, 0), &uuid), "Some input bytes were not read")
+		})
+	}
+
+	_, err := serde.LookupCodec("json")
+	assert.EqualError(t, err, `unknown codec "json" (forgotten import?)`)
+	assert.Panics(t, func() {
+		codec, _ := serde.LookupCodec("bcs")
+		serde.RegisterCodec("bcs", codec)
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+// Generic helpers to serialize containers. Elements are serialized with the
+// provided functions, e.g. `func(value uint32, serializer Serializer) error`.
+
+// SerializeVector serializes the length of `value` followed by its elements.
+func SerializeVector[T any](value []T, serializer Serializer, serializeElem func(T, Serializer) error) error {
+	if err := serializer.SerializeLen(uint64(len(value))); err != nil {
+		return err
+	}
+	for _, item := range value {
+		if err := serializeElem(item, serializer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SerializeOption serializes an optional value represented as a (possibly nil) pointer.
+func SerializeOption[T any](value *T, serializer Serializer, serializeValue func(T, Serializer) error) error {
+	if value == nil {
+		return serializer.SerializeOptionTag(false)
+	}
+	if err := serializer.SerializeOptionTag(true); err != nil {
+		return err
+	}
+	return serializeValue(*value, serializer)
+}
+
+// SerializeMap serializes the length of `value` followed by its entries, then
+// lets the serializer sort the entries as required by the encoding format.
+// Distinct Go keys whose serializations are equal (e.g. NaN floats) are rejected.
+func SerializeMap[K comparable, V any](value map[K]V, serializer Serializer, serializeKey func(K, Serializer) error, serializeValue func(V, Serializer) error) error {
+	if err := serializer.SerializeLen(uint64(len(value))); err != nil {
+		return err
+	}
+	BeginMap(serializer)
+	offsets := make([]uint64, 0, len(value))
+	keyEnds := make([]uint64, 0, len(value))
+	for k, v := range value {
+		offsets = append(offsets, serializer.GetBufferOffset())
+		if err := serializeKey(k, serializer); err != nil {
+			return err
+		}
+		keyEnds = append(keyEnds, serializer.GetBufferOffset())
+		if err := serializeValue(v, serializer); err != nil {
+			return err
+		}
+	}
+	if err := checkDistinctMapKeys(serializer.GetBytes(), offsets, keyEnds); err != nil {
+		return err
+	}
+	serializer.SortMapEntries(offsets)
+	return nil
+}
+
+// Generic helpers to deserialize containers. Elements are deserialized with the
+// provided functions, e.g. `func(deserializer Deserializer) (uint32, error)`.
+
+// Maximal number of elements allocated by `DeserializeVector` before reading them.
+const maxPreallocatedElements = 1024
+
+// DeserializeVector deserializes a length followed by as many elements.
+func DeserializeVector[T any](deserializer Deserializer, deserializeElem func(Deserializer) (T, error)) ([]T, error) {
+	length, err := deserializer.DeserializeLen()
+	if err != nil {
+		return nil, err
+	}
+	// The length is not trusted until the elements are read: grow the result gradually
+	// so that a malicious input cannot trigger a large allocation.
+	capacity := length
+	if capacity > maxPreallocatedElements {
+		capacity = maxPreallocatedElements
+	}
+	obj := make([]T, 0, capacity)
+	for i := 0; uint64(i) < length; i++ {
+		elem, err := deserializeElem(deserializer)
+		if err != nil {
+			return nil, WrapDecodeErrorIndex(err, deserializer, i)
+		}
+		obj = append(obj, elem)
+	}
+	return obj, nil
+}
+
+// DeserializeOption deserializes an optional value represented as a (possibly nil) pointer.
+func DeserializeOption[T any](deserializer Deserializer, deserializeValue func(Deserializer) (T, error)) (*T, error) {
+	tag, err := deserializer.DeserializeOptionTag()
+	if err != nil {
+		return nil, err
+	}
+	if !tag {
+		return nil, nil
+	}
+	value, err := deserializeValue(deserializer)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// DeserializeMap deserializes a length followed by as many entries. The
+// deserializer is responsible for checking that the keys are correctly ordered
+// (see `CheckThatKeySlicesAreIncreasing`).
+func DeserializeMap[K comparable, V any](deserializer Deserializer, deserializeKey func(Deserializer) (K, error), deserializeValue func(Deserializer) (V, error)) (map[K]V, error) {
+	length, err := deserializer.DeserializeMapLen()
+	if err != nil {
+		return nil, err
+	}
+	obj := make(map[K]V)
+	BeginReadingMap(deserializer)
+	var previousSlice Slice
+	for i := 0; i < int(length); i++ {
+		var slice Slice
+		slice.Start = deserializer.GetBufferOffset()
+		key, err := deserializeKey(deserializer)
+		if err != nil {
+			return nil, WrapDecodeErrorIndex(err, deserializer, i)
+		}
+		slice.End = deserializer.GetBufferOffset()
+		if i > 0 {
+			if err := deserializer.CheckThatKeySlicesAreIncreasing(previousSlice, slice); err != nil {
+				return nil, err
+			}
+		}
+		previousSlice = slice
+		if obj[key], err = deserializeValue(deserializer); err != nil {
+			return nil, WrapDecodeErrorIndex(err, deserializer, i)
+		}
+	}
+	EndReadingMap(deserializer)
+	return obj, nil
+}
@@ -0,0 +1,164 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serializeStr(value string, serializer serde.Serializer) error {
+	return serializer.SerializeStr(value)
+}
+
+func TestSerializeVector(t *testing.T) {
+	s := bcs.NewSerializer()
+	require.NoError(t, serde.SerializeVector([]uint32{1, 827}, s, serializeU32))
+	assert.Equal(t, []byte{2, 1, 0, 0, 0, 59, 3, 0, 0}, s.GetBytes())
+
+	s = bcs.NewSerializer()
+	require.NoError(t, serde.SerializeVector([]uint32{}, s, serializeU32))
+	assert.Equal(t, []byte{0}, s.GetBytes())
+
+	t.Run("element error", func(t *testing.T) {
+		failure := errors.New("failure")
+		err := serde.SerializeVector([]uint32{1}, bcs.NewSerializer(), func(uint32, serde.Serializer) error { return failure })
+		assert.Equal(t, failure, err)
+	})
+}
+
+func TestSerializeOption(t *testing.T) {
+	s := bcs.NewSerializer()
+	require.NoError(t, serde.SerializeOption(nil, s, serializeU32))
+	assert.Equal(t, []byte{0}, s.GetBytes())
+
+	value := uint32(827)
+	s = bcs.NewSerializer()
+	require.NoError(t, serde.SerializeOption(&value, s, serializeU32))
+	assert.Equal(t, []byte{1, 59, 3, 0, 0}, s.GetBytes())
+}
+
+func TestSerializeMap(t *testing.T) {
+	value := map[string]uint32{"b": 2, "a": 1, "ab": 3}
+
+	// BCS sorts entries by their serialized bytes.
+	s := bcs.NewSerializer()
+	require.NoError(t, serde.SerializeMap(value, s, serializeStr, serializeU32))
+	assert.Equal(t, []byte{
+		3,
+		1, 'a', 1, 0, 0, 0,
+		1, 'b', 2, 0, 0, 0,
+		2, 'a', 'b', 3, 0, 0, 0,
+	}, s.GetBytes())
+
+	// Bincode leaves entries in iteration order.
+	s = bincode.NewSerializer()
+	require.NoError(t, serde.SerializeMap(value, s, serializeStr, serializeU32))
+	assert.Len(t, s.GetBytes(), 8+3*(8+4)+4)
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		// Distinct NaN keys have the same serialization.
+		value := map[float64]uint32{math.NaN(): 1, math.NaN(): 2}
+		serializeF64 := func(value float64, serializer serde.Serializer) error { return serializer.SerializeF64(value) }
+		err := serde.SerializeMap(value, bincode.NewSerializer(), serializeF64, serializeU32)
+		assert.EqualError(t, err, "Error while serializing map: duplicate keys")
+	})
+}
+
+func deserializeStr(deserializer serde.Deserializer) (string, error) {
+	return deserializer.DeserializeStr()
+}
+
+func TestDeserializeVector(t *testing.T) {
+	d := bcs.NewDeserializer([]byte{2, 1, 0, 0, 0, 59, 3, 0, 0})
+	value, err := serde.DeserializeVector(d, deserializeU32)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{1, 827}, value)
+
+	d = bcs.NewDeserializer([]byte{0})
+	value, err = serde.DeserializeVector(d, deserializeU32)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{}, value)
+
+	t.Run("deserialize error: EOF", func(t *testing.T) {
+		d := bcs.NewDeserializer([]byte{2, 1, 0, 0, 0, 59, 3})
+		_, err := serde.DeserializeVector(d, deserializeU32)
+		require.Error(t, err)
+	})
+
+	t.Run("deserialize error: large length without elements", func(t *testing.T) {
+		// A length of 2^31 - 1 must not be allocated upfront.
+		d := bcs.NewDeserializer([]byte{0xff, 0xff, 0xff, 0xff, 0x07})
+		_, err := serde.DeserializeVector(d, deserializeU32)
+		require.Error(t, err)
+	})
+}
+
+func TestDeserializeOption(t *testing.T) {
+	d := bcs.NewDeserializer([]byte{0})
+	value, err := serde.DeserializeOption(d, deserializeU32)
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	d = bcs.NewDeserializer([]byte{1, 59, 3, 0, 0})
+	value, err = serde.DeserializeOption(d, deserializeU32)
+	require.NoError(t, err)
+	require.NotNil(t, value)
+	assert.Equal(t, uint32(827), *value)
+
+	t.Run("deserialize error: invalid tag", func(t *testing.T) {
+		d := bcs.NewDeserializer([]byte{2, 59, 3, 0, 0})
+		_, err := serde.DeserializeOption(d, deserializeU32)
+		require.Error(t, err)
+	})
+}
+
+func TestDeserializeMap(t *testing.T) {
+	input := []byte{
+		3,
+		1, 'a', 1, 0, 0, 0,
+		1, 'b', 2, 0, 0, 0,
+		2, 'a', 'b', 3, 0, 0, 0,
+	}
+	d := bcs.NewDeserializer(input)
+	value, err := serde.DeserializeMap(d, deserializeStr, deserializeU32)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint32{"b": 2, "a": 1, "ab": 3}, value)
+
+	t.Run("deserialize error: keys not in canonical order", func(t *testing.T) {
+		d := bcs.NewDeserializer([]byte{
+			2,
+			1, 'b', 2, 0, 0, 0,
+			1, 'a', 1, 0, 0, 0,
+		})
+		_, err := serde.DeserializeMap(d, deserializeStr, deserializeU32)
+		require.Error(t, err)
+	})
+
+	t.Run("deserialize error: duplicate keys", func(t *testing.T) {
+		d := bcs.NewDeserializer([]byte{
+			2,
+			1, 'a', 2, 0, 0, 0,
+			1, 'a', 1, 0, 0, 0,
+		})
+		_, err := serde.DeserializeMap(d, deserializeStr, deserializeU32)
+		require.Error(t, err)
+	})
+
+	t.Run("bincode does not check ordering", func(t *testing.T) {
+		s := bincode.NewSerializer()
+		require.NoError(t, serde.SerializeMap(map[string]uint32{"b": 2, "a": 1}, s, serializeStr, serializeU32))
+		d := bincode.NewDeserializer(s.GetBytes())
+		value, err := serde.DeserializeMap(d, deserializeStr, deserializeU32)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]uint32{"b": 2, "a": 1}, value)
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DecodeError adds context to a deserialization error: the offset in the input
+// where the error was detected and the path of the value being decoded, e.g.
+// `Transaction.payload.args[3]`.
+type DecodeError struct {
+	Offset uint64
+	Path   string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%v (at offset %d)", e.Err, e.Offset)
+	}
+	return fmt.Sprintf("Error while decoding %s: %v (at offset %d)", e.Path, e.Err, e.Offset)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// WrapDecodeError records that `err` happened while decoding the field (or
+// index, e.g. `[3]`) `segment` of the current value. The offset of the error is
+// the current offset of the deserializer, unless `err` already has one.
+func WrapDecodeError(err error, deserializer Deserializer, segment string) error {
+	if e, ok := err.(*DecodeError); ok {
+		if e.Path != "" && !strings.HasPrefix(e.Path, "[") {
+			segment += "."
+		}
+		e.Path = segment + e.Path
+		return e
+	}
+	return &DecodeError{Offset: deserializer.GetBufferOffset(), Path: segment, Err: err}
+}
+
+// WrapDecodeErrorIndex is the same as `WrapDecodeError` for the element `index` of a sequence.
+func WrapDecodeErrorIndex(err error, deserializer Deserializer, index int) error {
+	return WrapDecodeError(err, deserializer, fmt.Sprintf("[%d]", index))
+}
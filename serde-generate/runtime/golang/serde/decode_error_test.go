@@ -0,0 +1,54 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeError(t *testing.T) {
+	deserializeBool := func(deserializer serde.Deserializer) (bool, error) {
+		return deserializer.DeserializeBool()
+	}
+	deserializeVector := func(deserializer serde.Deserializer) ([]bool, error) {
+		return serde.DeserializeVector(deserializer, deserializeBool)
+	}
+	d := bcs.NewDeserializer([]byte{2, 1, 1, 1, 2})
+	_, err := serde.DeserializeVector(d, deserializeVector)
+	require.Error(t, err)
+	err = serde.WrapDecodeError(err, d, "args")
+	err = serde.WrapDecodeError(err, d, "Transaction")
+
+	var decodeErr *serde.DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "Transaction.args[1][0]", decodeErr.Path)
+	assert.Equal(t, uint64(5), decodeErr.Offset)
+	assert.True(t, errors.Is(err, serde.ErrInvalidBool))
+	assert.EqualError(t, err, "Error while decoding Transaction.args[1][0]: invalid bool byte: expected 0 / 1, but got 2 (at offset 5)")
+}
+
+func TestTypeError(t *testing.T) {
+	d := bcs.NewDeserializer([]byte{7})
+	index, err := d.DeserializeVariantIndex()
+	require.NoError(t, err)
+	err = serde.WrapDecodeError(serde.UnknownVariantIndex("Choice", index), d, "Transaction")
+
+	var typeErr *serde.TypeError
+	require.True(t, errors.As(err, &typeErr))
+	assert.Equal(t, "Choice", typeErr.Type)
+	assert.True(t, errors.Is(err, serde.ErrUnknownVariant))
+	assert.False(t, errors.Is(err, io.ErrUnexpectedEOF))
+	assert.EqualError(t, err, "Error while decoding Transaction: Choice: unknown variant index 7 (at offset 1)")
+
+	err = serde.InvalidValue("Choice", "B", "expected 2 fields")
+	assert.True(t, errors.Is(err, serde.ErrInvalidValue))
+	assert.EqualError(t, err, "Choice.B: invalid value: expected 2 fields")
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DiagnosticError is returned by the deserializers created with
+// `NewDiagnosticDeserializer` when a primitive read fails. It is usually found
+// with `errors.As` since other errors (e.g. `DecodeError`) may wrap it.
+type DiagnosticError struct {
+	// The complete input of the deserializer.
+	Input []byte
+	// Offset where the failed read started. All the bytes before were consumed.
+	Offset uint64
+	// Method of the deserializer that failed, e.g. `DeserializeU32`.
+	Operation string
+	Err       error
+}
+
+// NewDiagnosticDeserializer returns a deserializer that forwards all calls to
+// `inner` and reports read failures as `*DiagnosticError`. `input` must be the
+// input of `inner`.
+func NewDiagnosticDeserializer(inner Deserializer, input []byte) Deserializer {
+	onRead := func(offset uint64, op string, value interface{}, err error) error {
+		var diagnostic *DiagnosticError
+		if err == nil || errors.As(err, &diagnostic) {
+			return err
+		}
+		return &DiagnosticError{Input: input, Offset: offset, Operation: op, Err: err}
+	}
+	return &tracingDeserializer{inner: inner, onRead: onRead}
+}
+
+func (e *DiagnosticError) Error() string {
+	return fmt.Sprintf("%v (%s at offset %d)", e.Err, e.Operation, e.Offset)
+}
+
+func (e *DiagnosticError) Unwrap() error {
+	return e.Err
+}
+
+// Hexdump returns the consumed input bytes in hexadecimal, 16 bytes per line, up
+// to the line of the failing offset, followed by a line marking the failing offset
+// with the failed operation.
+func (e *DiagnosticError) Hexdump() string {
+	var out strings.Builder
+	end := (e.Offset/16 + 1) * 16
+	if end > uint64(len(e.Input)) {
+		end = uint64(len(e.Input))
+	}
+	for line := uint64(0); line < end || line == 0; line += 16 {
+		fmt.Fprintf(&out, "%08x ", line)
+		for i := line; i < line+16 && i < end; i++ {
+			fmt.Fprintf(&out, " %02x", e.Input[i])
+		}
+		out.WriteByte('\n')
+	}
+	column := 9 + 3*(e.Offset%16)
+	fmt.Fprintf(&out, "%s^^ %s: %v\n", strings.Repeat(" ", int(column)+1), e.Operation, e.Err)
+	if remaining := uint64(len(e.Input)) - end; remaining > 0 {
+		fmt.Fprintf(&out, "(%d more bytes)\n", remaining)
+	}
+	return out.String()
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnosticDeserializer(t *testing.T) {
+	input := []byte{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
+		32, 33,
+	}
+	d := serde.NewDiagnosticDeserializer(bcs.NewDeserializer(input), input)
+	_, err := d.DeserializeFixedBytes(17)
+	require.NoError(t, err)
+	_, err = d.DeserializeBool()
+	require.Error(t, err)
+	err = serde.WrapDecodeError(err, d, "flag")
+
+	var diagnostic *serde.DiagnosticError
+	require.True(t, errors.As(err, &diagnostic))
+	assert.Equal(t, uint64(17), diagnostic.Offset)
+	assert.Equal(t, "DeserializeBool", diagnostic.Operation)
+	assert.True(t, errors.Is(err, serde.ErrInvalidBool))
+	assert.Equal(t, "invalid bool byte: expected 0 / 1, but got 17 (DeserializeBool at offset 17)", diagnostic.Error())
+	assert.Equal(t, `00000000  00 01 02 03 04 05 06 07 08 09 0a 0b 0c 0d 0e 0f
+00000010  10 11 12 13 14 15 16 17 18 19 1a 1b 1c 1d 1e 1f
+             ^^ DeserializeBool: invalid bool byte: expected 0 / 1, but got 17
+(2 more bytes)
+`, diagnostic.Hexdump())
+
+	t.Run("end of input", func(t *testing.T) {
+		d := serde.NewDiagnosticDeserializer(bcs.NewDeserializer(input[:2]), input[:2])
+		_, err := d.DeserializeU32()
+		var diagnostic *serde.DiagnosticError
+		require.True(t, errors.As(err, &diagnostic))
+		assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+		assert.Equal(t, `00000000  00 01
+          ^^ DeserializeU32: unexpected EOF
+`, diagnostic.Hexdump())
+	})
+}
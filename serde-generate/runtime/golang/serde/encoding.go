@@ -0,0 +1,87 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// 128-bit integers use decimal numbers as textual representation (see `encoding.TextMarshaler`)
+// and 16 bytes in little-endian order as binary representation (see `encoding.BinaryMarshaler`).
+
+func (v Uint128) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v *Uint128) UnmarshalText(text []byte) error {
+	value, err := parseTextInteger(text)
+	if err != nil {
+		return err
+	}
+	*v, err = Uint128FromBigInt(value)
+	return err
+}
+
+func (v Uint128) MarshalBinary() ([]byte, error) {
+	return encodeBinary128(v.High, v.Low), nil
+}
+
+func (v *Uint128) UnmarshalBinary(data []byte) error {
+	high, low, err := decodeBinary128(data)
+	if err != nil {
+		return err
+	}
+	*v = Uint128{High: high, Low: low}
+	return nil
+}
+
+func (v Int128) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v *Int128) UnmarshalText(text []byte) error {
+	value, err := parseTextInteger(text)
+	if err != nil {
+		return err
+	}
+	*v, err = Int128FromBigInt(value)
+	return err
+}
+
+func (v Int128) MarshalBinary() ([]byte, error) {
+	return encodeBinary128(uint64(v.High), v.Low), nil
+}
+
+func (v *Int128) UnmarshalBinary(data []byte) error {
+	high, low, err := decodeBinary128(data)
+	if err != nil {
+		return err
+	}
+	*v = Int128{High: int64(high), Low: low}
+	return nil
+}
+
+func parseTextInteger(text []byte) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(string(text), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer: %q", text)
+	}
+	return value, nil
+}
+
+func encodeBinary128(high uint64, low uint64) []byte {
+	data := Uint128{High: high, Low: low}.ToLEBytes()
+	return data[:]
+}
+
+func decodeBinary128(data []byte) (uint64, uint64, error) {
+	if len(data) != 16 {
+		return 0, 0, fmt.Errorf("invalid binary length: expected 16, but got %d", len(data))
+	}
+	var bytes [16]byte
+	copy(bytes[:], data)
+	v := Uint128FromLEBytes(bytes)
+	return v.High, v.Low, nil
+}
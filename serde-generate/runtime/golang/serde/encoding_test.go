@@ -0,0 +1,78 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"encoding"
+	"encoding/json"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ encoding.TextMarshaler     = serde.Uint128{}
+	_ encoding.TextUnmarshaler   = (*serde.Uint128)(nil)
+	_ encoding.BinaryMarshaler   = serde.Uint128{}
+	_ encoding.BinaryUnmarshaler = (*serde.Uint128)(nil)
+	_ encoding.TextMarshaler     = serde.Int128{}
+	_ encoding.TextUnmarshaler   = (*serde.Int128)(nil)
+	_ encoding.BinaryMarshaler   = serde.Int128{}
+	_ encoding.BinaryUnmarshaler = (*serde.Int128)(nil)
+)
+
+func TestUint128TextBinary(t *testing.T) {
+	value := serde.Uint128{High: 1, Low: 2}
+
+	text, err := value.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "18446744073709551618", string(text))
+	var fromText serde.Uint128
+	require.NoError(t, fromText.UnmarshalText(text))
+	assert.Equal(t, value, fromText)
+
+	data, err := value.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}, data)
+	var fromBinary serde.Uint128
+	require.NoError(t, fromBinary.UnmarshalBinary(data))
+	assert.Equal(t, value, fromBinary)
+
+	assert.EqualError(t, fromText.UnmarshalText([]byte("-1")), "value out of range for uint128")
+	assert.EqualError(t, fromText.UnmarshalText([]byte("0x12")), `invalid integer: "0x12"`)
+	assert.EqualError(t, fromBinary.UnmarshalBinary(data[:15]), "invalid binary length: expected 16, but got 15")
+}
+
+func TestInt128TextBinary(t *testing.T) {
+	value := serde.Int128FromInt64(-2)
+
+	text, err := value.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "-2", string(text))
+	var fromText serde.Int128
+	require.NoError(t, fromText.UnmarshalText(text))
+	assert.Equal(t, value, fromText)
+
+	data, err := value.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, data)
+	var fromBinary serde.Int128
+	require.NoError(t, fromBinary.UnmarshalBinary(data))
+	assert.Equal(t, value, fromBinary)
+
+	assert.EqualError(t, fromText.UnmarshalText([]byte("170141183460469231731687303715884105728")), "value out of range for int128")
+}
+
+func TestInt128MapKeys(t *testing.T) {
+	values := map[serde.Int128]string{serde.Int128FromInt64(-2): "a"}
+	data, err := json.Marshal(values)
+	require.NoError(t, err)
+	assert.Equal(t, `{"-2":"a"}`, string(data))
+
+	var result map[serde.Int128]string
+	require.NoError(t, json.Unmarshal(data, &result))
+	assert.Equal(t, values, result)
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Errors returned by the serialization runtimes. Some errors add context to these
+// values (e.g. the invalid byte that was read), hence they should be compared
+// using `errors.Is`.
+var (
+	// The encoding format does not support this kind of values.
+	ErrUnimplemented = errors.New("unimplemented")
+	// A length exceeds the maximum allowed length (see `MaxSequenceLength` in the runtimes).
+	ErrLengthExceeded = errors.New("length is too large")
+	// The input ended before the expected number of bytes could be read. This
+	// matches `io.ErrUnexpectedEOF`, which is returned when the input ends in the
+	// middle of a value: such inputs may succeed once more bytes are available.
+	ErrInputTooShort error = &refinedError{"input is too short", io.ErrUnexpectedEOF}
+	// Some input bytes were not consumed by the deserialization of a value.
+	ErrRemainingBytes = errors.New("Some input bytes were not read")
+	// A deserialized string is not valid UTF-8.
+	ErrInvalidUTF8 = errors.New("invalid UTF8 string")
+	// A bool byte is neither 0 nor 1.
+	ErrInvalidBool = errors.New("invalid bool byte")
+	// An option tag is neither 0 nor 1.
+	ErrInvalidOptionTag = errors.New("invalid option tag")
+	// A ULEB128-encoded number does not fit in the expected integer type.
+	ErrUleb128Overflow = errors.New("overflow while parsing uleb128-encoded value")
+	// Specializations of `ErrUleb128Overflow` for each integer type.
+	ErrUleb128OverflowU32 error = &refinedError{"overflow while parsing uleb128-encoded uint32 value", ErrUleb128Overflow}
+	ErrUleb128OverflowU64 error = &refinedError{"overflow while parsing uleb128-encoded uint64 value", ErrUleb128Overflow}
+	// A ULEB128-encoded number is not minimal.
+	ErrNonCanonicalUleb128 = errors.New("invalid uleb128 number (unexpected zero digit)")
+	// A SLEB128-encoded number does not fit in the expected integer type.
+	ErrSleb128Overflow = errors.New("overflow while parsing sleb128-encoded int64 value")
+	// Entering a container would exceed the maximum container depth.
+	ErrMaxDepthExceeded = errors.New("exceeded maximum container depth")
+	// The keys of a deserialized map are not in the order required by the encoding format.
+	ErrMapKeysNotOrdered = errors.New("Error while decoding map: keys are not serialized in the expected order")
+	// A map contains several keys with the same serialization.
+	ErrDuplicateMapKeys = errors.New("duplicate keys")
+	// A variant index or name does not belong to the enum being decoded or encoded.
+	ErrUnknownVariant = errors.New("unknown variant")
+	// A value (e.g. a JSON document) does not have the shape required by its type.
+	ErrInvalidValue = errors.New("invalid value")
+	// A nil pointer or slice was given where a value is required.
+	ErrNilValue = errors.New("nil value")
+)
+
+// TypeError is returned by generated code when a value does not match the definition of
+// the generated type `Type`, for instance because of an unknown variant index. This is how
+// schema mismatches can be told apart from failures of the underlying input or output, which
+// are returned as they are (or wrapped in a `DecodeError`).
+type TypeError struct {
+	// Name of the generated type, e.g. "Transaction".
+	Type string
+	// Name of the variant or field involved, if any.
+	Field string
+	Err   error
+}
+
+func (e *TypeError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %v", e.Type, e.Err)
+	}
+	return fmt.Sprintf("%s.%s: %v", e.Type, e.Field, e.Err)
+}
+
+func (e *TypeError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownVariantIndex returns the error for an unknown variant `index` of the enum `typeName`.
+func UnknownVariantIndex(typeName string, index uint32) error {
+	return &TypeError{Type: typeName, Err: fmt.Errorf("%w index %d", ErrUnknownVariant, index)}
+}
+
+// UnknownVariantName returns the error for an unknown variant `name` of the enum `typeName`.
+func UnknownVariantName(typeName string, name string) error {
+	return &TypeError{Type: typeName, Err: fmt.Errorf("%w %q", ErrUnknownVariant, name)}
+}
+
+// InvalidValue returns an error recording that a value of `typeName` (or of its variant or
+// field `field`, if not empty) is invalid for the given `reason`.
+func InvalidValue(typeName string, field string, reason string) error {
+	return &TypeError{Type: typeName, Field: field, Err: fmt.Errorf("%w: %s", ErrInvalidValue, reason)}
+}
+
+// `refinedError` has its own message but matches a more general error with `errors.Is`.
+type refinedError struct {
+	msg string
+	err error
+}
+
+func (e *refinedError) Error() string {
+	return e.msg
+}
+
+func (e *refinedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Large integers are formatted like `big.Int` values: `%v` and `%d` print decimal
+// numbers, while `%b`, `%o`, `%x` and `%X` print the value in the corresponding base.
+// `%#v` still prints the underlying Go struct.
+
+func (v Uint128) String() string {
+	return v.BigInt().String()
+}
+
+func (v Uint128) Format(s fmt.State, verb rune) {
+	formatBigInt(s, verb, v.BigInt(), fmt.Sprintf("serde.Uint128{High:0x%x, Low:0x%x}", v.High, v.Low))
+}
+
+func (v Int128) String() string {
+	return v.BigInt().String()
+}
+
+func (v Int128) Format(s fmt.State, verb rune) {
+	formatBigInt(s, verb, v.BigInt(), fmt.Sprintf("serde.Int128{High:%d, Low:0x%x}", v.High, v.Low))
+}
+
+func (v Uint256) String() string {
+	return v.BigInt().String()
+}
+
+func (v Uint256) Format(s fmt.State, verb rune) {
+	formatBigInt(s, verb, v.BigInt(), fmt.Sprintf("serde.Uint256{High:%#v, Low:%#v}", v.High, v.Low))
+}
+
+func (v Int256) String() string {
+	return v.BigInt().String()
+}
+
+func (v Int256) Format(s fmt.State, verb rune) {
+	formatBigInt(s, verb, v.BigInt(), fmt.Sprintf("serde.Int256{High:%#v, Low:%#v}", v.High, v.Low))
+}
+
+func formatBigInt(s fmt.State, verb rune, value *big.Int, goSyntax string) {
+	switch {
+	case verb == 'v' && s.Flag('#'):
+		fmt.Fprint(s, goSyntax)
+	case verb == 's':
+		// `big.Int` does not support `%s`: format the value as with `%d`, keeping flags,
+		// width and precision.
+		value.Format(s, 'd')
+	default:
+		value.Format(s, verb)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"errors"
+)
+
+// Int128FromInt64 converts `value` into an `Int128` (with sign extension).
+func Int128FromInt64(value int64) Int128 {
+	return Int128{High: value >> 63, Low: uint64(value)}
+}
+
+// Int64 converts `v` into an `int64`, failing if `v` is out of range.
+func (v Int128) Int64() (int64, error) {
+	if v.High != int64(v.Low)>>63 {
+		return 0, errors.New("value out of range for int64")
+	}
+	return int64(v.Low), nil
+}
+
+// Neg returns `-v`. As with Go integers, negating the minimal value
+// `-2^127` overflows and returns `-2^127`.
+func (v Int128) Neg() Int128 {
+	low := ^v.Low + 1
+	high := ^v.High
+	if low == 0 {
+		// Carry from the low bits.
+		high += 1
+	}
+	return Int128{High: high, Low: low}
+}
+
+// Abs returns the absolute value of `v`. The minimal value `-2^127` overflows
+// and is returned unchanged.
+func (v Int128) Abs() Int128 {
+	if v.High < 0 {
+		return v.Neg()
+	}
+	return v
+}
+
+// Sign returns -1, 0, or +1 depending on the sign of `v`.
+func (v Int128) Sign() int {
+	if v.High < 0 {
+		return -1
+	}
+	if v.High == 0 && v.Low == 0 {
+		return 0
+	}
+	return 1
+}
+
+// Cmp returns -1, 0, or +1 depending on whether `v` is lower than, equal to, or
+// greater than `other`.
+func (v Int128) Cmp(other Int128) int {
+	switch {
+	case v.High < other.High:
+		return -1
+	case v.High > other.High:
+		return 1
+	case v.Low < other.Low:
+		return -1
+	case v.Low > other.Low:
+		return 1
+	default:
+		return 0
+	}
+}
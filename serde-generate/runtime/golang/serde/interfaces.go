@@ -8,6 +8,12 @@ type Serializer interface {
 
 	SerializeBytes(value []byte) error
 
+	// Serialize a sequence of byte arrays, e.g. for the Rust type `Vec<Vec<u8>>`.
+	SerializeVecBytes(value [][]byte) error
+
+	// Serialize the given bytes as-is, without length prefix (e.g. for the Rust type `[u8; N]`).
+	SerializeFixedBytes(value []byte) error
+
 	SerializeBool(value bool) error
 
 	SerializeUnit(value struct{}) error
@@ -28,6 +34,8 @@ type Serializer interface {
 
 	SerializeU128(value Uint128) error
 
+	SerializeU256(value Uint256) error
+
 	SerializeI8(value int8) error
 
 	SerializeI16(value int16) error
@@ -38,6 +46,8 @@ type Serializer interface {
 
 	SerializeI128(value Int128) error
 
+	SerializeI256(value Int256) error
+
 	SerializeLen(value uint64) error
 
 	SerializeVariantIndex(value uint32) error
@@ -50,8 +60,12 @@ type Serializer interface {
 
 	GetBytes() []byte
 
+	// Enter a nested container (struct or enum variant). Hand-written implementations
+	// should call this before serializing nested values so that the depth limit is
+	// enforced. Returns `ErrMaxDepthExceeded` when the limit is reached.
 	IncreaseContainerDepth() error
 
+	// Leave a container entered with `IncreaseContainerDepth`.
 	DecreaseContainerDepth()
 }
 
@@ -60,6 +74,12 @@ type Deserializer interface {
 
 	DeserializeBytes() ([]byte, error)
 
+	// Deserialize a sequence of byte arrays, e.g. for the Rust type `Vec<Vec<u8>>`.
+	DeserializeVecBytes() ([][]byte, error)
+
+	// Deserialize exactly `n` bytes without length prefix (e.g. for the Rust type `[u8; N]`).
+	DeserializeFixedBytes(n uint64) ([]byte, error)
+
 	DeserializeBool() (bool, error)
 
 	DeserializeUnit() (struct{}, error)
@@ -80,6 +100,8 @@ type Deserializer interface {
 
 	DeserializeU128() (Uint128, error)
 
+	DeserializeU256() (Uint256, error)
+
 	DeserializeI8() (int8, error)
 
 	DeserializeI16() (int16, error)
@@ -90,21 +112,82 @@ type Deserializer interface {
 
 	DeserializeI128() (Int128, error)
 
+	DeserializeI256() (Int256, error)
+
 	DeserializeLen() (uint64, error)
 
+	// Same as `DeserializeLen` but for the number of entries of a map.
+	DeserializeMapLen() (uint64, error)
+
 	DeserializeVariantIndex() (uint32, error)
 
 	DeserializeOptionTag() (bool, error)
 
 	GetBufferOffset() uint64
 
+	// Return an error if some input bytes have not been consumed yet.
+	EndOfInput() error
+
+	// Return a deserializer of the same format confined to the input bytes between
+	// the offsets `start` and `end` (see `GetBufferOffset`). The new deserializer
+	// shares the limits of the current one, including the remaining container depth.
+	Slice(start, end uint64) (Deserializer, error)
+
 	CheckThatKeySlicesAreIncreasing(key1, key2 Slice) error
 
+	// Enter a nested container (struct or enum variant). Hand-written implementations
+	// should call this before deserializing nested values so that the depth limit is
+	// enforced. Returns `ErrMaxDepthExceeded` when the limit is reached.
 	IncreaseContainerDepth() error
 
+	// Leave a container entered with `IncreaseContainerDepth`.
 	DecreaseContainerDepth()
 }
 
+// `Serializable` is implemented by generated types (and possibly by user-defined types)
+// to serialize a value in any encoding format provided by a `Serializer`.
+type Serializable interface {
+	Serialize(serializer Serializer) error
+}
+
+// `Deserializable` is implemented by pointers to generated types (except enums, which
+// are Go interfaces) to read a value from any encoding format provided by a `Deserializer`.
+type Deserializable interface {
+	Deserialize(deserializer Deserializer) error
+}
+
+// `Validatable` is implemented by generated types when validation is enabled. `Validate`
+// checks the constraints of a value that Go types do not express (e.g. enum fields must be
+// set, C-style enums must hold a known variant) before the value is serialized.
+type Validatable interface {
+	Validate() error
+}
+
+// Validate calls `value.Validate()`, unless `value` is nil (e.g. an enum field that was
+// not set) in which case it returns `ErrNilValue`.
+func Validate(value Validatable) error {
+	if value == nil {
+		return ErrNilValue
+	}
+	return value.Validate()
+}
+
+// `TrailingFieldsDeserializer` is implemented by the deserializers that can be configured
+// to accept inputs without the trailing fields of a struct, e.g. records persisted with an
+// older version of a schema. Generated code then assigns declared default values to the
+// missing fields.
+type TrailingFieldsDeserializer interface {
+	AllowsMissingTrailingFields() bool
+}
+
+// IsMissingTrailingField reports whether the next field to be deserialized is missing
+// altogether: the input was entirely consumed and `deserializer` accepts missing trailing
+// fields (see `TrailingFieldsDeserializer`).
+func IsMissingTrailingField(deserializer Deserializer) bool {
+	d, ok := deserializer.(TrailingFieldsDeserializer)
+	return ok && d.AllowsMissingTrailingFields() && deserializer.EndOfInput() == nil
+}
+
 type Slice struct {
 	Start uint64
 	End   uint64
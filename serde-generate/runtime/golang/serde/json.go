@@ -0,0 +1,148 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package serde
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// 128-bit integers are encoded in JSON as decimal strings (e.g. `"1234"`) because
+// most JSON parsers cannot represent integers beyond 53 bits precisely.
+// For convenience, unquoted JSON numbers are also accepted when decoding. As usual with
+// `encoding/json`, decoding `null` leaves the value unchanged.
+
+func (v Uint128) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(v.String())), nil
+}
+
+func (v *Uint128) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseJSONInteger(data)
+	if err != nil {
+		return err
+	}
+	*v, err = Uint128FromBigInt(value)
+	return err
+}
+
+func (v Int128) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(v.String())), nil
+}
+
+func (v *Int128) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseJSONInteger(data)
+	if err != nil {
+		return err
+	}
+	*v, err = Int128FromBigInt(value)
+	return err
+}
+
+// Options are encoded in JSON like pointers, i.e. as `null` or as the inner value.
+func (o Option[T]) MarshalJSON() ([]byte, error) {
+	if !o.isSome {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+func (o *Option[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = None[T]()
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*o = Some(value)
+	return nil
+}
+
+// Helpers for the generated code of enums encoded in JSON without the default
+// `{"Variant": content}` representation.
+
+// MarshalJSONWithTag encodes `content` as a JSON object with the additional entry
+// `"<tag>": "<variant>"`, as for internally tagged enums in Serde (`#[serde(tag = "...")]`).
+// The content of unit variants is nil. Other contents must be encoded as JSON objects.
+func MarshalJSONWithTag(tag string, variant string, content interface{}) ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	if content != nil {
+		data, err := json.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+			return nil, fmt.Errorf("cannot add the tag %q to a JSON value that is not an object: %s", tag, data)
+		}
+		if _, ok := fields[tag]; ok {
+			return nil, fmt.Errorf("the tag %q conflicts with a field of variant %q", tag, variant)
+		}
+	}
+	name, err := json.Marshal(variant)
+	if err != nil {
+		return nil, err
+	}
+	fields[tag] = name
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSONTag returns the name of the variant given by the entry `tag` of the JSON
+// object `data` (see `MarshalJSONWithTag`).
+func UnmarshalJSONTag(data []byte, tag string) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	value, ok := fields[tag]
+	if !ok {
+		return "", fmt.Errorf("missing tag %q", tag)
+	}
+	var name string
+	if err := json.Unmarshal(value, &name); err != nil {
+		return "", fmt.Errorf("invalid tag %q: %w", tag, err)
+	}
+	return name, nil
+}
+
+// CheckJSONFields fails unless `data` is a JSON object with the given fields. This is used
+// to recognize the struct variants of untagged enums, since `json.Unmarshal` accepts
+// missing fields.
+func CheckJSONFields(data []byte, names ...string) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if fields == nil {
+		return fmt.Errorf("expected a JSON object")
+	}
+	for _, name := range names {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("missing field %q", name)
+		}
+	}
+	return nil
+}
+
+func parseJSONInteger(data []byte) (*big.Int, error) {
+	text := string(data)
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		text = text[1 : len(text)-1]
+	}
+	value, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid JSON integer: %s", data)
+	}
+	return value, nil
+}
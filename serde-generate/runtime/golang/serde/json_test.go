@@ -0,0 +1,182 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package serde_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalJSONWithTag(t *testing.T) {
+	data, err := serde.MarshalJSONWithTag("type", "Unit", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"Unit"}`, string(data))
+
+	content := struct {
+		X uint32 `json:"x"`
+	}{3}
+	data, err = serde.MarshalJSONWithTag("type", "Point", content)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"Point","x":3}`, string(data))
+
+	name, err := serde.UnmarshalJSONTag(data, "type")
+	require.NoError(t, err)
+	assert.Equal(t, "Point", name)
+
+	_, err = serde.MarshalJSONWithTag("type", "Number", 3)
+	assert.EqualError(t, err, `cannot add the tag "type" to a JSON value that is not an object: 3`)
+	_, err = serde.MarshalJSONWithTag("x", "Point", content)
+	assert.EqualError(t, err, `the tag "x" conflicts with a field of variant "Point"`)
+	_, err = serde.UnmarshalJSONTag([]byte(`{"x":3}`), "type")
+	assert.EqualError(t, err, `missing tag "type"`)
+	_, err = serde.UnmarshalJSONTag([]byte(`{"type":3}`), "type")
+	assert.Error(t, err)
+}
+
+func TestCheckJSONFields(t *testing.T) {
+	require.NoError(t, serde.CheckJSONFields([]byte(`{"x":1,"y":2}`), "x", "y"))
+	assert.EqualError(t, serde.CheckJSONFields([]byte(`{"x":1}`), "x", "y"), `missing field "y"`)
+	assert.EqualError(t, serde.CheckJSONFields([]byte(`null`)), "expected a JSON object")
+	assert.Error(t, serde.CheckJSONFields([]byte(`[1]`)))
+}
+
+func TestUint128JSON(t *testing.T) {
+	cases := []struct {
+		target   serde.Uint128
+		expected string
+	}{
+		{
+			target:   serde.Uint128{High: 0, Low: 0},
+			expected: `"0"`,
+		},
+		{
+			target:   serde.Uint128{High: 0, Low: 321243314},
+			expected: `"321243314"`,
+		},
+		{
+			target:   serde.Uint128{High: 1, Low: 0},
+			expected: `"18446744073709551616"`,
+		},
+		{
+			target:   serde.Uint128{High: ^uint64(0), Low: ^uint64(0)},
+			expected: `"340282366920938463463374607431768211455"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%#v", tc.target), func(t *testing.T) {
+			data, err := json.Marshal(tc.target)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(data))
+
+			var decoded serde.Uint128
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, tc.target, decoded)
+		})
+	}
+
+	t.Run("unquoted number", func(t *testing.T) {
+		var decoded serde.Uint128
+		require.NoError(t, json.Unmarshal([]byte(`18446744073709551617`), &decoded))
+		assert.Equal(t, serde.Uint128{High: 1, Low: 1}, decoded)
+	})
+
+	t.Run("null", func(t *testing.T) {
+		decoded := serde.Uint128{High: 0, Low: 7}
+		require.NoError(t, json.Unmarshal([]byte(`null`), &decoded))
+		assert.Equal(t, serde.Uint128{High: 0, Low: 7}, decoded)
+	})
+
+	t.Run("nested in a struct", func(t *testing.T) {
+		data, err := json.Marshal(struct{ Amount serde.Uint128 }{serde.Uint128{High: 0, Low: 7}})
+		require.NoError(t, err)
+		assert.Equal(t, `{"Amount":"7"}`, string(data))
+	})
+
+	t.Run("unmarshal error: out of range", func(t *testing.T) {
+		var decoded serde.Uint128
+		require.Error(t, json.Unmarshal([]byte(`"340282366920938463463374607431768211456"`), &decoded))
+		require.Error(t, json.Unmarshal([]byte(`"-1"`), &decoded))
+	})
+
+	t.Run("unmarshal error: invalid integer", func(t *testing.T) {
+		var decoded serde.Uint128
+		require.Error(t, json.Unmarshal([]byte(`"12a"`), &decoded))
+		require.Error(t, json.Unmarshal([]byte(`1.5`), &decoded))
+	})
+}
+
+func TestInt128JSON(t *testing.T) {
+	cases := []struct {
+		target   serde.Int128
+		expected string
+	}{
+		{
+			target:   serde.Int128{High: 0, Low: 0},
+			expected: `"0"`,
+		},
+		{
+			target:   serde.Int128{High: -1, Low: ^uint64(0)},
+			expected: `"-1"`,
+		},
+		{
+			target:   serde.Int128{High: -232, Low: 321243314},
+			expected: `"-4279644625100294731598"`,
+		},
+		{
+			target:   serde.Int128{High: -1 << 63, Low: 0},
+			expected: `"-170141183460469231731687303715884105728"`,
+		},
+		{
+			target:   serde.Int128{High: 1<<63 - 1, Low: ^uint64(0)},
+			expected: `"170141183460469231731687303715884105727"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%#v", tc.target), func(t *testing.T) {
+			data, err := json.Marshal(tc.target)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(data))
+
+			var decoded serde.Int128
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, tc.target, decoded)
+		})
+	}
+
+	t.Run("null", func(t *testing.T) {
+		decoded := struct{ Amount serde.Int128 }{serde.Int128{High: -1, Low: 0}}
+		require.NoError(t, json.Unmarshal([]byte(`{"Amount":null}`), &decoded))
+		assert.Equal(t, serde.Int128{High: -1, Low: 0}, decoded.Amount)
+	})
+
+	t.Run("unmarshal error: out of range", func(t *testing.T) {
+		var decoded serde.Int128
+		require.Error(t, json.Unmarshal([]byte(`"170141183460469231731687303715884105728"`), &decoded))
+		require.Error(t, json.Unmarshal([]byte(`"-170141183460469231731687303715884105729"`), &decoded))
+	})
+}
+
+func TestOptionJSON(t *testing.T) {
+	type record struct {
+		A serde.Option[uint32] `json:"a"`
+		B serde.Option[string] `json:"b"`
+	}
+	data, err := json.Marshal(record{A: serde.Some(uint32(3))})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":3,"b":null}`, string(data))
+
+	var value record
+	require.NoError(t, json.Unmarshal([]byte(`{"a":null,"b":"x"}`), &value))
+	assert.Equal(t, record{B: serde.Some("x")}, value)
+	assert.Error(t, json.Unmarshal([]byte(`{"a":"x"}`), &value))
+}
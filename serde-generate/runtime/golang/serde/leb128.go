@@ -0,0 +1,87 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+// LEB128 variable-length encodings, to be used by binary formats extending
+// `BinarySerializer` and `BinaryDeserializer`.
+
+// SerializeSleb128 writes `value` using the signed LEB128 encoding.
+func (s *BinarySerializer) SerializeSleb128(value int64) {
+	for {
+		b := byte(value & 0x7f)
+		// Arithmetic shift: the sign is preserved.
+		value >>= 7
+		if (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0) {
+			_ = s.Buffer.WriteByte(b)
+			return
+		}
+		_ = s.Buffer.WriteByte(b | 0x80)
+	}
+}
+
+// DeserializeSleb128 reads a signed LEB128-encoded value.
+func (d *BinaryDeserializer) DeserializeSleb128() (int64, error) {
+	var value int64
+	for shift := 0; shift < 64; shift += 7 {
+		b, err := d.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if shift == 63 {
+			// The last byte may only carry the sign bit.
+			switch b {
+			case 0x00:
+				return value, nil
+			case 0x7f:
+				return value | (-1 << 63), nil
+			default:
+				return 0, ErrSleb128Overflow
+			}
+		}
+		value |= int64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			if b&0x40 != 0 {
+				// Sign extension.
+				value |= -1 << (shift + 7)
+			}
+			return value, nil
+		}
+	}
+	return 0, ErrSleb128Overflow
+}
+
+// SerializeUleb128 writes `value` using the unsigned LEB128 encoding.
+func (s *BinarySerializer) SerializeUleb128(value uint64) {
+	for value >= 0x80 {
+		b := byte((value & 0x7f) | 0x80)
+		_ = s.Buffer.WriteByte(b)
+		value = value >> 7
+	}
+	_ = s.Buffer.WriteByte(byte(value))
+}
+
+// DeserializeUleb128 reads an unsigned LEB128-encoded value. If `canonical` is true,
+// non-minimal encodings (i.e. with trailing zero digits) are rejected.
+func (d *BinaryDeserializer) DeserializeUleb128(canonical bool) (uint64, error) {
+	var value uint64
+	for shift := 0; shift < 64; shift += 7 {
+		byte, err := d.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		digit := byte & 0x7F
+		if shift == 63 && digit > 1 {
+			return 0, ErrUleb128OverflowU64
+		}
+		value = value | (uint64(digit) << shift)
+
+		if digit == byte {
+			if canonical && shift > 0 && digit == 0 {
+				return 0, ErrNonCanonicalUleb128
+			}
+			return value, nil
+		}
+	}
+	return 0, ErrUleb128OverflowU64
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSleb128(t *testing.T) {
+	cases := []struct {
+		value    int64
+		expected []byte
+	}{
+		{value: 0, expected: []byte{0x00}},
+		{value: 2, expected: []byte{0x02}},
+		{value: -2, expected: []byte{0x7e}},
+		{value: 63, expected: []byte{0x3f}},
+		{value: -64, expected: []byte{0x40}},
+		{value: 64, expected: []byte{0xc0, 0x00}},
+		{value: 127, expected: []byte{0xff, 0x00}},
+		{value: -127, expected: []byte{0x81, 0x7f}},
+		{value: 128, expected: []byte{0x80, 0x01}},
+		{value: -128, expected: []byte{0x80, 0x7f}},
+		{value: math.MaxInt64, expected: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}},
+		{value: math.MinInt64, expected: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%d", tc.value), func(t *testing.T) {
+			s := serde.NewBinarySerializer(0)
+			s.SerializeSleb128(tc.value)
+			assert.Equal(t, tc.expected, s.Buffer.Bytes())
+
+			d := serde.NewBinaryDeserializer(tc.expected, 0)
+			value, err := d.DeserializeSleb128()
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestSleb128Errors(t *testing.T) {
+	cases := []struct {
+		input []byte
+		err   string
+	}{
+		{input: []byte{}, err: "unexpected EOF"},
+		{input: []byte{0x80}, err: "unexpected EOF"},
+		{input: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}, err: "overflow while parsing sleb128-encoded int64 value"},
+		{input: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x80, 0x00}, err: "overflow while parsing sleb128-encoded int64 value"},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%x", tc.input), func(t *testing.T) {
+			d := serde.NewBinaryDeserializer(tc.input, 0)
+			_, err := d.DeserializeSleb128()
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestUleb128(t *testing.T) {
+	cases := []struct {
+		value    uint64
+		expected []byte
+	}{
+		{value: 0, expected: []byte{0x00}},
+		{value: 127, expected: []byte{0x7f}},
+		{value: 128, expected: []byte{0x80, 0x01}},
+		{value: 16384, expected: []byte{0x80, 0x80, 0x01}},
+		{value: math.MaxUint32, expected: []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{value: math.MaxUint32 + 1, expected: []byte{0x80, 0x80, 0x80, 0x80, 0x10}},
+		{value: math.MaxUint64, expected: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%d", tc.value), func(t *testing.T) {
+			s := serde.NewBinarySerializer(0)
+			s.SerializeUleb128(tc.value)
+			assert.Equal(t, tc.expected, s.Buffer.Bytes())
+
+			d := serde.NewBinaryDeserializer(tc.expected, 0)
+			value, err := d.DeserializeUleb128(true)
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestUleb128Errors(t *testing.T) {
+	cases := []struct {
+		input []byte
+		err   string
+	}{
+		{input: []byte{}, err: "unexpected EOF"},
+		{input: []byte{0x80}, err: "unexpected EOF"},
+		{input: []byte{0x80, 0x00}, err: "invalid uleb128 number (unexpected zero digit)"},
+		{input: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}, err: "overflow while parsing uleb128-encoded uint64 value"},
+		{input: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x81, 0x00}, err: "overflow while parsing uleb128-encoded uint64 value"},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%x", tc.input), func(t *testing.T) {
+			d := serde.NewBinaryDeserializer(tc.input, 0)
+			_, err := d.DeserializeUleb128(true)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestUleb128NonCanonical(t *testing.T) {
+	d := serde.NewBinaryDeserializer([]byte{0x81, 0x80, 0x00}, 0)
+	value, err := d.DeserializeUleb128(false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), value)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import "fmt"
+
+// `Option` represents an optional value of type `T`.
+// Contrary to a pointer `*T`, the zero value of `Option[T]` is always "none" and
+// a "some" value never aliases other data.
+type Option[T any] struct {
+	value  T
+	isSome bool
+}
+
+func Some[T any](value T) Option[T] {
+	return Option[T]{value: value, isSome: true}
+}
+
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// OptionFromPtr returns `None` for a nil pointer and `Some(*ptr)` otherwise.
+func OptionFromPtr[T any](ptr *T) Option[T] {
+	if ptr == nil {
+		return None[T]()
+	}
+	return Some(*ptr)
+}
+
+func (o Option[T]) IsSome() bool {
+	return o.isSome
+}
+
+func (o Option[T]) IsNone() bool {
+	return !o.isSome
+}
+
+// Get returns the inner value (if any) and whether the option is "some".
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.isSome
+}
+
+// GetOr returns the inner value, or `fallback` for a "none" value.
+func (o Option[T]) GetOr(fallback T) T {
+	if o.isSome {
+		return o.value
+	}
+	return fallback
+}
+
+// Ptr returns a pointer to a copy of the inner value, or nil for a "none" value.
+func (o Option[T]) Ptr() *T {
+	if !o.isSome {
+		return nil
+	}
+	value := o.value
+	return &value
+}
+
+// String displays the option as `Some(value)` or `None`.
+func (o Option[T]) String() string {
+	if !o.isSome {
+		return "None"
+	}
+	return fmt.Sprintf("Some(%v)", o.value)
+}
+
+// MapOption applies `f` to the inner value of `o` (if any).
+func MapOption[T, U any](o Option[T], f func(T) U) Option[U] {
+	if !o.isSome {
+		return None[U]()
+	}
+	return Some(f(o.value))
+}
+
+// `serializeValue` is called on the inner value (if any), after the option tag.
+func (o Option[T]) Serialize(serializer Serializer, serializeValue func(T, Serializer) error) error {
+	if err := serializer.SerializeOptionTag(o.isSome); err != nil {
+		return err
+	}
+	if o.isSome {
+		return serializeValue(o.value, serializer)
+	}
+	return nil
+}
+
+// `deserializeValue` is called to read the inner value, if the option tag is set.
+func (o *Option[T]) Deserialize(deserializer Deserializer, deserializeValue func(Deserializer) (T, error)) error {
+	tag, err := deserializer.DeserializeOptionTag()
+	if err != nil {
+		return err
+	}
+	if !tag {
+		*o = None[T]()
+		return nil
+	}
+	value, err := deserializeValue(deserializer)
+	if err != nil {
+		return err
+	}
+	*o = Some(value)
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serializeU32(value uint32, serializer serde.Serializer) error {
+	return serializer.SerializeU32(value)
+}
+
+func deserializeU32(deserializer serde.Deserializer) (uint32, error) {
+	return deserializer.DeserializeU32()
+}
+
+func TestOption(t *testing.T) {
+	var zero serde.Option[uint32]
+	assert.True(t, zero.IsNone())
+	assert.Equal(t, serde.None[uint32](), zero)
+
+	some := serde.Some(uint32(7))
+	assert.True(t, some.IsSome())
+	value, ok := some.Get()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(7), value)
+	_, ok = zero.Get()
+	assert.False(t, ok)
+
+	assert.Equal(t, uint32(7), some.GetOr(3))
+	assert.Equal(t, uint32(3), zero.GetOr(3))
+
+	assert.Equal(t, serde.Some("7"), serde.MapOption(some, func(v uint32) string { return strconv.Itoa(int(v)) }))
+	assert.Equal(t, serde.None[string](), serde.MapOption(zero, func(v uint32) string { return strconv.Itoa(int(v)) }))
+
+	assert.Nil(t, zero.Ptr())
+	ptr := some.Ptr()
+	require.NotNil(t, ptr)
+	*ptr = 8
+	assert.Equal(t, serde.Some(uint32(7)), some)
+	assert.Equal(t, serde.Some(uint32(8)), serde.OptionFromPtr(ptr))
+	assert.Equal(t, zero, serde.OptionFromPtr[uint32](nil))
+
+	assert.Equal(t, "Some(7)", some.String())
+	assert.Equal(t, "None", zero.String())
+}
+
+func TestSerializeDeserializeOption(t *testing.T) {
+	cases := []struct {
+		target   serde.Option[uint32]
+		expected []byte
+	}{
+		{
+			target:   serde.None[uint32](),
+			expected: []byte{0},
+		},
+		{
+			target:   serde.Some(uint32(827)),
+			expected: []byte{1, 59, 3, 0, 0},
+		},
+	}
+
+	for _, tc := range cases {
+		s := bcs.NewSerializer()
+		require.NoError(t, tc.target.Serialize(s, serializeU32))
+		assert.Equal(t, tc.expected, s.GetBytes())
+
+		var deserialized serde.Option[uint32]
+		d := bcs.NewDeserializer(tc.expected)
+		require.NoError(t, deserialized.Deserialize(d, deserializeU32))
+		assert.Equal(t, tc.target, deserialized)
+	}
+
+	t.Run("deserialize error: invalid tag", func(t *testing.T) {
+		var deserialized serde.Option[uint32]
+		d := bcs.NewDeserializer([]byte{2, 59, 3, 0, 0})
+		require.Error(t, deserialized.Deserialize(d, deserializeU32))
+	})
+
+	t.Run("deserialize error: EOF", func(t *testing.T) {
+		var deserialized serde.Option[uint32]
+		d := bcs.NewDeserializer([]byte{1, 59})
+		require.Error(t, deserialized.Deserialize(d, deserializeU32))
+	})
+}
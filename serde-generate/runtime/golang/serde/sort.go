@@ -0,0 +1,79 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// SortMapEntries sorts the serialized map entries found at the end of `data` in
+// place, using the lexicographic order of their bytes, as required by canonical
+// encodings such as BCS.
+//
+// Each entry `i` spans `data[offsets[i]:offsets[i+1]]`, with the last entry ending
+// at `len(data)`. Offsets must be increasing.
+func SortMapEntries(data []byte, offsets []uint64) {
+	if len(offsets) <= 1 {
+		return
+	}
+	slices := make([]Slice, len(offsets))
+	for i, v := range offsets {
+		var w uint64
+		if i+1 < len(offsets) {
+			w = offsets[i+1]
+		} else {
+			w = uint64(len(data))
+		}
+		slices[i] = Slice{Start: v, End: w}
+	}
+	entries := mapEntries{data, slices}
+	sort.Sort(entries)
+	buffer := make([]byte, 0, len(data)-int(offsets[0]))
+	for _, slice := range entries.slices {
+		buffer = append(buffer, data[slice.Start:slice.End]...)
+	}
+	copy(data[offsets[0]:], buffer)
+}
+
+// SortMapEntriesStrict is similar to `SortMapEntries` but first checks that the
+// serialized keys are distinct. Each key `i` spans `data[offsets[i]:keyEnds[i]]`.
+func SortMapEntriesStrict(data []byte, offsets []uint64, keyEnds []uint64) error {
+	if err := checkDistinctMapKeys(data, offsets, keyEnds); err != nil {
+		return err
+	}
+	SortMapEntries(data, offsets)
+	return nil
+}
+
+func checkDistinctMapKeys(data []byte, offsets []uint64, keyEnds []uint64) error {
+	if len(offsets) <= 1 {
+		return nil
+	}
+	keys := make(map[string]bool, len(offsets))
+	for i, start := range offsets {
+		key := string(data[start:keyEnds[i]])
+		if keys[key] {
+			return fmt.Errorf("Error while serializing map: %w", ErrDuplicateMapKeys)
+		}
+		keys[key] = true
+	}
+	return nil
+}
+
+type mapEntries struct {
+	data   []byte
+	slices []Slice
+}
+
+func (a mapEntries) Len() int { return len(a.slices) }
+
+func (a mapEntries) Less(i, j int) bool {
+	slice_i := a.data[a.slices[i].Start:a.slices[i].End]
+	slice_j := a.data[a.slices[j].Start:a.slices[j].End]
+	return bytes.Compare(slice_i, slice_j) < 0
+}
+
+func (a mapEntries) Swap(i, j int) { a.slices[i], a.slices[j] = a.slices[j], a.slices[i] }
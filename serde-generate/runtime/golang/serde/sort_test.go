@@ -0,0 +1,36 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortMapEntries(t *testing.T) {
+	// A header followed by the entries [3, 1], [1, 2, 2], [], [1, 2].
+	data := []byte{9, 9, 3, 1, 1, 2, 2, 1, 2}
+	serde.SortMapEntries(data, []uint64{2, 4, 7, 7})
+	assert.Equal(t, []byte{9, 9, 1, 2, 1, 2, 2, 3, 1}, data)
+
+	single := []byte{9, 3, 1}
+	serde.SortMapEntries(single, []uint64{1})
+	assert.Equal(t, []byte{9, 3, 1}, single)
+
+	serde.SortMapEntries(nil, nil)
+}
+
+func TestSortMapEntriesStrict(t *testing.T) {
+	data := []byte{9, 3, 1, 1, 2, 2}
+	require.NoError(t, serde.SortMapEntriesStrict(data, []uint64{1, 3}, []uint64{2, 4}))
+	assert.Equal(t, []byte{9, 1, 2, 2, 3, 1}, data)
+
+	// The entries [1, 2] and [1, 3] have the same key [1].
+	data = []byte{9, 1, 2, 1, 3}
+	assert.EqualError(t, serde.SortMapEntriesStrict(data, []uint64{1, 3}, []uint64{2, 4}), "Error while serializing map: duplicate keys")
+	assert.Equal(t, []byte{9, 1, 2, 1, 3}, data)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package serde
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// By default, `Uint128` values are stored in SQL databases as decimal strings, which
+// fits NUMERIC / DECIMAL(39, 0) columns.
+//
+// Use `Uint128Blob` instead to store values as 16-byte little-endian blobs (the same
+// layout as the BCS and Bincode encodings).
+
+// Value implements `driver.Valuer`.
+func (v Uint128) Value() (driver.Value, error) {
+	return v.String(), nil
+}
+
+// Scan implements `sql.Scanner`.
+func (v *Uint128) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case int64:
+		if src < 0 {
+			return fmt.Errorf("value out of range for uint128: %d", src)
+		}
+		*v = Uint128{High: 0, Low: uint64(src)}
+		return nil
+	case string:
+		return v.UnmarshalText([]byte(src))
+	case []byte:
+		return v.UnmarshalText(src)
+	default:
+		return fmt.Errorf("cannot scan %T into serde.Uint128", src)
+	}
+}
+
+// Uint128Blob is a `Uint128` stored in SQL databases as a 16-byte little-endian blob.
+type Uint128Blob Uint128
+
+// Value implements `driver.Valuer`.
+func (v Uint128Blob) Value() (driver.Value, error) {
+	return encodeBinary128(v.High, v.Low), nil
+}
+
+// Scan implements `sql.Scanner`.
+func (v *Uint128Blob) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into serde.Uint128Blob", src)
+	}
+	return (*Uint128)(v).UnmarshalBinary(data)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package serde_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ driver.Valuer = serde.Uint128{}
+	_ sql.Scanner   = (*serde.Uint128)(nil)
+	_ driver.Valuer = serde.Uint128Blob{}
+	_ sql.Scanner   = (*serde.Uint128Blob)(nil)
+)
+
+func TestUint128SQL(t *testing.T) {
+	value := serde.Uint128{High: 1, Low: 2}
+	stored, err := value.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "18446744073709551618", stored)
+
+	for _, src := range []interface{}{"18446744073709551618", []byte("18446744073709551618")} {
+		var result serde.Uint128
+		require.NoError(t, result.Scan(src))
+		assert.Equal(t, value, result)
+	}
+
+	var small serde.Uint128
+	require.NoError(t, small.Scan(int64(42)))
+	assert.Equal(t, serde.Uint128{High: 0, Low: 42}, small)
+
+	var result serde.Uint128
+	assert.EqualError(t, result.Scan(int64(-1)), "value out of range for uint128: -1")
+	assert.EqualError(t, result.Scan("340282366920938463463374607431768211456"), "value out of range for uint128")
+	assert.EqualError(t, result.Scan("12a"), `invalid integer: "12a"`)
+	assert.EqualError(t, result.Scan(1.5), "cannot scan float64 into serde.Uint128")
+}
+
+func TestUint128BlobSQL(t *testing.T) {
+	value := serde.Uint128Blob{High: 1, Low: 2}
+	stored, err := value.Value()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}, stored)
+
+	var result serde.Uint128Blob
+	require.NoError(t, result.Scan(stored))
+	assert.Equal(t, value, result)
+
+	assert.EqualError(t, result.Scan([]byte{1, 2, 3}), "invalid binary length: expected 16, but got 3")
+	assert.EqualError(t, result.Scan("abc"), "cannot scan string into serde.Uint128Blob")
+}
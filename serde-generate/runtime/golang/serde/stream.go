@@ -0,0 +1,145 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"bytes"
+	"io"
+)
+
+// Number of buffered bytes above which `StreamSerializer` writes to the underlying writer.
+const streamFlushThreshold = 4096
+
+// Implemented by the serializers that must know where the entries of a map start
+// (see `BeginMap`).
+type mapBeginner interface {
+	BeginMap()
+}
+
+// BeginMap tells `serializer` that the entries of a map follow, up to the next call to
+// `SortMapEntries`. `SerializeMap` does so. Other code serializing maps must call it after
+// serializing the length of the map, for `StreamSerializer` to keep the entries until they
+// are sorted. It does nothing for the other serializers.
+func BeginMap(serializer Serializer) {
+	if s, ok := serializer.(mapBeginner); ok {
+		s.BeginMap()
+	}
+}
+
+// Implemented by the deserializers that must know where the entries of a map start
+// and end (see `BeginReadingMap`).
+type mapReader interface {
+	BeginMap()
+	EndMap()
+}
+
+// BeginReadingMap tells `deserializer` that the entries of a map follow, up to the call
+// to `EndReadingMap`. `DeserializeMap` does so. Other code deserializing maps must call it
+// after deserializing the length of the map, for deserializers reading from an `io.Reader`
+// (see `NewBinaryStreamDeserializer`) to retain the keys until they are compared. Otherwise,
+// `CheckThatKeySlicesAreIncreasing` may fail with `ErrInputDiscarded`.
+func BeginReadingMap(deserializer Deserializer) {
+	if d, ok := deserializer.(mapReader); ok {
+		d.BeginMap()
+	}
+}
+
+// EndReadingMap tells `deserializer` that the entries of the map announced by
+// `BeginReadingMap` were read.
+func EndReadingMap(deserializer Deserializer) {
+	if d, ok := deserializer.(mapReader); ok {
+		d.EndMap()
+	}
+}
+
+// Implemented by the serializers that embed `BinarySerializer`.
+type bufferedSerializer interface {
+	Serializer
+	binaryBuffer() *bytes.Buffer
+}
+
+// StreamSerializer extends a binary serializer (e.g. `bcs.NewSerializer()`) so that
+// serialized bytes are written to an `io.Writer` as the serialization progresses,
+// rather than being kept in memory until the end. Bytes are only retained while they
+// may still be reordered, that is, during the serialization of map entries (between
+// `BeginMap` and `SortMapEntries`).
+//
+// Call `Flush` once the value is serialized. Offsets (see `GetBufferOffset`) and the
+// result of `GetBytes` only cover the bytes that were not written yet.
+type StreamSerializer struct {
+	bufferedSerializer
+	writer io.Writer
+	// Number of maps whose entries are being serialized.
+	openMaps int
+	// First error returned by `writer`.
+	err error
+}
+
+// NewStreamSerializer writes the output of `serializer` to `writer`. The serializer
+// must be a binary serializer provided by this module, otherwise the function panics.
+func NewStreamSerializer(serializer Serializer, writer io.Writer) *StreamSerializer {
+	inner, ok := serializer.(bufferedSerializer)
+	if !ok {
+		panic("serde: NewStreamSerializer requires a binary serializer")
+	}
+	return &StreamSerializer{bufferedSerializer: inner, writer: writer}
+}
+
+func (s *StreamSerializer) BeginMap() {
+	s.openMaps++
+}
+
+func (s *StreamSerializer) SortMapEntries(offsets []uint64) {
+	s.bufferedSerializer.SortMapEntries(offsets)
+	if s.openMaps > 0 {
+		s.openMaps--
+	}
+	s.flushIfNeeded()
+}
+
+// IncreaseContainerDepth also reports the errors of the underlying writer so that
+// serialization stops early.
+func (s *StreamSerializer) IncreaseContainerDepth() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.bufferedSerializer.IncreaseContainerDepth()
+}
+
+func (s *StreamSerializer) DecreaseContainerDepth() {
+	s.bufferedSerializer.DecreaseContainerDepth()
+	s.flushIfNeeded()
+}
+
+func (s *StreamSerializer) SerializeLen(value uint64) error {
+	if err := s.bufferedSerializer.SerializeLen(value); err != nil {
+		return err
+	}
+	s.flushIfNeeded()
+	return s.err
+}
+
+func (s *StreamSerializer) flushIfNeeded() {
+	if s.openMaps == 0 && s.binaryBuffer().Len() >= streamFlushThreshold {
+		s.write()
+	}
+}
+
+func (s *StreamSerializer) write() {
+	if s.err != nil {
+		return
+	}
+	buffer := s.binaryBuffer()
+	if _, err := s.writer.Write(buffer.Bytes()); err != nil {
+		s.err = err
+	}
+	buffer.Reset()
+}
+
+// Flush writes the remaining bytes and returns the first error of the underlying writer.
+func (s *StreamSerializer) Flush() error {
+	s.openMaps = 0
+	s.write()
+	return s.err
+}
@@ -0,0 +1,348 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+// `tracingSerializer` decorates a `Serializer` to log every primitive write.
+type tracingSerializer struct {
+	inner  Serializer
+	logger *log.Logger
+}
+
+// NewTracingSerializer returns a serializer that forwards all calls to `inner` and
+// logs each primitive operation with the offset where it starts and its value.
+func NewTracingSerializer(inner Serializer, logger *log.Logger) Serializer {
+	return &tracingSerializer{inner: inner, logger: logger}
+}
+
+// `tracingDeserializer` decorates a `Deserializer` to observe every primitive read.
+type tracingDeserializer struct {
+	inner Deserializer
+	// Called after each primitive read with the offset where the read started.
+	// Returns the error to be reported to the caller.
+	onRead func(offset uint64, op string, value interface{}, err error) error
+}
+
+// NewTracingDeserializer returns a deserializer that forwards all calls to `inner` and
+// logs each primitive operation with the offset where it starts and the value read.
+// This is meant to compare the interpretation of some input bytes with another
+// implementation (e.g. the Rust reference) when debugging interoperability issues.
+func NewTracingDeserializer(inner Deserializer, logger *log.Logger) Deserializer {
+	onRead := func(offset uint64, op string, value interface{}, err error) error {
+		if err != nil {
+			logger.Printf("%d: %s: %v", offset, op, err)
+		} else {
+			logger.Printf("%d: %s = %s", offset, op, formatTracedValue(value))
+		}
+		return err
+	}
+	return &tracingDeserializer{inner: inner, onRead: onRead}
+}
+
+func formatTracedValue(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return hex.EncodeToString(v)
+	case [][]byte:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = hex.EncodeToString(item)
+		}
+		return fmt.Sprint(items)
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func traceWrite[T any](s *tracingSerializer, op string, value T, write func(T) error) error {
+	offset := s.inner.GetBufferOffset()
+	err := write(value)
+	if err != nil {
+		s.logger.Printf("%d: %s(%s): %v", offset, op, formatTracedValue(value), err)
+	} else {
+		s.logger.Printf("%d: %s(%s)", offset, op, formatTracedValue(value))
+	}
+	return err
+}
+
+func traceRead[T any](d *tracingDeserializer, op string, read func() (T, error)) (T, error) {
+	offset := d.inner.GetBufferOffset()
+	value, err := read()
+	return value, d.onRead(offset, op, value, err)
+}
+
+func (s *tracingSerializer) SerializeStr(value string) error {
+	return traceWrite(s, "SerializeStr", value, s.inner.SerializeStr)
+}
+
+func (s *tracingSerializer) SerializeBytes(value []byte) error {
+	return traceWrite(s, "SerializeBytes", value, s.inner.SerializeBytes)
+}
+
+func (s *tracingSerializer) SerializeVecBytes(value [][]byte) error {
+	return traceWrite(s, "SerializeVecBytes", value, s.inner.SerializeVecBytes)
+}
+
+func (s *tracingSerializer) SerializeFixedBytes(value []byte) error {
+	return traceWrite(s, "SerializeFixedBytes", value, s.inner.SerializeFixedBytes)
+}
+
+func (s *tracingSerializer) SerializeBool(value bool) error {
+	return traceWrite(s, "SerializeBool", value, s.inner.SerializeBool)
+}
+
+func (s *tracingSerializer) SerializeUnit(value struct{}) error {
+	return traceWrite(s, "SerializeUnit", value, s.inner.SerializeUnit)
+}
+
+func (s *tracingSerializer) SerializeChar(value rune) error {
+	return traceWrite(s, "SerializeChar", value, s.inner.SerializeChar)
+}
+
+func (s *tracingSerializer) SerializeF32(value float32) error {
+	return traceWrite(s, "SerializeF32", value, s.inner.SerializeF32)
+}
+
+func (s *tracingSerializer) SerializeF64(value float64) error {
+	return traceWrite(s, "SerializeF64", value, s.inner.SerializeF64)
+}
+
+func (s *tracingSerializer) SerializeU8(value uint8) error {
+	return traceWrite(s, "SerializeU8", value, s.inner.SerializeU8)
+}
+
+func (s *tracingSerializer) SerializeU16(value uint16) error {
+	return traceWrite(s, "SerializeU16", value, s.inner.SerializeU16)
+}
+
+func (s *tracingSerializer) SerializeU32(value uint32) error {
+	return traceWrite(s, "SerializeU32", value, s.inner.SerializeU32)
+}
+
+func (s *tracingSerializer) SerializeU64(value uint64) error {
+	return traceWrite(s, "SerializeU64", value, s.inner.SerializeU64)
+}
+
+func (s *tracingSerializer) SerializeU128(value Uint128) error {
+	return traceWrite(s, "SerializeU128", value, s.inner.SerializeU128)
+}
+
+func (s *tracingSerializer) SerializeU256(value Uint256) error {
+	return traceWrite(s, "SerializeU256", value, s.inner.SerializeU256)
+}
+
+func (s *tracingSerializer) SerializeI8(value int8) error {
+	return traceWrite(s, "SerializeI8", value, s.inner.SerializeI8)
+}
+
+func (s *tracingSerializer) SerializeI16(value int16) error {
+	return traceWrite(s, "SerializeI16", value, s.inner.SerializeI16)
+}
+
+func (s *tracingSerializer) SerializeI32(value int32) error {
+	return traceWrite(s, "SerializeI32", value, s.inner.SerializeI32)
+}
+
+func (s *tracingSerializer) SerializeI64(value int64) error {
+	return traceWrite(s, "SerializeI64", value, s.inner.SerializeI64)
+}
+
+func (s *tracingSerializer) SerializeI128(value Int128) error {
+	return traceWrite(s, "SerializeI128", value, s.inner.SerializeI128)
+}
+
+func (s *tracingSerializer) SerializeI256(value Int256) error {
+	return traceWrite(s, "SerializeI256", value, s.inner.SerializeI256)
+}
+
+func (s *tracingSerializer) SerializeLen(value uint64) error {
+	return traceWrite(s, "SerializeLen", value, s.inner.SerializeLen)
+}
+
+func (s *tracingSerializer) SerializeVariantIndex(value uint32) error {
+	return traceWrite(s, "SerializeVariantIndex", value, s.inner.SerializeVariantIndex)
+}
+
+func (s *tracingSerializer) SerializeOptionTag(value bool) error {
+	return traceWrite(s, "SerializeOptionTag", value, s.inner.SerializeOptionTag)
+}
+
+func (s *tracingSerializer) GetBufferOffset() uint64 {
+	return s.inner.GetBufferOffset()
+}
+
+func (s *tracingSerializer) BeginMap() {
+	BeginMap(s.inner)
+}
+
+func (s *tracingSerializer) SortMapEntries(offsets []uint64) {
+	s.inner.SortMapEntries(offsets)
+}
+
+func (s *tracingSerializer) GetBytes() []byte {
+	return s.inner.GetBytes()
+}
+
+func (s *tracingSerializer) IncreaseContainerDepth() error {
+	return s.inner.IncreaseContainerDepth()
+}
+
+func (s *tracingSerializer) DecreaseContainerDepth() {
+	s.inner.DecreaseContainerDepth()
+}
+
+func (d *tracingDeserializer) DeserializeStr() (string, error) {
+	return traceRead(d, "DeserializeStr", d.inner.DeserializeStr)
+}
+
+func (d *tracingDeserializer) DeserializeBytes() ([]byte, error) {
+	return traceRead(d, "DeserializeBytes", d.inner.DeserializeBytes)
+}
+
+func (d *tracingDeserializer) DeserializeVecBytes() ([][]byte, error) {
+	return traceRead(d, "DeserializeVecBytes", d.inner.DeserializeVecBytes)
+}
+
+func (d *tracingDeserializer) DeserializeFixedBytes(n uint64) ([]byte, error) {
+	return traceRead(d, fmt.Sprintf("DeserializeFixedBytes(%d)", n), func() ([]byte, error) {
+		return d.inner.DeserializeFixedBytes(n)
+	})
+}
+
+func (d *tracingDeserializer) DeserializeBool() (bool, error) {
+	return traceRead(d, "DeserializeBool", d.inner.DeserializeBool)
+}
+
+func (d *tracingDeserializer) DeserializeUnit() (struct{}, error) {
+	return traceRead(d, "DeserializeUnit", d.inner.DeserializeUnit)
+}
+
+func (d *tracingDeserializer) DeserializeChar() (rune, error) {
+	return traceRead(d, "DeserializeChar", d.inner.DeserializeChar)
+}
+
+func (d *tracingDeserializer) DeserializeF32() (float32, error) {
+	return traceRead(d, "DeserializeF32", d.inner.DeserializeF32)
+}
+
+func (d *tracingDeserializer) DeserializeF64() (float64, error) {
+	return traceRead(d, "DeserializeF64", d.inner.DeserializeF64)
+}
+
+func (d *tracingDeserializer) DeserializeU8() (uint8, error) {
+	return traceRead(d, "DeserializeU8", d.inner.DeserializeU8)
+}
+
+func (d *tracingDeserializer) DeserializeU16() (uint16, error) {
+	return traceRead(d, "DeserializeU16", d.inner.DeserializeU16)
+}
+
+func (d *tracingDeserializer) DeserializeU32() (uint32, error) {
+	return traceRead(d, "DeserializeU32", d.inner.DeserializeU32)
+}
+
+func (d *tracingDeserializer) DeserializeU64() (uint64, error) {
+	return traceRead(d, "DeserializeU64", d.inner.DeserializeU64)
+}
+
+func (d *tracingDeserializer) DeserializeU128() (Uint128, error) {
+	return traceRead(d, "DeserializeU128", d.inner.DeserializeU128)
+}
+
+func (d *tracingDeserializer) DeserializeU256() (Uint256, error) {
+	return traceRead(d, "DeserializeU256", d.inner.DeserializeU256)
+}
+
+func (d *tracingDeserializer) DeserializeI8() (int8, error) {
+	return traceRead(d, "DeserializeI8", d.inner.DeserializeI8)
+}
+
+func (d *tracingDeserializer) DeserializeI16() (int16, error) {
+	return traceRead(d, "DeserializeI16", d.inner.DeserializeI16)
+}
+
+func (d *tracingDeserializer) DeserializeI32() (int32, error) {
+	return traceRead(d, "DeserializeI32", d.inner.DeserializeI32)
+}
+
+func (d *tracingDeserializer) DeserializeI64() (int64, error) {
+	return traceRead(d, "DeserializeI64", d.inner.DeserializeI64)
+}
+
+func (d *tracingDeserializer) DeserializeI128() (Int128, error) {
+	return traceRead(d, "DeserializeI128", d.inner.DeserializeI128)
+}
+
+func (d *tracingDeserializer) DeserializeI256() (Int256, error) {
+	return traceRead(d, "DeserializeI256", d.inner.DeserializeI256)
+}
+
+func (d *tracingDeserializer) DeserializeLen() (uint64, error) {
+	return traceRead(d, "DeserializeLen", d.inner.DeserializeLen)
+}
+
+func (d *tracingDeserializer) DeserializeMapLen() (uint64, error) {
+	return traceRead(d, "DeserializeMapLen", d.inner.DeserializeMapLen)
+}
+
+func (d *tracingDeserializer) DeserializeVariantIndex() (uint32, error) {
+	return traceRead(d, "DeserializeVariantIndex", d.inner.DeserializeVariantIndex)
+}
+
+func (d *tracingDeserializer) DeserializeOptionTag() (bool, error) {
+	return traceRead(d, "DeserializeOptionTag", d.inner.DeserializeOptionTag)
+}
+
+func (d *tracingDeserializer) GetBufferOffset() uint64 {
+	return d.inner.GetBufferOffset()
+}
+
+func (d *tracingDeserializer) EndOfInput() error {
+	return d.inner.EndOfInput()
+}
+
+func (d *tracingDeserializer) BeginMap() {
+	BeginReadingMap(d.inner)
+}
+
+func (d *tracingDeserializer) EndMap() {
+	EndReadingMap(d.inner)
+}
+
+func (d *tracingDeserializer) AllowsMissingTrailingFields() bool {
+	inner, ok := d.inner.(TrailingFieldsDeserializer)
+	return ok && inner.AllowsMissingTrailingFields()
+}
+
+// Slice returns a deserializer observed in the same way. Offsets are still
+// reported relatively to the input of `d`.
+func (d *tracingDeserializer) Slice(start, end uint64) (Deserializer, error) {
+	inner, err := d.inner.Slice(start, end)
+	if err != nil {
+		return nil, err
+	}
+	onRead := func(offset uint64, op string, value interface{}, err error) error {
+		return d.onRead(start+offset, op, value, err)
+	}
+	return &tracingDeserializer{inner: inner, onRead: onRead}, nil
+}
+
+func (d *tracingDeserializer) CheckThatKeySlicesAreIncreasing(key1, key2 Slice) error {
+	return d.inner.CheckThatKeySlicesAreIncreasing(key1, key2)
+}
+
+func (d *tracingDeserializer) IncreaseContainerDepth() error {
+	return d.inner.IncreaseContainerDepth()
+}
+
+func (d *tracingDeserializer) DecreaseContainerDepth() {
+	d.inner.DecreaseContainerDepth()
+}
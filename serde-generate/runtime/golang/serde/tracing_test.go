@@ -0,0 +1,96 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracingSerializer(t *testing.T) {
+	var output bytes.Buffer
+	s := serde.NewTracingSerializer(bcs.NewSerializer(), log.New(&output, "", 0))
+	require.NoError(t, s.SerializeU32(827))
+	require.NoError(t, s.SerializeStr("ab"))
+	require.NoError(t, s.SerializeBytes([]byte{0xca, 0xfe}))
+	assert.Equal(t, []byte{59, 3, 0, 0, 2, 'a', 'b', 2, 0xca, 0xfe}, s.GetBytes())
+	assert.Equal(t, `0: SerializeU32(827)
+4: SerializeStr("ab")
+7: SerializeBytes(cafe)
+`, output.String())
+}
+
+// The tracing serializer reads the offset before every write, which must not keep a stream
+// serializer from writing, except during the serialization of map entries.
+func TestTracingStreamSerializer(t *testing.T) {
+	var written bytes.Buffer
+	stream := bcs.NewStreamSerializer(&written)
+	s := serde.NewTracingSerializer(stream, log.New(io.Discard, "", 0))
+	expected := bcs.NewSerializer()
+	serializeStruct := func(value uint64, serializer serde.Serializer) error {
+		if err := serializer.IncreaseContainerDepth(); err != nil {
+			return err
+		}
+		if err := serializer.SerializeU64(value); err != nil {
+			return err
+		}
+		serializer.DecreaseContainerDepth()
+		return nil
+	}
+	serializeKey := func(key string, serializer serde.Serializer) error {
+		return serializer.SerializeStr(key)
+	}
+	entries := make(map[string]uint64)
+	for i := uint64(0); i < 1000; i++ {
+		entries[fmt.Sprintf("key%d", i)] = i
+	}
+
+	for _, serializer := range []serde.Serializer{s, expected} {
+		for i := uint64(0); i < 1000; i++ {
+			require.NoError(t, serializeStruct(i, serializer))
+		}
+		require.NoError(t, serde.SerializeMap(entries, serializer, serializeKey, serializeStruct))
+	}
+	assert.Greater(t, written.Len(), 4096)
+	require.NoError(t, stream.Flush())
+	assert.Equal(t, expected.GetBytes(), written.Bytes())
+}
+
+func TestTracingDeserializer(t *testing.T) {
+	var output bytes.Buffer
+	d := serde.NewTracingDeserializer(bcs.NewDeserializer([]byte{59, 3, 0, 0, 1, 2}), log.New(&output, "", 0))
+	value, err := d.DeserializeU32()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(827), value)
+	_, err = d.DeserializeBool()
+	require.NoError(t, err)
+	_, err = d.DeserializeBool()
+	require.Error(t, err)
+	assert.Equal(t, `0: DeserializeU32 = 827
+4: DeserializeBool = true
+5: DeserializeBool: invalid bool byte: expected 0 / 1, but got 2
+`, output.String())
+}
+
+func TestTracingDeserializerAllowsMissingTrailingFields(t *testing.T) {
+	config := bcs.DefaultConfig()
+	config.AllowMissingTrailingFields = true
+	d := serde.NewTracingDeserializer(bcs.NewDeserializerWithConfig([]byte{1}, config), log.New(&bytes.Buffer{}, "", 0))
+	_, err := d.DeserializeBool()
+	require.NoError(t, err)
+	assert.True(t, serde.IsMissingTrailingField(d))
+
+	d = serde.NewTracingDeserializer(bcs.NewDeserializer([]byte{1}), log.New(&bytes.Buffer{}, "", 0))
+	_, err = d.DeserializeBool()
+	require.NoError(t, err)
+	assert.False(t, serde.IsMissingTrailingField(d))
+}
@@ -3,6 +3,12 @@
 
 package serde
 
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
 type Uint128 struct {
 	High uint64
 	Low  uint64
@@ -12,3 +18,130 @@ type Int128 struct {
 	High int64
 	Low  uint64
 }
+
+type Uint256 struct {
+	High Uint128
+	Low  Uint128
+}
+
+type Int256 struct {
+	High Int128
+	Low  Uint128
+}
+
+var (
+	bigOne        = big.NewInt(1)
+	bigMaxUint64  = new(big.Int).SetUint64(^uint64(0))
+	bigTwo128     = new(big.Int).Lsh(bigOne, 128)
+	bigMaxUint128 = new(big.Int).Sub(bigTwo128, bigOne)
+	bigTwo256     = new(big.Int).Lsh(bigOne, 256)
+	bigMinInt256  = new(big.Int).Neg(new(big.Int).Lsh(bigOne, 255))
+	bigMaxInt256  = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 255), bigOne)
+	bigMinInt128  = new(big.Int).Neg(new(big.Int).Lsh(bigOne, 127))
+	bigMaxInt128  = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 127), bigOne)
+)
+
+// Uint128FromUint64 converts `value` into a `Uint128`.
+func Uint128FromUint64(value uint64) Uint128 {
+	return Uint128{High: 0, Low: value}
+}
+
+// Uint128FromLEBytes reads a `Uint128` from 16 bytes in little-endian order.
+func Uint128FromLEBytes(data [16]byte) Uint128 {
+	return Uint128{High: binary.LittleEndian.Uint64(data[8:]), Low: binary.LittleEndian.Uint64(data[:8])}
+}
+
+// ToLEBytes returns the 16 bytes of `v` in little-endian order.
+func (v Uint128) ToLEBytes() [16]byte {
+	var data [16]byte
+	binary.LittleEndian.PutUint64(data[:8], v.Low)
+	binary.LittleEndian.PutUint64(data[8:], v.High)
+	return data
+}
+
+// BigInt returns the value of `v` as a new `big.Int`.
+func (v Uint128) BigInt() *big.Int {
+	ret := new(big.Int).SetUint64(v.High)
+	ret.Lsh(ret, 64)
+	return ret.Or(ret, new(big.Int).SetUint64(v.Low))
+}
+
+// Uint128FromBigInt converts `value` into a `Uint128`, failing if `value` is out of range.
+func Uint128FromBigInt(value *big.Int) (Uint128, error) {
+	if value.Sign() < 0 || value.BitLen() > 128 {
+		return Uint128{}, errors.New("value out of range for uint128")
+	}
+	return uint128FromBits(value), nil
+}
+
+// BigInt returns the value of `v` as a new `big.Int`.
+func (v Int128) BigInt() *big.Int {
+	ret := big.NewInt(v.High)
+	ret.Lsh(ret, 64)
+	return ret.Add(ret, new(big.Int).SetUint64(v.Low))
+}
+
+// Int128FromBigInt converts `value` into an `Int128`, failing if `value` is out of range.
+func Int128FromBigInt(value *big.Int) (Int128, error) {
+	if value.Cmp(bigMinInt128) < 0 || value.Cmp(bigMaxInt128) > 0 {
+		return Int128{}, errors.New("value out of range for int128")
+	}
+	bits := value
+	if value.Sign() < 0 {
+		// Two's complement representation on 128 bits.
+		bits = new(big.Int).Add(value, bigTwo128)
+	}
+	u := uint128FromBits(bits)
+	return Int128{High: int64(u.High), Low: u.Low}, nil
+}
+
+// BigInt returns the value of `v` as a new `big.Int`.
+func (v Uint256) BigInt() *big.Int {
+	ret := v.High.BigInt()
+	ret.Lsh(ret, 128)
+	return ret.Or(ret, v.Low.BigInt())
+}
+
+// Uint256FromBigInt converts `value` into a `Uint256`, failing if `value` is out of range.
+func Uint256FromBigInt(value *big.Int) (Uint256, error) {
+	if value.Sign() < 0 || value.BitLen() > 256 {
+		return Uint256{}, errors.New("value out of range for uint256")
+	}
+	return uint256FromBits(value), nil
+}
+
+// BigInt returns the value of `v` as a new `big.Int`.
+func (v Int256) BigInt() *big.Int {
+	ret := v.High.BigInt()
+	ret.Lsh(ret, 128)
+	return ret.Add(ret, v.Low.BigInt())
+}
+
+// Int256FromBigInt converts `value` into an `Int256`, failing if `value` is out of range.
+func Int256FromBigInt(value *big.Int) (Int256, error) {
+	if value.Cmp(bigMinInt256) < 0 || value.Cmp(bigMaxInt256) > 0 {
+		return Int256{}, errors.New("value out of range for int256")
+	}
+	bits := value
+	if value.Sign() < 0 {
+		// Two's complement representation on 256 bits.
+		bits = new(big.Int).Add(value, bigTwo256)
+	}
+	u := uint256FromBits(bits)
+	return Int256{High: Int128{High: int64(u.High.High), Low: u.High.Low}, Low: u.Low}, nil
+}
+
+// Extract the lowest 256 bits of a non-negative `value`.
+func uint256FromBits(value *big.Int) Uint256 {
+	low := new(big.Int).And(value, bigMaxUint128)
+	high := new(big.Int).Rsh(value, 128)
+	return Uint256{High: uint128FromBits(high), Low: uint128FromBits(low)}
+}
+
+// Extract the lowest 128 bits of a non-negative `value`.
+func uint128FromBits(value *big.Int) Uint128 {
+	low := new(big.Int).And(value, bigMaxUint64)
+	high := new(big.Int).Rsh(value, 64)
+	high.And(high, bigMaxUint64)
+	return Uint128{High: high.Uint64(), Low: low.Uint64()}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInt128Arithmetic(t *testing.T) {
+	minusOne := serde.Int128{High: -1, Low: ^uint64(0)}
+	minInt128 := serde.Int128{High: -1 << 63, Low: 0}
+	maxInt128 := serde.Int128{High: 1<<63 - 1, Low: ^uint64(0)}
+
+	t.Run("Neg", func(t *testing.T) {
+		assert.Equal(t, minusOne, serde.Int128FromInt64(1).Neg())
+		assert.Equal(t, serde.Int128FromInt64(1), minusOne.Neg())
+		assert.Equal(t, serde.Int128{High: 0, Low: 0}, serde.Int128{High: 0, Low: 0}.Neg())
+		// Carry across the High/Low split.
+		assert.Equal(t, serde.Int128{High: -1, Low: 0}, serde.Int128{High: 1, Low: 0}.Neg())
+		assert.Equal(t, serde.Int128{High: -2, Low: ^uint64(0)}, serde.Int128{High: 1, Low: 1}.Neg())
+		assert.Equal(t, serde.Int128{High: -1 << 63, Low: 1}, maxInt128.Neg())
+		// Overflow.
+		assert.Equal(t, minInt128, minInt128.Neg())
+	})
+
+	t.Run("Abs", func(t *testing.T) {
+		assert.Equal(t, serde.Int128FromInt64(1), minusOne.Abs())
+		assert.Equal(t, maxInt128, maxInt128.Abs())
+		assert.Equal(t, serde.Int128{High: 0, Low: 1 << 63}, serde.Int128FromInt64(-1<<63).Abs())
+	})
+
+	t.Run("Sign", func(t *testing.T) {
+		assert.Equal(t, -1, minusOne.Sign())
+		assert.Equal(t, -1, minInt128.Sign())
+		assert.Equal(t, 0, serde.Int128{}.Sign())
+		assert.Equal(t, 1, serde.Int128{High: 0, Low: 1}.Sign())
+		assert.Equal(t, 1, maxInt128.Sign())
+	})
+
+	t.Run("Cmp", func(t *testing.T) {
+		sorted := []serde.Int128{
+			minInt128,
+			serde.Int128{High: -1, Low: 0},
+			minusOne,
+			serde.Int128{High: 0, Low: 0},
+			serde.Int128{High: 0, Low: ^uint64(0)},
+			serde.Int128{High: 1, Low: 0},
+			maxInt128,
+		}
+		for i := range sorted {
+			for j := range sorted {
+				expected := 0
+				if i < j {
+					expected = -1
+				} else if i > j {
+					expected = 1
+				}
+				assert.Equal(t, expected, sorted[i].Cmp(sorted[j]), "%v vs %v", sorted[i], sorted[j])
+			}
+		}
+	})
+
+	t.Run("int64 conversions", func(t *testing.T) {
+		for _, value := range []int64{0, 1, -1, -232, 1<<63 - 1, -1 << 63} {
+			converted, err := serde.Int128FromInt64(value).Int64()
+			require.NoError(t, err)
+			assert.Equal(t, value, converted)
+			assert.Equal(t, big.NewInt(value), serde.Int128FromInt64(value).BigInt())
+		}
+		_, err := serde.Int128{High: 0, Low: 1 << 63}.Int64()
+		require.Error(t, err)
+		_, err = serde.Int128{High: -1, Low: 1<<63 - 1}.Int64()
+		require.Error(t, err)
+		_, err = maxInt128.Int64()
+		require.Error(t, err)
+	})
+}
+
+func TestUint256BigInt(t *testing.T) {
+	max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	value, err := serde.Uint256FromBigInt(max)
+	require.NoError(t, err)
+	assert.Equal(t, serde.Uint256{
+		High: serde.Uint128{High: ^uint64(0), Low: ^uint64(0)},
+		Low:  serde.Uint128{High: ^uint64(0), Low: ^uint64(0)},
+	}, value)
+	assert.Equal(t, max, value.BigInt())
+
+	for _, target := range []serde.Uint256{
+		{},
+		{High: serde.Uint128{High: 0, Low: 1}, Low: serde.Uint128{High: 2, Low: 3}},
+		{High: serde.Uint128{High: 1 << 63, Low: 0}, Low: serde.Uint128{High: 0, Low: 1 << 63}},
+	} {
+		value, err := serde.Uint256FromBigInt(target.BigInt())
+		require.NoError(t, err)
+		assert.Equal(t, target, value)
+	}
+
+	_, err = serde.Uint256FromBigInt(new(big.Int).Add(max, big.NewInt(1)))
+	require.Error(t, err)
+	_, err = serde.Uint256FromBigInt(big.NewInt(-1))
+	require.Error(t, err)
+}
+
+func TestInt256BigInt(t *testing.T) {
+	one := big.NewInt(1)
+	min := new(big.Int).Neg(new(big.Int).Lsh(one, 255))
+	max := new(big.Int).Sub(new(big.Int).Lsh(one, 255), one)
+
+	value, err := serde.Int256FromBigInt(min)
+	require.NoError(t, err)
+	assert.Equal(t, serde.Int256{High: serde.Int128{High: -1 << 63, Low: 0}}, value)
+	assert.Equal(t, min, value.BigInt())
+
+	value, err = serde.Int256FromBigInt(big.NewInt(-1))
+	require.NoError(t, err)
+	assert.Equal(t, serde.Int256{
+		High: serde.Int128{High: -1, Low: ^uint64(0)},
+		Low:  serde.Uint128{High: ^uint64(0), Low: ^uint64(0)},
+	}, value)
+
+	for _, target := range []serde.Int256{
+		{},
+		{High: serde.Int128{High: -232, Low: 1}, Low: serde.Uint128{High: 2, Low: 3}},
+		{High: serde.Int128{High: 1<<63 - 1, Low: ^uint64(0)}, Low: serde.Uint128{High: ^uint64(0), Low: ^uint64(0)}},
+	} {
+		value, err := serde.Int256FromBigInt(target.BigInt())
+		require.NoError(t, err)
+		assert.Equal(t, target, value)
+	}
+
+	_, err = serde.Int256FromBigInt(new(big.Int).Add(max, one))
+	require.Error(t, err)
+	_, err = serde.Int256FromBigInt(new(big.Int).Sub(min, one))
+	require.Error(t, err)
+}
+
+func TestFormat(t *testing.T) {
+	u := serde.Uint128{High: 1, Low: 2}
+	i := serde.Int128{High: -1, Low: ^uint64(0) - 1}
+	u256 := serde.Uint256{High: serde.Uint128{High: 0, Low: 1}, Low: serde.Uint128{High: 0, Low: 0}}
+	i256 := serde.Int256{High: serde.Int128{High: -1, Low: ^uint64(0)}, Low: serde.Uint128{High: ^uint64(0), Low: ^uint64(0)}}
+
+	assert.Equal(t, "18446744073709551618", u.String())
+	assert.Equal(t, "-2", i.String())
+	assert.Equal(t, "340282366920938463463374607431768211456", u256.String())
+	assert.Equal(t, "-1", i256.String())
+
+	assert.Equal(t, "18446744073709551618", fmt.Sprintf("%v", u))
+	assert.Equal(t, "18446744073709551618", fmt.Sprintf("%d", u))
+	assert.Equal(t, "18446744073709551618", fmt.Sprintf("%s", u))
+	assert.Equal(t, "   -2|-2   |", fmt.Sprintf("%5s|%-5s|", i, i))
+	assert.Equal(t, "10000000000000002", fmt.Sprintf("%x", u))
+	assert.Equal(t, "0x10000000000000002", fmt.Sprintf("%#x", u))
+	assert.Equal(t, "0X10000000000000002", fmt.Sprintf("%#X", u))
+	assert.Equal(t, "2000000000000000000002", fmt.Sprintf("%o", u))
+	assert.Equal(t, "-10", fmt.Sprintf("%b", i))
+	assert.Equal(t, "   -2", fmt.Sprintf("%5d", i))
+	assert.Equal(t, "-0002", fmt.Sprintf("%05d", i))
+	assert.Equal(t, "+7", fmt.Sprintf("%+d", serde.Int128FromInt64(7)))
+	assert.Equal(t, "-1", fmt.Sprintf("%v", i256))
+	assert.Equal(t, "[1 -2]", fmt.Sprintf("%v", []interface{}{serde.Uint128{High: 0, Low: 1}, i}))
+
+	assert.Equal(t, "serde.Uint128{High:0x1, Low:0x2}", fmt.Sprintf("%#v", u))
+	assert.Equal(t, "serde.Int128{High:-1, Low:0xfffffffffffffffe}", fmt.Sprintf("%#v", i))
+	assert.Equal(t, "serde.Uint256{High:serde.Uint128{High:0x0, Low:0x1}, Low:serde.Uint128{High:0x0, Low:0x0}}", fmt.Sprintf("%#v", u256))
+}
+
+func TestUint128Bytes(t *testing.T) {
+	value := serde.Uint128{High: 0x0102030405060708, Low: 0x090a0b0c0d0e0f10}
+	data := [16]byte{0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01}
+	assert.Equal(t, data, value.ToLEBytes())
+	assert.Equal(t, value, serde.Uint128FromLEBytes(data))
+	assert.Equal(t, serde.Uint128{High: 0, Low: 42}, serde.Uint128FromUint64(42))
+}
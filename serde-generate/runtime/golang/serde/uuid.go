@@ -0,0 +1,76 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// UUID is a 16-byte universally unique identifier. It is serialized as 16 bytes
+// without length prefix, i.e. like the Rust type `[u8; 16]`, and is represented
+// as text (including in JSON) in the usual form `xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx`.
+type UUID [16]byte
+
+// ParseUUID parses a UUID written either in the hyphenated form
+// `xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx` or as 32 hexadecimal digits.
+func ParseUUID(text string) (UUID, error) {
+	var uuid UUID
+	var digits []byte
+	switch len(text) {
+	case 32:
+		digits = []byte(text)
+	case 36:
+		if text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+			return uuid, fmt.Errorf("invalid UUID: %q", text)
+		}
+		digits = []byte(text[0:8] + text[9:13] + text[14:18] + text[19:23] + text[24:36])
+	default:
+		return uuid, fmt.Errorf("invalid UUID: %q", text)
+	}
+	if _, err := hex.Decode(uuid[:], digits); err != nil {
+		return UUID{}, fmt.Errorf("invalid UUID: %q", text)
+	}
+	return uuid, nil
+}
+
+func (v UUID) String() string {
+	digits := hex.EncodeToString(v[:])
+	return digits[0:8] + "-" + digits[8:12] + "-" + digits[12:16] + "-" + digits[16:20] + "-" + digits[20:32]
+}
+
+func (v UUID) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v *UUID) UnmarshalText(text []byte) error {
+	uuid, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*v = uuid
+	return nil
+}
+
+func (v UUID) Serialize(serializer Serializer) error {
+	return serializer.SerializeFixedBytes(v[:])
+}
+
+func (v *UUID) Deserialize(deserializer Deserializer) error {
+	uuid, err := DeserializeUUID(deserializer)
+	if err == nil {
+		*v = uuid
+	}
+	return err
+}
+
+func DeserializeUUID(deserializer Deserializer) (UUID, error) {
+	var uuid UUID
+	bytes, err := deserializer.DeserializeFixedBytes(uint64(len(uuid)))
+	if err != nil {
+		return uuid, err
+	}
+	copy(uuid[:], bytes)
+	return uuid, nil
+}
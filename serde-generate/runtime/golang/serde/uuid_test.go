@@ -0,0 +1,61 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serde_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ serde.Serializable   = serde.UUID{}
+	_ serde.Deserializable = (*serde.UUID)(nil)
+)
+
+var testUUID = serde.UUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+func TestUUIDText(t *testing.T) {
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000", testUUID.String())
+
+	for _, text := range []string{"123e4567-e89b-12d3-a456-426614174000", "123E4567E89B12D3A456426614174000"} {
+		uuid, err := serde.ParseUUID(text)
+		require.NoError(t, err)
+		assert.Equal(t, testUUID, uuid)
+	}
+
+	for _, text := range []string{"", "123e4567-e89b-12d3-a456-42661417400", "123e4567+e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-42661417400g"} {
+		_, err := serde.ParseUUID(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestUUIDJSON(t *testing.T) {
+	data, err := json.Marshal(testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, `"123e4567-e89b-12d3-a456-426614174000"`, string(data))
+
+	var uuid serde.UUID
+	require.NoError(t, json.Unmarshal(data, &uuid))
+	assert.Equal(t, testUUID, uuid)
+
+	assert.EqualError(t, json.Unmarshal([]byte(`"123"`), &uuid), `invalid UUID: "123"`)
+}
+
+func TestUUIDSerialization(t *testing.T) {
+	s := bcs.NewSerializer()
+	require.NoError(t, testUUID.Serialize(s))
+	assert.Equal(t, testUUID[:], s.GetBytes())
+
+	var uuid serde.UUID
+	require.NoError(t, uuid.Deserialize(bcs.NewDeserializer(s.GetBytes())))
+	assert.Equal(t, testUUID, uuid)
+
+	_, err := serde.DeserializeUUID(bcs.NewDeserializer(testUUID[:15]))
+	assert.EqualError(t, err, "input is too short")
+}
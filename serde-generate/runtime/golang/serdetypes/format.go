@@ -0,0 +1,238 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+// Package serdetypes defines the Abstract Syntax Tree (AST) of Serde formats, as
+// recorded by serde-reflection in registries.
+//
+// Nodes of the AST are made of the following types:
+// * `ContainerFormat`: the format of a container (struct or enum),
+// * `Format`: the format of an unnamed value,
+// * `Named[Format]`: the format of a field in a struct,
+// * `VariantFormat`: the format of a variant in a enum,
+// * `Named[VariantFormat]`: the format of a variant in a enum, together with its name.
+//
+// Registries saved by serde-reflection (in YAML or JSON) are loaded with `ReadRegistry` or
+// `ParseRegistry`, e.g. to inspect, validate, or transcode serialized values in Go. Registries
+// traced from Go types (see the package `tracing`) are saved with `MarshalRegistry`.
+package serdetypes
+
+import (
+	"fmt"
+)
+
+// FormatKind is the kind of a `Format`.
+type FormatKind int
+
+const (
+	// The name of a container.
+	TypeNameKind FormatKind = iota
+
+	// The formats of primitive types.
+	UnitKind
+	BoolKind
+	I8Kind
+	I16Kind
+	I32Kind
+	I64Kind
+	I128Kind
+	U8Kind
+	U16Kind
+	U32Kind
+	U64Kind
+	U128Kind
+	F32Kind
+	F64Kind
+	CharKind
+	StrKind
+	BytesKind
+
+	// The format of `Option<T>`.
+	OptionKind
+	// A sequence, e.g. the format of `Vec<Foo>`.
+	SeqKind
+	// A map, e.g. the format of `BTreeMap<K, V>`.
+	MapKind
+	// A tuple, e.g. the format of `(Foo, Bar)`.
+	TupleKind
+	// Alias for `(Foo, ... Foo)`, e.g. the format of `[Foo; N]`.
+	TupleArrayKind
+)
+
+// Format is the serialization format of an anonymous "value" type.
+type Format struct {
+	Kind FormatKind
+	// The container name, for `TypeNameKind`.
+	Name string
+	// The format of the content, for `OptionKind`, `SeqKind` and `TupleArrayKind`.
+	Content *Format
+	// The formats of keys and values, for `MapKind`.
+	Key   *Format
+	Value *Format
+	// The formats of the elements, for `TupleKind`.
+	Elements []Format
+	// The number of elements, for `TupleArrayKind`.
+	Size uint64
+}
+
+// ContainerKind is the kind of a `ContainerFormat`.
+type ContainerKind int
+
+const (
+	// An empty struct, e.g. `struct A`.
+	UnitStructKind ContainerKind = iota
+	// A struct with a single unnamed parameter, e.g. `struct A(u16)`.
+	NewTypeStructKind
+	// A struct with several unnamed parameters, e.g. `struct A(u16, u32)`.
+	TupleStructKind
+	// A struct with named parameters, e.g. `struct A { a: Foo }`.
+	StructKind
+	// An enum, that is, an enumeration of variants.
+	EnumKind
+)
+
+// ContainerFormat is the serialization format of a named "container" type.
+// In Rust, those are enums and structs.
+type ContainerFormat struct {
+	Kind ContainerKind
+	// The format of the parameter, for `NewTypeStructKind`.
+	Content *Format
+	// The formats of the parameters, for `TupleStructKind`.
+	Elements []Format
+	// The formats of the fields, for `StructKind`.
+	Fields []Named[Format]
+	// The variants indexed by variant index, for `EnumKind`.
+	Variants map[uint32]Named[VariantFormat]
+}
+
+// VariantKind is the kind of a `VariantFormat`.
+type VariantKind int
+
+const (
+	// A variant without parameters, e.g. `A` in `enum X { A }`.
+	UnitVariantKind VariantKind = iota
+	// A variant with a single unnamed parameter, e.g. `A` in `enum X { A(u16) }`.
+	NewTypeVariantKind
+	// A variant with several unnamed parameters, e.g. `A` in `enum X { A(u16, u32) }`.
+	TupleVariantKind
+	// A variant with named parameters, e.g. `A` in `enum X { A { a: Foo } }`.
+	StructVariantKind
+)
+
+// VariantFormat is the description of a variant in an enum.
+type VariantFormat struct {
+	Kind VariantKind
+	// The format of the parameter, for `NewTypeVariantKind`.
+	Content *Format
+	// The formats of the parameters, for `TupleVariantKind`.
+	Elements []Format
+	// The formats of the fields, for `StructVariantKind`.
+	Fields []Named[Format]
+}
+
+// Named is a named value. Used for named parameters or variants.
+type Named[T any] struct {
+	Name  string
+	Value T
+}
+
+// Registry maps the names of containers to their formats.
+type Registry struct {
+	Containers map[string]ContainerFormat
+}
+
+// Lookup returns the format of the container with the given name.
+func (r *Registry) Lookup(name string) (ContainerFormat, error) {
+	format, ok := r.Containers[name]
+	if !ok {
+		return ContainerFormat{}, fmt.Errorf("unknown container %q", name)
+	}
+	return format, nil
+}
+
+// HasEmptyEncoding returns whether the values of `format` are serialized as zero bytes in
+// BCS and Bincode, e.g. `()`, `[u8; 0]`, or unit structs. Since reading such values does not
+// consume the input, decoders should not trust the length of a sequence of them.
+func (r *Registry) HasEmptyEncoding(format *Format) bool {
+	return r.hasEmptyEncoding(format, make(map[string]bool))
+}
+
+// Containers in `visiting` are being checked: a recursive container has no finite values
+// made of empty values only.
+func (r *Registry) hasEmptyEncoding(format *Format, visiting map[string]bool) bool {
+	switch format.Kind {
+	case UnitKind:
+		return true
+	case TupleKind:
+		for i := range format.Elements {
+			if !r.hasEmptyEncoding(&format.Elements[i], visiting) {
+				return false
+			}
+		}
+		return true
+	case TupleArrayKind:
+		return format.Size == 0 || r.hasEmptyEncoding(format.Content, visiting)
+	case TypeNameKind:
+		container, ok := r.Containers[format.Name]
+		if !ok || visiting[format.Name] {
+			return false
+		}
+		visiting[format.Name] = true
+		defer delete(visiting, format.Name)
+		switch container.Kind {
+		case UnitStructKind:
+			return true
+		case NewTypeStructKind:
+			return r.hasEmptyEncoding(container.Content, visiting)
+		case TupleStructKind:
+			return r.hasEmptyEncoding(&Format{Kind: TupleKind, Elements: container.Elements}, visiting)
+		case StructKind:
+			for i := range container.Fields {
+				if !r.hasEmptyEncoding(&container.Fields[i].Value, visiting) {
+					return false
+				}
+			}
+			return true
+		default:
+			// Variant indices take at least one byte.
+			return false
+		}
+	default:
+		// Other values take at least one byte (e.g. a length or an option tag).
+		return false
+	}
+}
+
+// Primitive returns the format of a primitive type, e.g. `Primitive(U64Kind)`.
+func Primitive(kind FormatKind) Format {
+	return Format{Kind: kind}
+}
+
+// TypeName returns the format referring to the container with the given name.
+func TypeName(name string) Format {
+	return Format{Kind: TypeNameKind, Name: name}
+}
+
+// Option returns the format of `Option<T>` where `T` has the format `content`.
+func Option(content Format) Format {
+	return Format{Kind: OptionKind, Content: &content}
+}
+
+// Seq returns the format of a sequence of values with the format `content`.
+func Seq(content Format) Format {
+	return Format{Kind: SeqKind, Content: &content}
+}
+
+// Map returns the format of a map with the given formats of keys and values.
+func Map(key Format, value Format) Format {
+	return Format{Kind: MapKind, Key: &key, Value: &value}
+}
+
+// Tuple returns the format of a tuple with the given formats of elements.
+func Tuple(elements ...Format) Format {
+	return Format{Kind: TupleKind, Elements: elements}
+}
+
+// TupleArray returns the format of an array of `size` values with the format `content`.
+func TupleArray(content Format, size uint64) Format {
+	return Format{Kind: TupleArrayKind, Content: &content, Size: size}
+}
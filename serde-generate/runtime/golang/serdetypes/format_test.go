@@ -0,0 +1,42 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package serdetypes_test
+
+import (
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasEmptyEncoding(t *testing.T) {
+	unit := serdetypes.Primitive(serdetypes.UnitKind)
+	// struct Empty; struct Pair((), Empty); struct Loop(Box<Loop>); enum Choice { A }
+	registry := serdetypes.Registry{Containers: map[string]serdetypes.ContainerFormat{
+		"Empty": {Kind: serdetypes.UnitStructKind},
+		"Pair":  {Kind: serdetypes.TupleStructKind, Elements: []serdetypes.Format{unit, serdetypes.TypeName("Empty")}},
+		"Loop":  {Kind: serdetypes.NewTypeStructKind, Content: &serdetypes.Format{Kind: serdetypes.TypeNameKind, Name: "Loop"}},
+		"Choice": {Kind: serdetypes.EnumKind, Variants: map[uint32]serdetypes.Named[serdetypes.VariantFormat]{
+			0: {Name: "A", Value: serdetypes.VariantFormat{Kind: serdetypes.UnitVariantKind}},
+		}},
+	}}
+	cases := []struct {
+		format serdetypes.Format
+		empty  bool
+	}{
+		{unit, true},
+		{serdetypes.Tuple(), true},
+		{serdetypes.TupleArray(serdetypes.Primitive(serdetypes.U64Kind), 0), true},
+		{serdetypes.TupleArray(serdetypes.TypeName("Pair"), 3), true},
+		{serdetypes.Tuple(unit, serdetypes.Primitive(serdetypes.U8Kind)), false},
+		{serdetypes.Option(unit), false},
+		{serdetypes.Seq(unit), false},
+		{serdetypes.TypeName("Loop"), false},
+		{serdetypes.TypeName("Choice"), false},
+		{serdetypes.TypeName("Unknown"), false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.empty, registry.HasEmptyEncoding(&tc.format), "%+v", tc.format)
+	}
+}
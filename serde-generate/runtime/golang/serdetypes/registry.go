@@ -0,0 +1,460 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package serdetypes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReadRegistry reads the registry saved by serde-reflection at `path` (see `ParseRegistry`).
+func ReadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := ParseRegistry(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return registry, nil
+}
+
+// ParseRegistry parses a registry serialized by serde-reflection in YAML (e.g. with
+// `serde_yaml::to_string(&registry)`) or JSON. Errors mention the name of the container and
+// the line of the invalid format, e.g. `Point: line 3: unknown format "U31"`.
+//
+// Registries are read as YAML nodes (rather than decoded into maps) so that the order of
+// fields is preserved. Since YAML is a superset of JSON, this also accepts JSON registries.
+func ParseRegistry(data []byte) (*Registry, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	registry := &Registry{Containers: make(map[string]ContainerFormat)}
+	if len(document.Content) == 0 {
+		return registry, nil
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nodeError(root, "expected a map from container names to formats")
+	}
+	for i := 0; i < len(root.Content); i += 2 {
+		name := root.Content[i].Value
+		format, err := parseContainerFormat(root.Content[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		registry.Containers[name] = format
+	}
+	return registry, nil
+}
+
+// MarshalRegistry serializes `registry` in YAML, as serde-reflection does (containers are
+// sorted by name), so that it can be read by `serdegen` and the generators of other languages.
+func MarshalRegistry(registry *Registry) ([]byte, error) {
+	names := make([]string, 0, len(registry.Containers))
+	for name := range registry.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range names {
+		format := registry.Containers[name]
+		node, err := containerFormatNode(&format)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		root.Content = append(root.Content, scalarNode(name), node)
+	}
+	var out bytes.Buffer
+	out.WriteString("---\n")
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// The inverse of `parseTag`.
+func tagNode(tag string, content *yaml.Node) *yaml.Node {
+	if content == nil {
+		return scalarNode(tag)
+	}
+	return &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{scalarNode(tag), content}}
+}
+
+func formatNode(format *Format) (*yaml.Node, error) {
+	for name, kind := range primitiveKinds {
+		if format.Kind == kind {
+			return scalarNode(name), nil
+		}
+	}
+	switch format.Kind {
+	case TypeNameKind:
+		return tagNode("TYPENAME", scalarNode(format.Name)), nil
+	case OptionKind, SeqKind:
+		content, err := formatNode(format.Content)
+		if err != nil {
+			return nil, err
+		}
+		return tagNode(map[FormatKind]string{OptionKind: "OPTION", SeqKind: "SEQ"}[format.Kind], content), nil
+	case MapKind:
+		key, err := formatNode(format.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := formatNode(format.Value)
+		if err != nil {
+			return nil, err
+		}
+		entries := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{scalarNode("KEY"), key, scalarNode("VALUE"), value}}
+		return tagNode("MAP", entries), nil
+	case TupleKind:
+		elements, err := formatsNode(format.Elements)
+		if err != nil {
+			return nil, err
+		}
+		return tagNode("TUPLE", elements), nil
+	case TupleArrayKind:
+		content, err := formatNode(format.Content)
+		if err != nil {
+			return nil, err
+		}
+		size := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatUint(format.Size, 10)}
+		entries := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{scalarNode("CONTENT"), content, scalarNode("SIZE"), size}}
+		return tagNode("TUPLEARRAY", entries), nil
+	}
+	return nil, fmt.Errorf("unexpected format kind: %d", format.Kind)
+}
+
+func formatsNode(formats []Format) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.SequenceNode}
+	for i := range formats {
+		element, err := formatNode(&formats[i])
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, element)
+	}
+	return node, nil
+}
+
+func namedFormatsNode(fields []Named[Format]) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.SequenceNode}
+	for i := range fields {
+		field, err := formatNode(&fields[i].Value)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, tagNode(fields[i].Name, field))
+	}
+	return node, nil
+}
+
+func containerFormatNode(format *ContainerFormat) (*yaml.Node, error) {
+	switch format.Kind {
+	case UnitStructKind:
+		return scalarNode("UNITSTRUCT"), nil
+	case NewTypeStructKind:
+		content, err := formatNode(format.Content)
+		if err != nil {
+			return nil, err
+		}
+		return tagNode("NEWTYPESTRUCT", content), nil
+	case TupleStructKind:
+		elements, err := formatsNode(format.Elements)
+		if err != nil {
+			return nil, err
+		}
+		return tagNode("TUPLESTRUCT", elements), nil
+	case StructKind:
+		fields, err := namedFormatsNode(format.Fields)
+		if err != nil {
+			return nil, err
+		}
+		return tagNode("STRUCT", fields), nil
+	case EnumKind:
+		indices := make([]uint32, 0, len(format.Variants))
+		for index := range format.Variants {
+			indices = append(indices, index)
+		}
+		sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+		variants := &yaml.Node{Kind: yaml.MappingNode}
+		for _, index := range indices {
+			variant := format.Variants[index]
+			node, err := variantFormatNode(&variant.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", variant.Name, err)
+			}
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatUint(uint64(index), 10)}
+			variants.Content = append(variants.Content, key, tagNode(variant.Name, node))
+		}
+		return tagNode("ENUM", variants), nil
+	}
+	return nil, fmt.Errorf("unexpected container kind: %d", format.Kind)
+}
+
+func variantFormatNode(format *VariantFormat) (*yaml.Node, error) {
+	switch format.Kind {
+	case UnitVariantKind:
+		return scalarNode("UNIT"), nil
+	case NewTypeVariantKind:
+		content, err := formatNode(format.Content)
+		if err != nil {
+			return nil, err
+		}
+		return tagNode("NEWTYPE", content), nil
+	case TupleVariantKind:
+		elements, err := formatsNode(format.Elements)
+		if err != nil {
+			return nil, err
+		}
+		return tagNode("TUPLE", elements), nil
+	case StructVariantKind:
+		fields, err := namedFormatsNode(format.Fields)
+		if err != nil {
+			return nil, err
+		}
+		return tagNode("STRUCT", fields), nil
+	}
+	return nil, fmt.Errorf("unexpected variant kind: %d", format.Kind)
+}
+
+func nodeError(node *yaml.Node, message string) error {
+	return fmt.Errorf("line %d: %s", node.Line, message)
+}
+
+// Serde-reflection serializes the variants of formats as plain strings when they carry no data
+// (e.g. `U64`), otherwise as single-entry maps (e.g. `SEQ: U64`).
+func parseTag(node *yaml.Node) (string, *yaml.Node, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value, nil, nil
+	case yaml.MappingNode:
+		if len(node.Content) == 2 {
+			return node.Content[0].Value, node.Content[1], nil
+		}
+	}
+	return "", nil, nodeError(node, "expected a string or a map with a single entry")
+}
+
+var primitiveKinds = map[string]FormatKind{
+	"UNIT":  UnitKind,
+	"BOOL":  BoolKind,
+	"I8":    I8Kind,
+	"I16":   I16Kind,
+	"I32":   I32Kind,
+	"I64":   I64Kind,
+	"I128":  I128Kind,
+	"U8":    U8Kind,
+	"U16":   U16Kind,
+	"U32":   U32Kind,
+	"U64":   U64Kind,
+	"U128":  U128Kind,
+	"F32":   F32Kind,
+	"F64":   F64Kind,
+	"CHAR":  CharKind,
+	"STR":   StrKind,
+	"BYTES": BytesKind,
+}
+
+func parseFormat(node *yaml.Node) (Format, error) {
+	tag, content, err := parseTag(node)
+	if err != nil {
+		return Format{}, err
+	}
+	if content == nil {
+		kind, ok := primitiveKinds[tag]
+		if !ok {
+			return Format{}, nodeError(node, fmt.Sprintf("unknown format %q", tag))
+		}
+		return Primitive(kind), nil
+	}
+	switch tag {
+	case "TYPENAME":
+		if content.Kind != yaml.ScalarNode {
+			return Format{}, nodeError(content, "expected a container name")
+		}
+		return TypeName(content.Value), nil
+	case "OPTION":
+		format, err := parseFormat(content)
+		return Option(format), err
+	case "SEQ":
+		format, err := parseFormat(content)
+		return Seq(format), err
+	case "MAP":
+		entries, err := parseEntries(content, "KEY", "VALUE")
+		if err != nil {
+			return Format{}, err
+		}
+		key, err := parseFormat(entries["KEY"])
+		if err != nil {
+			return Format{}, err
+		}
+		value, err := parseFormat(entries["VALUE"])
+		return Map(key, value), err
+	case "TUPLE":
+		formats, err := parseFormats(content)
+		return Tuple(formats...), err
+	case "TUPLEARRAY":
+		entries, err := parseEntries(content, "CONTENT", "SIZE")
+		if err != nil {
+			return Format{}, err
+		}
+		format, err := parseFormat(entries["CONTENT"])
+		if err != nil {
+			return Format{}, err
+		}
+		size, err := strconv.ParseUint(entries["SIZE"].Value, 10, 64)
+		if err != nil {
+			return Format{}, nodeError(entries["SIZE"], "expected the size of an array")
+		}
+		return TupleArray(format, size), nil
+	}
+	return Format{}, nodeError(node, fmt.Sprintf("unknown format %q", tag))
+}
+
+// Read a map with exactly the given keys.
+func parseEntries(node *yaml.Node, keys ...string) (map[string]*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2*len(keys) {
+		return nil, nodeError(node, fmt.Sprintf("expected a map with keys %v", keys))
+	}
+	entries := make(map[string]*yaml.Node)
+	for i := 0; i < len(node.Content); i += 2 {
+		entries[node.Content[i].Value] = node.Content[i+1]
+	}
+	for _, key := range keys {
+		if entries[key] == nil {
+			return nil, nodeError(node, fmt.Sprintf("missing key %q", key))
+		}
+	}
+	return entries, nil
+}
+
+func parseFormats(node *yaml.Node) ([]Format, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, nodeError(node, "expected a list of formats")
+	}
+	formats := make([]Format, 0, len(node.Content))
+	for _, item := range node.Content {
+		format, err := parseFormat(item)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// Named formats are single-entry maps, e.g. `- x: U32`.
+func parseNamedFormats(node *yaml.Node) ([]Named[Format], error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, nodeError(node, "expected a list of fields")
+	}
+	fields := make([]Named[Format], 0, len(node.Content))
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode || len(item.Content) != 2 {
+			return nil, nodeError(item, "expected a field")
+		}
+		format, err := parseFormat(item.Content[1])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, Named[Format]{Name: item.Content[0].Value, Value: format})
+	}
+	return fields, nil
+}
+
+func parseContainerFormat(node *yaml.Node) (ContainerFormat, error) {
+	tag, content, err := parseTag(node)
+	if err != nil {
+		return ContainerFormat{}, err
+	}
+	if content == nil {
+		if tag != "UNITSTRUCT" {
+			return ContainerFormat{}, nodeError(node, fmt.Sprintf("unknown container format %q", tag))
+		}
+		return ContainerFormat{Kind: UnitStructKind}, nil
+	}
+	switch tag {
+	case "NEWTYPESTRUCT":
+		format, err := parseFormat(content)
+		return ContainerFormat{Kind: NewTypeStructKind, Content: &format}, err
+	case "TUPLESTRUCT":
+		formats, err := parseFormats(content)
+		return ContainerFormat{Kind: TupleStructKind, Elements: formats}, err
+	case "STRUCT":
+		fields, err := parseNamedFormats(content)
+		return ContainerFormat{Kind: StructKind, Fields: fields}, err
+	case "ENUM":
+		variants, err := parseVariants(content)
+		return ContainerFormat{Kind: EnumKind, Variants: variants}, err
+	}
+	return ContainerFormat{}, nodeError(node, fmt.Sprintf("unknown container format %q", tag))
+}
+
+// Variants are indexed by their variant index, e.g. `0: {A: UNIT}`. (JSON keys are strings.)
+func parseVariants(node *yaml.Node) (map[uint32]Named[VariantFormat], error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nodeError(node, "expected a map from variant indices to variants")
+	}
+	variants := make(map[uint32]Named[VariantFormat])
+	for i := 0; i < len(node.Content); i += 2 {
+		index, err := strconv.ParseUint(node.Content[i].Value, 10, 32)
+		if err != nil {
+			return nil, nodeError(node.Content[i], "expected a variant index")
+		}
+		item := node.Content[i+1]
+		if item.Kind != yaml.MappingNode || len(item.Content) != 2 {
+			return nil, nodeError(item, "expected a variant")
+		}
+		variant, err := parseVariantFormat(item.Content[1])
+		if err != nil {
+			return nil, err
+		}
+		variants[uint32(index)] = Named[VariantFormat]{Name: item.Content[0].Value, Value: variant}
+	}
+	return variants, nil
+}
+
+func parseVariantFormat(node *yaml.Node) (VariantFormat, error) {
+	tag, content, err := parseTag(node)
+	if err != nil {
+		return VariantFormat{}, err
+	}
+	if content == nil {
+		if tag != "UNIT" {
+			return VariantFormat{}, nodeError(node, fmt.Sprintf("unknown variant format %q", tag))
+		}
+		return VariantFormat{Kind: UnitVariantKind}, nil
+	}
+	switch tag {
+	case "NEWTYPE":
+		format, err := parseFormat(content)
+		return VariantFormat{Kind: NewTypeVariantKind, Content: &format}, err
+	case "TUPLE":
+		formats, err := parseFormats(content)
+		return VariantFormat{Kind: TupleVariantKind, Elements: formats}, err
+	case "STRUCT":
+		fields, err := parseNamedFormats(content)
+		return VariantFormat{Kind: StructVariantKind, Fields: fields}, err
+	}
+	return VariantFormat{}, nodeError(node, fmt.Sprintf("unknown variant format %q", tag))
+}
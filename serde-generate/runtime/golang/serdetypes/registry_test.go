@@ -0,0 +1,145 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package serdetypes_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegistry(t *testing.T) {
+	yaml := `---
+Point:
+  STRUCT:
+    - x: U32
+    - label:
+        OPTION: STR
+Shape:
+  ENUM:
+    0:
+      Empty: UNIT
+    1:
+      Polygon:
+        NEWTYPE:
+          SEQ:
+            TYPENAME: Point
+    2:
+      Grid:
+        TUPLE:
+          - TUPLEARRAY:
+              CONTENT: U8
+              SIZE: 2
+          - MAP:
+              KEY: STR
+              VALUE: UNIT
+`
+	json := `{
+  "Point": {"STRUCT": [{"x": "U32"}, {"label": {"OPTION": "STR"}}]},
+  "Shape": {"ENUM": {
+    "0": {"Empty": "UNIT"},
+    "1": {"Polygon": {"NEWTYPE": {"SEQ": {"TYPENAME": "Point"}}}},
+    "2": {"Grid": {"TUPLE": [
+      {"TUPLEARRAY": {"CONTENT": "U8", "SIZE": 2}},
+      {"MAP": {"KEY": "STR", "VALUE": "UNIT"}}
+    ]}}
+  }}
+}`
+	polygon := serdetypes.Seq(serdetypes.TypeName("Point"))
+	expected := &serdetypes.Registry{Containers: map[string]serdetypes.ContainerFormat{
+		"Point": {
+			Kind: serdetypes.StructKind,
+			Fields: []serdetypes.Named[serdetypes.Format]{
+				{Name: "x", Value: serdetypes.Primitive(serdetypes.U32Kind)},
+				{Name: "label", Value: serdetypes.Option(serdetypes.Primitive(serdetypes.StrKind))},
+			},
+		},
+		"Shape": {
+			Kind: serdetypes.EnumKind,
+			Variants: map[uint32]serdetypes.Named[serdetypes.VariantFormat]{
+				0: {Name: "Empty", Value: serdetypes.VariantFormat{Kind: serdetypes.UnitVariantKind}},
+				1: {Name: "Polygon", Value: serdetypes.VariantFormat{Kind: serdetypes.NewTypeVariantKind, Content: &polygon}},
+				2: {Name: "Grid", Value: serdetypes.VariantFormat{
+					Kind: serdetypes.TupleVariantKind,
+					Elements: []serdetypes.Format{
+						serdetypes.TupleArray(serdetypes.Primitive(serdetypes.U8Kind), 2),
+						serdetypes.Map(serdetypes.Primitive(serdetypes.StrKind), serdetypes.Primitive(serdetypes.UnitKind)),
+					},
+				}},
+			},
+		},
+	}}
+	for _, input := range []string{yaml, json} {
+		registry, err := serdetypes.ParseRegistry([]byte(input))
+		require.NoError(t, err)
+		assert.Equal(t, expected, registry)
+	}
+
+	_, err := serdetypes.ParseRegistry([]byte("Point:\n  STRUCT:\n    - x: U31\n"))
+	assert.EqualError(t, err, `Point: line 3: unknown format "U31"`)
+	_, err = serdetypes.ParseRegistry([]byte("Shape:\n  ENUM:\n    first:\n      Empty: UNIT\n"))
+	assert.EqualError(t, err, "Shape: line 3: expected a variant index")
+}
+
+func TestReadRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("Unit: UNITSTRUCT\nPoint:\n  STRUCT:\n    - x: U31\n"), 0o644))
+	_, err := serdetypes.ReadRegistry(path)
+	assert.EqualError(t, err, path+`: Point: line 4: unknown format "U31"`)
+
+	require.NoError(t, os.WriteFile(path, []byte("Unit: UNITSTRUCT\n"), 0o644))
+	registry, err := serdetypes.ReadRegistry(path)
+	require.NoError(t, err)
+	format, err := registry.Lookup("Unit")
+	require.NoError(t, err)
+	assert.Equal(t, serdetypes.UnitStructKind, format.Kind)
+
+	_, err = serdetypes.ReadRegistry(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMarshalRegistry(t *testing.T) {
+	// Sequences are not indented, which serde-reflection (and `ParseRegistry`) also accept.
+	yaml := `---
+Point:
+  STRUCT:
+  - x: U32
+  - label:
+      OPTION: STR
+Shape:
+  ENUM:
+    0:
+      Empty: UNIT
+    1:
+      Polygon:
+        NEWTYPE:
+          SEQ:
+            TYPENAME: Point
+    2:
+      Grid:
+        TUPLE:
+        - TUPLEARRAY:
+            CONTENT: U8
+            SIZE: 2
+        - MAP:
+            KEY: STR
+            VALUE: UNIT
+Unit: UNITSTRUCT
+`
+	registry, err := serdetypes.ParseRegistry([]byte(yaml))
+	require.NoError(t, err)
+	data, err := serdetypes.MarshalRegistry(registry)
+	require.NoError(t, err)
+	assert.Equal(t, yaml, string(data))
+
+	parsed, err := serdetypes.ParseRegistry(data)
+	require.NoError(t, err)
+	assert.Equal(t, registry, parsed)
+}
@@ -0,0 +1,401 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+// Package tracing derives the Serde formats of Go types by reflection, in the registries of
+// serde-reflection. This allows publishing the schema of types defined in Go, e.g. to generate
+// code in other languages with `serdegen`:
+//
+//	registry, err := tracing.TraceTypes(Transfer{}, tracing.Enum[Status](new(Status__Pending), new(Status__Failed)))
+//	...
+//	data, err := serdetypes.MarshalRegistry(registry)
+//
+// Go types are mapped to formats following the conventions of the Go code generator, so that
+// tracing generated types mostly gives back their registry. Go types cannot tell apart some
+// formats, though: `Vec<u8>` is generated as `[]uint8`, the same type as `[]byte`, hence is
+// traced as `BYTES`, and `char` is generated as `rune` (`int32`), hence is traced as `I32`
+// unless the field is tagged `serde:",char"`. Go types are mapped as follows:
+//   - `bool`, `int8`, ..., `uint64`, `float32`, `float64`, `string`, `serde.Int128`, and
+//     `serde.Uint128` are primitive formats, `struct{}` is `UNIT`, and `[]byte` is `BYTES`,
+//   - pointers and `serde.Option[T]` are options, slices are sequences, arrays are
+//     `TUPLEARRAY`s, and maps are maps,
+//   - anonymous structs with fields `Field0`, `Field1`, ... are tuples,
+//   - other named types are containers, named after the Go type: structs are `STRUCT`s (or
+//     `TUPLESTRUCT`s, for fields `Field0`, `Field1`, ...), empty structs are `UNITSTRUCT`s, and
+//     other types are `NEWTYPESTRUCT`s of their underlying type,
+//   - interfaces are enums, whose variants must be declared with `Enum`.
+//
+// Fields are named after the tag `serde:"name"` (or otherwise `json:"name"`), or else after
+// the Go name in snake case. Unexported fields and fields tagged `serde:"-"` (or else
+// `json:"-"`) are skipped.
+// The option `serde:",char"` marks `rune` fields as `CHAR` (rather than `I32`). Since `int`
+// and `uint` do not have a fixed size, they are rejected.
+package tracing
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// EnumType declares the variants of an enum represented by the Go interface `T`
+// (see `Enum`).
+type EnumType struct {
+	enum     reflect.Type
+	variants []reflect.Type
+}
+
+// Enum declares the variants of an enum represented by the Go interface `T`, in the order of
+// their variant indices. Variants are given as values of `T` (e.g. `new(Status__Failed)`) and
+// are named after their method `VariantName() string`, if any, or else after their Go type
+// without the prefix `<Enum>__`. They are mapped to formats as structs are, except that
+// variants with a single field named `Value` are newtype variants.
+func Enum[T any](variants ...T) EnumType {
+	e := EnumType{enum: reflect.TypeOf((*T)(nil)).Elem()}
+	for _, variant := range variants {
+		e.variants = append(e.variants, reflect.TypeOf(variant))
+	}
+	return e
+}
+
+// TraceTypes returns the registry of the containers used by the types of `values`, which are
+// values (or pointers to values) of named Go types, or declarations of enums made with `Enum`.
+func TraceTypes(values ...interface{}) (*serdetypes.Registry, error) {
+	t := tracer{
+		registry: &serdetypes.Registry{Containers: make(map[string]serdetypes.ContainerFormat)},
+		enums:    make(map[reflect.Type]*EnumType),
+		names:    make(map[string]reflect.Type),
+	}
+	var roots []reflect.Type
+	for _, value := range values {
+		switch value := value.(type) {
+		case EnumType:
+			if value.enum.Kind() != reflect.Interface {
+				return nil, fmt.Errorf("%v is not an interface", value.enum)
+			}
+			enum := value
+			t.enums[value.enum] = &enum
+			roots = append(roots, value.enum)
+		case nil:
+			return nil, fmt.Errorf("unexpected nil value")
+		default:
+			root := reflect.TypeOf(value)
+			if root.Kind() == reflect.Ptr {
+				root = root.Elem()
+			}
+			roots = append(roots, root)
+		}
+	}
+	for _, root := range roots {
+		if !isContainer(root) {
+			return nil, fmt.Errorf("%v is not a named type", root)
+		}
+		if _, err := t.format(root, fieldOptions{}); err != nil {
+			return nil, err
+		}
+	}
+	return t.registry, nil
+}
+
+type tracer struct {
+	registry *serdetypes.Registry
+	enums    map[reflect.Type]*EnumType
+	// The Go type of each container, to detect types with the same name.
+	names map[string]reflect.Type
+}
+
+type fieldOptions struct {
+	char bool
+}
+
+var (
+	int128Type  = reflect.TypeOf(serde.Int128{})
+	uint128Type = reflect.TypeOf(serde.Uint128{})
+	serdePath   = int128Type.PkgPath()
+)
+
+func isOption(t reflect.Type) bool {
+	return t.PkgPath() == serdePath && strings.HasPrefix(t.Name(), "Option[")
+}
+
+// Named types are containers, except for the types of the package `serde`.
+func isContainer(t reflect.Type) bool {
+	return t.Name() != "" && t.PkgPath() != "" && t != int128Type && t != uint128Type && !isOption(t)
+}
+
+func (t *tracer) format(typ reflect.Type, options fieldOptions) (serdetypes.Format, error) {
+	if isContainer(typ) {
+		if err := t.container(typ); err != nil {
+			return serdetypes.Format{}, err
+		}
+		return serdetypes.TypeName(typ.Name()), nil
+	}
+	return t.content(typ, options)
+}
+
+// The format of `typ`, ignoring its name.
+func (t *tracer) content(typ reflect.Type, options fieldOptions) (serdetypes.Format, error) {
+	switch {
+	case typ == int128Type:
+		return serdetypes.Primitive(serdetypes.I128Kind), nil
+	case typ == uint128Type:
+		return serdetypes.Primitive(serdetypes.U128Kind), nil
+	case isOption(typ):
+		// The content of the option is in the unexported field `value`.
+		field, _ := typ.FieldByName("value")
+		content, err := t.format(field.Type, options)
+		return serdetypes.Option(content), err
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return serdetypes.Primitive(serdetypes.BoolKind), nil
+	case reflect.Int8:
+		return serdetypes.Primitive(serdetypes.I8Kind), nil
+	case reflect.Int16:
+		return serdetypes.Primitive(serdetypes.I16Kind), nil
+	case reflect.Int32:
+		if options.char {
+			return serdetypes.Primitive(serdetypes.CharKind), nil
+		}
+		return serdetypes.Primitive(serdetypes.I32Kind), nil
+	case reflect.Int64:
+		return serdetypes.Primitive(serdetypes.I64Kind), nil
+	case reflect.Uint8:
+		return serdetypes.Primitive(serdetypes.U8Kind), nil
+	case reflect.Uint16:
+		return serdetypes.Primitive(serdetypes.U16Kind), nil
+	case reflect.Uint32:
+		return serdetypes.Primitive(serdetypes.U32Kind), nil
+	case reflect.Uint64:
+		return serdetypes.Primitive(serdetypes.U64Kind), nil
+	case reflect.Float32:
+		return serdetypes.Primitive(serdetypes.F32Kind), nil
+	case reflect.Float64:
+		return serdetypes.Primitive(serdetypes.F64Kind), nil
+	case reflect.String:
+		return serdetypes.Primitive(serdetypes.StrKind), nil
+	case reflect.Ptr:
+		content, err := t.format(typ.Elem(), options)
+		return serdetypes.Option(content), err
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 && !isContainer(typ.Elem()) {
+			return serdetypes.Primitive(serdetypes.BytesKind), nil
+		}
+		content, err := t.format(typ.Elem(), options)
+		return serdetypes.Seq(content), err
+	case reflect.Array:
+		content, err := t.format(typ.Elem(), options)
+		return serdetypes.TupleArray(content, uint64(typ.Len())), err
+	case reflect.Map:
+		key, err := t.format(typ.Key(), fieldOptions{})
+		if err != nil {
+			return serdetypes.Format{}, err
+		}
+		value, err := t.format(typ.Elem(), options)
+		return serdetypes.Map(key, value), err
+	case reflect.Struct:
+		fields, err := t.fields(typ)
+		if err != nil {
+			return serdetypes.Format{}, err
+		}
+		if len(fields) == 0 {
+			return serdetypes.Primitive(serdetypes.UnitKind), nil
+		}
+		if !isTuple(fields) {
+			return serdetypes.Format{}, fmt.Errorf("%v: the fields of anonymous structs must be named Field0, Field1, ...", typ)
+		}
+		return serdetypes.Tuple(formats(fields)...), nil
+	case reflect.Interface:
+		return serdetypes.Format{}, fmt.Errorf("%v: anonymous interfaces are not supported", typ)
+	default:
+		return serdetypes.Format{}, fmt.Errorf("%v: %v values are not supported", typ, typ.Kind())
+	}
+}
+
+// A field of a Go struct, with its Go name.
+type field struct {
+	goName string
+	serdetypes.Named[serdetypes.Format]
+}
+
+func formats(fields []field) []serdetypes.Format {
+	result := make([]serdetypes.Format, 0, len(fields))
+	for _, f := range fields {
+		result = append(result, f.Value)
+	}
+	return result
+}
+
+func namedFormats(fields []field) []serdetypes.Named[serdetypes.Format] {
+	result := make([]serdetypes.Named[serdetypes.Format], 0, len(fields))
+	for _, f := range fields {
+		result = append(result, f.Named)
+	}
+	return result
+}
+
+// Whether the fields are named `Field0`, `Field1`, ... as the elements of tuples in generated code.
+func isTuple(fields []field) bool {
+	for i, f := range fields {
+		if f.goName != fmt.Sprintf("Field%d", i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *tracer) fields(typ reflect.Type) ([]field, error) {
+	var fields []field
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag, hasTag := f.Tag.Lookup("serde")
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+		if f.Anonymous {
+			return nil, fmt.Errorf("%v.%s: embedded fields are not supported", typ, f.Name)
+		}
+		name, rest, _ := strings.Cut(tag, ",")
+		if !hasTag {
+			jsonTag := f.Tag.Get("json")
+			if jsonTag == "-" {
+				continue
+			}
+			// Ignore the options of `encoding/json`, e.g. `omitempty`.
+			name, _, _ = strings.Cut(jsonTag, ",")
+		}
+		if name == "" {
+			name = toSnakeCase(f.Name)
+		}
+		var options fieldOptions
+		for _, option := range strings.Split(rest, ",") {
+			switch option {
+			case "":
+			case "char":
+				options.char = true
+			default:
+				return nil, fmt.Errorf("%v.%s: unknown option %q", typ, f.Name, option)
+			}
+		}
+		format, err := t.format(f.Type, options)
+		if err != nil {
+			return nil, fmt.Errorf("%v.%s: %w", typ, f.Name, err)
+		}
+		fields = append(fields, field{goName: f.Name, Named: serdetypes.Named[serdetypes.Format]{Name: name, Value: format}})
+	}
+	return fields, nil
+}
+
+func (t *tracer) container(typ reflect.Type) error {
+	name := typ.Name()
+	if other, ok := t.names[name]; ok {
+		if other != typ {
+			return fmt.Errorf("%v and %v are both named %s", other, typ, name)
+		}
+		// The container is already traced (or being traced, for recursive types).
+		return nil
+	}
+	t.names[name] = typ
+	format, err := t.containerFormat(typ)
+	if err != nil {
+		return err
+	}
+	t.registry.Containers[name] = format
+	return nil
+}
+
+func (t *tracer) containerFormat(typ reflect.Type) (serdetypes.ContainerFormat, error) {
+	switch typ.Kind() {
+	case reflect.Interface:
+		enum, ok := t.enums[typ]
+		if !ok {
+			return serdetypes.ContainerFormat{}, fmt.Errorf("%v is an interface: its variants must be declared with tracing.Enum", typ)
+		}
+		return t.enumFormat(enum)
+	case reflect.Struct:
+		fields, err := t.fields(typ)
+		if err != nil {
+			return serdetypes.ContainerFormat{}, err
+		}
+		switch {
+		case len(fields) == 0:
+			return serdetypes.ContainerFormat{Kind: serdetypes.UnitStructKind}, nil
+		case isTuple(fields):
+			return serdetypes.ContainerFormat{Kind: serdetypes.TupleStructKind, Elements: formats(fields)}, nil
+		default:
+			return serdetypes.ContainerFormat{Kind: serdetypes.StructKind, Fields: namedFormats(fields)}, nil
+		}
+	default:
+		content, err := t.content(typ, fieldOptions{})
+		if err != nil {
+			return serdetypes.ContainerFormat{}, err
+		}
+		return serdetypes.ContainerFormat{Kind: serdetypes.NewTypeStructKind, Content: &content}, nil
+	}
+}
+
+type variantNamer interface {
+	VariantName() string
+}
+
+func (t *tracer) enumFormat(enum *EnumType) (serdetypes.ContainerFormat, error) {
+	format := serdetypes.ContainerFormat{Kind: serdetypes.EnumKind, Variants: make(map[uint32]serdetypes.Named[serdetypes.VariantFormat])}
+	for index, typ := range enum.variants {
+		value := reflect.New(typ).Elem().Interface()
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+			value = reflect.New(typ).Interface()
+		}
+		name := strings.TrimPrefix(typ.Name(), enum.enum.Name()+"__")
+		if namer, ok := value.(variantNamer); ok {
+			name = namer.VariantName()
+		}
+		variant, err := t.variantFormat(typ)
+		if err != nil {
+			return serdetypes.ContainerFormat{}, fmt.Errorf("%v: %w", enum.enum, err)
+		}
+		format.Variants[uint32(index)] = serdetypes.Named[serdetypes.VariantFormat]{Name: name, Value: variant}
+	}
+	return format, nil
+}
+
+func (t *tracer) variantFormat(typ reflect.Type) (serdetypes.VariantFormat, error) {
+	if typ.Kind() != reflect.Struct {
+		content, err := t.content(typ, fieldOptions{})
+		return serdetypes.VariantFormat{Kind: serdetypes.NewTypeVariantKind, Content: &content}, err
+	}
+	fields, err := t.fields(typ)
+	if err != nil {
+		return serdetypes.VariantFormat{}, err
+	}
+	switch {
+	case len(fields) == 0:
+		return serdetypes.VariantFormat{Kind: serdetypes.UnitVariantKind}, nil
+	case len(fields) == 1 && fields[0].goName == "Value":
+		return serdetypes.VariantFormat{Kind: serdetypes.NewTypeVariantKind, Content: &fields[0].Value}, nil
+	case isTuple(fields):
+		return serdetypes.VariantFormat{Kind: serdetypes.TupleVariantKind, Elements: formats(fields)}, nil
+	default:
+		return serdetypes.VariantFormat{Kind: serdetypes.StructVariantKind, Fields: namedFormats(fields)}, nil
+	}
+}
+
+// Convert a Go name to snake case, e.g. `HTTPServer` to `http_server`.
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+//go:build !tinygo
+
+package tracing_test
+
+import (
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	st "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Transfer struct {
+	Amount  uint64
+	Memo    *string
+	Tags    map[string]bool `serde:"labels"`
+	Payload []byte
+	Digest  Digest
+	Status  Status
+	Pair    struct {
+		Field0 uint8
+		Field1 int16
+	}
+	Initial    rune `serde:",char"`
+	BigAmount  serde.Uint128
+	Children   []Transfer
+	HTTPHeader serde.Option[string] `json:"header,omitempty"`
+	Secret     string               `serde:"-"`
+	Cache      []byte               `json:"-"`
+	internal   int
+}
+
+type Digest [4]uint8
+
+type Status interface {
+	isStatus()
+}
+
+type Status__Pending struct{}
+
+type Status__Failed uint16
+
+type Status__Moved struct {
+	Value Digest
+}
+
+type Status__Split struct {
+	Field0 Point
+	Field1 int8
+}
+
+type Status__Renamed struct {
+	To string
+}
+
+func (*Status__Pending) isStatus() {}
+func (*Status__Failed) isStatus()  {}
+func (*Status__Moved) isStatus()   {}
+func (*Status__Split) isStatus()   {}
+func (*Status__Renamed) isStatus() {}
+
+func (*Status__Renamed) VariantName() string {
+	return "renamed"
+}
+
+type Point struct{ Field0, Field1 int32 }
+
+type Empty struct{}
+
+var statusEnum = tracing.Enum[Status](new(Status__Pending), new(Status__Failed), new(Status__Moved), new(Status__Split), new(Status__Renamed))
+
+func TestTraceTypes(t *testing.T) {
+	registry, err := tracing.TraceTypes(&Transfer{}, Empty{}, statusEnum)
+	require.NoError(t, err)
+
+	u16 := st.Primitive(st.U16Kind)
+	digest := st.TypeName("Digest")
+	digestContent := st.TupleArray(st.Primitive(st.U8Kind), 4)
+	expected := st.Registry{Containers: map[string]st.ContainerFormat{
+		"Transfer": {Kind: st.StructKind, Fields: []st.Named[st.Format]{
+			{Name: "amount", Value: st.Primitive(st.U64Kind)},
+			{Name: "memo", Value: st.Option(st.Primitive(st.StrKind))},
+			{Name: "labels", Value: st.Map(st.Primitive(st.StrKind), st.Primitive(st.BoolKind))},
+			{Name: "payload", Value: st.Primitive(st.BytesKind)},
+			{Name: "digest", Value: digest},
+			{Name: "status", Value: st.TypeName("Status")},
+			{Name: "pair", Value: st.Tuple(st.Primitive(st.U8Kind), st.Primitive(st.I16Kind))},
+			{Name: "initial", Value: st.Primitive(st.CharKind)},
+			{Name: "big_amount", Value: st.Primitive(st.U128Kind)},
+			{Name: "children", Value: st.Seq(st.TypeName("Transfer"))},
+			{Name: "header", Value: st.Option(st.Primitive(st.StrKind))},
+		}},
+		"Digest": {Kind: st.NewTypeStructKind, Content: &digestContent},
+		"Status": {Kind: st.EnumKind, Variants: map[uint32]st.Named[st.VariantFormat]{
+			0: {Name: "Pending", Value: st.VariantFormat{Kind: st.UnitVariantKind}},
+			1: {Name: "Failed", Value: st.VariantFormat{Kind: st.NewTypeVariantKind, Content: &u16}},
+			2: {Name: "Moved", Value: st.VariantFormat{Kind: st.NewTypeVariantKind, Content: &digest}},
+			3: {Name: "Split", Value: st.VariantFormat{Kind: st.TupleVariantKind, Elements: []st.Format{st.TypeName("Point"), st.Primitive(st.I8Kind)}}},
+			4: {Name: "renamed", Value: st.VariantFormat{Kind: st.StructVariantKind, Fields: []st.Named[st.Format]{
+				{Name: "to", Value: st.Primitive(st.StrKind)},
+			}}},
+		}},
+		"Point": {Kind: st.TupleStructKind, Elements: []st.Format{st.Primitive(st.I32Kind), st.Primitive(st.I32Kind)}},
+		"Empty": {Kind: st.UnitStructKind},
+	}}
+	assert.Equal(t, &expected, registry)
+
+	// Traced registries are saved in the format of serde-reflection.
+	data, err := st.MarshalRegistry(registry)
+	require.NoError(t, err)
+	parsed, err := st.ParseRegistry(data)
+	require.NoError(t, err)
+	assert.Equal(t, registry, parsed)
+}
+
+func TestTraceTypesErrors(t *testing.T) {
+	type Sized struct {
+		Count int
+	}
+	type Undeclared struct {
+		Status Status
+	}
+	type Anonymous struct {
+		Pair struct{ X, Y uint8 }
+	}
+	type Option struct {
+		Char rune `serde:",character"`
+	}
+	cases := []struct {
+		name  string
+		value interface{}
+		err   string
+	}{
+		{"int", Sized{}, "tracing_test.Sized.Count: int: int values are not supported"},
+		{"undeclared enum", Undeclared{}, "tracing_test.Undeclared.Status: tracing_test.Status is an interface: its variants must be declared with tracing.Enum"},
+		{"anonymous struct", Anonymous{}, "tracing_test.Anonymous.Pair: struct { X uint8; Y uint8 }: the fields of anonymous structs must be named Field0, Field1, ..."},
+		{"unknown option", Option{}, `tracing_test.Option.Char: unknown option "character"`},
+		{"unnamed type", []uint8{}, "[]uint8 is not a named type"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tracing.TraceTypes(tc.value)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestTraceTypesNameCollision(t *testing.T) {
+	type Point struct {
+		X, Y int32
+	}
+	// This type is different from the type `Point` declared at the top level.
+	type Points struct {
+		Local  Point
+		Shared []*Status
+	}
+	_, err := tracing.TraceTypes(Points{}, statusEnum)
+	assert.EqualError(t, err, "tracing_test.Points.Shared: tracing_test.Status: tracing_test.Status__Split.Field0: tracing_test.Point and tracing_test.Point are both named Point")
+}
@@ -0,0 +1,310 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package transcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// EncodeCBOR writes `value`, a value of the container `typeName`, in CBOR (RFC 8949).
+func EncodeCBOR(value *dynamic.Value, registry *serdetypes.Registry, typeName string) ([]byte, error) {
+	c := converter{registry: registry}
+	n, err := c.fromContainer(typeName, value)
+	if err != nil {
+		return nil, wrapError(err, typeName)
+	}
+	var out bytes.Buffer
+	if err := writeCBOR(&out, &n); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// DecodeCBOR reads the CBOR item `input` as a value of the container `typeName`.
+// Items of indefinite length and tags other than bignums are not supported.
+func DecodeCBOR(input []byte, registry *serdetypes.Registry, typeName string) (dynamic.Value, error) {
+	r := cborReader{input: input}
+	n, err := r.read(0)
+	if err != nil {
+		return dynamic.Value{}, fmt.Errorf("invalid CBOR: %w (at offset %d)", err, r.offset)
+	}
+	if r.offset != len(input) {
+		return dynamic.Value{}, serde.ErrRemainingBytes
+	}
+	c := converter{registry: registry}
+	value, err := c.toContainer(typeName, &n)
+	if err != nil {
+		return dynamic.Value{}, wrapError(err, typeName)
+	}
+	return value, nil
+}
+
+// Major types of CBOR.
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTag      = 6
+	cborSimple   = 7
+)
+
+// Tags of positive and negative bignums.
+const (
+	cborPositiveBignum = 2
+	cborNegativeBignum = 3
+)
+
+// Write the head of an item of the given major type, with the argument `n`.
+func writeCBORHead(out *bytes.Buffer, major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		out.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		out.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		out.WriteByte(major | 25)
+		writeBigEndian(out, n, 2)
+	case n <= math.MaxUint32:
+		out.WriteByte(major | 26)
+		writeBigEndian(out, n, 4)
+	default:
+		out.WriteByte(major | 27)
+		writeBigEndian(out, n, 8)
+	}
+}
+
+// Write the last `size` bytes of `n` in big-endian order.
+func writeBigEndian(out *bytes.Buffer, n uint64, size int) {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], n)
+	out.Write(data[8-size:])
+}
+
+func writeCBOR(out *bytes.Buffer, n *node) error {
+	switch n.kind {
+	case nullNode:
+		out.WriteByte(0xf6)
+	case boolNode:
+		if n.boolean {
+			out.WriteByte(0xf5)
+		} else {
+			out.WriteByte(0xf4)
+		}
+	case intNode:
+		major, magnitude := byte(cborUnsigned), n.integer
+		if n.integer.Sign() < 0 {
+			// Negative integers are encoded as -1 - n.
+			major, magnitude = cborNegative, new(big.Int).Not(n.integer)
+		}
+		if magnitude.IsUint64() {
+			writeCBORHead(out, major, magnitude.Uint64())
+		} else {
+			writeCBORHead(out, cborTag, uint64(cborPositiveBignum+major))
+			writeCBORHead(out, cborBytes, uint64(len(magnitude.Bytes())))
+			out.Write(magnitude.Bytes())
+		}
+	case floatNode:
+		if n.single {
+			out.WriteByte(cborSimple<<5 | 26)
+			writeBigEndian(out, uint64(math.Float32bits(float32(n.float))), 4)
+		} else {
+			out.WriteByte(cborSimple<<5 | 27)
+			writeBigEndian(out, math.Float64bits(n.float), 8)
+		}
+	case textNode:
+		writeCBORHead(out, cborText, uint64(len(n.text)))
+		out.WriteString(n.text)
+	case bytesNode:
+		writeCBORHead(out, cborBytes, uint64(len(n.bytes)))
+		out.Write(n.bytes)
+	case arrayNode:
+		writeCBORHead(out, cborArray, uint64(len(n.items)))
+		for i := range n.items {
+			if err := writeCBOR(out, &n.items[i]); err != nil {
+				return err
+			}
+		}
+	case mapNode:
+		writeCBORHead(out, cborMap, uint64(len(n.entries)))
+		for i := range n.entries {
+			if err := writeCBOR(out, &n.entries[i].key); err != nil {
+				return err
+			}
+			if err := writeCBOR(out, &n.entries[i].value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s cannot be encoded in CBOR", nodeKindNames[n.kind])
+	}
+	return nil
+}
+
+type cborReader struct {
+	input  []byte
+	offset int
+}
+
+var errCBORIndefiniteLength = errors.New("items of indefinite length are not supported")
+
+func (r *cborReader) take(length uint64) ([]byte, error) {
+	if length > uint64(len(r.input)-r.offset) {
+		return nil, serde.ErrInputTooShort
+	}
+	data := r.input[r.offset : r.offset+int(length)]
+	r.offset += int(length)
+	return data, nil
+}
+
+// Read the head of an item: its major type, its additional information, and its argument.
+func (r *cborReader) head() (byte, byte, uint64, error) {
+	data, err := r.take(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		data, err := r.take(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		var n uint64
+		for _, b := range data {
+			n = n<<8 | uint64(b)
+		}
+		return major, info, n, nil
+	case info == 31:
+		return 0, 0, 0, errCBORIndefiniteLength
+	default:
+		return 0, 0, 0, fmt.Errorf("invalid additional information %d", info)
+	}
+}
+
+func (r *cborReader) read(depth int) (node, error) {
+	major, info, n, err := r.head()
+	if err != nil {
+		return node{}, err
+	}
+	switch major {
+	case cborUnsigned:
+		return node{kind: intNode, integer: new(big.Int).SetUint64(n)}, nil
+	case cborNegative:
+		return node{kind: intNode, integer: new(big.Int).Not(new(big.Int).SetUint64(n))}, nil
+	case cborBytes:
+		data, err := r.take(n)
+		if err != nil {
+			return node{}, err
+		}
+		return node{kind: bytesNode, bytes: append([]byte{}, data...)}, nil
+	case cborText:
+		data, err := r.take(n)
+		if err != nil {
+			return node{}, err
+		}
+		if !utf8.Valid(data) {
+			return node{}, serde.ErrInvalidUTF8
+		}
+		return node{kind: textNode, text: string(data)}, nil
+	case cborArray, cborMap:
+		if depth >= maxNestingDepth {
+			return node{}, errNestingDepthExceeded
+		}
+		// Every item takes at least one byte: do not trust larger lengths.
+		if n > uint64(len(r.input)-r.offset) {
+			return node{}, serde.ErrInputTooShort
+		}
+		result := node{kind: arrayNode}
+		if major == cborMap {
+			result.kind = mapNode
+		}
+		for i := uint64(0); i < n; i++ {
+			item, err := r.read(depth + 1)
+			if err != nil {
+				return node{}, err
+			}
+			if major == cborArray {
+				result.items = append(result.items, item)
+				continue
+			}
+			value, err := r.read(depth + 1)
+			if err != nil {
+				return node{}, err
+			}
+			result.entries = append(result.entries, entry{key: item, value: value})
+		}
+		return result, nil
+	case cborTag:
+		if n != cborPositiveBignum && n != cborNegativeBignum {
+			return node{}, fmt.Errorf("unsupported tag %d", n)
+		}
+		if depth >= maxNestingDepth {
+			return node{}, errNestingDepthExceeded
+		}
+		content, err := r.read(depth + 1)
+		if err != nil {
+			return node{}, err
+		}
+		if content.kind != bytesNode {
+			return node{}, errors.New("invalid bignum")
+		}
+		i := new(big.Int).SetBytes(content.bytes)
+		if n == cborNegativeBignum {
+			i.Not(i)
+		}
+		return node{kind: intNode, integer: i}, nil
+	default:
+		switch info {
+		case 20, 21:
+			return node{kind: boolNode, boolean: info == 21}, nil
+		case 22:
+			return node{kind: nullNode}, nil
+		case 25:
+			return node{kind: floatNode, float: float64FromHalf(uint16(n)), single: true}, nil
+		case 26:
+			return node{kind: floatNode, float: float64(math.Float32frombits(uint32(n))), single: true}, nil
+		case 27:
+			return node{kind: floatNode, float: math.Float64frombits(n)}, nil
+		default:
+			return node{}, fmt.Errorf("unsupported simple value %d", n)
+		}
+	}
+}
+
+// Convert a half-precision float (IEEE 754 binary16).
+func float64FromHalf(bits uint16) float64 {
+	exponent, mantissa := int(bits>>10&0x1f), float64(bits&0x3ff)
+	var value float64
+	switch exponent {
+	case 0:
+		value = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa != 0 {
+			return math.NaN()
+		}
+		value = math.Inf(1)
+	default:
+		value = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if bits&0x8000 != 0 {
+		return -value
+	}
+	return value
+}
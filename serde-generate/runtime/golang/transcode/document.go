@@ -0,0 +1,684 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package transcode
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// Self-describing documents (JSON or CBOR) are read and written as trees of `node`s, which are
+// converted from and to `dynamic.Value`s according to the registry.
+type nodeKind int
+
+const (
+	nullNode nodeKind = iota
+	boolNode
+	intNode
+	floatNode
+	// A JSON number, kept as written until its format is known.
+	numberNode
+	textNode
+	bytesNode
+	arrayNode
+	mapNode
+)
+
+type node struct {
+	kind    nodeKind
+	boolean bool
+	integer *big.Int
+	float   float64
+	// Whether a float is written in single precision.
+	single bool
+	// The content of texts and JSON numbers.
+	text    string
+	bytes   []byte
+	items   []node
+	entries []entry
+}
+
+type entry struct {
+	key   node
+	value node
+}
+
+var nodeKindNames = map[nodeKind]string{
+	nullNode:   "null",
+	boolNode:   "boolean",
+	intNode:    "integer",
+	floatNode:  "float",
+	numberNode: "number",
+	textNode:   "string",
+	bytesNode:  "byte string",
+	arrayNode:  "array",
+	mapNode:    "map",
+}
+
+// Maximum nesting of arrays and maps accepted when parsing documents, so that a malicious
+// input cannot exhaust the stack.
+const maxNestingDepth = 500
+
+var errNestingDepthExceeded = fmt.Errorf("exceeded maximum nesting depth of %d", maxNestingDepth)
+
+type converter struct {
+	registry *serdetypes.Registry
+	// Whether bytes and 128-bit integers are represented as texts, as in JSON.
+	textual bool
+}
+
+func invalidValue(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", serde.ErrInvalidValue, fmt.Sprintf(format, args...))
+}
+
+func unexpectedNode(expected string, n *node) error {
+	return invalidValue("expected %s, got %s", expected, nodeKindNames[n.kind])
+}
+
+// Conversion from values to nodes.
+
+func primitive[T any](value *dynamic.Value) (T, error) {
+	v, ok := value.Primitive.(T)
+	if value.Kind != dynamic.PrimitiveValue || !ok {
+		return v, invalidValue("expected a value of type %T", v)
+	}
+	return v, nil
+}
+
+func integerOf(value *dynamic.Value) (*big.Int, error) {
+	if value.Kind == dynamic.PrimitiveValue {
+		switch v := value.Primitive.(type) {
+		case int8:
+			return big.NewInt(int64(v)), nil
+		case int16:
+			return big.NewInt(int64(v)), nil
+		case int32:
+			return big.NewInt(int64(v)), nil
+		case int64:
+			return big.NewInt(v), nil
+		case uint8:
+			return new(big.Int).SetUint64(uint64(v)), nil
+		case uint16:
+			return new(big.Int).SetUint64(uint64(v)), nil
+		case uint32:
+			return new(big.Int).SetUint64(uint64(v)), nil
+		case uint64:
+			return new(big.Int).SetUint64(v), nil
+		case serde.Int128:
+			return v.BigInt(), nil
+		case serde.Uint128:
+			return v.BigInt(), nil
+		}
+	}
+	return nil, invalidValue("expected an integer")
+}
+
+func checkValueKind(value *dynamic.Value, kind dynamic.ValueKind, name string) error {
+	if value.Kind != kind {
+		return invalidValue("expected %s", name)
+	}
+	return nil
+}
+
+func (c *converter) fromValue(format *serdetypes.Format, value *dynamic.Value) (node, error) {
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return c.fromContainer(format.Name, value)
+	case serdetypes.UnitKind:
+		if value.Kind != dynamic.PrimitiveValue || value.Primitive != nil {
+			return node{}, invalidValue("expected unit")
+		}
+		return node{kind: nullNode}, nil
+	case serdetypes.BoolKind:
+		b, err := primitive[bool](value)
+		return node{kind: boolNode, boolean: b}, err
+	case serdetypes.I8Kind, serdetypes.I16Kind, serdetypes.I32Kind, serdetypes.I64Kind,
+		serdetypes.U8Kind, serdetypes.U16Kind, serdetypes.U32Kind, serdetypes.U64Kind:
+		i, err := integerOf(value)
+		return node{kind: intNode, integer: i}, err
+	case serdetypes.I128Kind, serdetypes.U128Kind:
+		i, err := integerOf(value)
+		if err != nil {
+			return node{}, err
+		}
+		if c.textual {
+			return node{kind: textNode, text: i.String()}, nil
+		}
+		return node{kind: intNode, integer: i}, nil
+	case serdetypes.F32Kind:
+		f, err := primitive[float32](value)
+		return node{kind: floatNode, float: float64(f), single: true}, err
+	case serdetypes.F64Kind:
+		f, err := primitive[float64](value)
+		return node{kind: floatNode, float: f}, err
+	case serdetypes.CharKind:
+		r, err := primitive[rune](value)
+		return node{kind: textNode, text: string(r)}, err
+	case serdetypes.StrKind:
+		s, err := primitive[string](value)
+		return node{kind: textNode, text: s}, err
+	case serdetypes.BytesKind:
+		b, err := primitive[[]byte](value)
+		if c.textual {
+			return node{kind: textNode, text: base64.StdEncoding.EncodeToString(b)}, err
+		}
+		return node{kind: bytesNode, bytes: b}, err
+
+	case serdetypes.OptionKind:
+		if err := checkValueKind(value, dynamic.OptionValue, "an option"); err != nil {
+			return node{}, err
+		}
+		switch len(value.Elements) {
+		case 0:
+			return node{kind: nullNode}, nil
+		case 1:
+			return c.fromValue(format.Content, &value.Elements[0])
+		default:
+			return node{}, invalidValue("expected at most 1 element, got %d", len(value.Elements))
+		}
+
+	case serdetypes.SeqKind, serdetypes.TupleArrayKind:
+		if err := checkValueKind(value, dynamic.SeqValue, "a sequence"); err != nil {
+			return node{}, err
+		}
+		if format.Kind == serdetypes.TupleArrayKind && uint64(len(value.Elements)) != format.Size {
+			return node{}, invalidValue("expected %d elements, got %d", format.Size, len(value.Elements))
+		}
+		n := node{kind: arrayNode, items: make([]node, 0, len(value.Elements))}
+		for i := range value.Elements {
+			item, err := c.fromValue(format.Content, &value.Elements[i])
+			if err != nil {
+				return node{}, wrapErrorIndex(err, i)
+			}
+			n.items = append(n.items, item)
+		}
+		return n, nil
+
+	case serdetypes.MapKind:
+		if err := checkValueKind(value, dynamic.MapValue, "a map"); err != nil {
+			return node{}, err
+		}
+		n := node{kind: mapNode, entries: make([]entry, 0, len(value.Entries))}
+		for i := range value.Entries {
+			key, err := c.fromValue(format.Key, &value.Entries[i].Key)
+			if err != nil {
+				return node{}, wrapErrorIndex(err, i)
+			}
+			v, err := c.fromValue(format.Value, &value.Entries[i].Value)
+			if err != nil {
+				return node{}, wrapErrorIndex(err, i)
+			}
+			n.entries = append(n.entries, entry{key: key, value: v})
+		}
+		return n, nil
+
+	case serdetypes.TupleKind:
+		if err := checkValueKind(value, dynamic.TupleValue, "a tuple"); err != nil {
+			return node{}, err
+		}
+		return c.fromValues(format.Elements, value.Elements)
+
+	default:
+		return node{}, fmt.Errorf("unexpected format kind: %d", format.Kind)
+	}
+}
+
+func (c *converter) fromValues(formats []serdetypes.Format, values []dynamic.Value) (node, error) {
+	if len(values) != len(formats) {
+		return node{}, invalidValue("expected %d elements, got %d", len(formats), len(values))
+	}
+	n := node{kind: arrayNode, items: make([]node, 0, len(values))}
+	for i := range formats {
+		item, err := c.fromValue(&formats[i], &values[i])
+		if err != nil {
+			return node{}, wrapErrorIndex(err, i)
+		}
+		n.items = append(n.items, item)
+	}
+	return n, nil
+}
+
+func (c *converter) fromFields(fields []serdetypes.Named[serdetypes.Format], value *dynamic.Value) (node, error) {
+	if len(value.Fields) != len(fields) {
+		return node{}, invalidValue("expected %d fields, got %d", len(fields), len(value.Fields))
+	}
+	n := node{kind: mapNode, entries: make([]entry, 0, len(fields))}
+	for i := range fields {
+		field, ok := value.Field(fields[i].Name)
+		if !ok {
+			return node{}, invalidValue("missing field %q", fields[i].Name)
+		}
+		v, err := c.fromValue(&fields[i].Value, field)
+		if err != nil {
+			return node{}, wrapError(err, fields[i].Name)
+		}
+		n.entries = append(n.entries, entry{key: node{kind: textNode, text: fields[i].Name}, value: v})
+	}
+	return n, nil
+}
+
+func (c *converter) fromContainer(name string, value *dynamic.Value) (node, error) {
+	format, err := c.registry.Lookup(name)
+	if err != nil {
+		return node{}, err
+	}
+	if format.Kind == serdetypes.EnumKind {
+		return c.fromVariant(name, &format, value)
+	}
+	if err := checkValueKind(value, dynamic.StructValue, "a struct"); err != nil {
+		return node{}, err
+	}
+	switch format.Kind {
+	case serdetypes.UnitStructKind:
+		return node{kind: nullNode}, nil
+	case serdetypes.NewTypeStructKind:
+		if len(value.Elements) != 1 {
+			return node{}, invalidValue("expected 1 element, got %d", len(value.Elements))
+		}
+		return c.fromValue(format.Content, &value.Elements[0])
+	case serdetypes.TupleStructKind:
+		return c.fromValues(format.Elements, value.Elements)
+	case serdetypes.StructKind:
+		return c.fromFields(format.Fields, value)
+	default:
+		return node{}, fmt.Errorf("unexpected container kind: %d", format.Kind)
+	}
+}
+
+func (c *converter) fromVariant(name string, format *serdetypes.ContainerFormat, value *dynamic.Value) (node, error) {
+	if err := checkValueKind(value, dynamic.VariantValue, "a variant"); err != nil {
+		return node{}, err
+	}
+	variant, ok := format.Variants[value.VariantIndex]
+	if !ok {
+		return node{}, serde.UnknownVariantIndex(name, value.VariantIndex)
+	}
+	tag := node{kind: textNode, text: variant.Name}
+	var content node
+	var err error
+	switch variant.Value.Kind {
+	case serdetypes.UnitVariantKind:
+		return tag, nil
+	case serdetypes.NewTypeVariantKind:
+		if len(value.Elements) != 1 {
+			return node{}, wrapError(invalidValue("expected 1 element, got %d", len(value.Elements)), variant.Name)
+		}
+		content, err = c.fromValue(variant.Value.Content, &value.Elements[0])
+	case serdetypes.TupleVariantKind:
+		content, err = c.fromValues(variant.Value.Elements, value.Elements)
+	case serdetypes.StructVariantKind:
+		content, err = c.fromFields(variant.Value.Fields, value)
+	default:
+		return node{}, fmt.Errorf("unexpected variant kind: %d", variant.Value.Kind)
+	}
+	if err != nil {
+		return node{}, wrapError(err, variant.Name)
+	}
+	return node{kind: mapNode, entries: []entry{{key: tag, value: content}}}, nil
+}
+
+// Conversion from nodes to values.
+
+func primitiveValue(kind serdetypes.FormatKind, v interface{}) dynamic.Value {
+	return dynamic.Value{Kind: dynamic.PrimitiveValue, Format: kind, Primitive: v}
+}
+
+// Read an integer, also accepted as a decimal string (e.g. the keys of JSON objects).
+func integerNode(n *node) (*big.Int, error) {
+	switch n.kind {
+	case intNode:
+		return n.integer, nil
+	case numberNode, textNode:
+		if i, ok := new(big.Int).SetString(n.text, 10); ok {
+			return i, nil
+		}
+		return nil, invalidValue("invalid integer %q", n.text)
+	default:
+		return nil, unexpectedNode("an integer", n)
+	}
+}
+
+func signedValue(kind serdetypes.FormatKind, i *big.Int, bits uint) (dynamic.Value, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), bits-1)
+	if i.Cmp(new(big.Int).Neg(limit)) < 0 || i.Cmp(limit) >= 0 {
+		return dynamic.Value{}, invalidValue("integer %v out of range for int%d", i, bits)
+	}
+	v := i.Int64()
+	switch bits {
+	case 8:
+		return primitiveValue(kind, int8(v)), nil
+	case 16:
+		return primitiveValue(kind, int16(v)), nil
+	case 32:
+		return primitiveValue(kind, int32(v)), nil
+	default:
+		return primitiveValue(kind, v), nil
+	}
+}
+
+func unsignedValue(kind serdetypes.FormatKind, i *big.Int, bits uint) (dynamic.Value, error) {
+	if i.Sign() < 0 || i.BitLen() > int(bits) {
+		return dynamic.Value{}, invalidValue("integer %v out of range for uint%d", i, bits)
+	}
+	v := i.Uint64()
+	switch bits {
+	case 8:
+		return primitiveValue(kind, uint8(v)), nil
+	case 16:
+		return primitiveValue(kind, uint16(v)), nil
+	case 32:
+		return primitiveValue(kind, uint32(v)), nil
+	default:
+		return primitiveValue(kind, v), nil
+	}
+}
+
+func floatNode64(n *node) (float64, error) {
+	switch n.kind {
+	case floatNode:
+		return n.float, nil
+	case intNode:
+		f, _ := new(big.Float).SetInt(n.integer).Float64()
+		return f, nil
+	case numberNode:
+		f, ok := new(big.Float).SetString(n.text)
+		if !ok {
+			return 0, invalidValue("invalid number %q", n.text)
+		}
+		v, _ := f.Float64()
+		return v, nil
+	default:
+		return 0, unexpectedNode("a number", n)
+	}
+}
+
+func (c *converter) toValue(format *serdetypes.Format, n *node) (dynamic.Value, error) {
+	switch format.Kind {
+	case serdetypes.TypeNameKind:
+		return c.toContainer(format.Name, n)
+	case serdetypes.UnitKind:
+		if n.kind != nullNode {
+			return dynamic.Value{}, unexpectedNode("null", n)
+		}
+		return primitiveValue(format.Kind, nil), nil
+	case serdetypes.BoolKind:
+		if n.kind != boolNode {
+			return dynamic.Value{}, unexpectedNode("a boolean", n)
+		}
+		return primitiveValue(format.Kind, n.boolean), nil
+	case serdetypes.I8Kind, serdetypes.I16Kind, serdetypes.I32Kind, serdetypes.I64Kind:
+		i, err := integerNode(n)
+		if err != nil {
+			return dynamic.Value{}, err
+		}
+		bits := map[serdetypes.FormatKind]uint{serdetypes.I8Kind: 8, serdetypes.I16Kind: 16, serdetypes.I32Kind: 32, serdetypes.I64Kind: 64}
+		return signedValue(format.Kind, i, bits[format.Kind])
+	case serdetypes.U8Kind, serdetypes.U16Kind, serdetypes.U32Kind, serdetypes.U64Kind:
+		i, err := integerNode(n)
+		if err != nil {
+			return dynamic.Value{}, err
+		}
+		bits := map[serdetypes.FormatKind]uint{serdetypes.U8Kind: 8, serdetypes.U16Kind: 16, serdetypes.U32Kind: 32, serdetypes.U64Kind: 64}
+		return unsignedValue(format.Kind, i, bits[format.Kind])
+	case serdetypes.I128Kind:
+		i, err := integerNode(n)
+		if err != nil {
+			return dynamic.Value{}, err
+		}
+		v, err := serde.Int128FromBigInt(i)
+		if err != nil {
+			return dynamic.Value{}, invalidValue("%v", err)
+		}
+		return primitiveValue(format.Kind, v), nil
+	case serdetypes.U128Kind:
+		i, err := integerNode(n)
+		if err != nil {
+			return dynamic.Value{}, err
+		}
+		v, err := serde.Uint128FromBigInt(i)
+		if err != nil {
+			return dynamic.Value{}, invalidValue("%v", err)
+		}
+		return primitiveValue(format.Kind, v), nil
+	case serdetypes.F32Kind:
+		f, err := floatNode64(n)
+		if err != nil {
+			return dynamic.Value{}, err
+		}
+		if !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+			return dynamic.Value{}, invalidValue("number %v out of range for float32", f)
+		}
+		return primitiveValue(format.Kind, float32(f)), nil
+	case serdetypes.F64Kind:
+		f, err := floatNode64(n)
+		if err != nil {
+			return dynamic.Value{}, err
+		}
+		return primitiveValue(format.Kind, f), nil
+	case serdetypes.CharKind:
+		if n.kind != textNode {
+			return dynamic.Value{}, unexpectedNode("a string", n)
+		}
+		r, size := utf8.DecodeRuneInString(n.text)
+		if r == utf8.RuneError || size != len(n.text) {
+			return dynamic.Value{}, invalidValue("expected a single character, got %q", n.text)
+		}
+		return primitiveValue(format.Kind, r), nil
+	case serdetypes.StrKind:
+		if n.kind != textNode {
+			return dynamic.Value{}, unexpectedNode("a string", n)
+		}
+		return primitiveValue(format.Kind, n.text), nil
+	case serdetypes.BytesKind:
+		if n.kind == bytesNode {
+			return primitiveValue(format.Kind, n.bytes), nil
+		}
+		if n.kind == textNode && c.textual {
+			b, err := base64.StdEncoding.DecodeString(n.text)
+			if err != nil {
+				return dynamic.Value{}, invalidValue("invalid base64 string: %v", err)
+			}
+			return primitiveValue(format.Kind, b), nil
+		}
+		return dynamic.Value{}, unexpectedNode("bytes", n)
+
+	case serdetypes.OptionKind:
+		if n.kind == nullNode {
+			return dynamic.Value{Kind: dynamic.OptionValue}, nil
+		}
+		content, err := c.toValue(format.Content, n)
+		if err != nil {
+			return dynamic.Value{}, err
+		}
+		return dynamic.Value{Kind: dynamic.OptionValue, Elements: []dynamic.Value{content}}, nil
+
+	case serdetypes.SeqKind, serdetypes.TupleArrayKind:
+		if n.kind != arrayNode {
+			return dynamic.Value{}, unexpectedNode("an array", n)
+		}
+		if format.Kind == serdetypes.TupleArrayKind && uint64(len(n.items)) != format.Size {
+			return dynamic.Value{}, invalidValue("expected %d elements, got %d", format.Size, len(n.items))
+		}
+		value := dynamic.Value{Kind: dynamic.SeqValue, Elements: make([]dynamic.Value, 0, len(n.items))}
+		for i := range n.items {
+			element, err := c.toValue(format.Content, &n.items[i])
+			if err != nil {
+				return dynamic.Value{}, wrapErrorIndex(err, i)
+			}
+			value.Elements = append(value.Elements, element)
+		}
+		return value, nil
+
+	case serdetypes.MapKind:
+		if n.kind != mapNode {
+			return dynamic.Value{}, unexpectedNode("a map", n)
+		}
+		value := dynamic.Value{Kind: dynamic.MapValue, Entries: make([]dynamic.Entry, 0, len(n.entries))}
+		for i := range n.entries {
+			key, err := c.toValue(format.Key, &n.entries[i].key)
+			if err != nil {
+				return dynamic.Value{}, wrapErrorIndex(err, i)
+			}
+			v, err := c.toValue(format.Value, &n.entries[i].value)
+			if err != nil {
+				return dynamic.Value{}, wrapErrorIndex(err, i)
+			}
+			value.Entries = append(value.Entries, dynamic.Entry{Key: key, Value: v})
+		}
+		return value, nil
+
+	case serdetypes.TupleKind:
+		elements, err := c.toValues(format.Elements, n)
+		if err != nil {
+			return dynamic.Value{}, err
+		}
+		return dynamic.Value{Kind: dynamic.TupleValue, Elements: elements}, nil
+
+	default:
+		return dynamic.Value{}, fmt.Errorf("unexpected format kind: %d", format.Kind)
+	}
+}
+
+func (c *converter) toValues(formats []serdetypes.Format, n *node) ([]dynamic.Value, error) {
+	if n.kind != arrayNode {
+		return nil, unexpectedNode("an array", n)
+	}
+	if len(n.items) != len(formats) {
+		return nil, invalidValue("expected %d elements, got %d", len(formats), len(n.items))
+	}
+	values := make([]dynamic.Value, 0, len(formats))
+	for i := range formats {
+		value, err := c.toValue(&formats[i], &n.items[i])
+		if err != nil {
+			return nil, wrapErrorIndex(err, i)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Fields may be given in any order. Unknown fields are rejected so that no data is lost.
+func (c *converter) toFields(fields []serdetypes.Named[serdetypes.Format], n *node) ([]serdetypes.Named[dynamic.Value], error) {
+	if n.kind != mapNode {
+		return nil, unexpectedNode("a map", n)
+	}
+	members := make(map[string]*node)
+	for i := range n.entries {
+		key := &n.entries[i].key
+		if key.kind != textNode {
+			return nil, invalidValue("expected field names, got %s", nodeKindNames[key.kind])
+		}
+		if _, ok := members[key.text]; ok {
+			return nil, invalidValue("duplicate field %q", key.text)
+		}
+		members[key.text] = &n.entries[i].value
+	}
+	values := make([]serdetypes.Named[dynamic.Value], 0, len(fields))
+	for i := range fields {
+		member, ok := members[fields[i].Name]
+		if !ok {
+			return nil, invalidValue("missing field %q", fields[i].Name)
+		}
+		delete(members, fields[i].Name)
+		value, err := c.toValue(&fields[i].Value, member)
+		if err != nil {
+			return nil, wrapError(err, fields[i].Name)
+		}
+		values = append(values, serdetypes.Named[dynamic.Value]{Name: fields[i].Name, Value: value})
+	}
+	for i := range n.entries {
+		if _, ok := members[n.entries[i].key.text]; ok {
+			return nil, invalidValue("unknown field %q", n.entries[i].key.text)
+		}
+	}
+	return values, nil
+}
+
+func (c *converter) toContainer(name string, n *node) (dynamic.Value, error) {
+	format, err := c.registry.Lookup(name)
+	if err != nil {
+		return dynamic.Value{}, err
+	}
+	value := dynamic.Value{Kind: dynamic.StructValue, TypeName: name}
+	switch format.Kind {
+	case serdetypes.UnitStructKind:
+		if n.kind != nullNode {
+			return dynamic.Value{}, unexpectedNode("null", n)
+		}
+	case serdetypes.NewTypeStructKind:
+		var content dynamic.Value
+		content, err = c.toValue(format.Content, n)
+		value.Elements = []dynamic.Value{content}
+	case serdetypes.TupleStructKind:
+		value.Elements, err = c.toValues(format.Elements, n)
+	case serdetypes.StructKind:
+		value.Fields, err = c.toFields(format.Fields, n)
+	case serdetypes.EnumKind:
+		return c.toVariant(name, &format, n)
+	default:
+		return dynamic.Value{}, fmt.Errorf("unexpected container kind: %d", format.Kind)
+	}
+	if err != nil {
+		return dynamic.Value{}, err
+	}
+	return value, nil
+}
+
+func (c *converter) toVariant(name string, format *serdetypes.ContainerFormat, n *node) (dynamic.Value, error) {
+	var tag string
+	var content *node
+	switch {
+	case n.kind == textNode:
+		tag = n.text
+	case n.kind == mapNode && len(n.entries) == 1 && n.entries[0].key.kind == textNode:
+		tag = n.entries[0].key.text
+		content = &n.entries[0].value
+	default:
+		return dynamic.Value{}, unexpectedNode("a variant name or a map with a single entry", n)
+	}
+	value := dynamic.Value{Kind: dynamic.VariantValue, TypeName: name, VariantName: tag}
+	var variant *serdetypes.VariantFormat
+	for index, v := range format.Variants {
+		if v.Name == tag {
+			value.VariantIndex = index
+			variant = &v.Value
+			break
+		}
+	}
+	if variant == nil {
+		return dynamic.Value{}, serde.UnknownVariantName(name, tag)
+	}
+	// As with Serde, unit variants may also be written `{"Variant": null}`.
+	if variant.Kind == serdetypes.UnitVariantKind && content != nil && content.kind != nullNode {
+		return dynamic.Value{}, wrapError(unexpectedNode("null", content), tag)
+	}
+	if variant.Kind != serdetypes.UnitVariantKind && content == nil {
+		return dynamic.Value{}, wrapError(invalidValue("missing content"), tag)
+	}
+	var err error
+	switch variant.Kind {
+	case serdetypes.UnitVariantKind:
+	case serdetypes.NewTypeVariantKind:
+		var v dynamic.Value
+		v, err = c.toValue(variant.Content, content)
+		value.Elements = []dynamic.Value{v}
+	case serdetypes.TupleVariantKind:
+		value.Elements, err = c.toValues(variant.Elements, content)
+	case serdetypes.StructVariantKind:
+		value.Fields, err = c.toFields(variant.Fields, content)
+	default:
+		return dynamic.Value{}, fmt.Errorf("unexpected variant kind: %d", variant.Kind)
+	}
+	if err != nil {
+		return dynamic.Value{}, wrapError(err, tag)
+	}
+	return value, nil
+}
@@ -0,0 +1,171 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package transcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// EncodeJSON writes `value`, a value of the container `typeName`, in JSON.
+func EncodeJSON(value *dynamic.Value, registry *serdetypes.Registry, typeName string) ([]byte, error) {
+	c := converter{registry: registry, textual: true}
+	n, err := c.fromContainer(typeName, value)
+	if err != nil {
+		return nil, wrapError(err, typeName)
+	}
+	var out bytes.Buffer
+	if err := writeJSON(&out, &n); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// DecodeJSON reads the JSON document `input` as a value of the container `typeName`.
+func DecodeJSON(input []byte, registry *serdetypes.Registry, typeName string) (dynamic.Value, error) {
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+	n, err := readJSON(decoder, 0)
+	if err != nil {
+		return dynamic.Value{}, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return dynamic.Value{}, errors.New("invalid JSON: unexpected data after the top-level value")
+	}
+	c := converter{registry: registry, textual: true}
+	value, err := c.toContainer(typeName, &n)
+	if err != nil {
+		return dynamic.Value{}, wrapError(err, typeName)
+	}
+	return value, nil
+}
+
+func writeJSON(out *bytes.Buffer, n *node) error {
+	switch n.kind {
+	case nullNode:
+		out.WriteString("null")
+	case boolNode:
+		out.WriteString(strconv.FormatBool(n.boolean))
+	case intNode:
+		out.WriteString(n.integer.String())
+	case floatNode:
+		if math.IsNaN(n.float) || math.IsInf(n.float, 0) {
+			return fmt.Errorf("%v cannot be encoded in JSON", n.float)
+		}
+		bits := 64
+		if n.single {
+			bits = 32
+		}
+		out.WriteString(strconv.FormatFloat(n.float, 'g', -1, bits))
+	case numberNode:
+		out.WriteString(n.text)
+	case textNode:
+		writeJSONString(out, n.text)
+	case arrayNode:
+		out.WriteByte('[')
+		for i := range n.items {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := writeJSON(out, &n.items[i]); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case mapNode:
+		out.WriteByte('{')
+		for i := range n.entries {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			switch key := &n.entries[i].key; key.kind {
+			case textNode:
+				writeJSONString(out, key.text)
+			case intNode:
+				writeJSONString(out, key.integer.String())
+			default:
+				return fmt.Errorf("JSON object keys must be strings or integers, not %s", nodeKindNames[key.kind])
+			}
+			out.WriteByte(':')
+			if err := writeJSON(out, &n.entries[i].value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return fmt.Errorf("%s cannot be encoded in JSON", nodeKindNames[n.kind])
+	}
+	return nil
+}
+
+func writeJSONString(out *bytes.Buffer, s string) {
+	// Unlike `json.Marshal`, do not escape HTML characters.
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	// Strings are always encoded successfully.
+	_ = encoder.Encode(s)
+	// Remove the newline written by `Encode`.
+	out.Truncate(out.Len() - 1)
+}
+
+func readJSON(decoder *json.Decoder, depth int) (node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return node{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	switch token := token.(type) {
+	case nil:
+		return node{kind: nullNode}, nil
+	case bool:
+		return node{kind: boolNode, boolean: token}, nil
+	case json.Number:
+		return node{kind: numberNode, text: string(token)}, nil
+	case string:
+		return node{kind: textNode, text: token}, nil
+	case json.Delim:
+		if depth >= maxNestingDepth {
+			return node{}, errNestingDepthExceeded
+		}
+		var n node
+		if token == '[' {
+			n.kind = arrayNode
+			for decoder.More() {
+				item, err := readJSON(decoder, depth+1)
+				if err != nil {
+					return node{}, err
+				}
+				n.items = append(n.items, item)
+			}
+		} else {
+			n.kind = mapNode
+			for decoder.More() {
+				// Keys are always strings.
+				key, err := readJSON(decoder, depth+1)
+				if err != nil {
+					return node{}, err
+				}
+				value, err := readJSON(decoder, depth+1)
+				if err != nil {
+					return node{}, err
+				}
+				n.entries = append(n.entries, entry{key: key, value: value})
+			}
+		}
+		// Read the closing delimiter.
+		if _, err := decoder.Token(); err != nil {
+			return node{}, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return n, nil
+	default:
+		return node{}, fmt.Errorf("invalid JSON: unexpected token %v", token)
+	}
+}
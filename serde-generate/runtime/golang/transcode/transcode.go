@@ -0,0 +1,130 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+// Package transcode converts serialized values between BCS, Bincode, JSON, and CBOR, given
+// the registry of their formats (see `serdetypes.ReadRegistry`). Values are decoded into the
+// model of the package `dynamic`, so that no generated code is needed.
+//
+// JSON and CBOR use the data model of Serde (e.g. as written by `serde_json` or `ciborium`):
+//   - unit values, unit structs, and `None` are `null`,
+//   - structs are maps from field names to values, and tuples, tuple structs, sequences, and
+//     fixed-size arrays are arrays,
+//   - newtype structs are the same as their content,
+//   - variants are externally tagged, i.e. written `"Variant"` for unit variants and
+//     `{"Variant": content}` otherwise.
+//
+// In JSON, bytes are encoded as base64 strings and 128-bit integers as decimal strings
+// (as with `encoding/json` and the types of the package `serde`), and map keys must be
+// strings or integers, which are written as decimal strings. In CBOR, integers that do not
+// fit in 64 bits are encoded as bignums.
+package transcode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/dynamic"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+)
+
+// Encoding is a serialization format supported by `Transcode`.
+type Encoding int
+
+const (
+	BCS Encoding = iota
+	Bincode
+	JSON
+	CBOR
+)
+
+var encodingNames = []string{"bcs", "bincode", "json", "cbor"}
+
+func (e Encoding) String() string {
+	if int(e) < 0 || int(e) >= len(encodingNames) {
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+	return encodingNames[e]
+}
+
+// ParseEncoding returns the encoding with the given name, e.g. "bcs" or "json".
+func ParseEncoding(name string) (Encoding, error) {
+	for i, n := range encodingNames {
+		if strings.EqualFold(name, n) {
+			return Encoding(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown encoding %q", name)
+}
+
+// Transcode converts `input`, a value of the container `typeName` serialized in the
+// encoding `from`, into the encoding `to`.
+func Transcode(input []byte, from Encoding, to Encoding, registry *serdetypes.Registry, typeName string) ([]byte, error) {
+	value, err := Decode(input, from, registry, typeName)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(&value, to, registry, typeName)
+}
+
+// Decode decodes `input`, a value of the container `typeName` serialized in the given encoding.
+func Decode(input []byte, encoding Encoding, registry *serdetypes.Registry, typeName string) (dynamic.Value, error) {
+	switch encoding {
+	case BCS:
+		return dynamic.DecodeBcs(input, registry, typeName)
+	case Bincode:
+		return dynamic.DecodeBincode(input, registry, typeName)
+	case JSON:
+		return DecodeJSON(input, registry, typeName)
+	case CBOR:
+		return DecodeCBOR(input, registry, typeName)
+	default:
+		return dynamic.Value{}, fmt.Errorf("unknown encoding %v", encoding)
+	}
+}
+
+// Encode serializes `value`, a value of the container `typeName`, in the given encoding.
+func Encode(value *dynamic.Value, encoding Encoding, registry *serdetypes.Registry, typeName string) ([]byte, error) {
+	switch encoding {
+	case BCS:
+		return dynamic.EncodeBcs(value, registry, typeName)
+	case Bincode:
+		return dynamic.EncodeBincode(value, registry, typeName)
+	case JSON:
+		return EncodeJSON(value, registry, typeName)
+	case CBOR:
+		return EncodeCBOR(value, registry, typeName)
+	default:
+		return nil, fmt.Errorf("unknown encoding %v", encoding)
+	}
+}
+
+// Error adds the path of the value being converted to an error, e.g.
+// `Transaction.payload.args[3]`.
+type Error struct {
+	Path string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Error while converting %s: %v", e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Same as `serde.WrapDecodeError`.
+func wrapError(err error, segment string) error {
+	if e, ok := err.(*Error); ok {
+		if !strings.HasPrefix(e.Path, "[") {
+			segment += "."
+		}
+		e.Path = segment + e.Path
+		return e
+	}
+	return &Error{Path: segment, Err: err}
+}
+
+func wrapErrorIndex(err error, index int) error {
+	return wrapError(err, fmt.Sprintf("[%d]", index))
+}
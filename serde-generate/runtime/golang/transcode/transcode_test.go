@@ -0,0 +1,250 @@
+// Copyright (c) Facebook, Inc. and its affiliates
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package transcode_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+	st "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serdetypes"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/transcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// struct Test { a: BTreeMap<u8, String>, b: Option<Choice>, c: (bool, i64), d: [u16; 2], e: Wrapper, f: u128, g: Vec<Choice> }
+// enum Choice { A, B(u16), C { x: Vec<u8> } }
+// struct Wrapper(Vec<u8>) (with `serde_bytes`)
+// struct Point { x: f32, y: f64, c: char }
+var testRegistry = st.Registry{
+	Containers: map[string]st.ContainerFormat{
+		"Test": {
+			Kind: st.StructKind,
+			Fields: []st.Named[st.Format]{
+				{Name: "a", Value: st.Map(st.Primitive(st.U8Kind), st.Primitive(st.StrKind))},
+				{Name: "b", Value: st.Option(st.TypeName("Choice"))},
+				{Name: "c", Value: st.Tuple(st.Primitive(st.BoolKind), st.Primitive(st.I64Kind))},
+				{Name: "d", Value: st.TupleArray(st.Primitive(st.U16Kind), 2)},
+				{Name: "e", Value: st.TypeName("Wrapper")},
+				{Name: "f", Value: st.Primitive(st.U128Kind)},
+				{Name: "g", Value: st.Seq(st.TypeName("Choice"))},
+			},
+		},
+		"Choice": {
+			Kind: st.EnumKind,
+			Variants: map[uint32]st.Named[st.VariantFormat]{
+				0: {Name: "A", Value: st.VariantFormat{Kind: st.UnitVariantKind}},
+				1: {Name: "B", Value: st.VariantFormat{Kind: st.NewTypeVariantKind, Content: &st.Format{Kind: st.U16Kind}}},
+				2: {Name: "C", Value: st.VariantFormat{Kind: st.StructVariantKind, Fields: []st.Named[st.Format]{
+					{Name: "x", Value: st.Seq(st.Primitive(st.U8Kind))},
+				}}},
+			},
+		},
+		"Wrapper": {
+			Kind:    st.NewTypeStructKind,
+			Content: &st.Format{Kind: st.BytesKind},
+		},
+		"Point": {
+			Kind: st.StructKind,
+			Fields: []st.Named[st.Format]{
+				{Name: "x", Value: st.Primitive(st.F32Kind)},
+				{Name: "y", Value: st.Primitive(st.F64Kind)},
+				{Name: "c", Value: st.Primitive(st.CharKind)},
+			},
+		},
+	},
+}
+
+var testBcs = []byte{
+	1, 1, 1, 'a', // a
+	1, 2, 2, 3, 4, // b
+	1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // c
+	1, 0, 2, 0, // d
+	2, 1, 0xff, // e
+	0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, // f
+	2, 0, 1, 7, 0, // g
+}
+
+const testJSON = `{"a":{"1":"a"},"b":{"C":{"x":[3,4]}},"c":[true,-1],"d":[1,2],"e":"Af8=","f":"18446744073709551616","g":["A",{"B":7}]}`
+
+func TestTranscodeBcsToJSON(t *testing.T) {
+	output, err := transcode.Transcode(testBcs, transcode.BCS, transcode.JSON, &testRegistry, "Test")
+	require.NoError(t, err)
+	assert.Equal(t, testJSON, string(output))
+
+	output, err = transcode.Transcode(output, transcode.JSON, transcode.BCS, &testRegistry, "Test")
+	require.NoError(t, err)
+	assert.Equal(t, testBcs, output)
+}
+
+func TestTranscodeRoundTrips(t *testing.T) {
+	for _, encoding := range []transcode.Encoding{transcode.Bincode, transcode.JSON, transcode.CBOR} {
+		t.Run(encoding.String(), func(t *testing.T) {
+			output, err := transcode.Transcode(testBcs, transcode.BCS, encoding, &testRegistry, "Test")
+			require.NoError(t, err)
+			output, err = transcode.Transcode(output, encoding, transcode.BCS, &testRegistry, "Test")
+			require.NoError(t, err)
+			assert.Equal(t, testBcs, output)
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	// Fields are given in a different order and map keys are not sorted.
+	input := `{
+		"g": [{"A": null}],
+		"f": 1,
+		"a": {"2": "b", "1": "a"},
+		"b": null,
+		"c": [false, 3],
+		"d": [1, 2],
+		"e": ""
+	}`
+	value, err := transcode.DecodeJSON([]byte(input), &testRegistry, "Test")
+	require.NoError(t, err)
+	assert.Equal(t, `Test { a: {2: "b", 1: "a"}, b: None, c: (false, 3), d: [1, 2], e: Wrapper(0x), f: 1, g: [Choice::A] }`, value.String())
+
+	output, err := transcode.Encode(&value, transcode.BCS, &testRegistry, "Test")
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(output, []byte{2, 1, 1, 'a', 2, 1, 'b'}), "map entries are sorted in BCS")
+}
+
+func TestCBOR(t *testing.T) {
+	value, err := transcode.Decode([]byte{1, 7, 0}, transcode.BCS, &testRegistry, "Choice")
+	require.NoError(t, err)
+	output, err := transcode.EncodeCBOR(&value, &testRegistry, "Choice")
+	require.NoError(t, err)
+	// {"B": 7}
+	assert.Equal(t, []byte{0xa1, 0x61, 'B', 0x07}, output)
+
+	// {"x": 1.5 (half precision), "y": -2.0, "c": "é"}
+	input := []byte{0xa3, 0x61, 'x', 0xf9, 0x3e, 0x00, 0x61, 'y', 0xfb, 0xc0, 0, 0, 0, 0, 0, 0, 0, 0x61, 'c', 0x62, 0xc3, 0xa9}
+	value, err = transcode.DecodeCBOR(input, &testRegistry, "Point")
+	require.NoError(t, err)
+	assert.Equal(t, `Point { x: 1.5, y: -2, c: 'é' }`, value.String())
+	output, err = transcode.EncodeJSON(&value, &testRegistry, "Point")
+	require.NoError(t, err)
+	assert.Equal(t, `{"x":1.5,"y":-2,"c":"é"}`, string(output))
+
+	output, err = transcode.Transcode(output, transcode.JSON, transcode.CBOR, &testRegistry, "Point")
+	require.NoError(t, err)
+	value, err = transcode.DecodeCBOR(output, &testRegistry, "Point")
+	require.NoError(t, err)
+	assert.Equal(t, `Point { x: 1.5, y: -2, c: 'é' }`, value.String())
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		encoding transcode.Encoding
+		input    string
+		typeName string
+		err      string
+	}{
+		{
+			name:     "unknown field",
+			encoding: transcode.JSON,
+			input:    `{"x": 1, "y": 2, "c": "a", "z": 3}`,
+			typeName: "Point",
+			err:      `Error while converting Point: invalid value: unknown field "z"`,
+		},
+		{
+			name:     "missing field",
+			encoding: transcode.JSON,
+			input:    `{"x": 1, "y": 2}`,
+			typeName: "Point",
+			err:      `Error while converting Point: invalid value: missing field "c"`,
+		},
+		{
+			name:     "out of range",
+			encoding: transcode.JSON,
+			input:    `{"C": {"x": [1, 300]}}`,
+			typeName: "Choice",
+			err:      "Error while converting Choice.C.x[1]: invalid value: integer 300 out of range for uint8",
+		},
+		{
+			name:     "unknown variant",
+			encoding: transcode.JSON,
+			input:    `"D"`,
+			typeName: "Choice",
+			err:      `Error while converting Choice: Choice: unknown variant "D"`,
+		},
+		{
+			name:     "unit variant with content",
+			encoding: transcode.JSON,
+			input:    `{"A": 1}`,
+			typeName: "Choice",
+			err:      "Error while converting Choice.A: invalid value: expected null, got number",
+		},
+		{
+			name:     "trailing data",
+			encoding: transcode.JSON,
+			input:    `"A" "A"`,
+			typeName: "Choice",
+			err:      "invalid JSON: unexpected data after the top-level value",
+		},
+		{
+			name:     "CBOR truncated",
+			encoding: transcode.CBOR,
+			input:    "\x82\x01",
+			typeName: "Choice",
+			err:      "invalid CBOR: input is too short (at offset 1)",
+		},
+		{
+			name:     "CBOR indefinite length",
+			encoding: transcode.CBOR,
+			input:    "\x9f\xff",
+			typeName: "Choice",
+			err:      "invalid CBOR: items of indefinite length are not supported (at offset 1)",
+		},
+		{
+			name:     "CBOR nesting depth",
+			encoding: transcode.CBOR,
+			input:    string(bytes.Repeat([]byte{0x81}, 501)) + "\x00",
+			typeName: "Choice",
+			err:      "invalid CBOR: exceeded maximum nesting depth of 500 (at offset 501)",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := transcode.Decode([]byte(tc.input), tc.encoding, &testRegistry, tc.typeName)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestEncodeJSONErrors(t *testing.T) {
+	// struct Keys(BTreeMap<(u8, u8), u8>)
+	registry := st.Registry{Containers: map[string]st.ContainerFormat{
+		"Keys": {Kind: st.NewTypeStructKind, Content: &st.Format{
+			Kind:  st.MapKind,
+			Key:   &st.Format{Kind: st.TupleKind, Elements: []st.Format{st.Primitive(st.U8Kind), st.Primitive(st.U8Kind)}},
+			Value: &st.Format{Kind: st.U8Kind},
+		}},
+	}}
+	value, err := transcode.Decode([]byte{1, 1, 2, 3}, transcode.BCS, &registry, "Keys")
+	require.NoError(t, err)
+	_, err = transcode.EncodeJSON(&value, &registry, "Keys")
+	assert.EqualError(t, err, "JSON object keys must be strings or integers, not array")
+
+	// Such maps are supported in CBOR.
+	output, err := transcode.Transcode([]byte{1, 1, 2, 3}, transcode.BCS, transcode.CBOR, &registry, "Keys")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xa1, 0x82, 1, 2, 3}, output)
+
+	value.Elements[0].Entries[0].Value.Primitive = "x"
+	_, err = transcode.EncodeCBOR(&value, &registry, "Keys")
+	assert.EqualError(t, err, "Error while converting Keys[0]: invalid value: expected an integer")
+	assert.True(t, errors.Is(err, serde.ErrInvalidValue))
+}
+
+func TestParseEncoding(t *testing.T) {
+	encoding, err := transcode.ParseEncoding("CBOR")
+	require.NoError(t, err)
+	assert.Equal(t, transcode.CBOR, encoding)
+	_, err = transcode.ParseEncoding("lcs")
+	assert.EqualError(t, err, `unknown encoding "lcs"`)
+}
@@ -9,7 +9,7 @@
 
 use serde_generate::{
     cpp, csharp, golang, java, python3, rust, typescript, CodeGeneratorConfig, Encoding,
-    SourceInstaller,
+    ExternalDefinitions, SourceInstaller,
 };
 use serde_reflection::Registry;
 use std::path::PathBuf;
@@ -70,13 +70,90 @@ struct Options {
     #[structopt(long)]
     serde_package_name: Option<String>,
 
+    /// Optional name of the generated package (Go). By default, the last component of the
+    /// module name is used, e.g. "ledger" for "types/ledger".
+    #[structopt(long)]
+    go_package_name: Option<String>,
+
+    /// Install one source file per container instead of a single file (Go). Requires
+    /// `--target-source-dir`.
+    #[structopt(long)]
+    go_split_files: bool,
+
+    /// Also install native fuzz tests in "fuzz_test.go" (Go, BCS only). Requires
+    /// `--target-source-dir`.
+    #[structopt(long)]
+    go_fuzz_tests: bool,
+
+    /// Also install a table test in "roundtrip_test.go" checking that sample values survive
+    /// serialization and deserialization (Go). Requires `--target-source-dir`.
+    #[structopt(long)]
+    go_roundtrip_tests: bool,
+
+    /// Also generate maps from container names to deserialization functions, e.g.
+    /// `BcsDeserializers` (Go).
+    #[structopt(long)]
+    go_type_registry: bool,
+
+    /// Write common initialisms in upper case in the names of fields and variants, e.g.
+    /// `UserID` instead of `UserId` (Go).
+    #[structopt(long)]
+    go_initialisms: bool,
+
+    /// Use structs and c-style enums by value rather than by pointer in serialization methods,
+    /// constructors, and builders (Go).
+    #[structopt(long)]
+    go_value_semantics: bool,
+
+    /// Generate structs with unexported fields, read with getters and set by constructors,
+    /// builders, or deserialization (Go).
+    #[structopt(long)]
+    go_immutable_structs: bool,
+
+    /// Generate the method `SerializedSize` for the structs whose serialized values all have
+    /// the same size, and pre-allocate the output of their serialization (Go).
+    #[structopt(long)]
+    go_serialized_sizes: bool,
+
+    /// Copy the runtimes into `<target_source_dir>/internal` (Go). The value is the Go import
+    /// path of `target_source_dir`, e.g. "example.com/app/gen".
+    #[structopt(long)]
+    go_vendored_runtime: Option<String>,
+
+    /// Optional path to a YAML map from definitions to their Go names, e.g. `Account: Wallet`
+    /// or `Account.id: ID` (Go).
+    #[structopt(long, parse(from_os_str))]
+    go_renamings: Option<PathBuf>,
+
+    /// Optional path to a YAML map from containers to Go types provided by the application,
+    /// e.g. `AccountAddress: {go_type: types.Address, import_path: example.com/app/types}`.
+    /// The application must also provide functions such as `SerializeAccountAddress` (Go).
+    #[structopt(long, parse(from_os_str))]
+    go_custom_types: Option<PathBuf>,
+
+    /// Optional path to a YAML map from fields to the Go expressions of their default values,
+    /// e.g. `Record.version: "1"`, used when trailing fields are missing from the input (Go).
+    #[structopt(long, parse(from_os_str))]
+    go_field_defaults: Option<PathBuf>,
+
+    /// Optional path to a YAML map from external modules to the names of the containers that
+    /// they provide, which are then referenced instead of generated, e.g.
+    /// `example.com/base/types: [Address]` (in Go, modules are import paths).
+    #[structopt(long, parse(from_os_str))]
+    external_definitions: Option<PathBuf>,
+
     /// Translate enums without variant data (c-style enums) into their equivalent in the target language,
     /// if the target language and the generator code support them.
     #[structopt(long)]
     use_c_style_enums: bool,
 }
 
-fn get_codegen_config<'a, I>(name: String, runtimes: I, c_style_enums: bool) -> CodeGeneratorConfig
+fn get_codegen_config<'a, I>(
+    name: String,
+    runtimes: I,
+    c_style_enums: bool,
+    external_definitions: ExternalDefinitions,
+) -> CodeGeneratorConfig
 where
     I: IntoIterator<Item = &'a Runtime>,
 {
@@ -95,6 +172,45 @@ where
     CodeGeneratorConfig::new(name)
         .with_encodings(encodings)
         .with_c_style_enums(c_style_enums)
+        .with_external_definitions(external_definitions)
+}
+
+fn read_external_definitions(path: &std::path::Path) -> ExternalDefinitions {
+    let content = std::fs::read_to_string(path).expect("definition file must be readable");
+    serde_yaml::from_str(content.as_str()).unwrap()
+}
+
+fn read_go_renamings(path: &std::path::Path, module_name: &str) -> golang::Renamings {
+    read_go_definitions(path, module_name)
+}
+
+fn read_go_custom_types(path: &std::path::Path, module_name: &str) -> golang::CustomTypes {
+    read_go_definitions(path, module_name)
+}
+
+fn read_go_field_defaults(path: &std::path::Path, module_name: &str) -> golang::FieldDefaults {
+    read_go_definitions(path, module_name)
+}
+
+/// Read a YAML map whose keys are definitions relative to the module, e.g. `Account.id`.
+fn read_go_definitions<T>(
+    path: &std::path::Path,
+    module_name: &str,
+) -> std::collections::BTreeMap<Vec<String>, T>
+where
+    T: serde::de::DeserializeOwned,
+{
+    let content = std::fs::read_to_string(path).expect("definition file must be readable");
+    let definitions =
+        serde_yaml::from_str::<std::collections::BTreeMap<String, T>>(content.as_str()).unwrap();
+    definitions
+        .into_iter()
+        .map(|(name, value)| {
+            let mut path = vec![module_name.to_string()];
+            path.extend(name.split('.').map(String::from));
+            (path, value)
+        })
+        .collect()
 }
 
 fn main() {
@@ -116,11 +232,21 @@ fn main() {
         }
     };
     let runtimes: std::collections::BTreeSet<_> = options.with_runtimes.into_iter().collect();
+    let external_definitions = options
+        .external_definitions
+        .as_deref()
+        .map(read_external_definitions)
+        .unwrap_or_default();
 
     match options.target_source_dir {
         None => {
             if let Some((registry, name)) = named_registry_opt {
-                let config = get_codegen_config(name, &runtimes, options.use_c_style_enums);
+                let config = get_codegen_config(
+                    name,
+                    &runtimes,
+                    options.use_c_style_enums,
+                    external_definitions,
+                );
 
                 let stdout = std::io::stdout();
                 let mut out = stdout.lock();
@@ -135,9 +261,50 @@ fn main() {
                     Language::Cpp => cpp::CodeGenerator::new(&config)
                         .output(&mut out, &registry)
                         .unwrap(),
-                    Language::Go => golang::CodeGenerator::new(&config)
-                        .output(&mut out, &registry)
-                        .unwrap(),
+                    Language::Go => {
+                        for (enabled, option) in &[
+                            (options.go_split_files, "--go-split-files"),
+                            (options.go_fuzz_tests, "--go-fuzz-tests"),
+                            (options.go_roundtrip_tests, "--go-roundtrip-tests"),
+                            (
+                                options.go_vendored_runtime.is_some(),
+                                "--go-vendored-runtime",
+                            ),
+                        ] {
+                            if *enabled {
+                                panic!("The option `{}` requires `--target-source-dir`", option)
+                            }
+                        }
+                        let mut generator = golang::CodeGenerator::new(&config)
+                            .with_type_registry(options.go_type_registry)
+                            .with_initialisms(options.go_initialisms)
+                            .with_value_semantics(options.go_value_semantics)
+                            .with_immutable_structs(options.go_immutable_structs)
+                            .with_serialized_sizes(options.go_serialized_sizes);
+                        if let Some(path) = &options.go_renamings {
+                            generator = generator
+                                .with_renamings(read_go_renamings(path, config.module_name()));
+                        }
+                        if let Some(path) = &options.go_custom_types {
+                            generator = generator.with_custom_types(read_go_custom_types(
+                                path,
+                                config.module_name(),
+                            ));
+                        }
+                        if let Some(path) = &options.go_field_defaults {
+                            generator = generator.with_field_defaults(read_go_field_defaults(
+                                path,
+                                config.module_name(),
+                            ));
+                        }
+                        if let Some(path) = serde_package_name_opt {
+                            generator = generator.with_serde_module_path(path);
+                        }
+                        if let Some(name) = options.go_package_name {
+                            generator = generator.with_package_name(name);
+                        }
+                        generator.output(&mut out, &registry).unwrap()
+                    }
                     Language::Java => {
                         panic!("Code generation in Java requires `--target-source-dir`")
                     }
@@ -161,14 +328,48 @@ fn main() {
                     Language::Cpp => Box::new(cpp::Installer::new(install_dir)),
                     Language::Java => Box::new(java::Installer::new(install_dir)),
                     Language::Go => {
-                        Box::new(golang::Installer::new(install_dir, serde_package_name_opt))
+                        let mut installer =
+                            golang::Installer::new(install_dir, serde_package_name_opt)
+                                .with_package_name(options.go_package_name)
+                                .with_split_files(options.go_split_files)
+                                .with_fuzz_tests(options.go_fuzz_tests)
+                                .with_roundtrip_tests(options.go_roundtrip_tests)
+                                .with_type_registry(options.go_type_registry)
+                                .with_initialisms(options.go_initialisms)
+                                .with_value_semantics(options.go_value_semantics)
+                                .with_immutable_structs(options.go_immutable_structs)
+                                .with_serialized_sizes(options.go_serialized_sizes)
+                                .with_vendored_runtime(options.go_vendored_runtime);
+                        if let (Some(path), Some((_, name))) =
+                            (&options.go_renamings, &named_registry_opt)
+                        {
+                            installer = installer.with_renamings(read_go_renamings(path, name));
+                        }
+                        if let (Some(path), Some((_, name))) =
+                            (&options.go_custom_types, &named_registry_opt)
+                        {
+                            installer =
+                                installer.with_custom_types(read_go_custom_types(path, name));
+                        }
+                        if let (Some(path), Some((_, name))) =
+                            (&options.go_field_defaults, &named_registry_opt)
+                        {
+                            installer =
+                                installer.with_field_defaults(read_go_field_defaults(path, name));
+                        }
+                        Box::new(installer)
                     }
                     Language::TypeScript => Box::new(typescript::Installer::new(install_dir)),
                     Language::CSharp => Box::new(csharp::Installer::new(install_dir)),
                 };
 
             if let Some((registry, name)) = named_registry_opt {
-                let config = get_codegen_config(name, &runtimes, options.use_c_style_enums);
+                let config = get_codegen_config(
+                    name,
+                    &runtimes,
+                    options.use_c_style_enums,
+                    external_definitions,
+                );
                 installer.install_module(&config, &registry).unwrap();
             }
 
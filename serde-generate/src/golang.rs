@@ -2,28 +2,187 @@
 // SPDX-License-Identifier: MIT OR Apache-2.0
 
 use crate::{
-    common,
+    analyzer, common,
     indent::{IndentConfig, IndentedWriter},
     CodeGeneratorConfig, Encoding,
 };
-use heck::CamelCase;
+use heck::{CamelCase, SnakeCase};
+use include_dir::include_dir as include_directory;
+use serde::Deserialize;
 use serde_reflection::{ContainerFormat, Format, FormatHolder, Named, Registry, VariantFormat};
 use std::{
-    collections::{BTreeMap, HashMap},
+    collections::{BTreeMap, BTreeSet, HashMap},
     io::{Result, Write},
     path::PathBuf,
 };
 
+/// Module path of the published Go runtime.
+const DEFAULT_SERDE_MODULE_PATH: &str =
+    "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang";
+
+/// Files of the runtimes that generated code does not need and that depend on other modules
+/// (e.g. YAML parsers): they are left out of vendored runtimes.
+const VENDORED_RUNTIME_EXCLUDED_FILES: &[&str] = &["serdetypes/registry.go"];
+
+/// Go keywords as well as identifiers used in the body of constructors, which cannot name
+/// constructor arguments or unexported fields.
+const GO_RESERVED_NAMES: &str = "break case chan const continue default defer else fallthrough \
+    for func go goto if import interface map package range return select struct switch type \
+    var copy fmt len nil obj";
+
+/// Initialisms written in upper case with `CodeGenerator::with_initialisms` (same list as
+/// the linters golint and staticcheck).
+const GO_INITIALISMS: &[&str] = &[
+    "ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP", "HTTPS", "ID", "IP",
+    "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA", "SMTP", "SQL", "SSH", "TCP", "TLS", "TTL",
+    "UDP", "UI", "UID", "URI", "URL", "UTF8", "UUID", "VM", "XML", "XMPP", "XSRF", "XSS",
+];
+
 /// Main configuration object for code-generation in Go.
+///
+/// Fields and variants marked `#[serde(skip)]` in Rust are never traced, hence not
+/// generated, so that Go values are serialized like Rust values. Attributes that make the
+/// serialization of a field depend on its value (e.g. `skip_serializing_if`) are not
+/// supported by binary encodings, in Rust as in Go.
 pub struct CodeGenerator<'a> {
     /// Language-independent configuration.
     config: &'a CodeGeneratorConfig,
     /// Module path where to find the serde runtime packages (serde, bcs, bincode).
     /// Default: "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang".
     serde_module_path: String,
+    /// Name of the generated package.
+    /// Default: the last component of `config.module_name` (e.g. "ledger" for "types/ledger").
+    package_name: String,
     /// Mapping from external type names to fully-qualified class names (e.g. "MyClass" -> "com.my_org.my_package.MyClass").
     /// Derived from `config.external_definitions`.
     external_qualified_names: HashMap<String, String>,
+    /// Whether to generate JSON struct tags and JSON (un)marshaling for enums.
+    /// Default: false.
+    json: bool,
+    /// Whether to generate `Clone` methods.
+    /// Default: false.
+    clone: bool,
+    /// Whether to generate `Hash` methods.
+    /// Default: false.
+    hash: bool,
+    /// Whether to generate `Validate` methods.
+    /// Default: false.
+    validation: bool,
+    /// Whether to represent optional values as `serde.Option[T]` instead of pointers.
+    /// Default: false.
+    generic_options: bool,
+    /// Whether to generate `<Encoding>SerializeTo(io.Writer)` methods.
+    /// Default: false.
+    streaming: bool,
+    /// Whether to avoid the features that TinyGo does not support well.
+    /// Default: false.
+    tinygo: bool,
+    /// Whether to generate maps from container names to deserialization functions.
+    /// Default: false.
+    type_registry: bool,
+    /// Whether to write common initialisms in upper case in the names of fields and variants.
+    /// Default: false.
+    initialisms: bool,
+    /// Whether structs and c-style enums are used by value rather than by pointer.
+    /// Default: false.
+    value_semantics: bool,
+    /// Whether the fields of structs are unexported and read with getters.
+    /// Default: false.
+    immutable_structs: bool,
+    /// Whether to generate the method `SerializedSize` for the containers of fixed size.
+    /// Default: false.
+    serialized_sizes: bool,
+    /// Go names of particular definitions (see `with_renamings`).
+    renamings: Renamings,
+    /// JSON representations of particular enums (see `with_enum_representations`).
+    enum_representations: EnumRepresentations,
+    /// Minimal number of fields of the structs that have a builder (see `with_builders`).
+    /// Default: None (no builders).
+    builder_min_fields: Option<usize>,
+    /// Go types used in place of particular containers (see `with_custom_types`).
+    custom_types: CustomTypes,
+    /// Default values of trailing fields (see `with_field_defaults`).
+    field_defaults: FieldDefaults,
+}
+
+/// Track the Go names to be used for particular definitions (types, variants, and fields)
+/// instead of the names derived from the registry.
+pub type Renamings = BTreeMap</* qualified name */ Vec<String>, /* Go name */ String>;
+
+/// JSON representation of an enum, following the attributes of Serde.
+#[derive(Clone, Debug, PartialEq, Eq)]
+pub enum EnumRepresentation {
+    /// `{"Variant": content}`, or `"Variant"` for unit variants (default).
+    External,
+    /// `{"<tag>": "Variant", ...}` as for `#[serde(tag = "<tag>")]`. The content of
+    /// non-unit variants must be encoded as a JSON object (i.e. struct variants and
+    /// newtype variants wrapping structs or maps).
+    Internal(String),
+    /// The content alone as for `#[serde(untagged)]`, e.g. `null` for unit variants.
+    /// Decoding tries the variants in order and returns the first match.
+    Untagged,
+}
+
+/// Track the JSON representations of enums other than `EnumRepresentation::External`.
+pub type EnumRepresentations = BTreeMap</* qualified name */ Vec<String>, EnumRepresentation>;
+
+/// Go type provided by the application in place of a generated container (see
+/// `CodeGenerator::with_custom_types`).
+#[derive(Clone, Debug, PartialEq, Eq, Deserialize)]
+pub struct CustomType {
+    /// Go type, e.g. "address.AccountAddress".
+    pub go_type: String,
+    /// Package to import for the Go type, if any, e.g. "example.com/internal/address".
+    pub import_path: Option<String>,
+}
+
+/// Track the containers replaced by custom Go types.
+pub type CustomTypes = BTreeMap</* qualified name */ Vec<String>, CustomType>;
+
+/// Track the default values of fields that may be missing at the end of serialized structs.
+pub type FieldDefaults =
+    BTreeMap</* qualified name */ Vec<String>, /* Go expression */ String>;
+
+/// Sample values in round-trip tests are built recursively up to this depth. Deeper values
+/// are kept minimal (e.g. empty vectors) so that recursive types remain finite.
+const MAX_SAMPLE_DEPTH: usize = 2;
+
+/// State of the generation of sample values for round-trip tests.
+struct SampleContext<'r> {
+    registry: &'r Registry,
+    /// Containers whose values can be built without recursion, e.g. `List::Empty` for an enum
+    /// `List`. Each container is mapped to the iteration of the fixed point that proved it,
+    /// so that minimal values only refer to containers of lower ranks.
+    ranks: BTreeMap<String, usize>,
+    /// Counter used to vary primitive values.
+    counter: u64,
+}
+
+/// A Go expression for a sample value, together with the primitive types that it contains and
+/// that some encodings do not support.
+struct Sample {
+    expr: String,
+    has_char: bool,
+    has_float: bool,
+}
+
+impl Sample {
+    fn new(expr: String) -> Self {
+        Sample {
+            expr,
+            has_char: false,
+            has_float: false,
+        }
+    }
+
+    fn with_content<'s>(expr: String, contents: impl IntoIterator<Item = &'s Sample>) -> Self {
+        let mut sample = Sample::new(expr);
+        for content in contents {
+            sample.has_char |= content.has_char;
+            sample.has_float |= content.has_float;
+        }
+        sample
+    }
 }
 
 /// Shared state for the code generation of a Go source file.
@@ -34,35 +193,72 @@ struct GoEmitter<'a, T> {
     generator: &'a CodeGenerator<'a>,
     /// Current namespace (e.g. vec!["com", "my_org", "my_package", "MyClass"])
     current_namespace: Vec<String>,
+    /// Names of the enums defined in the registry.
+    enum_names: BTreeSet<String>,
+    /// Names of the structs that contain themselves (through other definitions). Optional
+    /// values containing these types are always represented by pointers to keep Go types
+    /// finite.
+    recursive_names: BTreeSet<String>,
+    /// Names of the types used as map keys that are not comparable in Go (e.g. structs
+    /// with slices). Such maps are indexed by the BCS serialization of keys (see `MapKey`).
+    map_key_names: BTreeSet<String>,
+    /// Original names of the renamed definitions, indexed by their qualified Go names.
+    original_names: HashMap<Vec<String>, String>,
+    /// Custom Go types, indexed by the (Go) names of the containers that they replace.
+    custom_types: BTreeMap<String, CustomType>,
+    /// Serialized sizes of the containers of fixed size (see `with_serialized_sizes`).
+    serialized_sizes: BTreeMap<String, usize>,
 }
 
 impl<'a> CodeGenerator<'a> {
     /// Create a Go code generator for the given config.
     pub fn new(config: &'a CodeGeneratorConfig) -> Self {
-        if config.c_style_enums {
-            panic!("Go does not support generating c-style enums");
-        }
         let mut external_qualified_names = HashMap::new();
         for (namespace, names) in &config.external_definitions {
-            let package_name = {
-                let path = namespace.rsplitn(2, '/').collect::<Vec<_>>();
-                if path.len() <= 1 {
-                    namespace
-                } else {
-                    path[0]
-                }
-            };
+            let package_name = Self::default_package_name(namespace);
             for name in names {
                 external_qualified_names
                     .insert(name.to_string(), format!("{}.{}", package_name, name));
             }
         }
+        let package_name = Self::default_package_name(&config.module_name).to_string();
         Self {
             config,
-            serde_module_path:
-                "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang"
-                    .to_string(),
+            serde_module_path: DEFAULT_SERDE_MODULE_PATH.to_string(),
+            package_name,
             external_qualified_names,
+            json: false,
+            clone: false,
+            hash: false,
+            validation: false,
+            generic_options: false,
+            streaming: false,
+            tinygo: false,
+            type_registry: false,
+            initialisms: false,
+            value_semantics: false,
+            immutable_structs: false,
+            serialized_sizes: false,
+            renamings: BTreeMap::new(),
+            enum_representations: BTreeMap::new(),
+            builder_min_fields: None,
+            custom_types: BTreeMap::new(),
+            field_defaults: BTreeMap::new(),
+        }
+    }
+
+    /// Name of the package at the given import path, following Go conventions: the last
+    /// component of the path, unless it is a major version (e.g. "example.com/base" for
+    /// "example.com/base/v2").
+    fn default_package_name(path: &str) -> &str {
+        let mut components = path.rsplit('/');
+        let last = components.next().unwrap_or_default();
+        let is_version = last.len() > 1
+            && last.starts_with('v')
+            && last[1..].chars().all(|c| c.is_ascii_digit());
+        match components.next() {
+            Some(previous) if is_version => previous,
+            _ => last,
         }
     }
 
@@ -72,842 +268,4357 @@ impl<'a> CodeGenerator<'a> {
         self
     }
 
-    /// Output class definitions for `registry`.
-    pub fn output(&self, out: &mut dyn Write, registry: &Registry) -> Result<()> {
-        let current_namespace = self
-            .config
-            .module_name
-            .split('.')
-            .map(String::from)
-            .collect::<Vec<_>>();
+    /// Name of the generated package, if different from the last component of the module name.
+    pub fn with_package_name(mut self, package_name: String) -> Self {
+        self.package_name = package_name;
+        self
+    }
 
-        let mut emitter = GoEmitter {
-            // `go fmt` indents using tabs so let's do the same.
-            out: IndentedWriter::new(out, IndentConfig::Tab),
-            generator: self,
-            current_namespace,
-        };
+    /// Whether to generate `json:"snake_case"` tags on struct fields as well as JSON
+    /// marshaling for enums, using the externally tagged representation of Serde
+    /// (e.g. `{"Variant": content}`) unless specified otherwise with
+    /// `with_enum_representations`. Enums can be read with `UnmarshalJSON<Enum>`.
+    pub fn with_json(mut self, json: bool) -> Self {
+        self.json = json;
+        self
+    }
 
-        emitter.output_preamble(registry)?;
+    /// Whether to generate `Clone()` methods returning deep copies of values.
+    pub fn with_clone(mut self, clone: bool) -> Self {
+        self.clone = clone;
+        self
+    }
 
-        for (name, format) in registry {
-            emitter.output_container(name, format)?;
+    /// Whether to generate `Hash() uint64` methods, computed over the BCS serialization
+    /// of values (see `bcs.Hash64`). This requires serialization to be enabled.
+    pub fn with_hash(mut self, hash: bool) -> Self {
+        self.hash = hash;
+        self
+    }
+
+    /// Whether to generate `Validate() error` methods checking that enum fields are set and
+    /// that C-style enums hold one of their variants, recursively. (Fixed-size arrays are
+    /// represented by Go arrays and need no check.) Errors are reported as `serde.TypeError`.
+    pub fn with_validation(mut self, validation: bool) -> Self {
+        self.validation = validation;
+        self
+    }
+
+    /// Whether to represent the type `Option<T>` by `serde.Option[T]` rather than `*T`, so
+    /// that "none" values cannot be confused with uninitialized pointers.
+    pub fn with_generic_options(mut self, generic_options: bool) -> Self {
+        self.generic_options = generic_options;
+        self
+    }
+
+    /// Whether to generate `<Encoding>SerializeTo(writer io.Writer) error` methods, which
+    /// write serialized bytes to `writer` as the serialization progresses (see
+    /// `serde.StreamSerializer`) instead of returning a new byte array, and functions
+    /// `<Encoding>Deserialize<Name>From(reader io.Reader) (<Name>, error)`, which read the
+    /// input from `reader` as the deserialization progresses (see
+    /// `serde.NewBinaryStreamDeserializer`) and fail if `reader` has trailing bytes.
+    pub fn with_streaming(mut self, streaming: bool) -> Self {
+        self.streaming = streaming;
+        self
+    }
+
+    /// Whether the generated code must build with TinyGo (e.g. for WebAssembly contracts):
+    /// `GoString()` methods, which print nested values with reflection, are then omitted.
+    /// The runtimes exclude their support of `encoding/json` and `database/sql` under the
+    /// `tinygo` build tag, hence this is incompatible with `with_json`: generating code
+    /// with both options fails. TinyGo itself is not tested: tests only build the generated
+    /// code and the runtimes with `go build -tags tinygo` and the standard Go toolchain.
+    pub fn with_tinygo(mut self, tinygo: bool) -> Self {
+        self.tinygo = tinygo;
+        self
+    }
+
+    /// Whether to generate a map `<Encoding>Deserializers` for each encoding, from the
+    /// (original) names of containers to functions `func([]byte) (interface{}, error)`
+    /// decoding them. With `write_source_files`, the maps are written in "registry.go".
+    pub fn with_type_registry(mut self, type_registry: bool) -> Self {
+        self.type_registry = type_registry;
+        self
+    }
+
+    /// Whether to write the initialisms recommended by Go linters in upper case when
+    /// converting the names of fields and variants to CamelCase, e.g. `UserID` or `HTTP`
+    /// instead of `UserId` or `Http` for `user_id` or `HTTP`. Renamings are not affected.
+    pub fn with_initialisms(mut self, initialisms: bool) -> Self {
+        self.initialisms = initialisms;
+        self
+    }
+
+    /// Whether to use structs and c-style enums by value rather than by pointer: their
+    /// serialization methods (`Serialize`, `<Encoding>Serialize`, `MarshalBinary`, etc.)
+    /// then have value receivers, so that values implement `serde.Serializable` and
+    /// `encoding.BinaryMarshaler` without nil checks, and constructors and builders return
+    /// values. Deserialization methods keep pointer receivers since they update the value.
+    /// Variants of enums are always pointers, which implement the interface of the enum,
+    /// and optional fields are pointers unless `with_generic_options` is set.
+    pub fn with_value_semantics(mut self, value_semantics: bool) -> Self {
+        self.value_semantics = value_semantics;
+        self
+    }
+
+    /// Whether to generate structs (and tuple structs) with unexported fields, which are
+    /// read with getters named after the fields (e.g. `obj.Sender()` for the field `sender`)
+    /// and set once by the constructor `New<Name>`, a builder, or deserialization. Values can
+    /// then be shared, e.g. across goroutines, without being modified by accident. Getters
+    /// return the slices and maps of values as is: callers must not modify them. Getters
+    /// that would have the name of another method (e.g. `Hash()`) are prefixed with `Get`.
+    /// Variants of enums keep exported fields. This is incompatible with `with_json`, since
+    /// `encoding/json` ignores unexported fields: generating code with both options fails.
+    pub fn with_immutable_structs(mut self, immutable_structs: bool) -> Self {
+        self.immutable_structs = immutable_structs;
+        self
+    }
+
+    /// Whether to generate a method `SerializedSize() int` for the structs (and tuple or
+    /// newtype structs) whose serialized size is the same for all values, that is, the structs
+    /// made of fixed-size integers, floats, booleans, arrays, tuples, and such structs. The size
+    /// is the same in BCS and Bincode. Serialization methods (e.g. `BcsSerialize`) then
+    /// allocate buffers of the exact size, and applications may use the sizes to plan storage.
+    /// Enums are never of fixed size, since the sizes of variants may differ.
+    pub fn with_serialized_sizes(mut self, serialized_sizes: bool) -> Self {
+        self.serialized_sizes = serialized_sizes;
+        self
+    }
+
+    /// Go names to use for particular definitions, instead of the names of the registry
+    /// (possibly converted to CamelCase). Definitions are designated by qualified names
+    /// as in `CodeGeneratorConfig::with_comments`, e.g. `["my_package", "Account"]`
+    /// for a container, `["my_package", "Account", "id"]` for a field, or
+    /// `["my_package", "Choice", "A"]` for a variant. JSON names and comments are
+    /// still derived from the original names.
+    pub fn with_renamings(mut self, renamings: Renamings) -> Self {
+        self.renamings = renamings;
+        self
+    }
+
+    /// JSON representations to use for particular enums, designated by qualified names
+    /// as in `CodeGeneratorConfig::with_comments` (e.g. `["my_package", "Message"]`). This
+    /// only applies to JSON (see `with_json`) and to enums generated as Go interfaces:
+    /// binary encodings always use variant indices, and C-style enums are always encoded
+    /// as variant names.
+    pub fn with_enum_representations(mut self, enum_representations: EnumRepresentations) -> Self {
+        self.enum_representations = enum_representations;
+        self
+    }
+
+    /// Whether to generate a builder `New<Struct>Builder()` for the structs with at least
+    /// `min_fields` named fields. Builders set fields one by one (e.g.
+    /// `NewFooBuilder().WithA(a).WithB(b).Build()`) and fail to build values when
+    /// non-optional fields are missing.
+    pub fn with_builders(mut self, min_fields: Option<usize>) -> Self {
+        self.builder_min_fields = min_fields;
+        self
+    }
+
+    /// Go types to use in place of particular containers, designated by qualified names as
+    /// in `CodeGeneratorConfig::with_comments` (e.g. `["my_package", "AccountAddress"]`).
+    /// Such containers are not generated. Instead, for a container `Foo`, the application
+    /// must provide the following functions in the generated package:
+    /// * `SerializeFoo(value T, serializer serde.Serializer) error` and
+    ///   `DeserializeFoo(deserializer serde.Deserializer) (T, error)` if serialization is enabled,
+    /// * `EqualFoo(a T, b T) bool`,
+    /// * `CloneFoo(value T) T` if `with_clone` is set.
+    ///
+    /// Values of custom types must be comparable with `==` when they are used as map keys.
+    pub fn with_custom_types(mut self, custom_types: CustomTypes) -> Self {
+        self.custom_types = custom_types;
+        self
+    }
+
+    /// Default values of the fields of structs (or struct variants) that may be missing at the
+    /// end of the serialized value, designated by qualified names as in
+    /// `CodeGeneratorConfig::with_comments` (e.g. `["my_package", "Record", "version"]`) and
+    /// given as Go expressions (e.g. `"1"`). This allows reading the values persisted before
+    /// the fields were added to the schema, with a deserializer that accepts missing trailing
+    /// fields (see `serde.IsMissingTrailingField`), e.g. obtained with
+    /// `bcs.NewDeserializerWithConfig` and the option `AllowMissingTrailingFields`.
+    ///
+    /// Defaults only apply to the last fields of a struct, and only if all the following fields
+    /// have defaults too. Missing fields are only detected at the end of the input, i.e. in
+    /// the last struct of a value (such as the top-level struct).
+    pub fn with_field_defaults(mut self, field_defaults: FieldDefaults) -> Self {
+        self.field_defaults = field_defaults;
+        self
+    }
+
+    /// Remove the containers that are replaced by custom types from the (renamed) registry.
+    fn extract_custom_types(
+        &self,
+        registry: &mut Registry,
+        original_names: &HashMap<Vec<String>, String>,
+    ) -> BTreeMap<String, CustomType> {
+        let mut result = BTreeMap::new();
+        for name in registry.keys() {
+            let mut path = self.current_namespace();
+            path.push(name.clone());
+            if let Some(original) = original_names.get(&path) {
+                *path.last_mut().unwrap() = original.clone();
+            }
+            if let Some(custom_type) = self.custom_types.get(&path) {
+                result.insert(name.clone(), custom_type.clone());
+            }
         }
+        for name in result.keys() {
+            registry.remove(name);
+        }
+        result
+    }
 
-        if self.config.serialization {
-            emitter.output_trait_helpers(registry)?;
+    /// Remove the containers declared in `config.external_definitions` from the (renamed)
+    /// registry: they are imported from their own package instead of being generated again.
+    /// This happens after the emitter has analyzed the registry, so that external types
+    /// keep the representation that they have in their package (e.g. as map keys).
+    fn remove_external_definitions(&self, registry: &mut Registry) {
+        // `BTreeMap::retain` requires Rust 1.53.
+        let names = registry
+            .keys()
+            .filter(|name| self.external_qualified_names.contains_key(*name))
+            .cloned()
+            .collect::<Vec<_>>();
+        for name in names {
+            registry.remove(&name);
         }
+    }
 
-        Ok(())
+    /// Compute the name of the constructor argument for the field `name` (also used as the
+    /// name of unexported fields).
+    fn quote_argument_name(name: &str) -> String {
+        let mut chars = name.chars();
+        let name = match chars.next() {
+            None => String::new(),
+            Some(c) => c.to_lowercase().chain(chars).collect(),
+        };
+        if GO_RESERVED_NAMES
+            .split_whitespace()
+            .any(|word| word == name)
+        {
+            format!("{}_", name)
+        } else {
+            name
+        }
     }
-}
 
-impl<'a, T> GoEmitter<'a, T>
-where
-    T: Write,
-{
-    fn output_preamble(&mut self, registry: &Registry) -> Result<()> {
-        writeln!(
-            self.out,
-            "package {}\n\n",
-            self.generator.config.module_name
-        )?;
-        // Go does not support disabling warnings on unused imports.
-        if registry.is_empty() {
-            return Ok(());
+    /// Compute the name of the Go field holding the member `name` of a struct, given as an
+    /// exported name (see `with_immutable_structs`).
+    fn quote_struct_field(&self, name: &str) -> String {
+        if self.immutable_structs {
+            Self::quote_argument_name(name)
+        } else {
+            name.to_string()
         }
-        writeln!(self.out, "import (")?;
-        self.out.indent();
-        if self.generator.config.serialization
-            && (Self::has_enum(registry) || !self.generator.config.encodings.is_empty())
-        {
-            writeln!(self.out, "\"fmt\"")?;
+    }
+
+    /// Compute the exported name of the struct field `name`, i.e. the inverse of
+    /// `quote_struct_field`.
+    fn quote_exported_field(&self, name: &str) -> String {
+        if !self.immutable_structs {
+            return name.to_string();
         }
-        if self.generator.config.serialization || Self::has_int128(registry) {
-            writeln!(self.out, "\"{}/serde\"", self.generator.serde_module_path)?;
+        let name = match name.strip_suffix('_') {
+            Some(word) if GO_RESERVED_NAMES.split_whitespace().any(|w| w == word) => word,
+            _ => name,
+        };
+        let mut chars = name.chars();
+        match chars.next() {
+            None => String::new(),
+            Some(c) => c.to_uppercase().chain(chars).collect(),
         }
-        if self.generator.config.serialization {
-            for encoding in &self.generator.config.encodings {
-                writeln!(
-                    self.out,
-                    "\"{}/{}\"",
-                    self.generator.serde_module_path,
-                    encoding.name()
-                )?;
-            }
+    }
+
+    /// Convert the name of a field or a variant to CamelCase.
+    fn quote_member(&self, name: &str) -> String {
+        let name = name.to_camel_case();
+        if !self.initialisms {
+            return name;
         }
-        for path in self.generator.config.external_definitions.keys() {
-            writeln!(self.out, "\"{}\"", path)?;
+        // Words start with an upper-case letter after conversion.
+        let mut words = Vec::new();
+        let mut start = 0;
+        for (index, c) in name.char_indices().skip(1) {
+            if c.is_uppercase() {
+                words.push(&name[start..index]);
+                start = index;
+            }
         }
-        self.out.unindent();
-        writeln!(self.out, ")\n")?;
-        Ok(())
+        words.push(&name[start..]);
+        words
+            .into_iter()
+            .map(|word| {
+                let upper = word.to_uppercase();
+                if GO_INITIALISMS.contains(&upper.as_str()) {
+                    upper
+                } else {
+                    word.to_string()
+                }
+            })
+            .collect()
     }
 
-    fn has_int128(registry: &Registry) -> bool {
-        for format in registry.values() {
-            if format
-                .visit(&mut |f| match f {
-                    Format::I128 | Format::U128 => {
-                        // Interrupt the visit if we find a (u)int128
-                        Err(serde_reflection::Error::Custom(String::new()))
+    /// Apply `self.renamings` to the registry and convert the remaining names of fields and
+    /// variants to CamelCase. Also returns the original names of the definitions whose name
+    /// changed, indexed by their new qualified names. Original names are used to look up
+    /// comments and to name values in JSON.
+    fn rename_registry(&self, registry: &Registry) -> (Registry, HashMap<Vec<String>, String>) {
+        let qualified_name = |path: &[&str]| {
+            let mut result = self.current_namespace();
+            result.extend(path.iter().map(|s| s.to_string()));
+            result
+        };
+        let rename = |path: &[&str]| self.renamings.get(&qualified_name(path)).cloned();
+        let rename_member =
+            |path: &[&str]| rename(path).unwrap_or_else(|| self.quote_member(path[path.len() - 1]));
+
+        let mut original_names = HashMap::new();
+        let mut result = Registry::new();
+        for (name, format) in registry {
+            let mut format = format.clone();
+            // The visit of formats in a container cannot fail.
+            format
+                .visit_mut(&mut |f| {
+                    if let Format::TypeName(x) = f {
+                        if let Some(new_name) = rename(&[x]) {
+                            *x = new_name;
+                        }
                     }
-                    _ => Ok(()),
+                    Ok(())
                 })
-                .is_err()
-            {
-                return true;
+                .unwrap();
+            let new_name = rename(&[name]).unwrap_or_else(|| name.clone());
+            match &mut format {
+                ContainerFormat::Struct(fields) => {
+                    for field in fields {
+                        let new_field =
+                            self.quote_struct_field(&rename_member(&[name, &field.name]));
+                        if new_field != field.name {
+                            original_names.insert(
+                                qualified_name(&[&new_name, &new_field]),
+                                std::mem::replace(&mut field.name, new_field),
+                            );
+                        }
+                    }
+                }
+                ContainerFormat::Enum(variants) => {
+                    for variant in variants.values_mut() {
+                        let new_variant = rename_member(&[name, &variant.name]);
+                        if let VariantFormat::Struct(fields) = &mut variant.value {
+                            for field in fields {
+                                let new_field = rename_member(&[name, &variant.name, &field.name]);
+                                if new_field != field.name {
+                                    original_names.insert(
+                                        qualified_name(&[&new_name, &new_variant, &new_field]),
+                                        std::mem::replace(&mut field.name, new_field),
+                                    );
+                                }
+                            }
+                        }
+                        if new_variant != variant.name {
+                            original_names.insert(
+                                qualified_name(&[&new_name, &new_variant]),
+                                std::mem::replace(&mut variant.name, new_variant),
+                            );
+                        }
+                    }
+                }
+                _ => (),
             }
+            if new_name != *name {
+                original_names.insert(qualified_name(&[&new_name]), name.clone());
+            }
+            result.insert(new_name, format);
         }
-        false
+        (result, original_names)
     }
 
-    fn has_enum(registry: &Registry) -> bool {
-        for format in registry.values() {
-            if let ContainerFormat::Enum(_) = format {
-                return true;
+    /// Enums without variant data are generated as integer types with a constant for each
+    /// variant when `config.c_style_enums` is set.
+    fn is_c_style_enum(&self, format: &ContainerFormat) -> bool {
+        match format {
+            ContainerFormat::Enum(variants) => {
+                self.config.c_style_enums
+                    && !variants.is_empty()
+                    && variants
+                        .values()
+                        .all(|variant| variant.value == VariantFormat::Unit)
             }
+            _ => false,
         }
-        false
     }
 
-    /// Compute a reference to the registry type `name`.
-    fn quote_qualified_name(&self, name: &str) -> String {
-        self.generator
-            .external_qualified_names
-            .get(name)
-            .cloned()
-            .unwrap_or_else(|| name.to_string())
+    fn current_namespace(&self) -> Vec<String> {
+        self.config
+            .module_name
+            .split('.')
+            .map(String::from)
+            .collect()
     }
 
-    fn output_comment(&mut self, name: &str) -> std::io::Result<()> {
-        let mut path = self.current_namespace.clone();
-        path.push(name.to_string());
-        if let Some(doc) = self.generator.config.comments.get(&path) {
-            let text = textwrap::indent(doc, "// ").replace("\n\n", "\n//\n");
-            write!(self.out, "{}", text)?;
+    fn get_recursive_names(registry: &Registry) -> BTreeSet<String> {
+        // The visit of formats in `get_dependency_map` cannot fail.
+        let dependencies = analyzer::get_dependency_map(registry).unwrap();
+        let mut result = BTreeSet::new();
+        for (name, format) in registry {
+            if let ContainerFormat::Enum(_) = format {
+                continue;
+            }
+            let mut seen = BTreeSet::new();
+            let mut queue = vec![name.as_str()];
+            while let Some(node) = queue.pop() {
+                for child in dependencies.get(node).into_iter().flatten() {
+                    if child == name {
+                        result.insert(name.clone());
+                    } else if seen.insert(*child) {
+                        queue.push(child);
+                    }
+                }
+            }
         }
-        Ok(())
+        result
     }
 
-    fn output_custom_code(&mut self, name: &str) -> std::io::Result<()> {
-        let mut path = self.current_namespace.clone();
-        path.push(name.to_string());
-        if let Some(code) = self.generator.config.custom_code.get(&path) {
-            write!(self.out, "\n{}", code)?;
+    /// Compute the serialized sizes of the containers whose values all have the same size.
+    /// External definitions are left out since they may not provide `SerializedSize`.
+    fn get_serialized_sizes(&self, registry: &Registry) -> BTreeMap<String, usize> {
+        let mut sizes = BTreeMap::new();
+        // Containers may depend on containers defined after them: iterate until no size is
+        // added. Recursive containers are never of fixed size.
+        loop {
+            let mut changed = false;
+            for (name, format) in registry {
+                if sizes.contains_key(name) || self.external_qualified_names.contains_key(name) {
+                    continue;
+                }
+                let size = match format {
+                    ContainerFormat::UnitStruct => Some(0),
+                    ContainerFormat::NewTypeStruct(format) => Self::format_size(format, &sizes),
+                    ContainerFormat::TupleStruct(formats) => Self::formats_size(formats, &sizes),
+                    ContainerFormat::Struct(fields) => {
+                        Self::formats_size(fields.iter().map(|field| &field.value), &sizes)
+                    }
+                    ContainerFormat::Enum(_) => None,
+                };
+                if let Some(size) = size {
+                    sizes.insert(name.clone(), size);
+                    changed = true;
+                }
+            }
+            if !changed {
+                return sizes;
+            }
         }
-        Ok(())
     }
 
-    fn quote_type(&self, format: &Format) -> String {
+    /// Serialized size of the values of `format`, if it is the same for all values.
+    fn format_size(format: &Format, sizes: &BTreeMap<String, usize>) -> Option<usize> {
         use Format::*;
         match format {
-            TypeName(x) => self.quote_qualified_name(x),
-            Unit => "struct {}".into(),
-            Bool => "bool".into(),
-            I8 => "int8".into(),
-            I16 => "int16".into(),
-            I32 => "int32".into(),
-            I64 => "int64".into(),
-            I128 => "serde.Int128".into(),
-            U8 => "uint8".into(),
-            U16 => "uint16".into(),
-            U32 => "uint32".into(),
-            U64 => "uint64".into(),
-            U128 => "serde.Uint128".into(),
-            F32 => "float32".into(),
-            F64 => "float64".into(),
-            Char => "rune".into(),
-            Str => "string".into(),
-            Bytes => "[]byte".into(),
-
-            Option(format) => format!("*{}", self.quote_type(format)),
-            Seq(format) => format!("[]{}", self.quote_type(format)),
-            Map { key, value } => {
-                format!("map[{}]{}", self.quote_type(key), self.quote_type(value))
-            }
-            Tuple(formats) => format!(
-                "struct {{{}}}",
-                formats
-                    .iter()
-                    .enumerate()
-                    .map(|(index, format)| format!("Field{} {}", index, self.quote_type(format)))
-                    .collect::<Vec<_>>()
-                    .join("; ")
-            ),
-            TupleArray { content, size } => format!("[{}]{}", size, self.quote_type(content)),
-
-            Variable(_) => panic!("unexpected value"),
+            TypeName(name) => sizes.get(name).cloned(),
+            Unit => Some(0),
+            Bool | I8 | U8 => Some(1),
+            I16 | U16 => Some(2),
+            I32 | U32 | F32 => Some(4),
+            I64 | U64 | F64 => Some(8),
+            I128 | U128 => Some(16),
+            Tuple(formats) => Self::formats_size(formats, sizes),
+            TupleArray { content, size } => Self::format_size(content, sizes)?.checked_mul(*size),
+            _ => None,
         }
     }
 
-    fn enter_class(&mut self, name: &str) {
-        self.out.indent();
-        self.current_namespace.push(name.to_string());
+    fn formats_size<'b>(
+        formats: impl IntoIterator<Item = &'b Format>,
+        sizes: &BTreeMap<String, usize>,
+    ) -> Option<usize> {
+        formats.into_iter().try_fold(0usize, |total, format| {
+            total.checked_add(Self::format_size(format, sizes)?)
+        })
     }
 
-    fn leave_class(&mut self) {
-        self.out.unindent();
-        self.current_namespace.pop();
+    /// Reject the combinations of options that cannot be generated.
+    fn check_options(&self) -> Result<()> {
+        if self.tinygo && self.json {
+            return Err(std::io::Error::new(
+                std::io::ErrorKind::InvalidInput,
+                "the option `tinygo` is incompatible with `json`: the runtimes leave out their \
+                 support of `encoding/json` under the `tinygo` build tag",
+            ));
+        }
+        if self.immutable_structs && self.json {
+            return Err(std::io::Error::new(
+                std::io::ErrorKind::InvalidInput,
+                "the option `immutable_structs` is incompatible with `json`: `encoding/json` \
+                 ignores unexported fields",
+            ));
+        }
+        Ok(())
     }
 
-    fn output_trait_helpers(&mut self, registry: &Registry) -> Result<()> {
-        let mut subtypes = BTreeMap::new();
-        for format in registry.values() {
-            format
-                .visit(&mut |f| {
-                    if Self::needs_helper(f) {
-                        subtypes.insert(common::mangle_type(f), f.clone());
+    fn new_emitter<'b, W: Write>(
+        &'b self,
+        out: W,
+        registry: &Registry,
+        original_names: HashMap<Vec<String>, String>,
+        custom_types: BTreeMap<String, CustomType>,
+    ) -> GoEmitter<'b, W> {
+        let mut emitter = GoEmitter {
+            // `go fmt` indents using tabs so let's do the same.
+            out: IndentedWriter::new(out, IndentConfig::Tab),
+            generator: self,
+            current_namespace: self.current_namespace(),
+            enum_names: registry
+                .iter()
+                .filter(|(_, format)| {
+                    matches!(format, ContainerFormat::Enum(_)) && !self.is_c_style_enum(format)
+                })
+                .map(|(name, _)| name.clone())
+                .collect(),
+            recursive_names: Self::get_recursive_names(registry),
+            map_key_names: BTreeSet::new(),
+            original_names,
+            custom_types,
+            serialized_sizes: BTreeMap::new(),
+        };
+        if self.config.serialization {
+            emitter.map_key_names = emitter.get_map_key_names(registry);
+            if self.serialized_sizes {
+                emitter.serialized_sizes = self.get_serialized_sizes(registry);
+            }
+        }
+        emitter
+    }
+
+    /// Output class definitions for `registry`.
+    pub fn output(&self, out: &mut dyn Write, registry: &Registry) -> Result<()> {
+        self.check_options()?;
+        let (mut registry, original_names) = self.rename_registry(registry);
+        let custom_types = self.extract_custom_types(&mut registry, &original_names);
+        let mut emitter = self.new_emitter(out, &registry, original_names, custom_types);
+        self.remove_external_definitions(&mut registry);
+        let registry = &registry;
+
+        emitter.output_preamble(registry)?;
+
+        for (name, format) in registry {
+            emitter.output_container(name, format)?;
+        }
+
+        emitter.output_helpers(registry)?;
+        emitter.output_type_registry(registry)
+    }
+
+    /// Output class definitions for `registry` in separate source files within `dir_path`:
+    /// one file per container (e.g. "serde_data_gen.go" for `SerdeData`) and a file
+    /// "serde_helpers.go" for the functions shared by containers. Each file only imports
+    /// the packages that it uses.
+    pub fn write_source_files(
+        &self,
+        dir_path: &std::path::Path,
+        registry: &Registry,
+    ) -> Result<()> {
+        self.check_options()?;
+        std::fs::create_dir_all(dir_path)?;
+        let (mut registry, original_names) = self.rename_registry(registry);
+        let custom_types = self.extract_custom_types(&mut registry, &original_names);
+        let emitter = self.new_emitter(std::io::sink(), &registry, original_names, custom_types);
+        self.remove_external_definitions(&mut registry);
+        let registry = &registry;
+        let imports = emitter.get_imports(registry);
+
+        for (name, file_name) in Self::get_source_file_names(registry) {
+            let mut body = Vec::new();
+            let mut file_emitter = emitter.with_output(&mut body);
+            file_emitter.output_container(&name, &registry[&name])?;
+            emitter.write_source_file(&dir_path.join(file_name), &imports, &body)?;
+        }
+
+        let mut body = Vec::new();
+        emitter.with_output(&mut body).output_helpers(registry)?;
+        emitter.write_source_file(&dir_path.join("serde_helpers.go"), &imports, &body)?;
+
+        if self.type_registry && self.config.serialization {
+            let mut body = Vec::new();
+            emitter
+                .with_output(&mut body)
+                .output_type_registry(registry)?;
+            emitter.write_source_file(&dir_path.join("registry.go"), &imports, &body)?;
+        }
+        Ok(())
+    }
+
+    /// Output a Go test file (e.g. "fuzz_test.go", to be placed next to the generated
+    /// code) with a native fuzz test `Fuzz<Name>BcsRoundtrip` for each container. Each test
+    /// deserializes arbitrary bytes and checks that valid inputs are serialized back to the
+    /// same bytes, which holds because BCS is canonical. No fuzz test is generated unless
+    /// serialization is enabled with the BCS encoding.
+    pub fn output_fuzz_tests(&self, out: &mut dyn Write, registry: &Registry) -> Result<()> {
+        let (mut registry, original_names) = self.rename_registry(registry);
+        let custom_types = self.extract_custom_types(&mut registry, &original_names);
+        let mut emitter = self.new_emitter(out, &registry, original_names, custom_types);
+        self.remove_external_definitions(&mut registry);
+        let registry = &registry;
+
+        writeln!(emitter.out, "package {}\n\n", self.package_name)?;
+        if registry.is_empty()
+            || !self.config.serialization
+            || !self.config.encodings.contains(&Encoding::Bcs)
+        {
+            return Ok(());
+        }
+        emitter.output_imports(&["bytes".to_string(), "testing".to_string()])?;
+        for name in registry.keys() {
+            emitter.output_fuzz_test(name)?;
+        }
+        Ok(())
+    }
+
+    /// Output a Go test file (e.g. "roundtrip_test.go", to be placed next to the generated
+    /// code) with a table test `TestRoundtrip`. For each container, the test serializes and
+    /// deserializes the zero value of the container (unless it contains enums, which are nil
+    /// interfaces) and a sample value of each variant (or of the struct) in every encoding,
+    /// then checks that values are preserved. Values that contain chars, or floats in BCS,
+    /// are not tested with the encodings that cannot serialize them. No test is generated
+    /// unless serialization is enabled with at least one encoding.
+    pub fn output_roundtrip_tests(&self, out: &mut dyn Write, registry: &Registry) -> Result<()> {
+        let (mut registry, original_names) = self.rename_registry(registry);
+        let custom_types = self.extract_custom_types(&mut registry, &original_names);
+        let mut emitter = self.new_emitter(out, &registry, original_names, custom_types);
+        self.remove_external_definitions(&mut registry);
+        let registry = &registry;
+
+        writeln!(emitter.out, "package {}\n\n", self.package_name)?;
+        if registry.is_empty() || !self.config.serialization || self.config.encodings.is_empty() {
+            return Ok(());
+        }
+        let mut body = Vec::new();
+        emitter
+            .with_output(&mut body)
+            .output_roundtrip_test(registry)?;
+        let body = String::from_utf8_lossy(&body);
+        let mut imports = vec![
+            "testing".to_string(),
+            format!("{}/serde", self.serde_module_path),
+        ];
+        // Sample values may refer to external types.
+        imports.extend(self.config.external_definitions.keys().cloned());
+        let imports = imports
+            .into_iter()
+            .filter(|path| Self::uses_package(&body, Self::default_package_name(path)))
+            .collect::<Vec<_>>();
+        emitter.output_imports(&imports)?;
+        write!(emitter.out, "{}", body)
+    }
+
+    /// Compute the name of the source file for each container. Names are derived from the
+    /// container names and given the suffix "_gen" so that Go never mistakes them for test
+    /// files or platform-specific files (e.g. "windows.go"). Collisions are resolved in the
+    /// order of the registry by adding a counter (e.g. "foo_bar_2_gen.go").
+    fn get_source_file_names(registry: &Registry) -> BTreeMap<String, String> {
+        let mut used = BTreeSet::new();
+        let mut result = BTreeMap::new();
+        for name in registry.keys() {
+            let base = name.to_snake_case();
+            let mut file_name = format!("{}_gen.go", base);
+            let mut counter = 1;
+            while !used.insert(file_name.clone()) {
+                counter += 1;
+                file_name = format!("{}_{}_gen.go", base, counter);
+            }
+            result.insert(name.clone(), file_name);
+        }
+        result
+    }
+
+    /// Whether the Go code in `body` refers to the given package outside of comments.
+    fn uses_package(body: &str, package: &str) -> bool {
+        let pattern = format!("{}.", package);
+        body.lines()
+            .filter(|line| !line.trim_start().starts_with("//"))
+            .any(|line| {
+                line.match_indices(&pattern).any(|(index, _)| {
+                    !line[..index]
+                        .chars()
+                        .last()
+                        .map_or(false, |c| c.is_alphanumeric() || c == '_' || c == '.')
+                })
+            })
+    }
+}
+
+impl<'a, T> GoEmitter<'a, T>
+where
+    T: Write,
+{
+    /// Clone the state of the emitter for a different writer.
+    fn with_output<W: Write>(&self, out: W) -> GoEmitter<'a, W> {
+        GoEmitter {
+            out: IndentedWriter::new(out, IndentConfig::Tab),
+            generator: self.generator,
+            current_namespace: self.current_namespace.clone(),
+            enum_names: self.enum_names.clone(),
+            recursive_names: self.recursive_names.clone(),
+            map_key_names: self.map_key_names.clone(),
+            original_names: self.original_names.clone(),
+            custom_types: self.custom_types.clone(),
+            serialized_sizes: self.serialized_sizes.clone(),
+        }
+    }
+
+    fn write_source_file(
+        &self,
+        path: &std::path::Path,
+        imports: &[String],
+        body: &[u8],
+    ) -> Result<()> {
+        let body = String::from_utf8_lossy(body);
+        // Go does not support disabling warnings on unused imports.
+        let imports = imports
+            .iter()
+            .filter(|path| {
+                CodeGenerator::uses_package(&body, CodeGenerator::default_package_name(path))
+            })
+            .cloned()
+            .collect::<Vec<_>>();
+
+        let mut file = std::fs::File::create(path)?;
+        let mut emitter = self.with_output(&mut file);
+        writeln!(emitter.out, "package {}\n\n", self.generator.package_name)?;
+        emitter.output_imports(&imports)?;
+        write!(emitter.out, "{}", body)
+    }
+
+    fn output_preamble(&mut self, registry: &Registry) -> Result<()> {
+        writeln!(self.out, "package {}\n\n", self.generator.package_name)?;
+        // Go does not support disabling warnings on unused imports.
+        if registry.is_empty() {
+            return Ok(());
+        }
+        let imports = self.get_imports(registry);
+        self.output_imports(&imports)
+    }
+
+    fn get_imports(&self, registry: &Registry) -> Vec<String> {
+        let mut imports = Vec::new();
+        if Self::has_bytes(registry) {
+            imports.push("bytes".to_string());
+        }
+        if self.generator.json {
+            imports.push("encoding/json".to_string());
+        }
+        imports.push("fmt".to_string());
+        if self.generator.config.serialization && self.generator.streaming {
+            imports.push("io".to_string());
+        }
+        let has_option = Self::has_option_field(registry) || self.has_generic_option(registry);
+        if self.generator.config.serialization
+            || Self::has_int128(registry)
+            || has_option
+            || self.uses_json_helpers(registry)
+            || self.uses_validation_errors(registry)
+        {
+            imports.push(format!("{}/serde", self.generator.serde_module_path));
+        }
+        if self.generator.config.serialization {
+            for encoding in &self.generator.config.encodings {
+                imports.push(format!(
+                    "{}/{}",
+                    self.generator.serde_module_path,
+                    encoding.name()
+                ));
+            }
+            if (self.generator.hash || !self.map_key_names.is_empty())
+                && !self.generator.config.encodings.contains(&Encoding::Bcs)
+            {
+                imports.push(format!("{}/bcs", self.generator.serde_module_path));
+            }
+        }
+        // Go does not support disabling warnings on unused imports.
+        let mut referenced_names = BTreeSet::new();
+        for format in registry.values() {
+            format
+                .visit(&mut |f| {
+                    if let Format::TypeName(name) = f {
+                        referenced_names.insert(name.clone());
+                    }
+                    Ok(())
+                })
+                .unwrap();
+        }
+        for (path, names) in &self.generator.config.external_definitions {
+            if names.iter().any(|name| referenced_names.contains(name)) {
+                imports.push(path.clone());
+            }
+        }
+        for custom_type in self.custom_types.values() {
+            if let Some(path) = &custom_type.import_path {
+                if !imports.contains(path) {
+                    imports.push(path.clone());
+                }
+            }
+        }
+        imports
+    }
+
+    // Whether the JSON unmarshalers of enums in `registry` use the `serde` package (for
+    // representation helpers and errors).
+    fn uses_json_helpers(&self, registry: &Registry) -> bool {
+        self.generator.json
+            && registry
+                .values()
+                .any(|format| matches!(format, ContainerFormat::Enum(_)))
+    }
+
+    // Whether the `Validate` methods of `registry` report errors with the `serde` package.
+    fn uses_validation_errors(&self, registry: &Registry) -> bool {
+        if !self.generator.validation {
+            return false;
+        }
+        registry.values().any(|format| {
+            self.generator.is_c_style_enum(format) || {
+                let mut result = false;
+                format
+                    .visit(&mut |f| {
+                        result |= self.needs_validation(f);
+                        Ok(())
+                    })
+                    .unwrap();
+                result
+            }
+        })
+    }
+
+    fn output_imports(&mut self, imports: &[String]) -> Result<()> {
+        if imports.is_empty() {
+            return Ok(());
+        }
+        writeln!(self.out, "import (")?;
+        self.out.indent();
+        for path in imports {
+            writeln!(self.out, "\"{}\"", path)?;
+        }
+        self.out.unindent();
+        writeln!(self.out, ")\n")?;
+        Ok(())
+    }
+
+    fn output_helpers(&mut self, registry: &Registry) -> Result<()> {
+        if self.generator.config.serialization {
+            self.output_trait_helpers(registry)?;
+        }
+        self.output_equal_helpers(registry)?;
+        if self.generator.clone {
+            self.output_clone_helpers(registry)?;
+        }
+        if self.generator.validation {
+            self.output_validate_helpers(registry)?;
+        }
+        Ok(())
+    }
+
+    fn has_int128(registry: &Registry) -> bool {
+        for format in registry.values() {
+            if format
+                .visit(&mut |f| match f {
+                    Format::I128 | Format::U128 => {
+                        // Interrupt the visit if we find a (u)int128
+                        Err(serde_reflection::Error::Custom(String::new()))
+                    }
+                    _ => Ok(()),
+                })
+                .is_err()
+            {
+                return true;
+            }
+        }
+        false
+    }
+
+    fn has_bytes(registry: &Registry) -> bool {
+        for format in registry.values() {
+            if format
+                .visit(&mut |f| match f {
+                    Format::Bytes => Err(serde_reflection::Error::Custom(String::new())),
+                    _ => Ok(()),
+                })
+                .is_err()
+            {
+                return true;
+            }
+        }
+        false
+    }
+
+    fn get_map_key_names(&self, registry: &Registry) -> BTreeSet<String> {
+        // Compute the names of all non-comparable types as a fixed point.
+        let mut names = self.enum_names.clone();
+        loop {
+            let size = names.len();
+            for (name, format) in registry {
+                if !names.contains(name)
+                    && format
+                        .visit(&mut |f| {
+                            if self.is_comparable(f, &names) {
+                                Ok(())
+                            } else {
+                                Err(serde_reflection::Error::Custom(String::new()))
+                            }
+                        })
+                        .is_err()
+                {
+                    names.insert(name.clone());
+                }
+            }
+            if names.len() == size {
+                break;
+            }
+        }
+        let mut result = BTreeSet::new();
+        for format in registry.values() {
+            format
+                .visit(&mut |f| {
+                    if let Format::Map { key, .. } = f {
+                        if let Format::TypeName(name) = key.as_ref() {
+                            if names.contains(name) {
+                                result.insert(name.clone());
+                            }
+                        }
                     }
                     Ok(())
                 })
                 .unwrap();
         }
-        for (mangled_name, subtype) in &subtypes {
-            self.output_serialization_helper(mangled_name, subtype)?;
-            self.output_deserialization_helper(mangled_name, subtype)?;
+        result
+    }
+
+    /// Whether values of type `format` can be compared with `==` in Go, assuming that the
+    /// registry types `incomparable_names` cannot (tuples and arrays are checked elsewhere by
+    /// visiting their content). Pointers and interfaces are compared by address.
+    fn is_comparable(&self, format: &Format, incomparable_names: &BTreeSet<String>) -> bool {
+        match format {
+            Format::TypeName(name) => !incomparable_names.contains(name),
+            Format::Option(format) => self.is_generic_option(format),
+            Format::Bytes | Format::Seq(_) | Format::Map { .. } => false,
+            _ => true,
+        }
+    }
+
+    fn is_map_key_name<'b>(&self, format: &'b Format) -> Option<&'b str> {
+        match format {
+            Format::TypeName(name) if self.map_key_names.contains(name) => Some(name),
+            _ => None,
+        }
+    }
+
+    /// Whether the optional values of type `format` are represented by `serde.Option`.
+    /// Values that contain a recursive struct (see `recursive_names`) are always boxed.
+    fn is_generic_option(&self, format: &Format) -> bool {
+        self.generator.generic_options && !self.contains_recursive_value(format)
+    }
+
+    /// Whether the Go representation of `format` embeds a recursive struct by value, as
+    /// opposed to behind a slice, a map, or a pointer. (Optional values never do so, since
+    /// they are boxed whenever they contain a recursive struct.)
+    fn contains_recursive_value(&self, format: &Format) -> bool {
+        match format {
+            Format::TypeName(name) => self.recursive_names.contains(name),
+            Format::Tuple(formats) => formats.iter().any(|f| self.contains_recursive_value(f)),
+            Format::TupleArray { content, .. } => self.contains_recursive_value(content),
+            _ => false,
+        }
+    }
+
+    fn has_generic_option(&self, registry: &Registry) -> bool {
+        for format in registry.values() {
+            if format
+                .visit(&mut |f| match f {
+                    Format::Option(format) if self.is_generic_option(format) => {
+                        Err(serde_reflection::Error::Custom(String::new()))
+                    }
+                    _ => Ok(()),
+                })
+                .is_err()
+            {
+                return true;
+            }
+        }
+        false
+    }
+
+    // Whether a struct or a variant has an optional field, formatted with `serde.OptionFromPtr`.
+    fn has_option_field(registry: &Registry) -> bool {
+        let is_option = |format: &Format| matches!(format, Format::Option(_));
+        let has_option = |variant: &VariantFormat| match variant {
+            VariantFormat::NewType(format) => is_option(format),
+            VariantFormat::Tuple(formats) => formats.iter().any(is_option),
+            VariantFormat::Struct(fields) => fields.iter().any(|f| is_option(&f.value)),
+            _ => false,
+        };
+        registry.values().any(|format| match format {
+            ContainerFormat::NewTypeStruct(format) => is_option(format),
+            ContainerFormat::TupleStruct(formats) => formats.iter().any(is_option),
+            ContainerFormat::Struct(fields) => fields.iter().any(|f| is_option(&f.value)),
+            ContainerFormat::Enum(variants) => variants.values().any(|v| has_option(&v.value)),
+            _ => false,
+        })
+    }
+
+    /// Compute a reference to the registry type `name`.
+    fn quote_qualified_name(&self, name: &str) -> String {
+        if let Some(custom_type) = self.custom_types.get(name) {
+            return custom_type.go_type.clone();
+        }
+        self.generator
+            .external_qualified_names
+            .get(name)
+            .cloned()
+            .unwrap_or_else(|| name.to_string())
+    }
+
+    /// Compute a reference to the function `<prefix><name>` defined next to the registry
+    /// type `name` (e.g. "foo.DeserializeTree" for an external type "foo.Tree").
+    fn quote_qualified_function(&self, prefix: &str, name: &str) -> String {
+        if self.custom_types.contains_key(name) {
+            return format!("{}{}", prefix, name);
+        }
+        let name = self.quote_qualified_name(name);
+        match name.rsplit_once('.') {
+            Some((package, name)) => format!("{}.{}{}", package, prefix, name),
+            None => format!("{}{}", prefix, name),
+        }
+    }
+
+    /// Qualified name of a definition relative to the current namespace.
+    fn qualified_name(&self, path: &[&str]) -> Vec<String> {
+        let mut result = self.current_namespace.clone();
+        result.extend(path.iter().map(|s| s.to_string()));
+        result
+    }
+
+    /// Name of a field or a variant in the registry, before renaming.
+    fn original_name(&self, path: &[&str]) -> String {
+        match self.original_names.get(&self.qualified_name(path)) {
+            Some(name) => name.clone(),
+            None => path[path.len() - 1].to_string(),
+        }
+    }
+
+    /// Qualified name of a definition before renaming (used to look up comments and custom code).
+    fn original_qualified_name(&self, name: &str) -> Vec<String> {
+        self.original_path(&[name])
+    }
+
+    /// Same as `original_qualified_name` for a path relative to the current namespace.
+    fn original_path(&self, path: &[&str]) -> Vec<String> {
+        let renamed = self.qualified_name(path);
+        let mut path = renamed.clone();
+        for i in 0..path.len() {
+            if let Some(original) = self.original_names.get(&renamed[..=i]) {
+                path[i] = original.clone();
+            }
+        }
+        path
+    }
+
+    fn output_comment(&mut self, name: &str) -> std::io::Result<()> {
+        let path = self.original_qualified_name(name);
+        if let Some(doc) = self.generator.config.comments.get(&path) {
+            let text = textwrap::indent(doc, "// ").replace("\n\n", "\n//\n");
+            write!(self.out, "{}", text)?;
+        }
+        Ok(())
+    }
+
+    fn output_custom_code(&mut self, name: &str) -> std::io::Result<()> {
+        let path = self.original_qualified_name(name);
+        if let Some(code) = self.generator.config.custom_code.get(&path) {
+            write!(self.out, "\n{}", code)?;
+        }
+        Ok(())
+    }
+
+    fn quote_type(&self, format: &Format) -> String {
+        use Format::*;
+        match format {
+            TypeName(x) => self.quote_qualified_name(x),
+            Unit => "struct {}".into(),
+            Bool => "bool".into(),
+            I8 => "int8".into(),
+            I16 => "int16".into(),
+            I32 => "int32".into(),
+            I64 => "int64".into(),
+            I128 => "serde.Int128".into(),
+            U8 => "uint8".into(),
+            U16 => "uint16".into(),
+            U32 => "uint32".into(),
+            U64 => "uint64".into(),
+            U128 => "serde.Uint128".into(),
+            F32 => "float32".into(),
+            F64 => "float64".into(),
+            Char => "rune".into(),
+            Str => "string".into(),
+            Bytes => "[]byte".into(),
+
+            Option(format) if self.is_generic_option(format) => {
+                format!("serde.Option[{}]", self.quote_type(format))
+            }
+            Option(format) => format!("*{}", self.quote_type(format)),
+            Seq(format) => format!("[]{}", self.quote_type(format)),
+            Map { key, value } if self.is_map_key_name(key).is_some() => {
+                format!("map[string]{}", self.quote_type(value))
+            }
+            Map { key, value } => {
+                format!("map[{}]{}", self.quote_type(key), self.quote_type(value))
+            }
+            Tuple(formats) => format!(
+                "struct {{{}}}",
+                formats
+                    .iter()
+                    .enumerate()
+                    .map(|(index, format)| format!("Field{} {}", index, self.quote_type(format)))
+                    .collect::<Vec<_>>()
+                    .join("; ")
+            ),
+            TupleArray { content, size } => format!("[{}]{}", size, self.quote_type(content)),
+
+            Variable(_) => panic!("unexpected value"),
+        }
+    }
+
+    fn enter_class(&mut self, name: &str) {
+        self.out.indent();
+        self.current_namespace.push(name.to_string());
+    }
+
+    fn leave_class(&mut self) {
+        self.out.unindent();
+        self.current_namespace.pop();
+    }
+
+    fn helper_subtypes(registry: &Registry) -> BTreeMap<String, Format> {
+        let mut subtypes = BTreeMap::new();
+        for format in registry.values() {
+            format
+                .visit(&mut |f| {
+                    if Self::needs_helper(f) {
+                        subtypes.insert(common::mangle_type(f), f.clone());
+                    }
+                    Ok(())
+                })
+                .unwrap();
+        }
+        subtypes
+    }
+
+    fn output_trait_helpers(&mut self, registry: &Registry) -> Result<()> {
+        for (mangled_name, subtype) in &Self::helper_subtypes(registry) {
+            self.output_serialization_helper(mangled_name, subtype)?;
+            self.output_deserialization_helper(mangled_name, subtype)?;
+        }
+        Ok(())
+    }
+
+    fn output_equal_helpers(&mut self, registry: &Registry) -> Result<()> {
+        for (mangled_name, subtype) in &Self::helper_subtypes(registry) {
+            self.output_equal_helper(mangled_name, subtype)?;
+        }
+        Ok(())
+    }
+
+    // Fixed-size byte arrays are passed as slices and checked for length. Enums are checked for nil.
+    fn output_struct_constructor(&mut self, name: &str, fields: &[Named<Format>]) -> Result<()> {
+        let arguments = fields
+            .iter()
+            .map(|field| {
+                let tpe = match &field.value {
+                    Format::TupleArray { content, size: _ } if content.as_ref() == &Format::U8 => {
+                        "[]byte".to_string()
+                    }
+                    format => self.quote_type(format),
+                };
+                format!(
+                    "{} {}",
+                    CodeGenerator::quote_argument_name(&field.name),
+                    tpe
+                )
+            })
+            .collect::<Vec<_>>()
+            .join(", ");
+        let (result, failure) = self.quote_constructor_result(name);
+        writeln!(
+            self.out,
+            "\n// New{0} creates a value of type {0} after checking the given fields.\nfunc New{0}({1}) ({2}, error) {{",
+            name, arguments, result
+        )?;
+        self.out.indent();
+        writeln!(self.out, "var obj {}", name)?;
+        for field in fields {
+            let argument = CodeGenerator::quote_argument_name(&field.name);
+            match &field.value {
+                Format::TupleArray { content, size } if content.as_ref() == &Format::U8 => {
+                    writeln!(
+                        self.out,
+                        r#"if len({0}) != {1} {{ return {4}, fmt.Errorf("Invalid length for {2}.{3}: expected {1} bytes but got %d", len({0})) }}
+copy(obj.{3}[:], {0})"#,
+                        argument, size, name, field.name, failure
+                    )?;
+                }
+                format => {
+                    if self.is_enum(format) {
+                        writeln!(
+                            self.out,
+                            "if {} == nil {{ return {}, fmt.Errorf(\"Missing value for {}.{}\") }}",
+                            argument, failure, name, field.name
+                        )?;
+                    }
+                    writeln!(self.out, "obj.{} = {}", field.name, argument)?;
+                }
+            }
+        }
+        if self.generator.value_semantics {
+            writeln!(self.out, "return obj, nil")?;
+        } else {
+            writeln!(self.out, "return &obj, nil")?;
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    /// Compute the type of the values returned by the constructor (and the builder) of the
+    /// struct `name`, and the value returned in case of error.
+    fn quote_constructor_result(&self, name: &str) -> (String, String) {
+        if self.generator.value_semantics {
+            (name.to_string(), format!("{}{{}}", name))
+        } else {
+            (format!("*{}", name), "nil".to_string())
+        }
+    }
+
+    fn output_struct_getters(&mut self, name: &str, fields: &[Named<Format>]) -> Result<()> {
+        // Methods of structs, which getters must not override.
+        let mut methods = [
+            "Serialize",
+            "Deserialize",
+            "Equal",
+            "String",
+            "GoString",
+            "Clone",
+            "Validate",
+            "Hash",
+            "MapKey",
+            "MarshalBinary",
+            "AppendBinary",
+            "UnmarshalBinary",
+            "SerializedSize",
+        ]
+        .iter()
+        .map(|method| method.to_string())
+        .collect::<BTreeSet<_>>();
+        for encoding in &self.generator.config.encodings {
+            let encoding = encoding.name().to_camel_case();
+            methods.insert(format!("{}Serialize", encoding));
+            methods.insert(format!("{}SerializeTo", encoding));
+        }
+        for field in fields {
+            let mut getter = self.generator.quote_exported_field(&field.name);
+            if methods.contains(&getter) {
+                getter = format!("Get{}", getter);
+            }
+            writeln!(
+                self.out,
+                "
+// {0} returns the field `{1}` of the value.
+func (obj {2}) {0}() {3} {{
+	return obj.{1}
+}}",
+                getter,
+                field.name,
+                name,
+                self.quote_type(&field.value)
+            )?;
+        }
+        Ok(())
+    }
+
+    /// The builder of a struct tracks which non-optional fields are set, then calls the
+    /// constructor to check them.
+    fn output_struct_builder(&mut self, name: &str, fields: &[Named<Format>]) -> Result<()> {
+        let required = fields
+            .iter()
+            .map(|field| !matches!(field.value, Format::Option(_)))
+            .collect::<Vec<_>>();
+        let num_required = required.iter().filter(|x| **x).count();
+        let (result, failure) = self.quote_constructor_result(name);
+        writeln!(
+            self.out,
+            r#"
+// {0}Builder sets the fields of a value of type {0} one by one. Fields that are not
+// optional must be set before calling `Build`.
+type {0}Builder struct {{
+	value {0}"#,
+            name
+        )?;
+        if num_required > 0 {
+            writeln!(self.out, "	set [{}]bool", num_required)?;
+        }
+        writeln!(
+            self.out,
+            "}}
+
+func New{0}Builder() *{0}Builder {{
+	return &{0}Builder{{}}
+}}",
+            name
+        )?;
+        let mut index = 0;
+        for (field, required) in fields.iter().zip(&required) {
+            writeln!(
+                self.out,
+                "
+func (b *{}Builder) With{}(value {}) *{}Builder {{",
+                name,
+                self.generator.quote_exported_field(&field.name),
+                self.quote_type(&field.value),
+                name
+            )?;
+            self.out.indent();
+            writeln!(self.out, "b.value.{} = value", field.name)?;
+            if *required {
+                writeln!(self.out, "b.set[{}] = true", index)?;
+                index += 1;
+            }
+            writeln!(self.out, "return b")?;
+            self.out.unindent();
+            writeln!(self.out, "}}")?;
+        }
+        writeln!(
+            self.out,
+            "
+// Build checks the fields and returns the value (see `New{0}`).
+func (b *{0}Builder) Build() ({1}, error) {{",
+            name, result
+        )?;
+        self.out.indent();
+        let mut index = 0;
+        for (field, required) in fields.iter().zip(&required) {
+            if *required {
+                writeln!(
+                    self.out,
+                    "if !b.set[{}] {{ return {}, fmt.Errorf(\"Missing value for {}.{}\") }}",
+                    index, failure, name, field.name
+                )?;
+                index += 1;
+            }
+        }
+        let arguments = fields
+            .iter()
+            .map(|field| match &field.value {
+                Format::TupleArray { content, size: _ } if content.as_ref() == &Format::U8 => {
+                    format!("b.value.{}[:]", field.name)
+                }
+                _ => format!("b.value.{}", field.name),
+            })
+            .collect::<Vec<_>>()
+            .join(", ");
+        writeln!(self.out, "return New{}({})", name, arguments)?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    /// Compute the formatting verb and the argument used to display the value `value` in `String()`.
+    fn quote_display_arg(&self, value: &str, format: &Format) -> (&'static str, String) {
+        match format {
+            Format::Str | Format::Char => ("%q", value.to_string()),
+            Format::Option(format) if !self.is_generic_option(format) => {
+                ("%v", format!("serde.OptionFromPtr({})", value))
+            }
+            _ => ("%v", value.to_string()),
+        }
+    }
+
+    // `String()` mimics the `Debug` output of Rust (e.g. `Enum::Variant{Field: 1}`) while
+    // `GoString()` avoids printing enums as pointers (except with TinyGo).
+    fn output_struct_string_methods(
+        &mut self,
+        variant_base: Option<&str>,
+        name: &str,
+        fields: &[Named<Format>],
+        tuple: bool,
+    ) -> Result<()> {
+        let (full_name, display_name, reference) = match variant_base {
+            None => (name.to_string(), name.to_string(), ""),
+            Some(base) => (
+                format!("{}__{}", base, name),
+                format!("{}::{}", base, name),
+                "&",
+            ),
+        };
+        let mut verbs = Vec::new();
+        let mut values = Vec::new();
+        let mut go_verbs = Vec::new();
+        for field in fields {
+            let value = format!("obj.{}", field.name);
+            let (verb, value) = self.quote_display_arg(&value, &field.value);
+            if tuple {
+                verbs.push(verb.to_string());
+            } else {
+                verbs.push(format!("{}: {}", field.name, verb));
+            }
+            values.push(format!(", {}", value));
+            go_verbs.push(format!("{}:%#v", field.name));
+        }
+        let display = if fields.is_empty() {
+            display_name
+        } else if tuple {
+            format!("{}({})", display_name, verbs.join(", "))
+        } else {
+            format!("{}{{{}}}", display_name, verbs.join(", "))
+        };
+        let go_values = fields
+            .iter()
+            .map(|field| format!(", obj.{}", field.name))
+            .collect::<String>();
+        writeln!(
+            self.out,
+            "\nfunc (obj {}) String() string {{\n\treturn fmt.Sprintf(\"{}\"{})\n}}",
+            full_name,
+            display,
+            values.join("")
+        )?;
+        if self.generator.tinygo {
+            return Ok(());
+        }
+        writeln!(
+            self.out,
+            "\nfunc (obj {0}) GoString() string {{\n\treturn fmt.Sprintf(\"{1}{2}.{0}{{{3}}}\"{4})\n}}",
+            full_name,
+            reference,
+            self.generator.package_name,
+            go_verbs.join(", "),
+            go_values
+        )
+    }
+
+    fn output_clone_helpers(&mut self, registry: &Registry) -> Result<()> {
+        if Self::has_bytes(registry) {
+            self.output_clone_helper("bytes", &Format::Bytes)?;
+        }
+        for (mangled_name, subtype) in &Self::helper_subtypes(registry) {
+            self.output_clone_helper(mangled_name, subtype)?;
+        }
+        Ok(())
+    }
+
+    /// Compute an expression returning a deep copy of the value `value` of type `format`.
+    fn quote_clone_expr(&self, value: &str, format: &Format) -> String {
+        use Format::*;
+        match format {
+            TypeName(name) if self.custom_types.contains_key(name) => {
+                format!("Clone{}({})", name, value)
+            }
+            TypeName(_) => format!("{}.Clone()", value),
+            Bytes => format!("clone_bytes({})", value),
+            _ if Self::needs_helper(format) => {
+                format!("clone_{}({})", common::mangle_type(format), value)
+            }
+            _ => value.to_string(),
+        }
+    }
+
+    fn quote_clone_fields(&self, value: &str, fields: &[Named<Format>]) -> String {
+        fields
+            .iter()
+            .map(|field| {
+                format!(
+                    "{}: {}",
+                    field.name,
+                    self.quote_clone_expr(&format!("{}.{}", value, field.name), &field.value)
+                )
+            })
+            .collect::<Vec<_>>()
+            .join(", ")
+    }
+
+    fn output_clone_helper(&mut self, name: &str, format0: &Format) -> Result<()> {
+        use Format::*;
+
+        write!(
+            self.out,
+            "func clone_{}(value {1}) {1} {{",
+            name,
+            self.quote_type(format0)
+        )?;
+        self.out.indent();
+        match format0 {
+            Bytes => {
+                writeln!(
+                    self.out,
+                    "\nif value == nil {{ return nil }}\nreturn append([]byte{{}}, value...)"
+                )?;
+            }
+
+            Option(format) if self.is_generic_option(format) => {
+                write!(
+                    self.out,
+                    "\nif item, ok := value.Get(); ok {{ return serde.Some({}) }}\nreturn value\n",
+                    self.quote_clone_expr("item", format)
+                )?;
+            }
+
+            Option(format) => {
+                write!(
+                    self.out,
+                    "\nif value == nil {{ return nil }}\nresult := {}\nreturn &result\n",
+                    self.quote_clone_expr("(*value)", format)
+                )?;
+            }
+
+            Seq(format) => {
+                write!(
+                    self.out,
+                    r#"
+if value == nil {{ return nil }}
+result := make({}, len(value))
+for i, item := range value {{
+	result[i] = {}
+}}
+return result
+"#,
+                    self.quote_type(format0),
+                    self.quote_clone_expr("item", format)
+                )?;
+            }
+
+            Map { key, value } => {
+                write!(
+                    self.out,
+                    r#"
+if value == nil {{ return nil }}
+result := make({}, len(value))
+for key, item := range value {{
+	result[{}] = {}
+}}
+return result
+"#,
+                    self.quote_type(format0),
+                    if self.is_map_key_name(key).is_some() {
+                        "key".to_string()
+                    } else {
+                        self.quote_clone_expr("key", key)
+                    },
+                    self.quote_clone_expr("item", value)
+                )?;
+            }
+
+            Tuple(formats) => {
+                writeln!(
+                    self.out,
+                    "\nreturn {}{{{}}}",
+                    self.quote_type(format0),
+                    formats
+                        .iter()
+                        .enumerate()
+                        .map(|(i, f)| self.quote_clone_expr(&format!("value.Field{}", i), f))
+                        .collect::<Vec<_>>()
+                        .join(", ")
+                )?;
+            }
+
+            TupleArray { content, size: _ } => {
+                write!(
+                    self.out,
+                    r#"
+var result {}
+for i, item := range value {{
+	result[i] = {}
+}}
+return result
+"#,
+                    self.quote_type(format0),
+                    self.quote_clone_expr("item", content)
+                )?;
+            }
+
+            _ => panic!("unexpected case"),
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}\n")
+    }
+
+    /// Whether values of type `format` contain generated types, which must be validated.
+    /// Custom and external types are left to the application.
+    fn needs_validation(&self, format: &Format) -> bool {
+        use Format::*;
+        match format {
+            TypeName(name) => {
+                !self.custom_types.contains_key(name)
+                    && !self.generator.external_qualified_names.contains_key(name)
+            }
+            Option(format)
+            | Seq(format)
+            | TupleArray {
+                content: format, ..
+            } => self.needs_validation(format),
+            // Keys indexed by their serialization are not validated.
+            Map { key, value } => {
+                (self.is_map_key_name(key).is_none() && self.needs_validation(key))
+                    || self.needs_validation(value)
+            }
+            Tuple(formats) => formats.iter().any(|format| self.needs_validation(format)),
+            _ => false,
+        }
+    }
+
+    fn output_validate_helpers(&mut self, registry: &Registry) -> Result<()> {
+        for (mangled_name, subtype) in &Self::helper_subtypes(registry) {
+            if self.needs_validation(subtype) {
+                self.output_validate_helper(mangled_name, subtype)?;
+            }
+        }
+        Ok(())
+    }
+
+    /// Compute an expression validating the value `value` of type `format`, if needed.
+    fn quote_validate_expr(&self, value: &str, format: &Format) -> Option<String> {
+        if !self.needs_validation(format) {
+            return None;
+        }
+        match format {
+            Format::TypeName(_) => Some(format!("serde.Validate({})", value)),
+            _ => Some(format!(
+                "validate_{}({})",
+                common::mangle_type(format),
+                value
+            )),
+        }
+    }
+
+    fn output_validate_helper(&mut self, name: &str, format0: &Format) -> Result<()> {
+        use Format::*;
+
+        write!(
+            self.out,
+            "func validate_{}(value {}) error {{",
+            name,
+            self.quote_type(format0)
+        )?;
+        self.out.indent();
+        match format0 {
+            Option(format) if self.is_generic_option(format) => {
+                write!(
+                    self.out,
+                    "\nif item, ok := value.Get(); ok {{ return {} }}\nreturn nil\n",
+                    self.quote_validate_expr("item", format).unwrap()
+                )?;
+            }
+
+            Option(format) => {
+                write!(
+                    self.out,
+                    "\nif value == nil {{ return nil }}\nreturn {}\n",
+                    self.quote_validate_expr("(*value)", format).unwrap()
+                )?;
+            }
+
+            Seq(format)
+            | TupleArray {
+                content: format,
+                size: _,
+            } => {
+                write!(
+                    self.out,
+                    r#"
+for _, item := range value {{
+	if err := {}; err != nil {{ return err }}
+}}
+return nil
+"#,
+                    self.quote_validate_expr("item", format).unwrap()
+                )?;
+            }
+
+            Map { key, value } => {
+                let key_check = match self.is_map_key_name(key) {
+                    Some(_) => None,
+                    None => self.quote_validate_expr("key", key),
+                };
+                let value_check = self.quote_validate_expr("item", value);
+                writeln!(
+                    self.out,
+                    "\nfor {}, {} := range value {{",
+                    if key_check.is_some() { "key" } else { "_" },
+                    if value_check.is_some() { "item" } else { "_" }
+                )?;
+                for check in key_check.iter().chain(value_check.iter()) {
+                    writeln!(
+                        self.out,
+                        "\tif err := {}; err != nil {{ return err }}",
+                        check
+                    )?;
+                }
+                writeln!(self.out, "}}\nreturn nil")?;
+            }
+
+            Tuple(formats) => {
+                writeln!(self.out)?;
+                for (i, format) in formats.iter().enumerate() {
+                    if let Some(check) =
+                        self.quote_validate_expr(&format!("value.Field{}", i), format)
+                    {
+                        writeln!(self.out, "if err := {}; err != nil {{ return err }}", check)?;
+                    }
+                }
+                writeln!(self.out, "return nil")?;
+            }
+
+            _ => panic!("unexpected case"),
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}\n")
+    }
+
+    /// Output the `Validate` method of a struct or variant, reporting errors on the fields
+    /// `<field_prefix><field>` of the type `type_name`.
+    fn output_struct_validate_method(
+        &mut self,
+        full_name: &str,
+        type_name: &str,
+        field_prefix: &str,
+        fields: &[Named<Format>],
+    ) -> Result<()> {
+        writeln!(self.out, "\nfunc (obj {}) Validate() error {{", full_name)?;
+        self.out.indent();
+        for field in fields {
+            if let Some(check) =
+                self.quote_validate_expr(&format!("obj.{}", field.name), &field.value)
+            {
+                writeln!(
+                    self.out,
+                    "if err := {}; err != nil {{ return &serde.TypeError{{Type: \"{}\", Field: \"{}{}\", Err: err}} }}",
+                    check, type_name, field_prefix, field.name
+                )?;
+            }
+        }
+        writeln!(self.out, "return nil")?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    /// Compute a boolean expression comparing the values `a` and `b` of type `format`.
+    fn quote_equal_expr(&self, a: &str, b: &str, format: &Format) -> String {
+        use Format::*;
+        match format {
+            TypeName(name) if self.custom_types.contains_key(name) => {
+                format!("Equal{}({}, {})", name, a, b)
+            }
+            TypeName(_) => format!("{}.Equal({})", a, b),
+            Bytes => format!("bytes.Equal({}, {})", a, b),
+            _ if Self::needs_helper(format) => {
+                format!("equal_{}({}, {})", common::mangle_type(format), a, b)
+            }
+            _ => format!("{} == {}", a, b),
+        }
+    }
+
+    fn quote_equal_fields(&self, a: &str, b: &str, fields: &[Named<Format>]) -> String {
+        if fields.is_empty() {
+            return "true".to_string();
+        }
+        fields
+            .iter()
+            .map(|field| {
+                self.quote_equal_expr(
+                    &format!("{}.{}", a, field.name),
+                    &format!("{}.{}", b, field.name),
+                    &field.value,
+                )
+            })
+            .collect::<Vec<_>>()
+            .join(" && ")
+    }
+
+    fn output_equal_helper(&mut self, name: &str, format0: &Format) -> Result<()> {
+        use Format::*;
+
+        write!(
+            self.out,
+            "func equal_{}(a {1}, b {1}) bool {{",
+            name,
+            self.quote_type(format0)
+        )?;
+        self.out.indent();
+        match format0 {
+            Option(format) if self.is_generic_option(format) => {
+                write!(
+                    self.out,
+                    r#"
+va, oka := a.Get()
+vb, okb := b.Get()
+if !oka || !okb {{ return oka == okb }}
+return {}
+"#,
+                    self.quote_equal_expr("va", "vb", format)
+                )?;
+            }
+
+            Option(format) => {
+                write!(
+                    self.out,
+                    "\nif a == nil || b == nil {{ return a == b }}\nreturn {}\n",
+                    self.quote_equal_expr("(*a)", "(*b)", format)
+                )?;
+            }
+
+            Seq(format) => {
+                write!(
+                    self.out,
+                    r#"
+if len(a) != len(b) {{ return false }}
+for i := range a {{
+	if !({}) {{ return false }}
+}}
+return true
+"#,
+                    self.quote_equal_expr("a[i]", "b[i]", format)
+                )?;
+            }
+
+            Map { key: _, value } => {
+                write!(
+                    self.out,
+                    r#"
+if len(a) != len(b) {{ return false }}
+for key, va := range a {{
+	if vb, ok := b[key]; !ok || !({}) {{ return false }}
+}}
+return true
+"#,
+                    self.quote_equal_expr("va", "vb", value)
+                )?;
+            }
+
+            Tuple(formats) => {
+                let fields = formats
+                    .iter()
+                    .enumerate()
+                    .map(|(i, f)| Named {
+                        name: format!("Field{}", i),
+                        value: f.clone(),
+                    })
+                    .collect::<Vec<_>>();
+                writeln!(
+                    self.out,
+                    "\nreturn {}",
+                    self.quote_equal_fields("a", "b", &fields)
+                )?;
+            }
+
+            TupleArray { content, size: _ } => {
+                write!(
+                    self.out,
+                    r#"
+for i := range a {{
+	if !({}) {{ return false }}
+}}
+return true
+"#,
+                    self.quote_equal_expr("a[i]", "b[i]", content)
+                )?;
+            }
+
+            _ => panic!("unexpected case"),
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}\n")
+    }
+
+    fn needs_helper(format: &Format) -> bool {
+        use Format::*;
+        matches!(
+            format,
+            Option(_) | Seq(_) | Map { .. } | Tuple(_) | TupleArray { .. }
+        )
+    }
+
+    fn quote_serialize_value(&self, value: &str, format: &Format) -> String {
+        format!(
+            "if err := {}; err != nil {{ return err }}",
+            self.quote_serialize_expr(value, format)
+        )
+    }
+
+    fn quote_serialize_expr(&self, value: &str, format: &Format) -> String {
+        use Format::*;
+        match format {
+            TypeName(name) if self.custom_types.contains_key(name) => {
+                format!("Serialize{}({}, serializer)", name, value)
+            }
+            TypeName(_) => format!("{}.Serialize(serializer)", value),
+            Unit => format!("serializer.SerializeUnit({})", value),
+            Bool => format!("serializer.SerializeBool({})", value),
+            I8 => format!("serializer.SerializeI8({})", value),
+            I16 => format!("serializer.SerializeI16({})", value),
+            I32 => format!("serializer.SerializeI32({})", value),
+            I64 => format!("serializer.SerializeI64({})", value),
+            I128 => format!("serializer.SerializeI128({})", value),
+            U8 => format!("serializer.SerializeU8({})", value),
+            U16 => format!("serializer.SerializeU16({})", value),
+            U32 => format!("serializer.SerializeU32({})", value),
+            U64 => format!("serializer.SerializeU64({})", value),
+            U128 => format!("serializer.SerializeU128({})", value),
+            F32 => format!("serializer.SerializeF32({})", value),
+            F64 => format!("serializer.SerializeF64({})", value),
+            Char => format!("serializer.SerializeChar({})", value),
+            Str => format!("serializer.SerializeStr({})", value),
+            Bytes => format!("serializer.SerializeBytes({})", value),
+            _ => format!(
+                "serialize_{}({}, serializer)",
+                common::mangle_type(format),
+                value
+            ),
+        }
+    }
+
+    /// Compute a function value of type `func(T, serde.Serializer) error` to serialize `format`.
+    fn quote_serialize_function(&self, format: &Format) -> String {
+        if Self::needs_helper(format) {
+            return format!("serialize_{}", common::mangle_type(format));
+        }
+        format!(
+            "func(item {}, serializer serde.Serializer) error {{ return {} }}",
+            self.quote_type(format),
+            self.quote_serialize_expr("item", format)
+        )
+    }
+
+    fn quote_deserialize(&self, format: &Format, dest: &str, fail: &str) -> String {
+        format!(
+            "if val, err := {}; err == nil {{ {} = val }} else {{ return {}, err }}",
+            self.quote_deserialize_expr(format),
+            dest,
+            fail
+        )
+    }
+
+    /// Same as `quote_deserialize` but errors are wrapped with the name of the field (or index) being decoded.
+    fn quote_deserialize_field(
+        &self,
+        format: &Format,
+        dest: &str,
+        fail: &str,
+        segment: &str,
+    ) -> String {
+        format!(
+            "if val, err := {}; err == nil {{ {} = val }} else {{ return {}, serde.WrapDecodeError(err, deserializer, {}) }}",
+            self.quote_deserialize_expr(format),
+            dest,
+            fail,
+            segment
+        )
+    }
+
+    fn quote_deserialize_expr(&self, format: &Format) -> String {
+        use Format::*;
+        match format {
+            TypeName(name) => format!(
+                "{}(deserializer)",
+                self.quote_qualified_function("Deserialize", name)
+            ),
+            Unit => "deserializer.DeserializeUnit()".to_string(),
+            Bool => "deserializer.DeserializeBool()".to_string(),
+            I8 => "deserializer.DeserializeI8()".to_string(),
+            I16 => "deserializer.DeserializeI16()".to_string(),
+            I32 => "deserializer.DeserializeI32()".to_string(),
+            I64 => "deserializer.DeserializeI64()".to_string(),
+            I128 => "deserializer.DeserializeI128()".to_string(),
+            U8 => "deserializer.DeserializeU8()".to_string(),
+            U16 => "deserializer.DeserializeU16()".to_string(),
+            U32 => "deserializer.DeserializeU32()".to_string(),
+            U64 => "deserializer.DeserializeU64()".to_string(),
+            U128 => "deserializer.DeserializeU128()".to_string(),
+            F32 => "deserializer.DeserializeF32()".to_string(),
+            F64 => "deserializer.DeserializeF64()".to_string(),
+            Char => "deserializer.DeserializeChar()".to_string(),
+            Str => "deserializer.DeserializeStr()".to_string(),
+            Bytes => "deserializer.DeserializeBytes()".to_string(),
+            _ => format!("deserialize_{}(deserializer)", common::mangle_type(format)),
+        }
+    }
+
+    /// Compute a function value of type `func(serde.Deserializer) (T, error)` to deserialize `format`.
+    fn quote_deserialize_function(&self, format: &Format) -> String {
+        if Self::needs_helper(format) {
+            return format!("deserialize_{}", common::mangle_type(format));
+        }
+        format!(
+            "func(deserializer serde.Deserializer) ({}, error) {{ return {} }}",
+            self.quote_type(format),
+            self.quote_deserialize_expr(format)
+        )
+    }
+
+    fn output_serialization_helper(&mut self, name: &str, format0: &Format) -> Result<()> {
+        use Format::*;
+
+        write!(
+            self.out,
+            "func serialize_{}(value {}, serializer serde.Serializer) error {{",
+            name,
+            self.quote_type(format0)
+        )?;
+        self.out.indent();
+        match format0 {
+            Option(format) if self.is_generic_option(format) => {
+                write!(
+                    self.out,
+                    "\nreturn value.Serialize(serializer, {})\n",
+                    self.quote_serialize_function(format)
+                )?;
+            }
+
+            Option(format) => {
+                write!(
+                    self.out,
+                    "\nreturn serde.SerializeOption(value, serializer, {})\n",
+                    self.quote_serialize_function(format)
+                )?;
+            }
+
+            Seq(format) if format.as_ref() == &Bytes => {
+                writeln!(self.out, "\nreturn serializer.SerializeVecBytes(value)")?;
+            }
+
+            Seq(format) => {
+                write!(
+                    self.out,
+                    "\nreturn serde.SerializeVector(value, serializer, {})\n",
+                    self.quote_serialize_function(format)
+                )?;
+            }
+
+            Map { key, value } => {
+                let serialize_key = match self.is_map_key_name(key) {
+                    Some(name) => format!(
+                        "func(key string, serializer serde.Serializer) error {{ obj, err := {}FromMapKey(key); if err != nil {{ return err }}; return obj.Serialize(serializer) }}",
+                        self.quote_qualified_name(name)
+                    ),
+                    None => self.quote_serialize_function(key),
+                };
+                write!(
+                    self.out,
+                    "\nreturn serde.SerializeMap(value, serializer, {}, {})\n",
+                    serialize_key,
+                    self.quote_serialize_function(value)
+                )?;
+            }
+
+            Tuple(formats) => {
+                writeln!(self.out)?;
+                for (index, format) in formats.iter().enumerate() {
+                    let expr = format!("value.Field{}", index);
+                    writeln!(self.out, "{}", self.quote_serialize_value(&expr, format))?;
+                }
+                writeln!(self.out, "return nil")?;
+            }
+
+            TupleArray { content, size: _ } if content.as_ref() == &U8 => {
+                writeln!(
+                    self.out,
+                    "\nreturn serializer.SerializeFixedBytes(value[:])"
+                )?;
+            }
+
+            TupleArray { content, size: _ } => {
+                write!(
+                    self.out,
+                    r#"
+for _, item := range(value) {{
+	{}
+}}
+"#,
+                    self.quote_serialize_value("item", content),
+                )?;
+                writeln!(self.out, "return nil")?;
+            }
+
+            _ => panic!("unexpected case"),
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}\n")
+    }
+
+    fn output_deserialization_helper(&mut self, name: &str, format0: &Format) -> Result<()> {
+        use Format::*;
+
+        write!(
+            self.out,
+            "func deserialize_{}(deserializer serde.Deserializer) ({}, error) {{",
+            name,
+            self.quote_type(format0),
+        )?;
+        self.out.indent();
+        match format0 {
+            Option(format) if self.is_generic_option(format) => {
+                write!(
+                    self.out,
+                    r#"
+var obj {}
+err := obj.Deserialize(deserializer, {})
+return obj, err
+"#,
+                    self.quote_type(format0),
+                    self.quote_deserialize_function(format)
+                )?;
+            }
+
+            Option(format) => {
+                write!(
+                    self.out,
+                    "\nreturn serde.DeserializeOption(deserializer, {})\n",
+                    self.quote_deserialize_function(format)
+                )?;
+            }
+
+            Seq(format) if format.as_ref() == &Bytes => {
+                writeln!(self.out, "\nreturn deserializer.DeserializeVecBytes()")?;
+            }
+
+            Seq(format) => {
+                write!(
+                    self.out,
+                    "\nreturn serde.DeserializeVector(deserializer, {})\n",
+                    self.quote_deserialize_function(format)
+                )?;
+            }
+
+            Map { key, value } => {
+                let deserialize_key = match self.is_map_key_name(key) {
+                    Some(_) => format!(
+                        "func(deserializer serde.Deserializer) (string, error) {{ obj, err := {}; if err != nil {{ return \"\", err }}; return obj.MapKey(), nil }}",
+                        self.quote_deserialize_expr(key)
+                    ),
+                    None => self.quote_deserialize_function(key),
+                };
+                write!(
+                    self.out,
+                    "\nreturn serde.DeserializeMap(deserializer, {}, {})\n",
+                    deserialize_key,
+                    self.quote_deserialize_function(value)
+                )?;
+            }
+
+            Tuple(formats) => {
+                write!(
+                    self.out,
+                    r#"
+var obj {}
+{}
+return obj, nil
+"#,
+                    self.quote_type(format0),
+                    formats
+                        .iter()
+                        .enumerate()
+                        .map(|(i, f)| self.quote_deserialize_field(
+                            f,
+                            &format!("obj.Field{}", i),
+                            "obj",
+                            &format!("\"Field{}\"", i)
+                        ))
+                        .collect::<Vec<_>>()
+                        .join("\n")
+                )?;
+            }
+
+            TupleArray { content, size } if content.as_ref() == &U8 => {
+                write!(
+                    self.out,
+                    r#"
+var obj [{0}]uint8
+bytes, err := deserializer.DeserializeFixedBytes({0})
+if err != nil {{ return obj, err }}
+copy(obj[:], bytes)
+return obj, nil
+"#,
+                    size,
+                )?;
+            }
+
+            TupleArray { content, size } => {
+                write!(
+                    self.out,
+                    r#"
+var obj [{1}]{0}
+for i := range(obj) {{
+	if val, err := {2}; err == nil {{ obj[i] = val }} else {{ return obj, serde.WrapDecodeErrorIndex(err, deserializer, i) }}
+}}
+return obj, nil
+"#,
+                    self.quote_type(content),
+                    size,
+                    self.quote_deserialize_expr(content)
+                )?;
+            }
+
+            _ => panic!("unexpected case"),
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}\n")
+    }
+
+    fn output_variant(
+        &mut self,
+        base: &str,
+        index: u32,
+        name: &str,
+        variant: &VariantFormat,
+    ) -> Result<()> {
+        use VariantFormat::*;
+        let fields = match variant {
+            Unit => Vec::new(),
+            NewType(format) => match format.as_ref() {
+                // We cannot define a "new type" (e.g. `type Foo Bar`) out of a typename `Bar` because `Bar`
+                // could point to a Go interface. This would make `Foo` an interface as well. Interfaces can't be used
+                // as structs (e.g. they cannot have methods).
+                //
+                // Similarly, option types are compiled as pointers but `type Foo *Bar` would prevent `Foo` from being a
+                // valid pointer receiver.
+                Format::TypeName(_) | Format::Option(_) => vec![Named {
+                    name: "Value".to_string(),
+                    value: format.as_ref().clone(),
+                }],
+                // Other cases are fine.
+                _ => {
+                    self.output_struct_or_variant_new_type_container(
+                        Some(base),
+                        Some(index),
+                        name,
+                        format,
+                    )?;
+                    if self.generator.json {
+                        self.output_variant_json_marshaler(base, name, variant)?;
+                    }
+                    return Ok(());
+                }
+            },
+            Tuple(formats) => formats
+                .iter()
+                .enumerate()
+                .map(|(i, f)| Named {
+                    name: format!("Field{}", i),
+                    value: f.clone(),
+                })
+                .collect(),
+            Struct(fields) => fields
+                .iter()
+                .map(|f| Named {
+                    name: f.name.clone(),
+                    value: f.value.clone(),
+                })
+                .collect(),
+            Variable(_) => panic!("incorrect value"),
+        };
+        let tuple = !matches!(variant, Struct(_));
+        self.output_struct_or_variant_container(Some(base), Some(index), name, &fields, tuple)?;
+        if self.generator.json {
+            self.output_variant_json_marshaler(base, name, variant)?;
+        }
+        Ok(())
+    }
+
+    /// Decode the JSON value `raw` into `dest`. Enums need a dedicated function
+    /// since they are Go interfaces.
+    fn quote_json_decode(&self, format: &Format, raw: &str, dest: &str, fail: &str) -> String {
+        match format {
+            Format::TypeName(name) if self.enum_names.contains(name) => format!(
+                "if val, err := UnmarshalJSON{}({}); err == nil {{ {} = val }} else {{ return {}err }}",
+                name, raw, dest, fail
+            ),
+            _ => format!(
+                "if err := json.Unmarshal({}, &{}); err != nil {{ return {}err }}",
+                raw, dest, fail
+            ),
+        }
+    }
+
+    fn is_enum(&self, format: &Format) -> bool {
+        match format {
+            Format::TypeName(name) => self.enum_names.contains(name),
+            _ => false,
+        }
+    }
+
+    // Structs with enum fields cannot be decoded by `json.Unmarshal` alone.
+    fn output_struct_json_unmarshaler(
+        &mut self,
+        name: &str,
+        full_name: &str,
+        fields: &[Named<Format>],
+    ) -> Result<()> {
+        if !fields.iter().any(|field| self.is_enum(&field.value)) {
+            return Ok(());
+        }
+        writeln!(
+            self.out,
+            "\nfunc (obj *{}) UnmarshalJSON(data []byte) error {{",
+            full_name
+        )?;
+        self.out.indent();
+        writeln!(self.out, "var fields struct {{")?;
+        self.out.indent();
+        for field in fields {
+            let tpe = if self.is_enum(&field.value) {
+                "json.RawMessage".to_string()
+            } else {
+                self.quote_type(&field.value)
+            };
+            writeln!(
+                self.out,
+                "{} {} `json:\"{}\"`",
+                field.name,
+                tpe,
+                self.original_name(&[name, &field.name]).to_snake_case()
+            )?;
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}")?;
+        writeln!(
+            self.out,
+            "if err := json.Unmarshal(data, &fields); err != nil {{ return err }}"
+        )?;
+        for field in fields {
+            if self.is_enum(&field.value) {
+                writeln!(
+                    self.out,
+                    "{}",
+                    self.quote_json_decode(
+                        &field.value,
+                        &format!("fields.{}", field.name),
+                        &format!("obj.{}", field.name),
+                        ""
+                    )
+                )?;
+            } else {
+                writeln!(self.out, "obj.{0} = fields.{0}", field.name)?;
+            }
+        }
+        writeln!(self.out, "return nil")?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    /// JSON representation of the enum whose variants are being generated.
+    fn enum_representation(&self) -> EnumRepresentation {
+        self.generator
+            .enum_representations
+            .get(&self.original_path(&[]))
+            .cloned()
+            .unwrap_or(EnumRepresentation::External)
+    }
+
+    fn output_variant_json_marshaler(
+        &mut self,
+        base: &str,
+        name: &str,
+        variant: &VariantFormat,
+    ) -> Result<()> {
+        use VariantFormat::*;
+        let full_name = format!("{}__{}", base, name);
+        let variant_name = self.original_name(&[name]);
+        let content = match variant {
+            Unit => None,
+            NewType(format) => match format.as_ref() {
+                Format::TypeName(_) | Format::Option(_) => Some("obj.Value".to_string()),
+                _ => Some(format!("({})(*obj)", self.quote_type(format))),
+            },
+            Tuple(formats) => Some(format!(
+                "[]interface{{}}{{{}}}",
+                (0..formats.len())
+                    .map(|i| format!("obj.Field{}", i))
+                    .collect::<Vec<_>>()
+                    .join(", ")
+            )),
+            // Convert to a type without the method `MarshalJSON`.
+            Struct(_) => Some("(*plain)(obj)".to_string()),
+            Variable(_) => panic!("incorrect value"),
+        };
+        writeln!(
+            self.out,
+            "\nfunc (obj *{}) MarshalJSON() ([]byte, error) {{",
+            full_name
+        )?;
+        self.out.indent();
+        if let Struct(_) = variant {
+            writeln!(self.out, "type plain {}", full_name)?;
+        }
+        match (self.enum_representation(), content) {
+            (EnumRepresentation::External, None) => {
+                writeln!(self.out, "return json.Marshal(\"{}\")", variant_name)?
+            }
+            (EnumRepresentation::External, Some(content)) => writeln!(
+                self.out,
+                "return json.Marshal(map[string]interface{{}}{{\"{}\": {}}})",
+                variant_name, content
+            )?,
+            (EnumRepresentation::Internal(tag), content) => writeln!(
+                self.out,
+                "return serde.MarshalJSONWithTag(\"{}\", \"{}\", {})",
+                tag,
+                variant_name,
+                content.as_deref().unwrap_or("nil")
+            )?,
+            (EnumRepresentation::Untagged, None) => {
+                writeln!(self.out, "return []byte(\"null\"), nil")?
+            }
+            (EnumRepresentation::Untagged, Some(content)) => {
+                writeln!(self.out, "return json.Marshal({})", content)?
+            }
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    fn output_enum_json_unmarshaler(
+        &mut self,
+        name: &str,
+        variants: &BTreeMap<u32, Named<VariantFormat>>,
+    ) -> Result<()> {
+        match self.enum_representation() {
+            EnumRepresentation::External => {
+                self.output_externally_tagged_enum_json_unmarshaler(name, variants)
+            }
+            EnumRepresentation::Internal(tag) => {
+                self.output_internally_tagged_enum_json_unmarshaler(name, variants, &tag)
+            }
+            EnumRepresentation::Untagged => {
+                self.output_untagged_enum_json_unmarshaler(name, variants)
+            }
+        }
+    }
+
+    fn output_externally_tagged_enum_json_unmarshaler(
+        &mut self,
+        name: &str,
+        variants: &BTreeMap<u32, Named<VariantFormat>>,
+    ) -> Result<()> {
+        use VariantFormat::*;
+        writeln!(
+            self.out,
+            r#"
+// UnmarshalJSON{0} reads a value of the enum {0} encoded as the name of a unit variant or as `{{"Variant": content}}`.
+func UnmarshalJSON{0}(data []byte) ({0}, error) {{"#,
+            name
+        )?;
+        self.out.indent();
+        writeln!(
+            self.out,
+            "var name string\nif err := json.Unmarshal(data, &name); err == nil {{"
+        )?;
+        self.out.indent();
+        writeln!(self.out, "switch name {{")?;
+        for variant in variants.values() {
+            if let Unit = variant.value {
+                writeln!(
+                    self.out,
+                    "case \"{}\":\n\treturn &{}__{}{{}}, nil",
+                    self.original_name(&[&variant.name]),
+                    name,
+                    variant.name
+                )?;
+            }
+        }
+        writeln!(
+            self.out,
+            "default:\n\treturn nil, serde.UnknownVariantName(\"{}\", name)\n}}",
+            name
+        )?;
+        self.out.unindent();
+        if variants
+            .values()
+            .all(|variant| matches!(variant.value, Unit))
+        {
+            writeln!(
+                self.out,
+                "}}\nreturn nil, serde.InvalidValue(\"{}\", \"\", \"expected a variant name\")",
+                name
+            )?;
+            self.out.unindent();
+            return writeln!(self.out, "}}");
+        }
+        writeln!(
+            self.out,
+            r#"}}
+var tagged map[string]json.RawMessage
+if err := json.Unmarshal(data, &tagged); err != nil {{ return nil, err }}
+if len(tagged) != 1 {{ return nil, serde.InvalidValue("{}", "", "expected exactly one variant") }}
+var content json.RawMessage
+for key, value := range tagged {{ name, content = key, value }}
+switch name {{"#,
+            name
+        )?;
+        for variant in variants.values() {
+            if let Unit = variant.value {
+                continue;
+            }
+            writeln!(
+                self.out,
+                "case \"{}\":",
+                self.original_name(&[&variant.name])
+            )?;
+            self.out.indent();
+            self.output_variant_json_decode(name, variant, "content")?;
+            self.out.unindent();
+        }
+        writeln!(
+            self.out,
+            "default:\n\treturn nil, serde.UnknownVariantName(\"{}\", name)\n}}",
+            name
+        )?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    fn output_internally_tagged_enum_json_unmarshaler(
+        &mut self,
+        name: &str,
+        variants: &BTreeMap<u32, Named<VariantFormat>>,
+        tag: &str,
+    ) -> Result<()> {
+        writeln!(
+            self.out,
+            r#"
+// UnmarshalJSON{0} reads a value of the enum {0} encoded as `{{"{1}": "Variant", ...}}`.
+func UnmarshalJSON{0}(data []byte) ({0}, error) {{
+	name, err := serde.UnmarshalJSONTag(data, "{1}")
+	if err != nil {{ return nil, err }}
+	switch name {{"#,
+            name, tag
+        )?;
+        self.out.indent();
+        for variant in variants.values() {
+            writeln!(
+                self.out,
+                "case \"{}\":",
+                self.original_name(&[&variant.name])
+            )?;
+            self.out.indent();
+            self.output_variant_json_decode(name, variant, "data")?;
+            self.out.unindent();
+        }
+        writeln!(
+            self.out,
+            "default:\n\treturn nil, serde.UnknownVariantName(\"{}\", name)\n}}",
+            name
+        )?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    fn output_untagged_enum_json_unmarshaler(
+        &mut self,
+        name: &str,
+        variants: &BTreeMap<u32, Named<VariantFormat>>,
+    ) -> Result<()> {
+        writeln!(
+            self.out,
+            r#"
+// UnmarshalJSON{0} reads a value of the untagged enum {0}, trying variants in order.
+func UnmarshalJSON{0}(data []byte) ({0}, error) {{"#,
+            name
+        )?;
+        self.out.indent();
+        for variant in variants.values() {
+            if let VariantFormat::Unit = variant.value {
+                writeln!(
+                    self.out,
+                    "if string(data) == \"null\" {{ return &{}__{}{{}}, nil }}",
+                    name, variant.name
+                )?;
+                continue;
+            }
+            writeln!(self.out, "if obj, err := func() ({}, error) {{", name)?;
+            self.out.indent();
+            if let VariantFormat::Struct(fields) = &variant.value {
+                // Go would otherwise accept any JSON object.
+                let required = fields
+                    .iter()
+                    .filter(|f| !matches!(f.value, Format::Option(_)))
+                    .map(|f| {
+                        format!(
+                            ", \"{}\"",
+                            self.original_name(&[&variant.name, &f.name])
+                                .to_snake_case()
+                        )
+                    })
+                    .collect::<String>();
+                writeln!(
+                    self.out,
+                    "if err := serde.CheckJSONFields(data{}); err != nil {{ return nil, err }}",
+                    required
+                )?;
+            }
+            self.output_variant_json_decode(name, variant, "data")?;
+            self.out.unindent();
+            writeln!(self.out, "}}(); err == nil {{ return obj, nil }}")?;
+        }
+        writeln!(
+            self.out,
+            "return nil, serde.InvalidValue(\"{}\", \"\", \"data did not match any variant of untagged enum\")",
+            name
+        )?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    /// Decode the JSON value `raw` as the given variant and return it.
+    fn output_variant_json_decode(
+        &mut self,
+        name: &str,
+        variant: &Named<VariantFormat>,
+        raw: &str,
+    ) -> Result<()> {
+        use VariantFormat::*;
+        let full_name = format!("{}__{}", name, variant.name);
+        if let Unit = variant.value {
+            return writeln!(self.out, "return &{}{{}}, nil", full_name);
+        }
+        writeln!(self.out, "var obj {}", full_name)?;
+        match &variant.value {
+            Unit => unreachable!(),
+            NewType(format) => match format.as_ref() {
+                Format::TypeName(_) | Format::Option(_) => writeln!(
+                    self.out,
+                    "{}",
+                    self.quote_json_decode(format, raw, "obj.Value", "nil, ")
+                )?,
+                _ => writeln!(
+                    self.out,
+                    "if err := json.Unmarshal({}, &obj); err != nil {{ return nil, err }}",
+                    raw
+                )?,
+            },
+            Tuple(formats) => {
+                writeln!(self.out, "var items []json.RawMessage")?;
+                writeln!(
+                    self.out,
+                    "if err := json.Unmarshal({}, &items); err != nil {{ return nil, err }}",
+                    raw
+                )?;
+                writeln!(
+                    self.out,
+                    "if len(items) != {0} {{ return nil, serde.InvalidValue(\"{1}\", \"{2}\", \"expected {0} fields\") }}",
+                    formats.len(),
+                    name,
+                    variant.name
+                )?;
+                for (i, format) in formats.iter().enumerate() {
+                    writeln!(
+                        self.out,
+                        "{}",
+                        self.quote_json_decode(
+                            format,
+                            &format!("items[{}]", i),
+                            &format!("obj.Field{}", i),
+                            "nil, "
+                        )
+                    )?;
+                }
+            }
+            Struct(_) => writeln!(
+                self.out,
+                "if err := json.Unmarshal({}, &obj); err != nil {{ return nil, err }}",
+                raw
+            )?,
+            Variable(_) => panic!("incorrect value"),
+        }
+        writeln!(self.out, "return &obj, nil")
+    }
+
+    /// Fields of tuple-like containers (i.e. `tuple` is true) are displayed without names.
+    fn output_struct_or_variant_container(
+        &mut self,
+        variant_base: Option<&str>,
+        variant_index: Option<u32>,
+        name: &str,
+        fields: &[Named<Format>],
+        tuple: bool,
+    ) -> Result<()> {
+        let full_name = match variant_base {
+            None => name.to_string(),
+            Some(base) => format!("{}__{}", base, name),
+        };
+        // Struct
+        writeln!(self.out)?;
+        self.output_comment(name)?;
+        writeln!(self.out, "type {} struct {{", full_name)?;
+        self.enter_class(name);
+        for field in fields {
+            self.output_comment(&field.name)?;
+            if self.generator.json {
+                writeln!(
+                    self.out,
+                    "{} {} `json:\"{}\"`",
+                    field.name,
+                    self.quote_type(&field.value),
+                    self.original_name(&[&field.name]).to_snake_case()
+                )?;
+            } else {
+                writeln!(self.out, "{} {}", field.name, self.quote_type(&field.value))?;
+            }
+        }
+        self.leave_class();
+        writeln!(self.out, "}}")?;
+
+        // Link to base interface.
+        if let Some(base) = variant_base {
+            writeln!(self.out, "\nfunc (*{}) is{}() {{}}", full_name, base)?;
+            self.output_variant_name_method(&full_name, name)?;
+        }
+
+        // Constructor
+        if variant_base.is_none() && !fields.is_empty() {
+            self.output_struct_constructor(&full_name, fields)?;
+            match self.generator.builder_min_fields {
+                Some(min_fields) if !tuple && fields.len() >= min_fields => {
+                    self.output_struct_builder(&full_name, fields)?;
+                }
+                _ => (),
+            }
+            if self.generator.immutable_structs {
+                self.output_struct_getters(&full_name, fields)?;
+            }
+        }
+
+        // JSON
+        if self.generator.json {
+            self.output_struct_json_unmarshaler(name, &full_name, fields)?;
+        }
+
+        // Equal
+        match variant_base {
+            None => writeln!(
+                self.out,
+                "\nfunc (obj {0}) Equal(other {0}) bool {{\n\treturn {1}\n}}",
+                full_name,
+                self.quote_equal_fields("obj", "other", fields)
+            )?,
+            Some(base) => writeln!(
+                self.out,
+                r#"
+func (obj {0}) Equal(other {1}) bool {{
+	value, ok := other.(*{0})
+	if !ok || value == nil {{ return false }}
+	return {2}
+}}"#,
+                full_name,
+                base,
+                self.quote_equal_fields("obj", "value", fields)
+            )?,
+        }
+
+        // String
+        self.output_struct_string_methods(variant_base, name, fields, tuple)?;
+
+        // Clone
+        if self.generator.clone {
+            writeln!(
+                self.out,
+                "\nfunc (obj {0}) Clone() {1} {{\n\treturn {2}{0}{{{3}}}\n}}",
+                full_name,
+                variant_base.unwrap_or(&full_name),
+                if variant_base.is_some() { "&" } else { "" },
+                self.quote_clone_fields("obj", fields)
+            )?;
+        }
+
+        // Validate
+        if self.generator.validation {
+            let (type_name, field_prefix) = match variant_base {
+                None => (full_name.clone(), String::new()),
+                Some(base) => (base.to_string(), format!("{}.", name)),
+            };
+            self.output_struct_validate_method(&full_name, &type_name, &field_prefix, fields)?;
+        }
+
+        // Serialize
+        if self.generator.config.serialization {
+            let by_value = self.generator.value_semantics && variant_base.is_none();
+            writeln!(
+                self.out,
+                "\nfunc (obj {}{}) Serialize(serializer serde.Serializer) error {{",
+                if by_value { "" } else { "*" },
+                full_name
+            )?;
+            self.out.indent();
+            writeln!(
+                self.out,
+                "if err := serializer.IncreaseContainerDepth(); err != nil {{ return err }}"
+            )?;
+            if let Some(index) = variant_index {
+                writeln!(self.out, "serializer.SerializeVariantIndex({})", index)?;
+            }
+            for field in fields {
+                writeln!(
+                    self.out,
+                    "{}",
+                    self.quote_serialize_value(&format!("obj.{}", &field.name), &field.value)
+                )?;
+            }
+            writeln!(self.out, "serializer.DecreaseContainerDepth()")?;
+            writeln!(self.out, "return nil")?;
+            self.out.unindent();
+            writeln!(self.out, "}}")?;
+
+            if variant_base.is_none() {
+                self.output_struct_serialized_size(&full_name, by_value)?;
+            }
+            for encoding in &self.generator.config.encodings {
+                self.output_struct_serialize_for_encoding(&full_name, *encoding, by_value)?;
+            }
+            self.output_struct_binary_marshaler(&full_name, by_value)?;
+            self.output_struct_hash_method(&full_name)?;
+            self.output_struct_map_key_methods(variant_base, &full_name)?;
+        }
+        // Deserialize (struct) or Load (variant)
+        if self.generator.config.serialization {
+            writeln!(
+                self.out,
+                "\nfunc {0}{1}(deserializer serde.Deserializer) ({1}, error) {{",
+                if variant_base.is_none() {
+                    "Deserialize"
+                } else {
+                    "load_"
+                },
+                full_name,
+            )?;
+            self.out.indent();
+            writeln!(self.out, "var obj {}", full_name)?;
+            writeln!(
+                self.out,
+                "if err := deserializer.IncreaseContainerDepth(); err != nil {{ return obj, err }}"
+            )?;
+            let defaults = self.get_trailing_defaults(name, fields);
+            for (field, default) in fields.iter().zip(defaults) {
+                if let Some(default) = default {
+                    write!(
+                        self.out,
+                        "if serde.IsMissingTrailingField(deserializer) {{ obj.{} = {} }} else ",
+                        field.name, default
+                    )?;
+                }
+                writeln!(
+                    self.out,
+                    "{}",
+                    self.quote_deserialize_field(
+                        &field.value,
+                        &format!("obj.{}", field.name),
+                        "obj",
+                        &format!("\"{}\"", field.name)
+                    )
+                )?;
+            }
+            writeln!(self.out, "deserializer.DecreaseContainerDepth()")?;
+            writeln!(self.out, "return obj, nil")?;
+            self.out.unindent();
+            writeln!(self.out, "}}")?;
+
+            if variant_base.is_none() {
+                self.output_struct_deserialize_method(&full_name)?;
+                for encoding in &self.generator.config.encodings {
+                    self.output_struct_deserialize_for_encoding(&full_name, *encoding)?;
+                }
+                self.output_struct_binary_unmarshaler(&full_name)?;
+            }
+        }
+        // Custom code
+        self.output_custom_code(name)?;
+        Ok(())
+    }
+
+    // Same as output_struct_or_variant_container but we map the container with a single anonymous field
+    // to a new type in Go.
+    fn output_struct_or_variant_new_type_container(
+        &mut self,
+        variant_base: Option<&str>,
+        variant_index: Option<u32>,
+        name: &str,
+        format: &Format,
+    ) -> Result<()> {
+        let full_name = match variant_base {
+            None => name.to_string(),
+            Some(base) => format!("{}__{}", base, name),
+        };
+        // Struct
+        writeln!(self.out)?;
+        self.output_comment(name)?;
+        writeln!(self.out, "type {} {}", full_name, self.quote_type(format))?;
+
+        // Link to base interface.
+        if let Some(base) = variant_base {
+            writeln!(self.out, "\nfunc (*{}) is{}() {{}}", full_name, base)?;
+            self.output_variant_name_method(&full_name, name)?;
+        }
+
+        // Equal
+        let tpe = self.quote_type(format);
+        match variant_base {
+            None => writeln!(
+                self.out,
+                "\nfunc (obj {0}) Equal(other {0}) bool {{\n\treturn {1}\n}}",
+                full_name,
+                self.quote_equal_expr(
+                    &format!("(({})(obj))", tpe),
+                    &format!("(({})(other))", tpe),
+                    format
+                )
+            )?,
+            Some(base) => writeln!(
+                self.out,
+                r#"
+func (obj {0}) Equal(other {1}) bool {{
+	value, ok := other.(*{0})
+	if !ok || value == nil {{ return false }}
+	return {2}
+}}"#,
+                full_name,
+                base,
+                self.quote_equal_expr(
+                    &format!("(({})(obj))", tpe),
+                    &format!("(({})(*value))", tpe),
+                    format
+                )
+            )?,
+        }
+
+        // String
+        let display_name = match variant_base {
+            None => name.to_string(),
+            Some(base) => format!("{}::{}", base, name),
+        };
+        let (verb, value) = self.quote_display_arg(&format!("(({})(obj))", tpe), format);
+        writeln!(
+            self.out,
+            "\nfunc (obj {}) String() string {{\n\treturn fmt.Sprintf(\"{}({})\", {})\n}}",
+            full_name, display_name, verb, value
+        )?;
+        if !self.generator.tinygo {
+            writeln!(
+                self.out,
+                "\nfunc (obj {0}) GoString() string {{\n\treturn fmt.Sprintf(\"{1}.{0}(%#v)\", (({2})(obj)))\n}}",
+                full_name, self.generator.package_name, tpe
+            )?;
+        }
+
+        // Clone
+        if self.generator.clone {
+            let value = self.quote_clone_expr(&format!("(({})(obj))", tpe), format);
+            match variant_base {
+                None => writeln!(
+                    self.out,
+                    "\nfunc (obj {0}) Clone() {0} {{\n\treturn ({0})({1})\n}}",
+                    full_name, value
+                )?,
+                Some(base) => writeln!(
+                    self.out,
+                    "\nfunc (obj {0}) Clone() {1} {{\n\tresult := ({0})({2})\n\treturn &result\n}}",
+                    full_name, base, value
+                )?,
+            }
+        }
+
+        // Validate
+        if self.generator.validation {
+            writeln!(self.out, "\nfunc (obj {}) Validate() error {{", full_name)?;
+            if let Some(check) = self.quote_validate_expr(&format!("(({})(obj))", tpe), format) {
+                let (type_name, field) = match variant_base {
+                    None => (full_name.as_str(), ""),
+                    Some(base) => (base, name),
+                };
+                writeln!(
+                    self.out,
+                    "\tif err := {}; err != nil {{ return &serde.TypeError{{Type: \"{}\", Field: \"{}\", Err: err}} }}",
+                    check, type_name, field
+                )?;
+            }
+            writeln!(self.out, "\treturn nil\n}}")?;
+        }
+
+        // Serialize
+        if self.generator.config.serialization {
+            let by_value = self.generator.value_semantics && variant_base.is_none();
+            writeln!(
+                self.out,
+                "\nfunc (obj {}{}) Serialize(serializer serde.Serializer) error {{",
+                if by_value { "" } else { "*" },
+                full_name
+            )?;
+            self.out.indent();
+            writeln!(
+                self.out,
+                "if err := serializer.IncreaseContainerDepth(); err != nil {{ return err }}"
+            )?;
+            if let Some(index) = variant_index {
+                writeln!(self.out, "serializer.SerializeVariantIndex({})", index)?;
+            }
+            writeln!(
+                self.out,
+                "{}",
+                self.quote_serialize_value(
+                    &format!(
+                        "(({})({}obj))",
+                        self.quote_type(format),
+                        if by_value { "" } else { "*" }
+                    ),
+                    format
+                )
+            )?;
+            writeln!(self.out, "serializer.DecreaseContainerDepth()")?;
+            writeln!(self.out, "return nil")?;
+            self.out.unindent();
+            writeln!(self.out, "}}")?;
+
+            if variant_base.is_none() {
+                self.output_struct_serialized_size(&full_name, by_value)?;
+            }
+            for encoding in &self.generator.config.encodings {
+                self.output_struct_serialize_for_encoding(&full_name, *encoding, by_value)?;
+            }
+            self.output_struct_binary_marshaler(&full_name, by_value)?;
+            self.output_struct_hash_method(&full_name)?;
+            self.output_struct_map_key_methods(variant_base, &full_name)?;
+        }
+        // Deserialize (struct) or Load (variant)
+        if self.generator.config.serialization {
+            writeln!(
+                self.out,
+                "\nfunc {0}{1}(deserializer serde.Deserializer) ({1}, error) {{",
+                if variant_base.is_none() {
+                    "Deserialize"
+                } else {
+                    "load_"
+                },
+                full_name,
+            )?;
+            self.out.indent();
+            writeln!(self.out, "var obj {}", self.quote_type(format))?;
+            writeln!(self.out, "if err := deserializer.IncreaseContainerDepth(); err != nil {{ return ({})(obj), err }}", full_name)?;
+            writeln!(
+                self.out,
+                "{}",
+                self.quote_deserialize(format, "obj", &format!("(({})(obj))", full_name))
+            )?;
+            writeln!(self.out, "deserializer.DecreaseContainerDepth()")?;
+            writeln!(self.out, "return ({})(obj), nil", full_name)?;
+            self.out.unindent();
+            writeln!(self.out, "}}")?;
+
+            if variant_base.is_none() {
+                self.output_struct_deserialize_method(&full_name)?;
+                for encoding in &self.generator.config.encodings {
+                    self.output_struct_deserialize_for_encoding(&full_name, *encoding)?;
+                }
+                self.output_struct_binary_unmarshaler(&full_name)?;
+            }
         }
+        // Custom code
+        self.output_custom_code(name)?;
         Ok(())
     }
 
-    fn needs_helper(format: &Format) -> bool {
-        use Format::*;
-        matches!(
-            format,
-            Option(_) | Seq(_) | Map { .. } | Tuple(_) | TupleArray { .. }
+    /// Compute the default value of each field of the struct (or variant) `name`, if any,
+    /// keeping only the defaults that are followed by defaults (see `with_field_defaults`).
+    fn get_trailing_defaults(&self, name: &str, fields: &[Named<Format>]) -> Vec<Option<String>> {
+        let mut defaults = vec![None; fields.len()];
+        for (index, field) in fields.iter().enumerate().rev() {
+            let path = self.original_path(&[name, &field.name]);
+            match self.generator.field_defaults.get(&path) {
+                Some(default) => defaults[index] = Some(default.clone()),
+                None => break,
+            }
+        }
+        defaults
+    }
+
+    // Implement `serde.Deserializable` on top of the function `Deserialize<name>`.
+    fn output_struct_deserialize_method(&mut self, name: &str) -> Result<()> {
+        writeln!(
+            self.out,
+            r#"
+func (obj *{0}) Deserialize(deserializer serde.Deserializer) error {{
+	value, err := Deserialize{0}(deserializer)
+	if err == nil {{ *obj = value }}
+	return err
+}}"#,
+            name,
         )
     }
 
-    fn quote_serialize_value(&self, value: &str, format: &Format) -> String {
-        use Format::*;
-        let expr = match format {
-            TypeName(_) => format!("{}.Serialize(serializer)", value),
-            Unit => format!("serializer.SerializeUnit({})", value),
-            Bool => format!("serializer.SerializeBool({})", value),
-            I8 => format!("serializer.SerializeI8({})", value),
-            I16 => format!("serializer.SerializeI16({})", value),
-            I32 => format!("serializer.SerializeI32({})", value),
-            I64 => format!("serializer.SerializeI64({})", value),
-            I128 => format!("serializer.SerializeI128({})", value),
-            U8 => format!("serializer.SerializeU8({})", value),
-            U16 => format!("serializer.SerializeU16({})", value),
-            U32 => format!("serializer.SerializeU32({})", value),
-            U64 => format!("serializer.SerializeU64({})", value),
-            U128 => format!("serializer.SerializeU128({})", value),
-            F32 => format!("serializer.SerializeF32({})", value),
-            F64 => format!("serializer.SerializeF64({})", value),
-            Char => format!("serializer.SerializeChar({})", value),
-            Str => format!("serializer.SerializeStr({})", value),
-            Bytes => format!("serializer.SerializeBytes({})", value),
-            _ => format!(
-                "serialize_{}({}, serializer)",
-                common::mangle_type(format),
-                value
-            ),
+    // Values used `by_value` (see `with_value_semantics`) cannot be nil.
+    fn output_struct_serialize_for_encoding(
+        &mut self,
+        name: &str,
+        encoding: Encoding,
+        by_value: bool,
+    ) -> Result<()> {
+        let receiver = Self::quote_serialize_receiver(name, by_value);
+        let grow = if self.serialized_sizes.contains_key(name) {
+            "\n\tserde.Grow(serializer, obj.SerializedSize())"
+        } else {
+            ""
         };
-        format!("if err := {}; err != nil {{ return err }}", expr)
+        writeln!(
+            self.out,
+            r#"
+func (obj {2}) {1}Serialize() ([]byte, error) {{{3}
+	serializer := {0}.NewSerializer();{4}
+	if err := obj.Serialize(serializer); err != nil {{ return nil, err }}
+	// Empty values (e.g. unit structs) are not returned as nil, which deserializers reject.
+	if output := serializer.GetBytes(); output != nil {{ return output, nil }}
+	return []byte{{}}, nil
+}}"#,
+            encoding.name(),
+            encoding.name().to_camel_case(),
+            receiver,
+            Self::quote_nil_receiver_check(name, by_value, "nil, "),
+            grow
+        )?;
+        if self.generator.streaming {
+            writeln!(
+                self.out,
+                r#"
+func (obj {2}) {1}SerializeTo(writer io.Writer) error {{{3}
+	serializer := {0}.NewStreamSerializer(writer);
+	if err := obj.Serialize(serializer); err != nil {{ return err }}
+	return serializer.Flush()
+}}"#,
+                encoding.name(),
+                encoding.name().to_camel_case(),
+                receiver,
+                Self::quote_nil_receiver_check(name, by_value, "")
+            )?;
+        }
+        Ok(())
     }
 
-    fn quote_deserialize(&self, format: &Format, dest: &str, fail: &str) -> String {
-        use Format::*;
-        let expr = match format {
-            TypeName(name) => format!(
-                "Deserialize{}(deserializer)",
-                self.quote_qualified_name(name)
-            ),
-            Unit => "deserializer.DeserializeUnit()".to_string(),
-            Bool => "deserializer.DeserializeBool()".to_string(),
-            I8 => "deserializer.DeserializeI8()".to_string(),
-            I16 => "deserializer.DeserializeI16()".to_string(),
-            I32 => "deserializer.DeserializeI32()".to_string(),
-            I64 => "deserializer.DeserializeI64()".to_string(),
-            I128 => "deserializer.DeserializeI128()".to_string(),
-            U8 => "deserializer.DeserializeU8()".to_string(),
-            U16 => "deserializer.DeserializeU16()".to_string(),
-            U32 => "deserializer.DeserializeU32()".to_string(),
-            U64 => "deserializer.DeserializeU64()".to_string(),
-            U128 => "deserializer.DeserializeU128()".to_string(),
-            F32 => "deserializer.DeserializeF32()".to_string(),
-            F64 => "deserializer.DeserializeF64()".to_string(),
-            Char => "deserializer.DeserializeChar()".to_string(),
-            Str => "deserializer.DeserializeStr()".to_string(),
-            Bytes => "deserializer.DeserializeBytes()".to_string(),
-            _ => format!("deserialize_{}(deserializer)", common::mangle_type(format)),
-        };
-        format!(
-            "if val, err := {}; err == nil {{ {} = val }} else {{ return {}, err }}",
-            expr, dest, fail
-        )
+    fn output_struct_serialized_size(&mut self, name: &str, by_value: bool) -> Result<()> {
+        if let Some(size) = self.serialized_sizes.get(name) {
+            writeln!(
+                self.out,
+                r#"
+// SerializedSize returns the number of bytes of the serialized values of `{0}`.
+func (obj {1}) SerializedSize() int {{
+	return {2}
+}}"#,
+                name,
+                Self::quote_serialize_receiver(name, by_value),
+                size
+            )?;
+        }
+        Ok(())
     }
 
-    fn output_serialization_helper(&mut self, name: &str, format0: &Format) -> Result<()> {
-        use Format::*;
+    /// Compute the receiver type of the serialization methods of `name`.
+    fn quote_serialize_receiver(name: &str, by_value: bool) -> String {
+        if by_value {
+            name.to_string()
+        } else {
+            format!("*{}", name)
+        }
+    }
 
-        write!(
+    /// Compute the statement rejecting nil receivers in the serialization methods of `name`
+    /// (if any), given the values returned before the error (e.g. "nil, ").
+    fn quote_nil_receiver_check(name: &str, by_value: bool, results: &str) -> String {
+        if by_value {
+            String::new()
+        } else {
+            format!(
+                "\n\tif obj == nil {{\n\t\treturn {}&serde.TypeError{{Type: \"{}\", Err: serde.ErrNilValue}}\n\t}}",
+                results, name
+            )
+        }
+    }
+
+    /// Encoding used to implement `encoding.BinaryMarshaler`, `encoding.BinaryAppender`, and
+    /// `encoding.BinaryUnmarshaler`: BCS if available, otherwise the first encoding.
+    fn binary_encoding(&self) -> Option<Encoding> {
+        let encodings = &self.generator.config.encodings;
+        if encodings.contains(&Encoding::Bcs) {
+            Some(Encoding::Bcs)
+        } else {
+            encodings.iter().next().cloned()
+        }
+    }
+
+    fn output_struct_binary_marshaler(&mut self, name: &str, by_value: bool) -> Result<()> {
+        if let Some(encoding) = self.binary_encoding() {
+            writeln!(
+                self.out,
+                r#"
+func (obj {0}) MarshalBinary() ([]byte, error) {{
+	return obj.{1}Serialize()
+}}
+
+func (obj {0}) AppendBinary(b []byte) ([]byte, error) {{{3}
+	return {2}.Append(b, obj)
+}}"#,
+                Self::quote_serialize_receiver(name, by_value),
+                encoding.name().to_camel_case(),
+                encoding.name(),
+                Self::quote_nil_receiver_check(name, by_value, "nil, ")
+            )?;
+        }
+        Ok(())
+    }
+
+    fn output_struct_hash_method(&mut self, name: &str) -> Result<()> {
+        if self.generator.hash {
+            writeln!(
+                self.out,
+                "\nfunc (obj {}) Hash() uint64 {{\n\treturn bcs.Hash64(&obj)\n}}",
+                name
+            )?;
+        }
+        Ok(())
+    }
+
+    fn output_struct_map_key_methods(
+        &mut self,
+        variant_base: Option<&str>,
+        name: &str,
+    ) -> Result<()> {
+        if !self.map_key_names.contains(variant_base.unwrap_or(name)) {
+            return Ok(());
+        }
+        writeln!(
             self.out,
-            "func serialize_{}(value {}, serializer serde.Serializer) error {{",
-            name,
-            self.quote_type(format0)
+            r#"
+// MapKey returns the BCS serialization of the value, used to index maps.
+func (obj {}) MapKey() string {{
+	return bcs.MapKey(&obj)
+}}"#,
+            name
         )?;
-        self.out.indent();
-        match format0 {
-            Option(format) => {
-                write!(
+        if variant_base.is_none() {
+            self.output_from_map_key_function(name)?;
+        }
+        Ok(())
+    }
+
+    fn output_from_map_key_function(&mut self, name: &str) -> Result<()> {
+        writeln!(
+            self.out,
+            r#"
+func {0}FromMapKey(key string) ({0}, error) {{
+	deserializer := bcs.NewDeserializer([]byte(key))
+	obj, err := Deserialize{0}(deserializer)
+	if err != nil {{ return obj, err }}
+	return obj, deserializer.EndOfInput()
+}}"#,
+            name
+        )
+    }
+
+    fn output_type_registry(&mut self, registry: &Registry) -> Result<()> {
+        if !self.generator.type_registry || !self.generator.config.serialization {
+            return Ok(());
+        }
+        for encoding in &self.generator.config.encodings {
+            let prefix = encoding.name().to_camel_case();
+            writeln!(
+                self.out,
+                "\n// {0}Deserializers maps the name of each container to a function decoding its {1} serialization.\nvar {0}Deserializers = map[string]func([]byte) (interface{{}}, error){{",
+                prefix,
+                match encoding {
+                    Encoding::Bcs => "BCS",
+                    Encoding::Bincode => "Bincode",
+                }
+            )?;
+            self.out.indent();
+            for name in registry.keys() {
+                writeln!(
                     self.out,
-                    r#"
-if value != nil {{
-	if err := serializer.SerializeOptionTag(true); err != nil {{ return err }}
-	{}
-}} else {{
-	if err := serializer.SerializeOptionTag(false); err != nil {{ return err }}
-}}
-"#,
-                    self.quote_serialize_value("(*value)", format)
+                    "\"{}\": func(input []byte) (interface{{}}, error) {{ return {}Deserialize{}(input) }},",
+                    self.original_name(&[name]),
+                    prefix,
+                    name
                 )?;
             }
+            self.out.unindent();
+            writeln!(self.out, "}}")?;
+        }
+        Ok(())
+    }
 
-            Seq(format) => {
-                write!(
+    fn output_fuzz_test(&mut self, name: &str) -> Result<()> {
+        writeln!(
+            self.out,
+            r#"
+func Fuzz{0}BcsRoundtrip(f *testing.F) {{
+	f.Fuzz(func(t *testing.T, input []byte) {{
+		value, err := BcsDeserialize{0}(input)
+		if err != nil {{ return }}
+		output, err := value.BcsSerialize()
+		if err != nil {{ t.Fatalf("Cannot serialize {0}: %v", err) }}
+		if !bytes.Equal(input, output) {{
+			t.Fatalf("Serialization of {0} does not match the input:\n%x\n%x", input, output)
+		}}
+	}})
+}}"#,
+            name
+        )
+    }
+
+    fn output_roundtrip_test(&mut self, registry: &Registry) -> Result<()> {
+        writeln!(
+            self.out,
+            r#"
+// pointerTo returns a pointer to a copy of `value`.
+func pointerTo[T any](value T) *T {{
+	return &value
+}}
+
+// checkRoundtrip checks that the serialization of a value is deserialized into an equal value.
+func checkRoundtrip[T any](t *testing.T, serialize func() ([]byte, error), deserialize func([]byte) (T, error), equal func(T) bool) {{
+	t.Helper()
+	input, err := serialize()
+	if err != nil {{ t.Fatalf("Cannot serialize value: %v", err) }}
+	output, err := deserialize(input)
+	if err != nil {{ t.Fatalf("Cannot deserialize %x: %v", input, err) }}
+	if !equal(output) {{ t.Fatalf("Deserialized value %v does not match the original value", output) }}
+}}
+
+func TestRoundtrip(t *testing.T) {{
+	tests := []struct {{
+		name string
+		run  func(t *testing.T)
+	}}{{"#
+        )?;
+        let mut context = SampleContext {
+            registry,
+            ranks: Self::get_sample_ranks(registry),
+            counter: 0,
+        };
+        self.out.indent();
+        self.out.indent();
+        for (name, format) in registry {
+            for (label, declaration, sample) in self.get_roundtrip_cases(name, format, &mut context)
+            {
+                self.output_roundtrip_case(name, &label, &declaration, &sample)?;
+            }
+        }
+        self.out.unindent();
+        self.out.unindent();
+        writeln!(
+            self.out,
+            "\t}}\n\tfor _, test := range tests {{\n\t\tt.Run(test.name, test.run)\n\t}}\n}}"
+        )
+    }
+
+    fn output_roundtrip_case(
+        &mut self,
+        name: &str,
+        label: &str,
+        declaration: &str,
+        sample: &Sample,
+    ) -> Result<()> {
+        let encodings = self
+            .generator
+            .config
+            .encodings
+            .iter()
+            .filter(|encoding| {
+                !sample.has_char && !(sample.has_float && **encoding == Encoding::Bcs)
+            })
+            .collect::<Vec<_>>();
+        if encodings.is_empty() {
+            return Ok(());
+        }
+        writeln!(self.out, "{{\"{}\", func(t *testing.T) {{", label)?;
+        self.out.indent();
+        writeln!(self.out, "{}", declaration)?;
+        for encoding in encodings {
+            writeln!(
+                self.out,
+                "checkRoundtrip(t, value.{0}Serialize, {0}Deserialize{1}, value.Equal)",
+                encoding.name().to_camel_case(),
+                name
+            )?;
+            if self.serialized_sizes.contains_key(name) {
+                writeln!(
                     self.out,
-                    r#"
-if err := serializer.SerializeLen(uint64(len(value))); err != nil {{ return err }}
-for _, item := range(value) {{
-	{}
-}}
-"#,
-                    self.quote_serialize_value("item", format)
+                    "if output, err := value.{}Serialize(); err == nil && len(output) != value.SerializedSize() {{ t.Fatalf(\"Serialized size %d does not match SerializedSize()\", len(output)) }}",
+                    encoding.name().to_camel_case(),
                 )?;
             }
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}}},")
+    }
 
-            Map { key, value } => {
-                write!(
-                    self.out,
-                    r#"
-if err := serializer.SerializeLen(uint64(len(value))); err != nil {{ return err }}
-offsets := make([]uint64, len(value))
-count := 0
-for k, v := range(value) {{
-	offsets[count] = serializer.GetBufferOffset()
-	count += 1
-	{}
-	{}
-}}
-serializer.SortMapEntries(offsets);
-"#,
-                    self.quote_serialize_value("k", key),
-                    self.quote_serialize_value("v", value)
-                )?;
+    /// Compute the label, the declaration of `value`, and the sample of each test case for
+    /// the container `name`.
+    fn get_roundtrip_cases(
+        &self,
+        name: &str,
+        format: &ContainerFormat,
+        context: &mut SampleContext,
+    ) -> Vec<(String, String, Sample)> {
+        let mut cases = Vec::new();
+        match format {
+            ContainerFormat::Enum(variants) if self.generator.is_c_style_enum(format) => {
+                for variant in variants.values() {
+                    cases.push((
+                        format!("{}/{}", name, variant.name),
+                        format!("value := {}__{}", name, variant.name),
+                        Sample::new(String::new()),
+                    ));
+                }
             }
-
-            Tuple(formats) => {
-                writeln!(self.out)?;
-                for (index, format) in formats.iter().enumerate() {
-                    let expr = format!("value.Field{}", index);
-                    writeln!(self.out, "{}", self.quote_serialize_value(&expr, format))?;
+            ContainerFormat::Enum(variants) => {
+                for variant in variants.values() {
+                    if let Some(sample) =
+                        self.quote_variant_sample(name, &variant.name, &variant.value, 0, context)
+                    {
+                        cases.push((
+                            format!("{}/{}", name, variant.name),
+                            format!("value := {}({})", name, sample.expr),
+                            sample,
+                        ));
+                    }
                 }
             }
-
-            TupleArray { content, size: _ } => {
-                write!(
-                    self.out,
-                    r#"
-for _, item := range(value) {{
-	{}
-}}
-"#,
-                    self.quote_serialize_value("item", content),
-                )?;
+            _ => {
+                let type_name = Format::TypeName(name.to_string());
+                if let Some(zero) = self.get_zero_value_sample(&type_name, context.registry) {
+                    cases.push((
+                        format!("{}/zero", name),
+                        format!("var value {}", name),
+                        zero,
+                    ));
+                }
+                if let Some(sample) = self.quote_container_sample(name, 0, context) {
+                    cases.push((
+                        format!("{}/sample", name),
+                        format!("value := {}", sample.expr),
+                        sample,
+                    ));
+                }
             }
+        }
+        cases
+    }
 
-            _ => panic!("unexpected case"),
+    /// Rank the containers whose values can be built without recursion (see `SampleContext`).
+    fn get_sample_ranks(registry: &Registry) -> BTreeMap<String, usize> {
+        let mut ranks = BTreeMap::new();
+        for rank in 0.. {
+            let names = registry
+                .iter()
+                .filter(|(name, format)| {
+                    !ranks.contains_key(*name) && Self::is_container_grounded(format, &ranks, rank)
+                })
+                .map(|(name, _)| name.clone())
+                .collect::<Vec<_>>();
+            if names.is_empty() {
+                break;
+            }
+            for name in names {
+                ranks.insert(name, rank);
+            }
         }
-        writeln!(self.out, "return nil")?;
-        self.out.unindent();
-        writeln!(self.out, "}}\n")
+        ranks
     }
 
-    fn output_deserialization_helper(&mut self, name: &str, format0: &Format) -> Result<()> {
-        use Format::*;
+    /// Whether values of type `format` can be built from containers of rank lower than `bound`.
+    /// Optional values, vectors, and maps may be empty.
+    fn is_grounded(format: &Format, ranks: &BTreeMap<String, usize>, bound: usize) -> bool {
+        match format {
+            Format::TypeName(name) => ranks.get(name).map_or(false, |rank| *rank < bound),
+            Format::Tuple(formats) => formats.iter().all(|f| Self::is_grounded(f, ranks, bound)),
+            Format::TupleArray { content, .. } => Self::is_grounded(content, ranks, bound),
+            _ => true,
+        }
+    }
 
-        write!(
-            self.out,
-            "func deserialize_{}(deserializer serde.Deserializer) ({}, error) {{",
-            name,
-            self.quote_type(format0),
-        )?;
-        self.out.indent();
-        match format0 {
-            Option(format) => {
-                write!(
-                    self.out,
-                    r#"
-tag, err := deserializer.DeserializeOptionTag()
-if err != nil {{ return nil, err }}
-if tag {{
-	value := new({})
-	{}
-        return value, nil
-}} else {{
-	return nil, nil
-}}
-"#,
-                    self.quote_type(format),
-                    self.quote_deserialize(format, "*value", "nil"),
-                )?;
+    fn is_variant_grounded(
+        variant: &VariantFormat,
+        ranks: &BTreeMap<String, usize>,
+        bound: usize,
+    ) -> bool {
+        match variant {
+            VariantFormat::Unit => true,
+            VariantFormat::NewType(format) => Self::is_grounded(format, ranks, bound),
+            VariantFormat::Tuple(formats) => {
+                formats.iter().all(|f| Self::is_grounded(f, ranks, bound))
             }
+            VariantFormat::Struct(fields) => fields
+                .iter()
+                .all(|f| Self::is_grounded(&f.value, ranks, bound)),
+            VariantFormat::Variable(_) => false,
+        }
+    }
 
-            Seq(format) => {
-                write!(
-                    self.out,
-                    r#"
-length, err := deserializer.DeserializeLen()
-if err != nil {{ return nil, err }}
-obj := make([]{}, length)
-for i := range(obj) {{
-	{}
-}}
-return obj, nil
-"#,
-                    self.quote_type(format),
-                    self.quote_deserialize(format, "obj[i]", "nil")
-                )?;
+    fn is_container_grounded(
+        format: &ContainerFormat,
+        ranks: &BTreeMap<String, usize>,
+        bound: usize,
+    ) -> bool {
+        match format {
+            ContainerFormat::UnitStruct => true,
+            ContainerFormat::NewTypeStruct(format) => Self::is_grounded(format, ranks, bound),
+            ContainerFormat::TupleStruct(formats) => {
+                formats.iter().all(|f| Self::is_grounded(f, ranks, bound))
             }
+            ContainerFormat::Struct(fields) => fields
+                .iter()
+                .all(|f| Self::is_grounded(&f.value, ranks, bound)),
+            ContainerFormat::Enum(variants) => variants
+                .values()
+                .any(|v| Self::is_variant_grounded(&v.value, ranks, bound)),
+        }
+    }
 
-            Map { key, value } => {
-                write!(
-                    self.out,
-                    r#"
-length, err := deserializer.DeserializeLen()
-if err != nil {{ return nil, err }}
-obj := make(map[{0}]{1})
-previous_slice := serde.Slice {{ 0, 0 }}
-for i := 0; i < int(length); i++ {{
-	var slice serde.Slice
-	slice.Start = deserializer.GetBufferOffset()
-	var key {0}
-	{2}
-	slice.End = deserializer.GetBufferOffset()
-	if i > 0 {{
-		err := deserializer.CheckThatKeySlicesAreIncreasing(previous_slice, slice)
-		if err != nil {{ return nil, err }}
-	}}
-	previous_slice = slice
-	{3}
-}}
-return obj, nil
-"#,
-                    self.quote_type(key),
-                    self.quote_type(value),
-                    self.quote_deserialize(key, "key", "nil"),
-                    self.quote_deserialize(value, "obj[key]", "nil"),
-                )?;
-            }
+    /// Whether all the encodings support the primitive types of `sample`.
+    fn is_sample_supported(&self, sample: &Sample) -> bool {
+        !sample.has_char
+            && !(sample.has_float && self.generator.config.encodings.contains(&Encoding::Bcs))
+    }
 
-            Tuple(formats) => {
-                write!(
-                    self.out,
-                    r#"
-var obj {}
-{}
-return obj, nil
-"#,
-                    self.quote_type(format0),
-                    formats
-                        .iter()
-                        .enumerate()
-                        .map(|(i, f)| self.quote_deserialize(f, &format!("obj.Field{}", i), "obj"))
-                        .collect::<Vec<_>>()
-                        .join("\n")
-                )?;
+    /// Describe the zero value of `format` (with an empty expression), unless it contains
+    /// enums, which are nil interfaces (or invalid indices for C-style enums).
+    fn get_zero_value_sample(&self, format: &Format, registry: &Registry) -> Option<Sample> {
+        let mut sample = Sample::new(String::new());
+        match format {
+            Format::TypeName(name) => match registry.get(name)? {
+                ContainerFormat::UnitStruct => (),
+                ContainerFormat::NewTypeStruct(content) => {
+                    return self.get_zero_value_sample(content, registry)
+                }
+                ContainerFormat::TupleStruct(formats) => {
+                    return self.get_zero_values_sample(formats.iter(), registry)
+                }
+                ContainerFormat::Struct(fields) => {
+                    return self.get_zero_values_sample(fields.iter().map(|f| &f.value), registry)
+                }
+                format @ ContainerFormat::Enum(variants) => {
+                    if !self.generator.is_c_style_enum(format) || !variants.contains_key(&0) {
+                        return None;
+                    }
+                }
+            },
+            Format::Tuple(formats) => return self.get_zero_values_sample(formats.iter(), registry),
+            Format::TupleArray { content, size } if *size > 0 => {
+                return self.get_zero_value_sample(content, registry)
             }
+            Format::Char => sample.has_char = true,
+            Format::F32 | Format::F64 => sample.has_float = true,
+            _ => (),
+        }
+        Some(sample)
+    }
 
-            TupleArray { content, size } => {
-                write!(
-                    self.out,
-                    r#"
-var obj [{1}]{0}
-for i := range(obj) {{
-	{2}
-}}
-return obj, nil
-"#,
-                    self.quote_type(content),
-                    size,
-                    self.quote_deserialize(content, "obj[i]", "obj")
-                )?;
-            }
+    fn get_zero_values_sample<'f>(
+        &self,
+        formats: impl Iterator<Item = &'f Format>,
+        registry: &Registry,
+    ) -> Option<Sample> {
+        let samples = formats
+            .map(|f| self.get_zero_value_sample(f, registry))
+            .collect::<Option<Vec<_>>>()?;
+        Some(Sample::with_content(String::new(), &samples))
+    }
 
-            _ => panic!("unexpected case"),
+    /// Compute a sample value of the container `name`, if possible. Below `MAX_SAMPLE_DEPTH`,
+    /// optional values, vectors and maps are not empty. Enums use their first variant that
+    /// can be built.
+    fn quote_container_sample(
+        &self,
+        name: &str,
+        depth: usize,
+        context: &mut SampleContext,
+    ) -> Option<Sample> {
+        // Custom and external types are not in the registry.
+        let registry = context.registry;
+        let format = registry.get(name)?;
+        if depth >= MAX_SAMPLE_DEPTH && !context.ranks.contains_key(name) {
+            return None;
+        }
+        match format {
+            ContainerFormat::UnitStruct => Some(Sample::new(format!("{}{{}}", name))),
+            ContainerFormat::NewTypeStruct(content) => {
+                let sample = self.quote_sample(content, depth, context)?;
+                let expr = match content.as_ref() {
+                    Format::TypeName(_) | Format::Option(_) => format!(
+                        "{}{{{}: {}}}",
+                        name,
+                        self.generator.quote_struct_field("Value"),
+                        sample.expr
+                    ),
+                    _ => format!("{}({})", name, sample.expr),
+                };
+                Some(Sample::with_content(expr, [&sample]))
+            }
+            ContainerFormat::TupleStruct(formats) => {
+                self.quote_fields_sample(name, &self.tuple_struct_fields(formats), depth, context)
+            }
+            ContainerFormat::Struct(fields) => {
+                self.quote_fields_sample(name, fields, depth, context)
+            }
+            ContainerFormat::Enum(variants) if self.generator.is_c_style_enum(format) => {
+                let variant = variants.values().next()?;
+                Some(Sample::new(format!("{}__{}", name, variant.name)))
+            }
+            ContainerFormat::Enum(variants) => {
+                // Minimal values only use variants that do not lead back to `name`.
+                let rank = context.ranks.get(name).cloned().unwrap_or_default();
+                for variant in variants.values() {
+                    if depth >= MAX_SAMPLE_DEPTH
+                        && !Self::is_variant_grounded(&variant.value, &context.ranks, rank)
+                    {
+                        continue;
+                    }
+                    if let Some(sample) = self.quote_variant_sample(
+                        name,
+                        &variant.name,
+                        &variant.value,
+                        depth,
+                        context,
+                    ) {
+                        return Some(sample);
+                    }
+                }
+                None
+            }
         }
-        self.out.unindent();
-        writeln!(self.out, "}}\n")
     }
 
-    fn output_variant(
-        &mut self,
+    fn quote_variant_sample(
+        &self,
         base: &str,
-        index: u32,
         name: &str,
         variant: &VariantFormat,
-    ) -> Result<()> {
-        use VariantFormat::*;
-        let fields = match variant {
-            Unit => Vec::new(),
-            NewType(format) => match format.as_ref() {
-                // We cannot define a "new type" (e.g. `type Foo Bar`) out of a typename `Bar` because `Bar`
-                // could point to a Go interface. This would make `Foo` an interface as well. Interfaces can't be used
-                // as structs (e.g. they cannot have methods).
-                //
-                // Similarly, option types are compiled as pointers but `type Foo *Bar` would prevent `Foo` from being a
-                // valid pointer receiver.
-                Format::TypeName(_) | Format::Option(_) => vec![Named {
-                    name: "Value".to_string(),
-                    value: format.as_ref().clone(),
-                }],
-                // Other cases are fine.
-                _ => {
-                    self.output_struct_or_variant_new_type_container(
-                        Some(base),
-                        Some(index),
-                        name,
-                        format,
-                    )?;
-                    return Ok(());
+        depth: usize,
+        context: &mut SampleContext,
+    ) -> Option<Sample> {
+        let full_name = format!("{}__{}", base, name);
+        match variant {
+            VariantFormat::Unit => Some(Sample::new(format!("&{}{{}}", full_name))),
+            VariantFormat::NewType(content) => {
+                let sample = self.quote_sample(content, depth, context)?;
+                // See `output_variant` for the representation of newtype variants.
+                let expr = match content.as_ref() {
+                    Format::TypeName(_) | Format::Option(_) => {
+                        format!("&{}{{Value: {}}}", full_name, sample.expr)
+                    }
+                    _ => format!("pointerTo({}({}))", full_name, sample.expr),
+                };
+                Some(Sample::with_content(expr, [&sample]))
+            }
+            VariantFormat::Tuple(formats) => self.quote_fields_sample(
+                &format!("&{}", full_name),
+                &Self::tuple_fields(formats),
+                depth,
+                context,
+            ),
+            VariantFormat::Struct(fields) => {
+                self.quote_fields_sample(&format!("&{}", full_name), fields, depth, context)
+            }
+            VariantFormat::Variable(_) => panic!("incorrect value"),
+        }
+    }
+
+    fn tuple_fields(formats: &[Format]) -> Vec<Named<Format>> {
+        formats
+            .iter()
+            .enumerate()
+            .map(|(i, f)| Named {
+                name: format!("Field{}", i),
+                value: f.clone(),
+            })
+            .collect()
+    }
+
+    fn tuple_struct_fields(&self, formats: &[Format]) -> Vec<Named<Format>> {
+        let mut fields = Self::tuple_fields(formats);
+        for field in &mut fields {
+            field.name = self.generator.quote_struct_field(&field.name);
+        }
+        fields
+    }
+
+    fn quote_fields_sample(
+        &self,
+        type_name: &str,
+        fields: &[Named<Format>],
+        depth: usize,
+        context: &mut SampleContext,
+    ) -> Option<Sample> {
+        let mut samples = Vec::new();
+        for field in fields {
+            samples.push(self.quote_sample(&field.value, depth, context)?);
+        }
+        let values = fields
+            .iter()
+            .zip(&samples)
+            .map(|(field, sample)| format!("{}: {}", field.name, sample.expr))
+            .collect::<Vec<_>>()
+            .join(", ");
+        Some(Sample::with_content(
+            format!("{}{{{}}}", type_name, values),
+            &samples,
+        ))
+    }
+
+    /// Sample for the content of an optional value, a vector, or a map, unless it should be
+    /// empty.
+    fn quote_content_sample(
+        &self,
+        format: &Format,
+        depth: usize,
+        context: &mut SampleContext,
+    ) -> Option<Sample> {
+        if depth >= MAX_SAMPLE_DEPTH {
+            return None;
+        }
+        self.quote_sample(format, depth, context)
+            .filter(|sample| self.is_sample_supported(sample))
+    }
+
+    fn quote_sample(
+        &self,
+        format: &Format,
+        depth: usize,
+        context: &mut SampleContext,
+    ) -> Option<Sample> {
+        let sample = match format {
+            Format::TypeName(name) => return self.quote_container_sample(name, depth + 1, context),
+            Format::Option(content) => {
+                let tpe = self.quote_type(content);
+                let generic = self.is_generic_option(content);
+                match self.quote_content_sample(content, depth, context) {
+                    Some(value) if generic => Sample::with_content(
+                        format!("serde.Some[{}]({})", tpe, value.expr),
+                        [&value],
+                    ),
+                    Some(value) => Sample::with_content(
+                        format!("pointerTo[{}]({})", tpe, value.expr),
+                        [&value],
+                    ),
+                    None if generic => Sample::new(format!("serde.None[{}]()", tpe)),
+                    None => Sample::new("nil".to_string()),
                 }
+            }
+            Format::Seq(content) => match self.quote_content_sample(content, depth, context) {
+                Some(value) => Sample::with_content(
+                    format!("{}{{{}}}", self.quote_type(format), value.expr),
+                    [&value],
+                ),
+                None => Sample::new("nil".to_string()),
             },
-            Tuple(formats) => formats
-                .iter()
-                .enumerate()
-                .map(|(i, f)| Named {
-                    name: format!("Field{}", i),
-                    value: f.clone(),
-                })
-                .collect(),
-            Struct(fields) => fields
-                .iter()
-                .map(|f| Named {
-                    name: f.name.to_camel_case(),
-                    value: f.value.clone(),
-                })
-                .collect(),
-            Variable(_) => panic!("incorrect value"),
+            Format::Map { key, value } => {
+                let key_sample = self.quote_content_sample(key, depth, context);
+                let value_sample = self.quote_content_sample(value, depth, context);
+                match (key_sample, value_sample) {
+                    (Some(key_sample), Some(value_sample)) => {
+                        let key_expr = match self.is_map_key_name(key) {
+                            Some(_) => format!("({}).MapKey()", key_sample.expr),
+                            None => key_sample.expr.clone(),
+                        };
+                        Sample::with_content(
+                            format!(
+                                "{}{{{}: {}}}",
+                                self.quote_type(format),
+                                key_expr,
+                                value_sample.expr
+                            ),
+                            [&key_sample, &value_sample],
+                        )
+                    }
+                    _ => Sample::new("nil".to_string()),
+                }
+            }
+            Format::Tuple(formats) => {
+                let samples = formats
+                    .iter()
+                    .map(|f| self.quote_sample(f, depth, context))
+                    .collect::<Option<Vec<_>>>()?;
+                let values = samples
+                    .iter()
+                    .map(|sample| sample.expr.as_str())
+                    .collect::<Vec<_>>()
+                    .join(", ");
+                Sample::with_content(
+                    format!("{}{{{}}}", self.quote_type(format), values),
+                    &samples,
+                )
+            }
+            Format::TupleArray { content, size } => {
+                let sample = self.quote_sample(content, depth, context)?;
+                // Only set the first element unless the others cannot be left to zero.
+                let count = match self.get_zero_value_sample(content, context.registry) {
+                    Some(_) => std::cmp::min(*size, 1),
+                    None => *size,
+                };
+                Sample::with_content(
+                    format!(
+                        "{}{{{}}}",
+                        self.quote_type(format),
+                        vec![sample.expr.as_str(); count].join(", ")
+                    ),
+                    [&sample],
+                )
+            }
+            Format::Variable(_) => panic!("unexpected value"),
+            _ => Self::quote_primitive_sample(format, context),
+        };
+        Some(sample)
+    }
+
+    fn quote_primitive_sample(format: &Format, context: &mut SampleContext) -> Sample {
+        context.counter += 1;
+        let n = 1 + context.counter % 100;
+        let mut sample = Sample::new(String::new());
+        sample.expr = match format {
+            Format::Unit => "struct{}{}".to_string(),
+            Format::Bool => "true".to_string(),
+            Format::I8 | Format::I16 | Format::I32 | Format::I64 => format!("-{}", n),
+            Format::I128 => format!("serde.Int128{{High: -1, Low: {}}}", n),
+            Format::U128 => format!("serde.Uint128{{High: 1, Low: {}}}", n),
+            Format::F32 | Format::F64 => {
+                sample.has_float = true;
+                format!("{}.5", n)
+            }
+            Format::Char => {
+                sample.has_char = true;
+                "'a'".to_string()
+            }
+            Format::Str => format!("\"sample {}\"", n),
+            Format::Bytes => format!("[]byte{{{}, {}}}", n, n + 1),
+            _ => n.to_string(),
         };
-        self.output_struct_or_variant_container(Some(base), Some(index), name, &fields)
+        sample
+    }
+
+    fn output_struct_binary_unmarshaler(&mut self, name: &str) -> Result<()> {
+        if let Some(encoding) = self.binary_encoding() {
+            writeln!(
+                self.out,
+                r#"
+func (obj *{0}) UnmarshalBinary(data []byte) error {{
+	value, err := {1}Deserialize{0}(data)
+	if err == nil {{ *obj = value }}
+	return err
+}}"#,
+                name,
+                encoding.name().to_camel_case()
+            )?;
+        }
+        Ok(())
     }
 
-    fn output_struct_or_variant_container(
+    fn output_struct_deserialize_for_encoding(
         &mut self,
-        variant_base: Option<&str>,
-        variant_index: Option<u32>,
         name: &str,
-        fields: &[Named<Format>],
+        encoding: Encoding,
+    ) -> Result<()> {
+        writeln!(
+            self.out,
+            r#"
+func {2}Deserialize{0}(input []byte) ({0}, error) {{
+	if input == nil {{
+		var obj {0}
+		return obj, &serde.TypeError{{Type: "{0}", Err: serde.ErrNilValue}}
+	}}
+	deserializer := {1}.NewDeserializer(input);
+	obj, err := Deserialize{0}(deserializer)
+	if err != nil {{ return obj, serde.WrapDecodeError(err, deserializer, "{0}") }}
+	return obj, deserializer.EndOfInput()
+}}"#,
+            name,
+            encoding.name(),
+            encoding.name().to_camel_case(),
+        )?;
+        if self.generator.streaming {
+            writeln!(
+                self.out,
+                r#"
+func {2}Deserialize{0}From(reader io.Reader) ({0}, error) {{
+	deserializer := {1}.NewStreamDeserializer(reader);
+	obj, err := Deserialize{0}(deserializer)
+	if err != nil {{ return obj, serde.WrapDecodeError(err, deserializer, "{0}") }}
+	return obj, deserializer.EndOfInput()
+}}"#,
+                name,
+                encoding.name(),
+                encoding.name().to_camel_case(),
+            )?;
+        }
+        Ok(())
+    }
+
+    fn output_enum_container(
+        &mut self,
+        name: &str,
+        variants: &BTreeMap<u32, Named<VariantFormat>>,
     ) -> Result<()> {
-        let full_name = match variant_base {
-            None => name.to_string(),
-            Some(base) => format!("{}__{}", base, name),
-        };
-        // Struct
         writeln!(self.out)?;
         self.output_comment(name)?;
-        writeln!(self.out, "type {} struct {{", full_name)?;
-        self.enter_class(name);
-        for field in fields {
-            self.output_comment(&field.name)?;
-            writeln!(self.out, "{} {}", field.name, self.quote_type(&field.value))?;
+        writeln!(self.out, "type {} interface {{", name)?;
+        self.current_namespace.push(name.to_string());
+        self.out.indent();
+        writeln!(self.out, "is{}()", name)?;
+        writeln!(self.out, "Equal(other {}) bool", name)?;
+        writeln!(self.out, "VariantName() string")?;
+        if self.generator.clone {
+            writeln!(self.out, "Clone() {}", name)?;
         }
-        self.leave_class();
-        writeln!(self.out, "}}")?;
-
-        // Link to base interface.
-        if let Some(base) = variant_base {
-            writeln!(self.out, "\nfunc (*{}) is{}() {{}}", full_name, base)?;
+        if self.generator.validation {
+            writeln!(self.out, "Validate() error")?;
         }
-
-        // Serialize
         if self.generator.config.serialization {
-            writeln!(
-                self.out,
-                "\nfunc (obj *{}) Serialize(serializer serde.Serializer) error {{",
-                full_name
-            )?;
-            self.out.indent();
-            writeln!(
-                self.out,
-                "if err := serializer.IncreaseContainerDepth(); err != nil {{ return err }}"
-            )?;
-            if let Some(index) = variant_index {
-                writeln!(self.out, "serializer.SerializeVariantIndex({})", index)?;
-            }
-            for field in fields {
+            writeln!(self.out, "Serialize(serializer serde.Serializer) error")?;
+            for encoding in &self.generator.config.encodings {
                 writeln!(
                     self.out,
-                    "{}",
-                    self.quote_serialize_value(&format!("obj.{}", &field.name), &field.value)
+                    "{}Serialize() ([]byte, error)",
+                    encoding.name().to_camel_case()
                 )?;
+                if self.generator.streaming {
+                    writeln!(
+                        self.out,
+                        "{}SerializeTo(writer io.Writer) error",
+                        encoding.name().to_camel_case()
+                    )?;
+                }
             }
-            writeln!(self.out, "serializer.DecreaseContainerDepth()")?;
-            writeln!(self.out, "return nil")?;
-            self.out.unindent();
-            writeln!(self.out, "}}")?;
-
-            for encoding in &self.generator.config.encodings {
-                self.output_struct_serialize_for_encoding(&full_name, *encoding)?;
+            if self.binary_encoding().is_some() {
+                writeln!(self.out, "MarshalBinary() ([]byte, error)")?;
+                writeln!(self.out, "AppendBinary(b []byte) ([]byte, error)")?;
+            }
+            if self.generator.hash {
+                writeln!(self.out, "Hash() uint64")?;
+            }
+            if self.map_key_names.contains(name) {
+                writeln!(self.out, "MapKey() string")?;
             }
         }
-        // Deserialize (struct) or Load (variant)
+        self.out.unindent();
+        writeln!(self.out, "}}")?;
+
         if self.generator.config.serialization {
-            writeln!(
+            write!(
                 self.out,
-                "\nfunc {0}{1}(deserializer serde.Deserializer) ({1}, error) {{",
-                if variant_base.is_none() {
-                    "Deserialize"
-                } else {
-                    "load_"
-                },
-                full_name,
+                "\nfunc Deserialize{0}(deserializer serde.Deserializer) ({0}, error) {{",
+                name
             )?;
             self.out.indent();
-            writeln!(self.out, "var obj {}", full_name)?;
             writeln!(
                 self.out,
-                "if err := deserializer.IncreaseContainerDepth(); err != nil {{ return obj, err }}"
+                r#"
+index, err := deserializer.DeserializeVariantIndex()
+if err != nil {{ return nil, err }}
+
+switch index {{"#,
             )?;
-            for field in fields {
+            for (index, variant) in variants {
                 writeln!(
                     self.out,
-                    "{}",
-                    self.quote_deserialize(&field.value, &format!("obj.{}", field.name), "obj")
+                    r#"case {0}:
+	if val, err := load_{1}__{2}(deserializer); err == nil {{
+		return &val, nil
+	}} else {{
+		return nil, serde.WrapDecodeError(err, deserializer, "{2}")
+	}}
+"#,
+                    index, name, variant.name
                 )?;
             }
-            writeln!(self.out, "deserializer.DecreaseContainerDepth()")?;
-            writeln!(self.out, "return obj, nil")?;
+            writeln!(
+                self.out,
+                "default:
+	return nil, serde.UnknownVariantIndex(\"{}\", index)",
+                name,
+            )?;
+            writeln!(self.out, "}}")?;
             self.out.unindent();
             writeln!(self.out, "}}")?;
 
-            if variant_base.is_none() {
-                for encoding in &self.generator.config.encodings {
-                    self.output_struct_deserialize_for_encoding(&full_name, *encoding)?;
-                }
+            for encoding in &self.generator.config.encodings {
+                self.output_struct_deserialize_for_encoding(name, *encoding)?;
+            }
+            if self.map_key_names.contains(name) {
+                self.output_from_map_key_function(name)?;
             }
         }
+
+        for (index, variant) in variants {
+            self.output_variant(name, *index, &variant.name, &variant.value)?;
+        }
+        self.output_enum_visitor(name, variants)?;
+        self.output_enum_variant_indices(name, variants)?;
+        self.output_enum_from_variant_name(name, variants)?;
+        if self.generator.json {
+            self.output_enum_json_unmarshaler(name, variants)?;
+        }
+        self.current_namespace.pop();
         // Custom code
         self.output_custom_code(name)?;
         Ok(())
     }
 
-    // Same as output_struct_or_variant_container but we map the container with a single anonymous field
-    // to a new type in Go.
-    fn output_struct_or_variant_new_type_container(
+    // Since `{name}Visitor` has one method per variant, adding a variant makes existing
+    // visitors fail to compile.
+    fn output_c_style_enum_container(
         &mut self,
-        variant_base: Option<&str>,
-        variant_index: Option<u32>,
         name: &str,
-        format: &Format,
+        variants: &BTreeMap<u32, &str>,
     ) -> Result<()> {
-        let full_name = match variant_base {
-            None => name.to_string(),
-            Some(base) => format!("{}__{}", base, name),
-        };
-        // Struct
         writeln!(self.out)?;
         self.output_comment(name)?;
-        writeln!(self.out, "type {} {}", full_name, self.quote_type(format))?;
+        writeln!(self.out, "type {} uint32", name)?;
+        writeln!(self.out, "\nconst (")?;
+        self.out.indent();
+        self.current_namespace.push(name.to_string());
+        let mut next_index = 0;
+        for (index, variant) in variants {
+            // Skip missing indices.
+            for _ in next_index..*index {
+                writeln!(self.out, "_")?;
+            }
+            self.output_comment(variant)?;
+            if *index == 0 {
+                writeln!(self.out, "{0}__{1} {0} = iota", name, variant)?;
+            } else {
+                writeln!(self.out, "{}__{}", name, variant)?;
+            }
+            next_index = index + 1;
+        }
+        self.current_namespace.pop();
+        self.out.unindent();
+        writeln!(self.out, ")")?;
+        let cases = variants
+            .values()
+            .map(|variant| format!("{}__{}", name, variant))
+            .collect::<Vec<_>>()
+            .join(", ");
 
-        // Link to base interface.
-        if let Some(base) = variant_base {
-            writeln!(self.out, "\nfunc (*{}) is{}() {{}}", full_name, base)?;
+        // Equal
+        writeln!(
+            self.out,
+            "\nfunc (obj {0}) Equal(other {0}) bool {{\n\treturn obj == other\n}}",
+            name
+        )?;
+
+        // String
+        let go_name = format!("{}.{}", self.generator.package_name, name);
+        for (method, variant_prefix, default_prefix) in &[
+            ("String", format!("{}::", name), name.to_string()),
+            ("GoString", format!("{}__", go_name), go_name.clone()),
+        ] {
+            writeln!(
+                self.out,
+                "\nfunc (obj {}) {}() string {{\n\tswitch obj {{",
+                name, method
+            )?;
+            for variant in variants.values() {
+                writeln!(
+                    self.out,
+                    "\tcase {}__{}:\n\t\treturn \"{}{}\"",
+                    name, variant, variant_prefix, variant
+                )?;
+            }
+            writeln!(
+                self.out,
+                "\tdefault:\n\t\treturn fmt.Sprintf(\"{}(%d)\", uint32(obj))\n\t}}\n}}",
+                default_prefix
+            )?;
         }
 
-        // Serialize
-        if self.generator.config.serialization {
+        // Variant names
+        writeln!(
+            self.out,
+            "\nfunc (obj {}) VariantName() string {{\n\tswitch obj {{",
+            name
+        )?;
+        for variant in variants.values() {
             writeln!(
                 self.out,
-                "\nfunc (obj *{}) Serialize(serializer serde.Serializer) error {{",
-                full_name
+                "\tcase {}__{}:\n\t\treturn \"{}\"",
+                name,
+                variant,
+                self.original_name(&[name, variant])
             )?;
-            self.out.indent();
+        }
+        writeln!(self.out, "\tdefault:\n\t\treturn \"\"\n\t}}\n}}")?;
+        writeln!(
+            self.out,
+            r#"
+// {0}FromVariantName returns the variant of {0} with the given name, if any.
+func {0}FromVariantName(name string) ({0}, bool) {{
+	switch name {{"#,
+            name
+        )?;
+        for variant in variants.values() {
             writeln!(
                 self.out,
-                "if err := serializer.IncreaseContainerDepth(); err != nil {{ return err }}"
+                "\tcase \"{2}\":\n\t\treturn {0}__{1}, true",
+                name,
+                variant,
+                self.original_name(&[name, variant])
             )?;
-            if let Some(index) = variant_index {
-                writeln!(self.out, "serializer.SerializeVariantIndex({})", index)?;
-            }
+        }
+        writeln!(self.out, "\tdefault:\n\t\treturn 0, false\n\t}}\n}}")?;
+
+        // Clone
+        if self.generator.clone {
             writeln!(
                 self.out,
-                "{}",
-                self.quote_serialize_value(
-                    &format!("(({})(*obj))", self.quote_type(format)),
-                    format
-                )
+                "\nfunc (obj {0}) Clone() {0} {{\n\treturn obj\n}}",
+                name
             )?;
-            writeln!(self.out, "serializer.DecreaseContainerDepth()")?;
-            writeln!(self.out, "return nil")?;
-            self.out.unindent();
-            writeln!(self.out, "}}")?;
+        }
 
-            for encoding in &self.generator.config.encodings {
-                self.output_struct_serialize_for_encoding(&full_name, *encoding)?;
+        // Validate
+        if self.generator.validation {
+            writeln!(
+                self.out,
+                r#"
+func (obj {0}) Validate() error {{
+	switch obj {{
+	case {1}:
+		return nil
+	default:
+		return serde.UnknownVariantIndex("{0}", uint32(obj))
+	}}
+}}"#,
+                name, cases
+            )?;
+        }
+
+        // JSON
+        if self.generator.json {
+            writeln!(
+                self.out,
+                "\nfunc (obj {}) MarshalJSON() ([]byte, error) {{\n\tswitch obj {{",
+                name
+            )?;
+            for variant in variants.values() {
+                writeln!(
+                    self.out,
+                    "\tcase {}__{}:\n\t\treturn json.Marshal(\"{}\")",
+                    name,
+                    variant,
+                    self.original_name(&[name, variant])
+                )?;
+            }
+            writeln!(
+                self.out,
+                "\tdefault:\n\t\treturn nil, serde.UnknownVariantIndex(\"{}\", uint32(obj))\n\t}}\n}}",
+                name
+            )?;
+            writeln!(
+                self.out,
+                r#"
+func (obj *{}) UnmarshalJSON(data []byte) error {{
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {{ return err }}
+	switch name {{"#,
+                name
+            )?;
+            for variant in variants.values() {
+                writeln!(
+                    self.out,
+                    "\tcase \"{2}\":\n\t\t*obj = {0}__{1}",
+                    name,
+                    variant,
+                    self.original_name(&[name, variant])
+                )?;
             }
+            writeln!(
+                self.out,
+                "\tdefault:\n\t\treturn serde.UnknownVariantName(\"{}\", name)\n\t}}\n\treturn nil\n}}",
+                name
+            )?;
         }
-        // Deserialize (struct) or Load (variant)
+
         if self.generator.config.serialization {
+            // Serialize
+            let by_value = self.generator.value_semantics;
             writeln!(
                 self.out,
-                "\nfunc {0}{1}(deserializer serde.Deserializer) ({1}, error) {{",
-                if variant_base.is_none() {
-                    "Deserialize"
-                } else {
-                    "load_"
-                },
-                full_name,
+                r#"
+func (obj {2}{0}) Serialize(serializer serde.Serializer) error {{
+	switch {2}obj {{
+	case {1}:
+	default:
+		return serde.UnknownVariantIndex("{0}", uint32({2}obj))
+	}}
+	if err := serializer.IncreaseContainerDepth(); err != nil {{ return err }}
+	serializer.SerializeVariantIndex(uint32({2}obj))
+	serializer.DecreaseContainerDepth()
+	return nil
+}}"#,
+                name,
+                cases,
+                if by_value { "" } else { "*" }
             )?;
-            self.out.indent();
-            writeln!(self.out, "var obj {}", self.quote_type(format))?;
-            writeln!(self.out, "if err := deserializer.IncreaseContainerDepth(); err != nil {{ return ({})(obj), err }}", full_name)?;
+            for encoding in &self.generator.config.encodings {
+                self.output_struct_serialize_for_encoding(name, *encoding, by_value)?;
+            }
+            self.output_struct_binary_marshaler(name, by_value)?;
+            self.output_struct_hash_method(name)?;
+
+            // Deserialize
             writeln!(
                 self.out,
-                "{}",
-                self.quote_deserialize(format, "obj", &format!("(({})(obj))", full_name))
+                r#"
+func Deserialize{0}(deserializer serde.Deserializer) ({0}, error) {{
+	var obj {0}
+	if err := deserializer.IncreaseContainerDepth(); err != nil {{ return obj, err }}
+	index, err := deserializer.DeserializeVariantIndex()
+	if err != nil {{ return obj, err }}
+	obj = {0}(index)
+	switch obj {{
+	case {1}:
+	default:
+		return obj, serde.UnknownVariantIndex("{0}", index)
+	}}
+	deserializer.DecreaseContainerDepth()
+	return obj, nil
+}}"#,
+                name, cases
             )?;
-            writeln!(self.out, "deserializer.DecreaseContainerDepth()")?;
-            writeln!(self.out, "return ({})(obj), nil", full_name)?;
-            self.out.unindent();
-            writeln!(self.out, "}}")?;
-
-            if variant_base.is_none() {
-                for encoding in &self.generator.config.encodings {
-                    self.output_struct_deserialize_for_encoding(&full_name, *encoding)?;
-                }
+            self.output_struct_deserialize_method(name)?;
+            for encoding in &self.generator.config.encodings {
+                self.output_struct_deserialize_for_encoding(name, *encoding)?;
             }
+            self.output_struct_binary_unmarshaler(name)?;
         }
         // Custom code
-        self.output_custom_code(name)?;
-        Ok(())
+        self.output_custom_code(name)
     }
 
-    fn output_struct_serialize_for_encoding(
+    fn output_enum_visitor(
         &mut self,
         name: &str,
-        encoding: Encoding,
+        variants: &BTreeMap<u32, Named<VariantFormat>>,
     ) -> Result<()> {
+        writeln!(
+            self.out,
+            "\n// {0}Visitor handles every variant of the enum {0} (see `Match{0}`).\ntype {0}Visitor[R any] interface {{",
+            name
+        )?;
+        self.out.indent();
+        for variant in variants.values() {
+            writeln!(self.out, "Visit{1}(value *{0}__{1}) R", name, variant.name)?;
+        }
+        self.out.unindent();
+        writeln!(self.out, "}}")?;
         writeln!(
             self.out,
             r#"
-func (obj *{0}) {2}Serialize() ([]byte, error) {{
-	if obj == nil {{
-		return nil, fmt.Errorf("Cannot serialize null object")
-	}}
-	serializer := {1}.NewSerializer();
-	if err := obj.Serialize(serializer); err != nil {{ return nil, err }}
-	return serializer.GetBytes(), nil
-}}"#,
-            name,
-            encoding.name(),
-            encoding.name().to_camel_case()
+// Match{0} calls the method of `visitor` corresponding to the variant of `value`.
+func Match{0}[R any](value {0}, visitor {0}Visitor[R]) R {{"#,
+            name
+        )?;
+        self.out.indent();
+        writeln!(self.out, "switch value := value.(type) {{")?;
+        for variant in variants.values() {
+            writeln!(
+                self.out,
+                "case *{0}__{1}:\n\treturn visitor.Visit{1}(value)",
+                name, variant.name
+            )?;
+        }
+        writeln!(self.out, "}}")?;
+        writeln!(self.out, "panic(\"Cannot match null object for {}\")", name)?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
+    }
+
+    fn output_variant_name_method(&mut self, full_name: &str, name: &str) -> Result<()> {
+        writeln!(
+            self.out,
+            "\nfunc (*{}) VariantName() string {{\n\treturn \"{}\"\n}}",
+            full_name,
+            self.original_name(&[name])
         )
     }
 
-    fn output_struct_deserialize_for_encoding(
+    fn output_enum_from_variant_name(
         &mut self,
         name: &str,
-        encoding: Encoding,
+        variants: &BTreeMap<u32, Named<VariantFormat>>,
     ) -> Result<()> {
         writeln!(
             self.out,
             r#"
-func {2}Deserialize{0}(input []byte) ({0}, error) {{
-	if input == nil {{
-		var obj {0}
-		return obj, fmt.Errorf("Cannot deserialize null array")
-	}}
-	deserializer := {1}.NewDeserializer(input);
-	obj, err := Deserialize{0}(deserializer)
-	if err == nil && deserializer.GetBufferOffset() < uint64(len(input)) {{
-		return obj, fmt.Errorf("Some input bytes were not read")
-	}}
-	return obj, err
-}}"#,
-            name,
-            encoding.name(),
-            encoding.name().to_camel_case(),
-        )
+// {0}FromVariantName returns a value of the variant of {0} with the given name, if any. The
+// content of the variant (if any) is the zero value.
+func {0}FromVariantName(name string) ({0}, bool) {{"#,
+            name
+        )?;
+        self.out.indent();
+        writeln!(self.out, "switch name {{")?;
+        for variant in variants.values() {
+            writeln!(
+                self.out,
+                "case \"{}\":\n\treturn new({}__{}), true",
+                self.original_name(&[&variant.name]),
+                name,
+                variant.name
+            )?;
+        }
+        writeln!(self.out, "default:\n\treturn nil, false\n}}")?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
     }
 
-    fn output_enum_container(
+    // Values of C-style enums are already variant indices.
+    fn output_enum_variant_indices(
         &mut self,
         name: &str,
         variants: &BTreeMap<u32, Named<VariantFormat>>,
     ) -> Result<()> {
-        writeln!(self.out)?;
-        self.output_comment(name)?;
-        writeln!(self.out, "type {} interface {{", name)?;
-        self.current_namespace.push(name.to_string());
+        writeln!(
+            self.out,
+            "\n// Variant indices of the enum {}, as serialized in binary encodings.\nconst (",
+            name
+        )?;
         self.out.indent();
-        writeln!(self.out, "is{}()", name)?;
-        if self.generator.config.serialization {
-            writeln!(self.out, "Serialize(serializer serde.Serializer) error")?;
-            for encoding in &self.generator.config.encodings {
-                writeln!(
-                    self.out,
-                    "{}Serialize() ([]byte, error)",
-                    encoding.name().to_camel_case()
-                )?;
-            }
-        }
-        self.out.unindent();
-        writeln!(self.out, "}}")?;
-
-        if self.generator.config.serialization {
-            write!(
-                self.out,
-                "\nfunc Deserialize{0}(deserializer serde.Deserializer) ({0}, error) {{",
-                name
-            )?;
-            self.out.indent();
+        for (index, variant) in variants {
             writeln!(
                 self.out,
-                r#"
-index, err := deserializer.DeserializeVariantIndex()
-if err != nil {{ return nil, err }}
-
-switch index {{"#,
+                "{}Variant{}Index uint32 = {}",
+                name, variant.name, index
             )?;
-            for (index, variant) in variants {
-                writeln!(
-                    self.out,
-                    r#"case {}:
-	if val, err := load_{}__{}(deserializer); err == nil {{
-		return &val, nil
-	}} else {{
-		return nil, err
-	}}
-"#,
-                    index, name, variant.name
-                )?;
-            }
+        }
+        self.out.unindent();
+        writeln!(self.out, ")")?;
+        writeln!(
+            self.out,
+            r#"
+// {0}VariantIndexName returns the name of the variant of {0} with the given index, if any.
+func {0}VariantIndexName(index uint32) (string, bool) {{"#,
+            name
+        )?;
+        self.out.indent();
+        writeln!(self.out, "switch index {{")?;
+        for variant in variants.values() {
             writeln!(
                 self.out,
-                "default:
-	return nil, fmt.Errorf(\"Unknown variant index for {}: %d\", index)",
+                "case {}Variant{}Index:\n\treturn \"{}\", true",
                 name,
+                variant.name,
+                self.original_name(&[&variant.name])
             )?;
-            writeln!(self.out, "}}")?;
-            self.out.unindent();
-            writeln!(self.out, "}}")?;
-
-            for encoding in &self.generator.config.encodings {
-                self.output_struct_deserialize_for_encoding(name, *encoding)?;
-            }
-        }
-
-        for (index, variant) in variants {
-            self.output_variant(name, *index, &variant.name, &variant.value)?;
         }
-        self.current_namespace.pop();
-        // Custom code
-        self.output_custom_code(name)?;
-        Ok(())
+        writeln!(self.out, "default:\n\treturn \"\", false\n}}")?;
+        self.out.unindent();
+        writeln!(self.out, "}}")
     }
 
     fn output_container(&mut self, name: &str, format: &ContainerFormat) -> Result<()> {
@@ -917,7 +4628,7 @@ switch index {{"#,
             NewTypeStruct(format) => match format.as_ref() {
                 // See comment in `output_variant`.
                 Format::TypeName(_) | Format::Option(_) => vec![Named {
-                    name: "Value".to_string(),
+                    name: self.generator.quote_struct_field("Value"),
                     value: format.as_ref().clone(),
                 }],
                 _ => {
@@ -925,21 +4636,22 @@ switch index {{"#,
                     return Ok(());
                 }
             },
-            TupleStruct(formats) => formats
-                .iter()
-                .enumerate()
-                .map(|(i, f)| Named {
-                    name: format!("Field{}", i),
-                    value: f.clone(),
-                })
-                .collect(),
+            TupleStruct(formats) => self.tuple_struct_fields(formats),
             Struct(fields) => fields
                 .iter()
                 .map(|f| Named {
-                    name: f.name.to_camel_case(),
+                    name: f.name.clone(),
                     value: f.value.clone(),
                 })
                 .collect(),
+            Enum(variants) if self.generator.is_c_style_enum(format) => {
+                let variants = variants
+                    .iter()
+                    .map(|(i, f)| (*i, f.name.as_str()))
+                    .collect();
+                self.output_c_style_enum_container(name, &variants)?;
+                return Ok(());
+            }
             Enum(variants) => {
                 let variants = variants
                     .iter()
@@ -947,7 +4659,7 @@ switch index {{"#,
                         (
                             *i,
                             Named {
-                                name: f.name.to_camel_case(),
+                                name: f.name.clone(),
                                 value: f.value.clone(),
                             },
                         )
@@ -957,14 +4669,34 @@ switch index {{"#,
                 return Ok(());
             }
         };
-        self.output_struct_or_variant_container(None, None, name, &fields)
+        let tuple = !matches!(format, Struct(_));
+        self.output_struct_or_variant_container(None, None, name, &fields, tuple)
     }
 }
 
 /// Installer for generated source files in Go.
+///
+/// Modules are installed in `install_dir/<module_name>/lib.go`, where module names may
+/// contain slashes to designate subpackages (e.g. "types/ledger").
+///
+/// By default, runtimes are not installed: generated packages import them from the published
+/// module (or from `serde_module_path`). See `with_vendored_runtime` to copy them instead.
 pub struct Installer {
     install_dir: PathBuf,
     serde_module_path: Option<String>,
+    vendored_runtime: Option<String>,
+    package_name: Option<String>,
+    split_files: bool,
+    fuzz_tests: bool,
+    roundtrip_tests: bool,
+    type_registry: bool,
+    initialisms: bool,
+    value_semantics: bool,
+    immutable_structs: bool,
+    serialized_sizes: bool,
+    renamings: Renamings,
+    custom_types: CustomTypes,
+    field_defaults: FieldDefaults,
 }
 
 impl Installer {
@@ -972,7 +4704,269 @@ impl Installer {
         Installer {
             install_dir,
             serde_module_path,
+            vendored_runtime: None,
+            package_name: None,
+            split_files: false,
+            fuzz_tests: false,
+            roundtrip_tests: false,
+            type_registry: false,
+            initialisms: false,
+            value_semantics: false,
+            immutable_structs: false,
+            serialized_sizes: false,
+            renamings: BTreeMap::new(),
+            custom_types: BTreeMap::new(),
+            field_defaults: BTreeMap::new(),
+        }
+    }
+
+    /// Name of the installed packages, if different from the last component of module names.
+    pub fn with_package_name(mut self, package_name: Option<String>) -> Self {
+        self.package_name = package_name;
+        self
+    }
+
+    /// Whether to install one source file per container instead of a single file "lib.go"
+    /// (see `CodeGenerator::write_source_files`).
+    pub fn with_split_files(mut self, split_files: bool) -> Self {
+        self.split_files = split_files;
+        self
+    }
+
+    /// Go names to use for particular definitions (see `CodeGenerator::with_renamings`).
+    pub fn with_renamings(mut self, renamings: Renamings) -> Self {
+        self.renamings = renamings;
+        self
+    }
+
+    /// Go types to use in place of particular containers (see
+    /// `CodeGenerator::with_custom_types`).
+    pub fn with_custom_types(mut self, custom_types: CustomTypes) -> Self {
+        self.custom_types = custom_types;
+        self
+    }
+
+    /// Default values of trailing fields (see `CodeGenerator::with_field_defaults`).
+    pub fn with_field_defaults(mut self, field_defaults: FieldDefaults) -> Self {
+        self.field_defaults = field_defaults;
+        self
+    }
+
+    /// Whether to also install the file "fuzz_test.go" (see `CodeGenerator::output_fuzz_tests`).
+    pub fn with_fuzz_tests(mut self, fuzz_tests: bool) -> Self {
+        self.fuzz_tests = fuzz_tests;
+        self
+    }
+
+    /// Whether to also install the file "roundtrip_test.go"
+    /// (see `CodeGenerator::output_roundtrip_tests`).
+    pub fn with_roundtrip_tests(mut self, roundtrip_tests: bool) -> Self {
+        self.roundtrip_tests = roundtrip_tests;
+        self
+    }
+
+    /// Whether to generate maps from container names to deserialization functions
+    /// (see `CodeGenerator::with_type_registry`).
+    pub fn with_type_registry(mut self, type_registry: bool) -> Self {
+        self.type_registry = type_registry;
+        self
+    }
+
+    /// Whether to write common initialisms in upper case (see `CodeGenerator::with_initialisms`).
+    pub fn with_initialisms(mut self, initialisms: bool) -> Self {
+        self.initialisms = initialisms;
+        self
+    }
+
+    /// Whether to use structs by value (see `CodeGenerator::with_value_semantics`).
+    pub fn with_value_semantics(mut self, value_semantics: bool) -> Self {
+        self.value_semantics = value_semantics;
+        self
+    }
+
+    /// Whether to generate structs with unexported fields and getters (see
+    /// `CodeGenerator::with_immutable_structs`).
+    pub fn with_immutable_structs(mut self, immutable_structs: bool) -> Self {
+        self.immutable_structs = immutable_structs;
+        self
+    }
+
+    /// Whether to generate the method `SerializedSize` for the containers of fixed size
+    /// (see `CodeGenerator::with_serialized_sizes`).
+    pub fn with_serialized_sizes(mut self, serialized_sizes: bool) -> Self {
+        self.serialized_sizes = serialized_sizes;
+        self
+    }
+
+    /// Copy the sources of the runtimes into `install_dir/internal` instead of depending on
+    /// the published module. The given value is the Go import path of `install_dir` (e.g.
+    /// "example.com/app/gen"): generated packages then import the runtimes from
+    /// "example.com/app/gen/internal", which Go only allows for packages under `install_dir`.
+    pub fn with_vendored_runtime(mut self, import_path: Option<String>) -> Self {
+        self.vendored_runtime = import_path;
+        self
+    }
+
+    /// Install several registries as sibling packages `install_dir/<module_name>` without
+    /// duplicating the containers that they have in common. Containers defined identically
+    /// in several registries (and only referring to other shared containers) are installed
+    /// once, in the package of `common_config`, which the other packages import from
+    /// `<import_path>/<common module name>`. Here, `import_path` is the Go import path of
+    /// `install_dir`, e.g. "example.com/app/gen".
+    pub fn install_modules(
+        &self,
+        import_path: &str,
+        common_config: &CodeGeneratorConfig,
+        modules: &[(CodeGeneratorConfig, Registry)],
+    ) -> std::result::Result<(), Box<dyn std::error::Error>> {
+        use crate::SourceInstaller;
+
+        let registries = modules
+            .iter()
+            .map(|(_, registry)| registry)
+            .collect::<Vec<_>>();
+        let shared = Self::get_shared_containers(&registries);
+        if !shared.is_empty() {
+            let mut common_registry = Registry::new();
+            for registry in &registries {
+                for (name, format) in registry.iter() {
+                    if shared.contains(name) {
+                        common_registry.insert(name.clone(), format.clone());
+                    }
+                }
+            }
+            self.install_module(common_config, &common_registry)?;
+        }
+        let common_path = format!("{}/{}", import_path, common_config.module_name);
+        for (config, registry) in modules {
+            let registry = registry
+                .iter()
+                .filter(|(name, _)| !shared.contains(*name))
+                .map(|(name, format)| (name.clone(), format.clone()))
+                .collect::<Registry>();
+            let used = registry
+                .values()
+                .flat_map(Self::get_referenced_names)
+                .filter(|name| shared.contains(name))
+                .collect::<BTreeSet<_>>();
+            let mut config = config.clone();
+            // Go rejects unused imports.
+            if !used.is_empty() {
+                config
+                    .external_definitions
+                    .entry(common_path.clone())
+                    .or_default()
+                    .extend(used);
+            }
+            self.install_module(&config, &registry)?;
+        }
+        Ok(())
+    }
+
+    /// Compute the containers defined identically in at least two registries, and only
+    /// referring to such containers. Containers used as map keys (and the containers that
+    /// they refer to) are never shared since the Go types of maps depend on the definitions
+    /// of keys.
+    fn get_shared_containers(registries: &[&Registry]) -> BTreeSet<String> {
+        let mut definitions = BTreeMap::<&String, Vec<&ContainerFormat>>::new();
+        for registry in registries {
+            for (name, format) in registry.iter() {
+                definitions.entry(name).or_default().push(format);
+            }
+        }
+        let mut shared = definitions
+            .iter()
+            .filter(|(_, formats)| formats.len() >= 2 && formats.iter().all(|f| *f == formats[0]))
+            .map(|(name, _)| (*name).clone())
+            .collect::<BTreeSet<_>>();
+        for registry in registries {
+            let mut keys = Vec::new();
+            for format in registry.values() {
+                format
+                    .visit(&mut |f| {
+                        if let Format::Map { key, .. } = f {
+                            if let Format::TypeName(name) = key.as_ref() {
+                                keys.push(name.clone());
+                            }
+                        }
+                        Ok(())
+                    })
+                    .unwrap();
+            }
+            while let Some(name) = keys.pop() {
+                if shared.remove(&name) {
+                    if let Some(format) = registry.get(&name) {
+                        keys.extend(Self::get_referenced_names(format));
+                    }
+                }
+            }
+        }
+        loop {
+            let unshared = shared
+                .iter()
+                .filter(|name| {
+                    Self::get_referenced_names(definitions[name][0])
+                        .iter()
+                        .any(|other| !shared.contains(other))
+                })
+                .cloned()
+                .collect::<Vec<_>>();
+            if unshared.is_empty() {
+                return shared;
+            }
+            for name in unshared {
+                shared.remove(&name);
+            }
+        }
+    }
+
+    fn get_referenced_names(format: &ContainerFormat) -> BTreeSet<String> {
+        let mut names = BTreeSet::new();
+        format
+            .visit(&mut |f| {
+                if let Format::TypeName(name) = f {
+                    names.insert(name.clone());
+                }
+                Ok(())
+            })
+            .unwrap();
+        names
+    }
+
+    fn runtime_module_path(&self) -> Option<String> {
+        match &self.vendored_runtime {
+            Some(path) => Some(format!("{}/internal", path)),
+            None => self.serde_module_path.clone(),
+        }
+    }
+
+    fn install_runtime(
+        &self,
+        source_dir: include_dir::Dir,
+        name: &str,
+    ) -> std::result::Result<(), Box<dyn std::error::Error>> {
+        if self.vendored_runtime.is_none() {
+            self.runtime_installation_message(name);
+            return Ok(());
+        }
+        let module_path = self.runtime_module_path().unwrap_or_default();
+        let dir_path = self.install_dir.join("internal").join(name);
+        std::fs::create_dir_all(&dir_path)?;
+        for entry in source_dir.files() {
+            let file_name = entry.path().to_string_lossy();
+            if !file_name.ends_with(".go")
+                || file_name.ends_with("_test.go")
+                || VENDORED_RUNTIME_EXCLUDED_FILES
+                    .contains(&format!("{}/{}", name, file_name).as_str())
+            {
+                continue;
+            }
+            let contents = std::str::from_utf8(entry.contents())?
+                .replace(DEFAULT_SERDE_MODULE_PATH, &module_path);
+            let mut file = std::fs::File::create(dir_path.join(entry.path()))?;
+            file.write_all(contents.as_bytes())?;
         }
+        Ok(())
     }
 
     fn runtime_installation_message(&self, name: &str) {
@@ -996,30 +4990,54 @@ impl crate::SourceInstaller for Installer {
         registry: &Registry,
     ) -> std::result::Result<(), Self::Error> {
         let dir_path = self.install_dir.join(&config.module_name);
-        std::fs::create_dir_all(&dir_path)?;
-        let source_path = dir_path.join("lib.go");
-        let mut file = std::fs::File::create(source_path)?;
-
-        let mut generator = CodeGenerator::new(config);
-        if let Some(path) = &self.serde_module_path {
-            generator = generator.with_serde_module_path(path.clone());
+        let mut generator = CodeGenerator::new(config)
+            .with_renamings(self.renamings.clone())
+            .with_custom_types(self.custom_types.clone())
+            .with_field_defaults(self.field_defaults.clone())
+            .with_type_registry(self.type_registry)
+            .with_initialisms(self.initialisms)
+            .with_value_semantics(self.value_semantics)
+            .with_immutable_structs(self.immutable_structs)
+            .with_serialized_sizes(self.serialized_sizes);
+        if let Some(path) = self.runtime_module_path() {
+            generator = generator.with_serde_module_path(path);
+        }
+        if let Some(name) = &self.package_name {
+            generator = generator.with_package_name(name.clone());
+        }
+        if self.split_files {
+            generator.write_source_files(&dir_path, registry)?;
+        } else {
+            std::fs::create_dir_all(&dir_path)?;
+            let source_path = dir_path.join("lib.go");
+            let mut file = std::fs::File::create(source_path)?;
+            generator.output(&mut file, registry)?;
+        }
+        if self.fuzz_tests {
+            let mut file = std::fs::File::create(dir_path.join("fuzz_test.go"))?;
+            generator.output_fuzz_tests(&mut file, registry)?;
+        }
+        if self.roundtrip_tests {
+            let mut file = std::fs::File::create(dir_path.join("roundtrip_test.go"))?;
+            generator.output_roundtrip_tests(&mut file, registry)?;
         }
-        generator.output(&mut file, registry)?;
         Ok(())
     }
 
     fn install_serde_runtime(&self) -> std::result::Result<(), Self::Error> {
-        self.runtime_installation_message("serde");
-        Ok(())
+        self.install_runtime(include_directory!("runtime/golang/serde"), "serde")?;
+        // Formats are needed by `bcs.Canonicalize`.
+        self.install_runtime(
+            include_directory!("runtime/golang/serdetypes"),
+            "serdetypes",
+        )
     }
 
     fn install_bincode_runtime(&self) -> std::result::Result<(), Self::Error> {
-        self.runtime_installation_message("bincode");
-        Ok(())
+        self.install_runtime(include_directory!("runtime/golang/bincode"), "bincode")
     }
 
     fn install_bcs_runtime(&self) -> std::result::Result<(), Self::Error> {
-        self.runtime_installation_message("bcs");
-        Ok(())
+        self.install_runtime(include_directory!("runtime/golang/bcs"), "bcs")
     }
 }
@@ -14,7 +14,7 @@
 //! * Java 8
 //! * Python 3 (requires numpy >= 1.20.1)
 //! * Rust 2018
-//! * Go >= 1.14
+//! * Go >= 1.18
 //! * C# (NetCoreApp >= 2.1)
 //!
 //! The following languages are partially supported and still considered under development:
@@ -111,6 +111,35 @@
 //! See the help message of the tool with `--help` for more options.
 //!
 //! Note: Outside of this repository, you may install the tool with `cargo install serde-generate` then use `$HOME/.cargo/bin/serdegen`.
+//!
+//! For Go, the Go module of the runtimes also provides a command `serdegen-go` that generates the same code
+//! as `serdegen --language go` without requiring the Rust toolchain, e.g. in a `go generate` step:
+//! ```bash
+//! go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/serdegen-go -with-runtimes bcs -module-name test -o test.go test.yaml
+//! ```
+//!
+//! The command may be invoked from a `//go:generate` comment in the package of the generated code, so that
+//! `go generate ./...` regenerates it from the registry. Options that `serdegen-go` does not support natively
+//! are forwarded to the Rust tool given by `-serdegen`:
+//! ```go
+//! //go:generate go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/serdegen-go -serdegen serdegen -with-runtimes bcs -o lib.go test.yaml --go-renamings renamings.yaml
+//! ```
+//!
+//! To debug serialized payloads, the command `bcs-inspect` decodes BCS (or Bincode) bytes given in hexadecimal,
+//! base64, or a file, according to a registry, and prints the decoded values with their offsets:
+//! ```bash
+//! go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/bcs-inspect test.yaml Test 0x0102
+//! ```
+//!
+//! Conversely, Go-first projects may derive a registry from their Go types with the package `tracing` of the Go
+//! runtime (`tracing.TraceTypes`), save it with `serdetypes.MarshalRegistry`, and generate code in other languages
+//! from it.
+//!
+//! To enforce a policy of schema evolution, the command `registry-compat` lists the changes between two versions of
+//! a registry and fails if some of them are not (backward) compatible for values serialized in BCS or Bincode:
+//! ```bash
+//! go run github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/cmd/registry-compat -allow backward old.yaml test.yaml
+//! ```
 
 /// Dependency analysis and topological sort for Serde formats.
 pub mod analyzer;
@@ -225,3 +225,29 @@ fn test_that_installed_java_code_compiles() {
         .unwrap();
     assert!(status.success());
 }
+
+#[test]
+fn test_that_go_installer_options_require_target_source_dir() {
+    let registry = test_utils::get_registry().unwrap();
+    let dir = tempdir().unwrap();
+    let yaml_path = dir.path().join("test.yaml");
+    std::fs::write(yaml_path.clone(), serde_yaml::to_string(&registry).unwrap()).unwrap();
+
+    let output = Command::new("cargo")
+        .arg("run")
+        .arg("-p")
+        .arg("serde-generate")
+        .arg("--")
+        .arg("--language")
+        .arg("go")
+        .arg("--go-split-files")
+        .arg("--module-name")
+        .arg("testing")
+        .arg("--")
+        .arg(yaml_path)
+        .output()
+        .unwrap();
+    assert!(!output.status.success());
+    assert!(String::from_utf8_lossy(&output.stderr)
+        .contains("The option `--go-split-files` requires `--target-source-dir`"));
+}
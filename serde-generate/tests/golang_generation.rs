@@ -2,7 +2,7 @@
 // SPDX-License-Identifier: MIT OR Apache-2.0
 
 use serde::{Deserialize, Serialize};
-use serde_generate::{golang, test_utils, CodeGeneratorConfig, Encoding};
+use serde_generate::{golang, test_utils, CodeGeneratorConfig, Encoding, SourceInstaller};
 use serde_reflection::{Registry, Result, Samples, Tracer, TracerConfig};
 use std::collections::BTreeMap;
 use std::fs::File;
@@ -22,34 +22,813 @@ fn get_small_registry() -> Result<Registry> {
     tracer.registry()
 }
 
+#[derive(Serialize, Deserialize, PartialEq, Eq, PartialOrd, Ord)]
+struct PathKey {
+    path: Vec<String>,
+}
+
+#[derive(Serialize, Deserialize, PartialEq, Eq, PartialOrd, Ord)]
+enum ChoiceKey {
+    A,
+    B(u32),
+}
+
+#[derive(Serialize, Deserialize)]
+struct Index {
+    paths: BTreeMap<PathKey, u8>,
+    choices: BTreeMap<ChoiceKey, Vec<PathKey>>,
+}
+
+fn get_map_key_registry() -> Result<Registry> {
+    let mut tracer = Tracer::new(TracerConfig::default());
+    let samples = Samples::new();
+    tracer.trace_type::<ChoiceKey>(&samples)?;
+    tracer.trace_type::<Index>(&samples)?;
+    tracer.registry()
+}
+
+#[derive(Serialize, Deserialize)]
+struct Chain {
+    head: u8,
+    tail: Option<(u8, Box<Chain>)>,
+}
+
+#[derive(Serialize, Deserialize)]
+struct Ping {
+    pong: Option<Box<Pong>>,
+}
+
+#[derive(Serialize, Deserialize)]
+struct Pong {
+    ping: Ping,
+    others: Option<[Box<Ping>; 2]>,
+}
+
+fn get_recursive_registry() -> Result<Registry> {
+    let mut tracer = Tracer::new(TracerConfig::default());
+    let samples = Samples::new();
+    tracer.trace_type::<Chain>(&samples)?;
+    tracer.trace_type::<Pong>(&samples)?;
+    tracer.registry()
+}
+
 fn get_empty_registry() -> Result<Registry> {
     let tracer = Tracer::new(TracerConfig::default());
     tracer.registry()
 }
 
-fn test_that_golang_code_compiles_with_config(
-    config: &CodeGeneratorConfig,
-) -> (TempDir, std::path::PathBuf) {
-    test_that_golang_code_compiles_with_config_and_registry(config, &get_empty_registry().unwrap());
-    test_that_golang_code_compiles_with_config_and_registry(config, &get_small_registry().unwrap());
-    test_that_golang_code_compiles_with_config_and_registry(
-        config,
-        &test_utils::get_registry().unwrap(),
-    )
+fn test_that_golang_code_compiles_with_config(
+    config: &CodeGeneratorConfig,
+) -> (TempDir, std::path::PathBuf) {
+    test_that_golang_code_compiles_with_generator(&golang::CodeGenerator::new(config))
+}
+
+fn test_that_golang_code_compiles_with_generator(
+    generator: &golang::CodeGenerator,
+) -> (TempDir, std::path::PathBuf) {
+    test_that_golang_code_compiles_with_generator_and_registry(
+        generator,
+        &get_empty_registry().unwrap(),
+    );
+    test_that_golang_code_compiles_with_generator_and_registry(
+        generator,
+        &get_small_registry().unwrap(),
+    );
+    test_that_golang_code_compiles_with_generator_and_registry(
+        generator,
+        &test_utils::get_registry().unwrap(),
+    )
+}
+
+fn test_that_golang_code_compiles_with_generator_and_registry(
+    generator: &golang::CodeGenerator,
+    registry: &Registry,
+) -> (TempDir, std::path::PathBuf) {
+    let dir = tempdir().unwrap();
+    let source_path = dir.path().join("test.go");
+    let mut source = File::create(&source_path).unwrap();
+
+    generator.output(&mut source, &registry).unwrap();
+
+    writeln!(&mut source, "func main() {{}}").unwrap();
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("mod")
+        .arg("init")
+        .arg("example.com/test")
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    let runtime_mod_path = std::env::current_exe()
+        .unwrap()
+        .parent()
+        .unwrap()
+        .join("../../../serde-generate/runtime/golang");
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("mod")
+        .arg("edit")
+        .arg("-replace")
+        .arg(format!(
+            "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang={}",
+            runtime_mod_path.to_str().unwrap()
+        ))
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("build")
+        .arg(&source_path)
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    (dir, source_path)
+}
+
+#[test]
+fn test_that_golang_code_compiles() {
+    let config = CodeGeneratorConfig::new("main".to_string());
+    test_that_golang_code_compiles_with_config(&config);
+}
+
+#[test]
+fn test_that_golang_code_compiles_without_serialization() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_serialization(false);
+    test_that_golang_code_compiles_with_config(&config);
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_bcs() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    // Values serialized as zero bytes (e.g. unit structs) give `[]byte{}` rather than nil.
+    assert!(content.contains("return []byte{}, nil"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_bincode() {
+    let config =
+        CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bincode]);
+    test_that_golang_code_compiles_with_config(&config);
+}
+
+#[test]
+fn test_that_golang_code_with_a_single_encoding_omits_other_encodings() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func BcsDeserializeSerdeData("));
+    assert!(!content.to_lowercase().contains("bincode"));
+
+    let config =
+        CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bincode]);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func BincodeDeserializeSerdeData("));
+    assert!(!content.to_lowercase().contains("bcs"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_json() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config).with_json(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("`json:\"f_string\"`"));
+    assert!(content.contains("func UnmarshalJSONSerdeData(data []byte) (SerdeData, error) {"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_enum_representations() {
+    let representations = vec![
+        (
+            vec!["main", "SerdeData"],
+            golang::EnumRepresentation::Internal("type".to_string()),
+        ),
+        (vec!["main", "List"], golang::EnumRepresentation::Untagged),
+    ]
+    .into_iter()
+    .map(|(k, v)| (k.into_iter().map(String::from).collect(), v))
+    .collect();
+    let config = CodeGeneratorConfig::new("main".to_string()).with_serialization(false);
+    let generator = golang::CodeGenerator::new(&config)
+        .with_json(true)
+        .with_enum_representations(representations);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains(r#"return serde.MarshalJSONWithTag("type", "UnitVariant", nil)"#));
+    assert!(content.contains(r#"name, err := serde.UnmarshalJSONTag(data, "type")"#));
+    assert!(content.contains(
+        r#"return nil, fmt.Errorf("Data did not match any variant of untagged enum List")"#
+    ));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_builders() {
+    let config = CodeGeneratorConfig::new("main".to_string());
+    let generator = golang::CodeGenerator::new(&config).with_builders(Some(3));
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func NewOtherTypesBuilder() *OtherTypesBuilder {"));
+    assert!(content
+        .contains("func (b *OtherTypesBuilder) WithFString(value string) *OtherTypesBuilder {"));
+    // Structs with fewer fields have no builder.
+    assert!(!content.contains("StructBuilder"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_clone() {
+    let config = CodeGeneratorConfig::new("main".to_string());
+    let generator = golang::CodeGenerator::new(&config).with_clone(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func (obj SerdeData__UnitVariant) Clone() SerdeData {"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_validation() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_c_style_enums(true);
+    let generator = golang::CodeGenerator::new(&config).with_validation(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func (obj CStyleEnum) Validate() error {"));
+    assert!(content.contains(
+        r#"if err := serde.Validate(obj.Field1); err != nil { return &serde.TypeError{Type: "List", Field: "Node.Field1", Err: err} }"#
+    ));
+
+    let config = CodeGeneratorConfig::new("main".to_string()).with_serialization(false);
+    let generator = golang::CodeGenerator::new(&config).with_validation(true);
+    test_that_golang_code_compiles_with_generator(&generator);
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_hash() {
+    let config = CodeGeneratorConfig::new("main".to_string());
+    let generator = golang::CodeGenerator::new(&config).with_hash(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func (obj SerdeData__UnitVariant) Hash() uint64 {"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_streaming() {
+    let config = CodeGeneratorConfig::new("main".to_string())
+        .with_encodings(vec![Encoding::Bcs, Encoding::Bincode]);
+    let generator = golang::CodeGenerator::new(&config).with_streaming(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("BcsSerializeTo(writer io.Writer) error\n"));
+    assert!(content.contains(
+        "func (obj *SerdeData__UnitVariant) BincodeSerializeTo(writer io.Writer) error {"
+    ));
+    assert!(
+        content.contains("func BcsDeserializeSerdeDataFrom(reader io.Reader) (SerdeData, error) {")
+    );
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_tinygo_build_tag() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config).with_tinygo(true);
+    let (dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(!content.contains("GoString()"));
+
+    // Build again without the runtime support of `encoding/json` and `database/sql`.
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("build")
+        .arg("-tags")
+        .arg("tinygo")
+        .arg(&source_path)
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[test]
+fn test_that_golang_tinygo_is_incompatible_with_json() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config)
+        .with_tinygo(true)
+        .with_json(true);
+    let registry = test_utils::get_registry().unwrap();
+    let error = generator.output(&mut Vec::new(), &registry).unwrap_err();
+    assert_eq!(error.kind(), std::io::ErrorKind::InvalidInput);
+    let dir = tempdir().unwrap();
+    assert!(generator.write_source_files(dir.path(), &registry).is_err());
+}
+
+#[test]
+fn test_that_golang_fuzz_tests_compile() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config);
+    let (dir, _source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let fuzz_path = dir.path().join("fuzz_test.go");
+    let mut fuzz = File::create(&fuzz_path).unwrap();
+    generator
+        .output_fuzz_tests(&mut fuzz, &test_utils::get_registry().unwrap())
+        .unwrap();
+    let content = std::fs::read_to_string(&fuzz_path).unwrap();
+    assert!(content.contains("func FuzzSerdeDataBcsRoundtrip(f *testing.F) {"));
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("test")
+        .arg(".")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[test]
+fn test_that_golang_roundtrip_tests_pass() {
+    let config = CodeGeneratorConfig::new("main".to_string())
+        .with_encodings(vec![Encoding::Bincode, Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config);
+    let (dir, _source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let roundtrip_path = dir.path().join("roundtrip_test.go");
+    let mut roundtrip = File::create(&roundtrip_path).unwrap();
+    generator
+        .output_roundtrip_tests(&mut roundtrip, &test_utils::get_registry().unwrap())
+        .unwrap();
+    let content = std::fs::read_to_string(&roundtrip_path).unwrap();
+    assert!(content.contains("func TestRoundtrip(t *testing.T) {"));
+    assert!(content
+        .contains("checkRoundtrip(t, value.BcsSerialize, BcsDeserializeSerdeData, value.Equal)"));
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("test")
+        .arg(".")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_serialized_sizes() {
+    let config = CodeGeneratorConfig::new("main".to_string())
+        .with_encodings(vec![Encoding::Bincode, Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config).with_serialized_sizes(true);
+    let (dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func (obj *Struct) SerializedSize() int {\n\treturn 12\n}"));
+    assert!(content.contains("func (obj *TupleStruct) SerializedSize() int {\n\treturn 12\n}"));
+    assert!(content.contains("func (obj *UnitStruct) SerializedSize() int {\n\treturn 0\n}"));
+    assert!(content.contains("serde.Grow(serializer, obj.SerializedSize())"));
+    // Enums and containers with sequences have no fixed size.
+    assert!(!content.contains("func (obj *SerdeData) SerializedSize() int {"));
+    assert!(!content.contains("func (obj *OtherTypes) SerializedSize() int {"));
+
+    // Round-trip tests check the sizes of serialized samples.
+    let roundtrip_path = dir.path().join("roundtrip_test.go");
+    let mut roundtrip = File::create(&roundtrip_path).unwrap();
+    generator
+        .output_roundtrip_tests(&mut roundtrip, &test_utils::get_registry().unwrap())
+        .unwrap();
+    let content = std::fs::read_to_string(&roundtrip_path).unwrap();
+    assert!(content.contains("len(output) != value.SerializedSize()"));
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("test")
+        .arg(".")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[test]
+fn test_that_golang_code_appends_binary_values() {
+    let config = CodeGeneratorConfig::new("main".to_string())
+        .with_encodings(vec![Encoding::Bincode, Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config);
+    let (dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func (obj *Struct) AppendBinary(b []byte) ([]byte, error) {"));
+    assert!(content.contains("\treturn bcs.Append(b, obj)\n"));
+
+    // Values implement `encoding.BinaryAppender` (Go 1.24) with the same output as `MarshalBinary`.
+    let mut test = File::create(dir.path().join("append_test.go")).unwrap();
+    writeln!(
+        test,
+        r#"package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+type binaryAppender interface {{
+	AppendBinary(b []byte) ([]byte, error)
+}}
+
+var _ binaryAppender = (*Struct)(nil)
+var _ binaryAppender = SerdeData(nil)
+
+func TestAppendBinary(t *testing.T) {{
+	value := &Struct{{X: 1, Y: 2}}
+	expected, err := value.MarshalBinary()
+	if err != nil {{ t.Fatal(err) }}
+	output, err := value.AppendBinary([]byte{{7}})
+	if err != nil || !bytes.Equal(output, append([]byte{{7}}, expected...)) {{ t.Fatal(output, err) }}
+	var empty *Struct
+	if _, err := empty.AppendBinary(nil); err == nil {{ t.Fatal("nil values must be rejected") }}
+}}"#
+    )
+    .unwrap();
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("test")
+        .arg(".")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_enum_visitors() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_serialization(false);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("VisitUnitVariant(value *SerdeData__UnitVariant) R"));
+    assert!(content
+        .contains("func MatchSerdeData[R any](value SerdeData, visitor SerdeDataVisitor[R]) R {"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_variant_names() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains(
+        "func (*SerdeData__UnitVariant) VariantName() string {\n\treturn \"UnitVariant\"\n}"
+    ));
+    assert!(content.contains("func SerdeDataFromVariantName(name string) (SerdeData, bool) {"));
+    assert!(content.contains("case \"UnitVariant\":\n\t\treturn new(SerdeData__UnitVariant), true"));
+
+    let config = config.with_c_style_enums(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("func (obj CStyleEnum) VariantName() string {"));
+    assert!(content.contains("func CStyleEnumFromVariantName(name string) (CStyleEnum, bool) {"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_variant_indices() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("SerdeDataVariantUnitVariantIndex uint32 = 2"));
+    assert!(content.contains("func SerdeDataVariantIndexName(index uint32) (string, bool) {"));
+    assert!(content
+        .contains("case SerdeDataVariantUnitVariantIndex:\n\t\treturn \"UnitVariant\", true"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_generic_options() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config).with_generic_options(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("FOption serde.Option[Struct]"));
+    // Recursive types need a pointer indirection.
+    assert!(content.contains("Value *SimpleList"));
+
+    let config = CodeGeneratorConfig::new("main".to_string()).with_serialization(false);
+    let generator = golang::CodeGenerator::new(&config).with_generic_options(true);
+    test_that_golang_code_compiles_with_generator(&generator);
+}
+
+#[test]
+fn test_that_golang_code_uses_arrays_for_fixed_size_arrays() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("type SerdeData__TupleArray [3]uint32"));
+    // Arrays are comparable in Go, hence valid map keys.
+    assert!(content.contains("map[struct {Field0 [2]uint32; Field1 [4]uint8}]struct {}"));
+    // Byte arrays are (de)serialized at once.
+    assert!(content.contains("return serializer.SerializeFixedBytes(value[:])"));
+    assert!(content.contains("bytes, err := deserializer.DeserializeFixedBytes(4)"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_recursive_types() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    for generic_options in [false, true] {
+        let generator = golang::CodeGenerator::new(&config)
+            .with_generic_options(generic_options)
+            .with_clone(true)
+            .with_hash(true);
+        let (_dir, source_path) = test_that_golang_code_compiles_with_generator_and_registry(
+            &generator,
+            &get_recursive_registry().unwrap(),
+        );
+        let content = std::fs::read_to_string(&source_path).unwrap();
+        // Optional values containing recursive structs are boxed.
+        assert!(content.contains("Tail *struct {Field0 uint8; Field1 Chain}"));
+        assert!(content.contains("Others *[2]Ping"));
+        assert!(content.contains("Pong *Pong"));
+    }
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_incomparable_map_keys() {
+    let config =
+        CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bincode]);
+    let generator = golang::CodeGenerator::new(&config);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator_and_registry(
+        &generator,
+        &get_map_key_registry().unwrap(),
+    );
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("Paths map[string]uint8"));
+    assert!(content.contains("Choices map[string][]PathKey"));
+    assert!(content.contains("func PathKeyFromMapKey(key string) (PathKey, error) {"));
+    assert!(content.contains("func ChoiceKeyFromMapKey(key string) (ChoiceKey, error) {"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_package_name() {
+    // The package name defaults to the last component of the module name.
+    let config = CodeGeneratorConfig::new("types/main".to_string());
+    let (_dir, source_path) = test_that_golang_code_compiles_with_config(&config);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.starts_with("package main\n"));
+
+    let config = CodeGeneratorConfig::new("types".to_string());
+    let generator = golang::CodeGenerator::new(&config).with_package_name("main".to_string());
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.starts_with("package main\n"));
+    assert!(content.contains("&main.SerdeData__UnitVariant{"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_in_separate_files() {
+    let registry = test_utils::get_registry().unwrap();
+    let dir = tempdir().unwrap();
+    let config = CodeGeneratorConfig::new("types".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config).with_clone(true);
+    generator
+        .write_source_files(&dir.path().join("types"), &registry)
+        .unwrap();
+    assert!(dir.path().join("types/serde_data_gen.go").exists());
+    assert!(dir.path().join("types/serde_helpers.go").exists());
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("mod")
+        .arg("init")
+        .arg("example.com/test")
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    let runtime_mod_path = std::env::current_exe()
+        .unwrap()
+        .parent()
+        .unwrap()
+        .join("../../../serde-generate/runtime/golang");
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("mod")
+        .arg("edit")
+        .arg("-replace")
+        .arg(format!(
+            "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang={}",
+            runtime_mod_path.to_str().unwrap()
+        ))
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("build")
+        .arg("./types")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_type_registry() {
+    let registry = test_utils::get_registry().unwrap();
+    let dir = tempdir().unwrap();
+    let config = CodeGeneratorConfig::new("types".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config).with_type_registry(true);
+    generator
+        .write_source_files(&dir.path().join("types"), &registry)
+        .unwrap();
+    let content = std::fs::read_to_string(dir.path().join("types/registry.go")).unwrap();
+    assert!(content.contains(
+        r#""SerdeData": func(input []byte) (interface{}, error) { return BcsDeserializeSerdeData(input) },"#
+    ));
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("mod")
+        .arg("init")
+        .arg("example.com/test")
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    let runtime_mod_path = std::env::current_exe()
+        .unwrap()
+        .parent()
+        .unwrap()
+        .join("../../../serde-generate/runtime/golang");
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("mod")
+        .arg("edit")
+        .arg("-replace")
+        .arg(format!(
+            "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang={}",
+            runtime_mod_path.to_str().unwrap()
+        ))
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("build")
+        .arg("./types")
+        .status()
+        .unwrap();
+    assert!(status.success());
 }
 
-fn test_that_golang_code_compiles_with_config_and_registry(
-    config: &CodeGeneratorConfig,
-    registry: &Registry,
-) -> (TempDir, std::path::PathBuf) {
+#[test]
+fn test_that_golang_code_compiles_with_vendored_runtime() {
+    let registry = test_utils::get_registry().unwrap();
+    let dir = tempdir().unwrap();
+    let config = CodeGeneratorConfig::new("types".to_string())
+        .with_encodings(vec![Encoding::Bcs, Encoding::Bincode]);
+    let installer = golang::Installer::new(dir.path().join("gen"), None)
+        .with_vendored_runtime(Some("example.com/test/gen".to_string()));
+    installer.install_module(&config, &registry).unwrap();
+    installer.install_serde_runtime().unwrap();
+    installer.install_bincode_runtime().unwrap();
+    installer.install_bcs_runtime().unwrap();
+    assert!(dir.path().join("gen/internal/serde/interfaces.go").exists());
+    assert!(!dir.path().join("gen/internal/bcs/bcs_test.go").exists());
+    // The registry loader of `serdetypes` would require a YAML module.
+    let formats_dir = dir.path().join("gen/internal/serdetypes");
+    assert!(formats_dir.join("format.go").exists());
+    assert!(!formats_dir.join("registry.go").exists());
+
+    // No dependency on the published runtime is needed.
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("mod")
+        .arg("init")
+        .arg("example.com/test")
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("build")
+        .arg("./...")
+        .env("GOPROXY", "off")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[derive(Serialize, Deserialize)]
+struct Header {
+    version: u32,
+}
+
+#[derive(Serialize, Deserialize)]
+struct Request {
+    header: Header,
+    paths: BTreeMap<PathKey, u8>,
+}
+
+#[derive(Serialize, Deserialize)]
+struct Response {
+    header: Header,
+    path: PathKey,
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_shared_containers() {
+    let mut tracer = Tracer::new(TracerConfig::default());
+    tracer.trace_type::<Request>(&Samples::new()).unwrap();
+    let requests = tracer.registry().unwrap();
+    let mut tracer = Tracer::new(TracerConfig::default());
+    tracer.trace_type::<Response>(&Samples::new()).unwrap();
+    let responses = tracer.registry().unwrap();
+
+    let dir = tempdir().unwrap();
+    let config =
+        |name: &str| CodeGeneratorConfig::new(name.to_string()).with_encodings(vec![Encoding::Bcs]);
+    let installer = golang::Installer::new(dir.path().join("gen"), None)
+        .with_vendored_runtime(Some("example.com/test/gen".to_string()));
+    installer
+        .install_modules(
+            "example.com/test/gen",
+            &config("common"),
+            &[
+                (config("requests"), requests),
+                (config("responses"), responses),
+            ],
+        )
+        .unwrap();
+    installer.install_serde_runtime().unwrap();
+    installer.install_bcs_runtime().unwrap();
+
+    let common = std::fs::read_to_string(dir.path().join("gen/common/lib.go")).unwrap();
+    assert!(common.contains("type Header struct"));
+    // Keys of maps are not shared.
+    assert!(!common.contains("type PathKey struct"));
+    let content = std::fs::read_to_string(dir.path().join("gen/requests/lib.go")).unwrap();
+    assert!(content.contains("\"example.com/test/gen/common\""));
+    assert!(content.contains("Header common.Header"));
+    assert!(content.contains("type PathKey struct"));
+    assert!(!content.contains("type Header struct"));
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("mod")
+        .arg("init")
+        .arg("example.com/test")
+        .status()
+        .unwrap();
+    assert!(status.success());
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("build")
+        .arg("./...")
+        .env("GOPROXY", "off")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_custom_types() {
+    let registry = get_map_key_registry().unwrap();
     let dir = tempdir().unwrap();
+    let custom_types = vec![(
+        vec!["main".to_string(), "PathKey".to_string()],
+        golang::CustomType {
+            go_type: "string".to_string(),
+            import_path: None,
+        },
+    )]
+    .into_iter()
+    .collect();
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config)
+        .with_clone(true)
+        .with_custom_types(custom_types);
     let source_path = dir.path().join("test.go");
     let mut source = File::create(&source_path).unwrap();
-
-    let generator = golang::CodeGenerator::new(&config);
     generator.output(&mut source, &registry).unwrap();
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(!content.contains("type PathKey"));
+    assert!(content.contains("Paths map[string]uint8"));
 
-    writeln!(&mut source, "func main() {{}}").unwrap();
+    let mut source = File::create(dir.path().join("custom.go")).unwrap();
+    writeln!(
+        source,
+        r#"package main
+
+import "github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+
+func SerializePathKey(value string, serializer serde.Serializer) error {{
+	return serializer.SerializeStr(value)
+}}
+
+func DeserializePathKey(deserializer serde.Deserializer) (string, error) {{
+	return deserializer.DeserializeStr()
+}}
+
+func EqualPathKey(a string, b string) bool {{ return a == b }}
+
+func ClonePathKey(value string) string {{ return value }}
+
+func main() {{}}"#
+    )
+    .unwrap();
 
     let status = Command::new("go")
         .current_dir(dir.path())
@@ -81,36 +860,329 @@ fn test_that_golang_code_compiles_with_config_and_registry(
     let status = Command::new("go")
         .current_dir(dir.path())
         .arg("build")
-        .arg(&source_path)
+        .arg(".")
         .status()
         .unwrap();
     assert!(status.success());
+}
 
-    (dir, source_path)
+#[test]
+fn test_that_golang_installer_supports_custom_types() {
+    let registry = get_map_key_registry().unwrap();
+    let dir = tempdir().unwrap();
+    // Same format as in `serdegen --go-custom-types`.
+    let custom_types: BTreeMap<String, golang::CustomType> = serde_yaml::from_str(
+        "PathKey:\n  go_type: paths.Key\n  import_path: example.com/test/paths\n",
+    )
+    .unwrap();
+    let custom_types = custom_types
+        .into_iter()
+        .map(|(name, custom_type)| (vec!["main".to_string(), name], custom_type))
+        .collect();
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let installer =
+        golang::Installer::new(dir.path().to_path_buf(), None).with_custom_types(custom_types);
+    installer.install_module(&config, &registry).unwrap();
+    let content = std::fs::read_to_string(dir.path().join("main/lib.go")).unwrap();
+    assert!(!content.contains("type PathKey"));
+    assert!(content.contains("\"example.com/test/paths\""));
+    assert!(content.contains("Paths map[paths.Key]uint8"));
+}
+
+#[derive(Serialize, Deserialize)]
+struct Session {
+    id: u32,
+    #[serde(skip)]
+    cache: Vec<u8>,
 }
 
 #[test]
-fn test_that_golang_code_compiles() {
-    let config = CodeGeneratorConfig::new("main".to_string());
-    test_that_golang_code_compiles_with_config(&config);
+fn test_that_golang_code_omits_skipped_fields() {
+    let mut tracer = Tracer::new(TracerConfig::default());
+    tracer.trace_type::<Session>(&Samples::new()).unwrap();
+    let registry = tracer.registry().unwrap();
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config);
+    let (dir, source_path) =
+        test_that_golang_code_compiles_with_generator_and_registry(&generator, &registry);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(!content.contains("Cache"));
+
+    // Go values serialize like Rust values, which ignore skipped fields.
+    let session = Session {
+        id: 7,
+        cache: vec![1, 2],
+    };
+    let mut test = File::create(dir.path().join("session_test.go")).unwrap();
+    writeln!(
+        test,
+        r#"package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSession(t *testing.T) {{
+	input := []byte{{{}}}
+	value, err := BcsDeserializeSession(input)
+	if err != nil || value.Id != 7 {{ t.Fatal(value, err) }}
+	output, err := value.BcsSerialize()
+	if err != nil || !bytes.Equal(input, output) {{ t.Fatal(output, err) }}
+}}"#,
+        bcs::to_bytes(&session)
+            .unwrap()
+            .iter()
+            .map(|b| b.to_string())
+            .collect::<Vec<_>>()
+            .join(", ")
+    )
+    .unwrap();
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("test")
+        .arg(".")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[derive(Serialize, Deserialize)]
+struct Record {
+    id: u32,
+    tags: Vec<String>,
+    version: u8,
 }
 
 #[test]
-fn test_that_golang_code_compiles_without_serialization() {
-    let config = CodeGeneratorConfig::new("main".to_string()).with_serialization(false);
-    test_that_golang_code_compiles_with_config(&config);
+fn test_that_golang_code_fills_missing_trailing_fields() {
+    let mut tracer = Tracer::new(TracerConfig::default());
+    tracer.trace_type::<Record>(&Samples::new()).unwrap();
+    let registry = tracer.registry().unwrap();
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let mut defaults = BTreeMap::new();
+    defaults.insert(
+        vec!["main".to_string(), "Record".to_string(), "tags".to_string()],
+        "[]string{\"legacy\"}".to_string(),
+    );
+    defaults.insert(
+        vec![
+            "main".to_string(),
+            "Record".to_string(),
+            "version".to_string(),
+        ],
+        "1".to_string(),
+    );
+    let generator = golang::CodeGenerator::new(&config).with_field_defaults(defaults);
+    let (dir, _source_path) =
+        test_that_golang_code_compiles_with_generator_and_registry(&generator, &registry);
+
+    // Records persisted before the fields `tags` and `version` were added only contain an id.
+    let record = Record {
+        id: 7,
+        tags: vec!["new".to_string()],
+        version: 2,
+    };
+    let mut test = File::create(dir.path().join("record_test.go")).unwrap();
+    writeln!(
+        test,
+        r#"package main
+
+import (
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bcs"
+)
+
+func TestRecord(t *testing.T) {{
+	input := []byte{{{}}}
+	config := bcs.DefaultConfig()
+	config.AllowMissingTrailingFields = true
+	value, err := DeserializeRecord(bcs.NewDeserializerWithConfig(input[:4], config))
+	if err != nil || value.Id != 7 || len(value.Tags) != 1 || value.Tags[0] != "legacy" || value.Version != 1 {{ t.Fatal(value, err) }}
+	value, err = DeserializeRecord(bcs.NewDeserializerWithConfig(input, config))
+	if err != nil || value.Tags[0] != "new" || value.Version != 2 {{ t.Fatal(value, err) }}
+	if _, err := BcsDeserializeRecord(input[:4]); err == nil {{ t.Fatal("missing fields must be rejected by default") }}
+}}"#,
+        bcs::to_bytes(&record)
+            .unwrap()
+            .iter()
+            .map(|b| b.to_string())
+            .collect::<Vec<_>>()
+            .join(", ")
+    )
+    .unwrap();
+
+    let status = Command::new("go")
+        .current_dir(dir.path())
+        .arg("test")
+        .arg(".")
+        .status()
+        .unwrap();
+    assert!(status.success());
+}
+
+#[derive(Serialize, Deserialize)]
+struct Account {
+    user_id: u32,
+    home_url: String,
+    protocol: Protocol,
+}
+
+#[derive(Serialize, Deserialize)]
+#[allow(clippy::upper_case_acronyms)]
+enum Protocol {
+    HTTP,
+    Ssh { api_key: String },
 }
 
 #[test]
-fn test_that_golang_code_compiles_with_bcs() {
+fn test_that_golang_code_compiles_with_initialisms() {
+    let mut tracer = Tracer::new(TracerConfig::default());
+    tracer.trace_type::<Account>(&Samples::new()).unwrap();
+    tracer.trace_type::<Protocol>(&Samples::new()).unwrap();
+    let registry = tracer.registry().unwrap();
     let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
-    test_that_golang_code_compiles_with_config(&config);
+    let generator = golang::CodeGenerator::new(&config)
+        .with_json(true)
+        .with_initialisms(true);
+    let (_dir, source_path) =
+        test_that_golang_code_compiles_with_generator_and_registry(&generator, &registry);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("UserID uint32 `json:\"user_id\"`"));
+    assert!(content.contains("HomeURL string `json:\"home_url\"`"));
+    assert!(content.contains("type Protocol__HTTP struct"));
+    assert!(content.contains("type Protocol__SSH struct"));
+    assert!(content.contains("APIKey string `json:\"api_key\"`"));
 }
 
 #[test]
-fn test_that_golang_code_compiles_with_bincode() {
-    let config =
-        CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bincode]);
+fn test_that_golang_code_compiles_with_value_semantics() {
+    let config = CodeGeneratorConfig::new("main".to_string())
+        .with_encodings(vec![Encoding::Bcs, Encoding::Bincode]);
+    let generator = golang::CodeGenerator::new(&config)
+        .with_streaming(true)
+        .with_builders(Some(2))
+        .with_value_semantics(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content
+        .contains("func (obj PrimitiveTypes) Serialize(serializer serde.Serializer) error {"));
+    assert!(content
+        .contains("func (obj PrimitiveTypes) BcsSerialize() ([]byte, error) {\n\tserializer"));
+    assert!(
+        content.contains("func (obj PrimitiveTypes) BincodeSerializeTo(writer io.Writer) error {")
+    );
+    assert!(content.contains("func (obj PrimitiveTypes) MarshalBinary() ([]byte, error) {"));
+    assert!(content.contains("func (b *PrimitiveTypesBuilder) Build() (PrimitiveTypes, error) {"));
+    // Deserialization methods and variants keep pointer receivers.
+    assert!(content.contains(
+        "func (obj *PrimitiveTypes) Deserialize(deserializer serde.Deserializer) error {"
+    ));
+    assert!(content.contains(
+        "func (obj *SerdeData__UnitVariant) Serialize(serializer serde.Serializer) error {"
+    ));
+}
+
+#[derive(Serialize, Deserialize)]
+struct Entry {
+    hash: u64,
+    r#type: u8,
+    tags: Vec<String>,
+}
+
+#[derive(Serialize, Deserialize)]
+struct Entries(u32, Vec<Entry>);
+
+#[test]
+fn test_that_golang_code_compiles_with_immutable_structs() {
+    let mut tracer = Tracer::new(TracerConfig::default());
+    tracer.trace_type::<Entries>(&Samples::new()).unwrap();
+    let registry = tracer.registry().unwrap();
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config)
+        .with_hash(true)
+        .with_builders(Some(2))
+        .with_immutable_structs(true);
+    let (_dir, source_path) =
+        test_that_golang_code_compiles_with_generator_and_registry(&generator, &registry);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(
+        content.contains("type Entry struct {\n\thash uint64\n\ttype_ uint8\n\ttags []string\n}")
+    );
+    assert!(content.contains("func (obj Entry) Type() uint8 {\n\treturn obj.type_\n}"));
+    assert!(content.contains("func (obj Entry) Tags() []string {"));
+    assert!(content.contains("func (b *EntryBuilder) WithType(value uint8) *EntryBuilder {"));
+    // Getters do not override other methods.
+    assert!(content.contains("func (obj Entry) GetHash() uint64 {"));
+    assert!(content.contains("func (obj Entry) Hash() uint64 {"));
+    assert!(content.contains("func (obj Entries) Field1() []Entry {"));
+}
+
+#[test]
+fn test_that_golang_immutable_structs_are_incompatible_with_json() {
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let generator = golang::CodeGenerator::new(&config)
+        .with_immutable_structs(true)
+        .with_json(true);
+    let registry = test_utils::get_registry().unwrap();
+    let error = generator.output(&mut Vec::new(), &registry).unwrap_err();
+    assert_eq!(error.kind(), std::io::ErrorKind::InvalidInput);
+    let dir = tempdir().unwrap();
+    assert!(generator.write_source_files(dir.path(), &registry).is_err());
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_renamings() {
+    let comments = vec![(
+        vec!["main".to_string(), "SerdeData".to_string()],
+        "Some comments".to_string(),
+    )]
+    .into_iter()
+    .collect();
+    let config = CodeGeneratorConfig::new("main".to_string())
+        .with_encodings(vec![Encoding::Bcs])
+        .with_comments(comments);
+    let renamings = vec![
+        (vec!["main", "SerdeData"], "Data"),
+        (vec!["main", "SerdeData", "UnitVariant"], "Unit"),
+        (vec!["main", "OtherTypes", "f_string"], "Text"),
+    ]
+    .into_iter()
+    .map(|(path, name)| {
+        (
+            path.into_iter().map(String::from).collect(),
+            name.to_string(),
+        )
+    })
+    .collect();
+    let generator = golang::CodeGenerator::new(&config)
+        .with_json(true)
+        .with_renamings(renamings);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("\n// Some comments\ntype Data interface {"));
+    assert!(content.contains("type Data__Unit struct {"));
+    assert!(content.contains("Field0 Data\n"));
+    assert!(content.contains("Text string `json:\"f_string\"`"));
+    assert!(content.contains("return json.Marshal(\"UnitVariant\")"));
+}
+
+#[test]
+fn test_that_golang_code_compiles_with_c_style_enums() {
+    let config = CodeGeneratorConfig::new("main".to_string())
+        .with_encodings(vec![Encoding::Bcs])
+        .with_c_style_enums(true);
+    let generator = golang::CodeGenerator::new(&config).with_json(true);
+    let (_dir, source_path) = test_that_golang_code_compiles_with_generator(&generator);
+    let content = std::fs::read_to_string(&source_path).unwrap();
+    assert!(content.contains("type CStyleEnum uint32"));
+    assert!(content.contains("CStyleEnum__A CStyleEnum = iota"));
+
+    let config = CodeGeneratorConfig::new("main".to_string())
+        .with_serialization(false)
+        .with_c_style_enums(true);
     test_that_golang_code_compiles_with_config(&config);
 }
 
@@ -125,6 +1197,14 @@ fn test_that_golang_code_compiles_with_comments() {
             vec!["main".to_string(), "List".to_string(), "Node".to_string()],
             "Some other comments".to_string(),
         ),
+        (
+            vec![
+                "main".to_string(),
+                "OtherTypes".to_string(),
+                "f_string".to_string(),
+            ],
+            "A field".to_string(),
+        ),
     ]
     .into_iter()
     .collect();
@@ -142,6 +1222,13 @@ fn test_that_golang_code_compiles_with_comments() {
     assert!(content.contains(
         r#"
 // Some other comments
+"#
+    ));
+    // Fields are designated by their names in the registry.
+    assert!(content.contains(
+        r#"
+	// A field
+	FString string
 "#
     ));
 }
@@ -166,6 +1253,28 @@ fn test_golang_code_with_external_definitions() {
     assert!(content.contains("foo.Tree"));
 }
 
+#[test]
+fn test_golang_code_with_versioned_external_definitions() {
+    let registry = test_utils::get_registry().unwrap();
+    let dir = tempdir().unwrap();
+    let source_path = dir.path().join("test.go");
+    let mut source = File::create(&source_path).unwrap();
+
+    // The package name of a versioned import path omits the major version.
+    let mut definitions = BTreeMap::new();
+    definitions.insert("example.com/base/v2".to_string(), vec!["Tree".to_string()]);
+    let config =
+        CodeGeneratorConfig::new("main".to_string()).with_external_definitions(definitions);
+    let generator = golang::CodeGenerator::new(&config);
+    generator.output(&mut source, &registry).unwrap();
+
+    let content = std::fs::read_to_string(source_path).unwrap();
+    assert!(content.contains("\"example.com/base/v2\""));
+    assert!(content.contains("base.Tree"));
+    assert!(!content.contains("v2.Tree"));
+    assert!(!content.contains("type Tree struct"));
+}
+
 #[test]
 fn test_that_golang_code_compiles_with_custom_code() {
     let custom_code = vec![
@@ -188,3 +1297,21 @@ fn test_that_golang_code_compiles_with_custom_code() {
     assert!(content.contains("// custom1"));
     assert!(content.contains("// custom2"));
 }
+
+// The Go port of the generator (runtime/golang/cmd/serdegen-go) checks its output against
+// the same expected file.
+#[test]
+fn test_that_golang_code_matches_the_go_generator_golden_file() {
+    let testdata = std::path::Path::new(env!("CARGO_MANIFEST_DIR"))
+        .join("runtime/golang/cmd/serdegen-go/testdata");
+    let registry: Registry =
+        serde_yaml::from_str(&std::fs::read_to_string(testdata.join("registry.yaml")).unwrap())
+            .unwrap();
+    let config = CodeGeneratorConfig::new("main".to_string()).with_encodings(vec![Encoding::Bcs]);
+    let mut code = Vec::new();
+    golang::CodeGenerator::new(&config)
+        .output(&mut code, &registry)
+        .unwrap();
+    let expected = std::fs::read_to_string(testdata.join("registry_bcs.go.golden")).unwrap();
+    assert_eq!(expected, String::from_utf8(code).unwrap());
+}
@@ -75,11 +75,29 @@ func main() {{
 		C: &Choice__C {{ X: 7 }},
 	}}
 	if !cmp.Equal(value, value2) {{ panic("value != value2") }}
+	if !value.Equal(value2) {{ panic("value is not Equal to value2") }}
+	value2.C = &Choice__C {{ X: 8 }}
+	if value.Equal(value2) {{ panic("value is Equal to a different value") }}
+	value2.C = &Choice__C {{ X: 7 }}
+	if fmt.Sprint(value) != "Test{{A: [4 6], B: {{-3 5}}, C: Choice::C{{X: 7}}}}" {{ panic("unexpected String()") }}
+	if fmt.Sprintf("%#v", value.C) != "&main.Choice__C{{X:0x7}}" {{ panic("unexpected GoString()") }}
+	if _, err := NewTest(value2.A, value2.B, nil); err == nil {{ panic("was expecting a missing value") }}
+	if value5, err := NewTest(value2.A, value2.B, value2.C); err != nil || !value5.Equal(value2) {{ panic("failed to construct") }}
 
 	output, err := value2.{1}Serialize()
 	if err != nil {{ panic("failed to serialize") }}
 	if !cmp.Equal(input, output) {{ panic("input != output") }}
 
+	var value3 Test
+	if err := value3.Deserialize({2}.NewDeserializer(input)); err != nil {{ panic("failed to deserialize") }}
+	if !cmp.Equal(value3, value2) {{ panic("value3 != value2") }}
+
+	binary, err := value2.MarshalBinary()
+	if err != nil || !cmp.Equal(input, binary) {{ panic("failed to marshal") }}
+	var value4 Test
+	if err := value4.UnmarshalBinary(input); err != nil {{ panic("failed to unmarshal") }}
+	if !cmp.Equal(value4, value2) {{ panic("value4 != value2") }}
+
 	input2 := []byte{{{0}, 1}}
 	value2, err2 := {1}DeserializeTest(input2)
 	if err2 == nil {{ panic("was expecting an error") }}
@@ -91,6 +109,7 @@ func main() {{
             .collect::<Vec<_>>()
             .join(", "),
         runtime.name().to_camel_case(),
+        runtime.name(),
     )
     .unwrap();
 